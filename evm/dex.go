@@ -0,0 +1,140 @@
+package evm
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	t "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/forta-network/go-multicall"
+	"github.com/meme-bots/go-web3/types"
+)
+
+type (
+	// Dex is a venue tokens trade against the wrapped native token on. EVM
+	// reaches the venue of Config.Dex only through here.
+	Dex interface {
+		// ID is the venue's types.Dex* value.
+		ID() int
+		// FindPool returns the pool of token.
+		FindPool(ctx context.Context, token common.Address) (common.Address, error)
+		// PoolCalls are the calls reading pool, made in one multicall with
+		// the token's own and handed back to DecodePool.
+		PoolCalls(pool *Pool) ([]*multicall.Call, error)
+		DecodePool(pool *Pool, calls []*multicall.Call) error
+		// Quote is what amountIn of native token, or of token when selling,
+		// swaps for in pool.
+		Quote(ctx context.Context, pool *Pool, amountIn *big.Int, buy bool) (*big.Int, error)
+		// Spender is the contract sells must approve.
+		Spender() common.Address
+		SwapCall(req *SwapRequest) (*SwapCall, error)
+		// DecodeTrade decodes the swap of token in receipt, seen from owner.
+		DecodeTrade(ctx context.Context, tx *t.Transaction, receipt *t.Receipt, owner, token, feeRecipient common.Address) (*Trade, error)
+	}
+
+	// Pool is a pool of token against the wrapped native token.
+	Pool struct {
+		Address      common.Address
+		Token        common.Address
+		TokenReserve *big.Int
+		QuoteReserve *big.Int
+		State        interface{} // whatever else the venue quotes from
+	}
+
+	SwapRequest struct {
+		Owner        common.Address
+		Token        common.Address
+		Buy          bool
+		AmountIn     *big.Int // native token paid, fee included, or tokens sold
+		MinAmountOut *big.Int
+		FeeRecipient common.Address // of the bot fee, for venues taking it in the swap
+		FeeRatio     uint64
+		Deadline     *big.Int
+	}
+
+	// SwapCall is the transaction a swap is sent as.
+	SwapCall struct {
+		To    common.Address
+		Value *big.Int
+		Data  []byte
+	}
+
+	// NewDexFunc builds a venue for a network configured by cfg.
+	NewDexFunc func(cfg *types.Config, client *ethclient.Client) (Dex, error)
+)
+
+var (
+	ErrUnknownDex = errors.New("unknown dex")
+
+	dexesLock sync.RWMutex
+	dexes     = map[int]NewDexFunc{
+		types.DexUniswapV2: NewUniswapV2,
+	}
+)
+
+// RegisterDex makes the venue newDex builds selectable as Config.Dex id.
+func RegisterDex(id int, newDex NewDexFunc) {
+	dexesLock.Lock()
+	defer dexesLock.Unlock()
+	dexes[id] = newDex
+}
+
+// NewDex returns the venue of cfg.Dex.
+func NewDex(cfg *types.Config, client *ethclient.Client) (Dex, error) {
+	dexesLock.RLock()
+	newDex, ok := dexes[cfg.Dex]
+	dexesLock.RUnlock()
+	if !ok {
+		return nil, ErrUnknownDex
+	}
+	return newDex(cfg, client)
+}
+
+// SendSwap signs call and submits it.
+func SendSwap(
+	cli *ethclient.Client,
+	chainId uint64,
+	call *SwapCall,
+	gasPrice *big.Int,
+	gasMargin uint64,
+	privateKey string,
+	submitter Submitter,
+) (common.Hash, error) {
+	senderPriKey, err := crypto.HexToECDSA(privateKey)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	auth, err := bind.NewKeyedTransactorWithChainID(senderPriKey, new(big.Int).SetUint64(chainId))
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	senderAddress := crypto.PubkeyToAddress(senderPriKey.PublicKey)
+	gas, err := EstimateGasLimit(cli, senderAddress, &call.To, call.Value, call.Data, gasMargin)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	contract := bind.NewBoundContract(call.To, abi.ABI{}, cli, cli, cli)
+	tx, err := contract.RawTransact(
+		&bind.TransactOpts{From: senderAddress, Signer: auth.Signer, Value: call.Value, GasPrice: gasPrice, GasLimit: gas, NoSend: true},
+		call.Data,
+	)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	err = submitter.Submit(context.Background(), tx)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	return tx.Hash(), nil
+}
@@ -10,26 +10,29 @@ import (
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	t "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/forta-network/go-multicall"
 	mc "github.com/forta-network/go-multicall/contracts/contract_multicall"
 	"github.com/meme-bots/go-web3/evm/erc20"
-	"github.com/meme-bots/go-web3/evm/uniswap"
 	"github.com/meme-bots/go-web3/types"
-	"github.com/meme-bots/go-web3/utils"
 	"github.com/samber/lo"
 	"github.com/shopspring/decimal"
 )
 
 type EVM struct {
-	ctx     context.Context
-	cfg     *types.Config
-	client  *ethclient.Client
-	chainId uint64
-	watcher *Watcher
-	factory common.Address
+	ctx       context.Context
+	cfg       *types.Config
+	client    *ethclient.Client
+	chainId   uint64
+	watcher   *Watcher
+	dex       Dex
+	submitter Submitter
+	sent      *sentTransactions
 }
 
+// BUY_GAS and SELL_GAS are only used when a swap cannot be estimated, e.g.
+// before the router is approved.
 const (
 	BUY_GAS  uint64 = 138263
 	SELL_GAS uint64 = 138263
@@ -49,29 +52,38 @@ func NewEVM(
 		return nil, err
 	}
 
-	router, err := uniswap.NewRouterv2(common.HexToAddress(cfg.Router), client)
+	dex, err := NewDex(cfg, client)
 	if err != nil {
 		return nil, err
 	}
 
-	factory, err := router.Factory(&bind.CallOpts{})
+	watcher, err := NewWatcher(
+		cfg.RPC,
+		common.HexToAddress(cfg.NativeTokenOracle),
+		common.HexToAddress(cfg.NativeTokenStablePool),
+		common.HexToAddress(cfg.WrapNativeToken),
+	)
 	if err != nil {
 		return nil, err
 	}
 
-	watcher, err := NewWatcher(cfg.RPC, common.HexToAddress(cfg.NativeTokenOracle))
+	submitter, err := NewSubmitter(cfg, client)
 	if err != nil {
 		return nil, err
 	}
 
-	return &EVM{
-		ctx:     ctx,
-		cfg:     cfg,
-		client:  client,
-		chainId: chainId,
-		watcher: watcher,
-		factory: factory,
-	}, nil
+	sent := newSentTransactions()
+	v := &EVM{
+		ctx:       ctx,
+		cfg:       cfg,
+		client:    client,
+		chainId:   chainId,
+		watcher:   watcher,
+		dex:       dex,
+		submitter: &recordingSubmitter{Submitter: submitter, sent: sent},
+		sent:      sent,
+	}
+	return v, nil
 }
 
 func (s *EVM) Start() error {
@@ -102,6 +114,10 @@ func (v *EVM) GetNativeTokenPrice() decimal.Decimal {
 	return v.watcher.GetETHPrice()
 }
 
+func (v *EVM) GetNativeTokenPriceInfo() *types.NativeTokenPrice {
+	return v.watcher.GetETHPriceInfo()
+}
+
 func (v *EVM) GetMaxMultiSendCount() int {
 	return 100
 }
@@ -135,36 +151,23 @@ func (v *EVM) GetPool(req *types.GetPoolRequest, pool *types.Pool) (*types.GetPo
 		Value uint8
 	}
 
-	type reservesOutput struct {
-		Reserve0           *big.Int
-		Reserve1           *big.Int
-		BlockTimestampLast uint32
-	}
-
-	poolAddr, err := CalculatePoolAddress(
-		common.HexToAddress(v.cfg.WrapNativeToken),
-		common.HexToAddress(req.Token),
-		v.factory,
-		v.cfg.UniswapPairInitCodeHash,
-	)
+	tokenAddr := common.HexToAddress(req.Token)
+	poolAddr, err := v.dex.FindPool(v.ctx, tokenAddr)
 	if err != nil {
 		return nil, err
 	}
-
-	token0, _ := sortAddressess(common.HexToAddress(req.Token), common.HexToAddress(v.cfg.WrapNativeToken))
-	isToken0 := req.Token == token0.String()
-
-	caller, err := multicall.Dial(context.Background(), v.cfg.RPC)
+	dexPool := &Pool{Address: poolAddr, Token: tokenAddr}
+	poolCalls, err := v.dex.PoolCalls(dexPool)
 	if err != nil {
 		return nil, err
 	}
 
-	erc20Contract, err := multicall.NewContract(erc20.Erc20ABI, req.Token)
+	caller, err := multicall.Dial(context.Background(), v.cfg.RPC)
 	if err != nil {
 		return nil, err
 	}
 
-	routerContract, err := multicall.NewContract(uniswap.PairABI, poolAddr.String())
+	erc20Contract, err := multicall.NewContract(erc20.Erc20ABI, req.Token)
 	if err != nil {
 		return nil, err
 	}
@@ -176,42 +179,40 @@ func (v *EVM) GetPool(req *types.GetPoolRequest, pool *types.Pool) (*types.GetPo
 
 	calls, err := caller.Call(
 		nil,
-		erc20Contract.NewCall( // 0
-			new(balanceOutput),
-			"balanceOf",
-			common.HexToAddress(req.Owner),
-		),
-		erc20Contract.NewCall( // 1
-			new(balanceOutput),
-			"totalSupply",
-		),
-		erc20Contract.NewCall( // 2
-			new(stringOutput),
-			"name",
-		),
-		erc20Contract.NewCall( // 3
-			new(stringOutput),
-			"symbol",
-		),
-		erc20Contract.NewCall( // 4
-			new(uint8Output),
-			"decimals",
-		),
-		erc20Contract.NewCall( // 5
-			new(balanceOutput),
-			"allowance",
-			common.HexToAddress(req.Owner),
-			common.HexToAddress(v.cfg.Router),
-		),
-		routerContract.NewCall( // 6
-			new(reservesOutput),
-			"getReserves",
-		),
-		mcContract.NewCall( // 7
-			new(balanceOutput),
-			"getEthBalance",
-			common.HexToAddress(req.Owner),
-		),
+		append([]*multicall.Call{
+			erc20Contract.NewCall( // 0
+				new(balanceOutput),
+				"balanceOf",
+				common.HexToAddress(req.Owner),
+			),
+			erc20Contract.NewCall( // 1
+				new(balanceOutput),
+				"totalSupply",
+			),
+			erc20Contract.NewCall( // 2
+				new(stringOutput),
+				"name",
+			),
+			erc20Contract.NewCall( // 3
+				new(stringOutput),
+				"symbol",
+			),
+			erc20Contract.NewCall( // 4
+				new(uint8Output),
+				"decimals",
+			),
+			erc20Contract.NewCall( // 5
+				new(balanceOutput),
+				"allowance",
+				common.HexToAddress(req.Owner),
+				v.dex.Spender(),
+			),
+			mcContract.NewCall( // 6
+				new(balanceOutput),
+				"getEthBalance",
+				common.HexToAddress(req.Owner),
+			),
+		}, poolCalls...)...,
 	)
 	if err != nil {
 		return nil, err
@@ -223,23 +224,14 @@ func (v *EVM) GetPool(req *types.GetPoolRequest, pool *types.Pool) (*types.GetPo
 	symbol := calls[3].Outputs.(*stringOutput).Value
 	decimals := calls[4].Outputs.(*uint8Output).Value
 	allowance := calls[5].Outputs.(*balanceOutput).Balance
-	reserves := calls[6].Outputs.(*reservesOutput)
-	nativeBalance := calls[7].Outputs.(*balanceOutput).Balance
-
-	var tokenReserve, quoteReserve decimal.Decimal
-	var tokenReserveBig, quoteReserveBig *big.Int
-	if isToken0 {
-		tokenReserve = decimal.NewFromBigInt(reserves.Reserve0, 0-int32(decimals))
-		quoteReserve = decimal.NewFromBigInt(reserves.Reserve1, 0-int32(v.GetNativeTokenDecimals()))
-		tokenReserveBig = reserves.Reserve0
-		quoteReserveBig = reserves.Reserve1
-	} else {
-		tokenReserve = decimal.NewFromBigInt(reserves.Reserve1, 0-int32(decimals))
-		quoteReserve = decimal.NewFromBigInt(reserves.Reserve0, 0-int32(v.GetNativeTokenDecimals()))
-		tokenReserveBig = reserves.Reserve1
-		quoteReserveBig = reserves.Reserve0
+	nativeBalance := calls[6].Outputs.(*balanceOutput).Balance
+	err = v.dex.DecodePool(dexPool, calls[7:])
+	if err != nil {
+		return nil, err
 	}
 
+	tokenReserve := decimal.NewFromBigInt(dexPool.TokenReserve, 0-int32(decimals))
+	quoteReserve := decimal.NewFromBigInt(dexPool.QuoteReserve, 0-int32(v.GetNativeTokenDecimals()))
 	totalSupply := decimal.NewFromBigInt(totalSupplyWithDecimals, 0-int32(decimals))
 
 	nativeTokenPrice := v.GetNativeTokenPrice()
@@ -254,9 +246,9 @@ func (v *EVM) GetPool(req *types.GetPoolRequest, pool *types.Pool) (*types.GetPo
 		FreezeDisabled:        true,
 		Burnt:                 true,
 		MintAuthorityDisabled: true,
-		TokenReserve:          tokenReserveBig,
-		QuoteReserve:          quoteReserveBig,
-		DexID:                 0,
+		TokenReserve:          dexPool.TokenReserve,
+		QuoteReserve:          dexPool.QuoteReserve,
+		DexID:                 v.dex.ID(),
 		Name:                  name,
 		Symbol:                symbol,
 		Decimals:              decimals,
@@ -314,72 +306,141 @@ func (v *EVM) WatchTransaction(req *types.WatchTransactionRequest) (interface{},
 }
 
 func (v *EVM) GetTransaction(req *types.GetTransactionRequest) (*types.GetTransactionResponse, error) {
+	txHash := common.HexToHash(req.TxHash)
 	tx, _ := req.Tx.(*t.Transaction)
+	if tx == nil {
+		var err error
+		tx, _, err = v.client.TransactionByHash(v.ctx, txHash)
+		if err != nil {
+			return nil, err
+		}
+	}
 
-	receipt, err := v.client.TransactionReceipt(v.ctx, common.HexToHash(req.TxHash))
+	receipt, err := v.client.TransactionReceipt(v.ctx, txHash)
 	if err != nil {
 		return nil, err
 	}
-
-	transferTopic := common.HexToHash("0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef")
+	if receipt.Status != t.ReceiptStatusSuccessful {
+		return nil, types.ErrTransactionFailed
+	}
 
 	fee := new(big.Int).Mul(new(big.Int).SetUint64(receipt.GasUsed), receipt.EffectiveGasPrice)
-
-	var balanceChanged, tokenChanged *big.Int
-	{
-		tokenLog, ok := lo.Find(receipt.Logs, func(n *t.Log) bool {
-			return n.Address.String() == req.Token && n.Topics[0] == transferTopic
-		})
-		if !ok {
-			return nil, types.ErrTransactionInvalid
-		}
-
-		token, err := erc20.NewErc20(common.HexToAddress(req.Token), nil)
+	l1Fee := big.NewInt(0)
+	if v.cfg.OPStack {
+		l1Fee, err = GetL1Fee(v.ctx, v.client, receipt.TxHash)
 		if err != nil {
 			return nil, err
 		}
-		tokenTransfer, err := token.ParseTransfer(*tokenLog)
+		fee.Add(fee, l1Fee)
+	}
+
+	owner := common.HexToAddress(req.Owner)
+	if req.Owner == "" {
+		owner, err = t.Sender(t.LatestSignerForChainID(new(big.Int).SetUint64(v.chainId)), tx)
 		if err != nil {
 			return nil, err
 		}
-		tokenChanged = tokenTransfer.Value
-		if tokenTransfer.From.String() == req.Owner {
-			tokenChanged = new(big.Int).Neg(tokenChanged)
-		}
+	}
+	feeRecipient := common.Address{}
+	if req.FeeRecipient != "" {
+		feeRecipient = common.HexToAddress(req.FeeRecipient)
 	}
 
-	var botFee *big.Int
-	value := tx.Value()
-	{
-		wethLog, ok := lo.Find(receipt.Logs, func(n *t.Log) bool {
-			return n.Address.String() == v.cfg.WrapNativeToken && n.Topics[0] == transferTopic
-		})
-		if !ok {
-			return nil, types.ErrTransactionInvalid
+	trade, err := v.dex.DecodeTrade(v.ctx, tx, receipt, owner, common.HexToAddress(req.Token), feeRecipient)
+	if err != nil {
+		return nil, err
+	}
+	if trade.Hops == 0 || trade.TokenChanged.Sign() == 0 {
+		return nil, types.ErrTransactionInvalid
+	}
+
+	var balanceChanged *big.Int
+	if trade.Buy {
+		balanceChanged = new(big.Int).Neg(new(big.Int).Add(trade.NativeIn, trade.FeePaid))
+	} else {
+		balanceChanged = new(big.Int).Sub(trade.NativeOut, trade.FeePaid)
+	}
+
+	header, err := v.client.HeaderByHash(v.ctx, receipt.BlockHash)
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.GetTransactionResponse{
+		BalanceChanged: balanceChanged,
+		TokenChanged:   trade.TokenChanged,
+		Fee:            fee,
+		L1Fee:          l1Fee,
+		BotFee:         trade.FeePaid,
+		Timestamp:      time.Unix(int64(header.Time), 0),
+	}, nil
+}
+
+// TrackTransaction waits for a transaction sent over any route to land.
+func (v *EVM) TrackTransaction(txHash string, duration time.Duration) (*Inclusion, error) {
+	return TrackInclusion(v.ctx, v.client, common.HexToHash(txHash), duration)
+}
+
+// SpeedUpTransaction resends the pending transaction txHash with the same
+// nonce and fees bumped by bumpPercent, over the route it was sent on.
+func (v *EVM) SpeedUpTransaction(txHash string, bumpPercent uint64, privateKey string) (string, error) {
+	return v.replaceTransaction(txHash, bumpPercent, false, privateKey)
+}
+
+// CancelTransaction replaces the pending transaction txHash with a zero-value
+// transfer to the sender.
+func (v *EVM) CancelTransaction(txHash string, privateKey string) (string, error) {
+	return v.replaceTransaction(txHash, DEFAULT_REPLACEMENT_BUMP, true, privateKey)
+}
+
+func (v *EVM) replaceTransaction(txHash string, bumpPercent uint64, cancel bool, privateKey string) (string, error) {
+	hash := common.HexToHash(txHash)
+	var original *t.Transaction
+	var submitter Submitter
+	if sent, ok := v.sent.get(hash); ok {
+		// private and bundled transactions are never seen pending, the
+		// sender's nonce tells whether they landed
+		from, err := t.Sender(t.LatestSignerForChainID(new(big.Int).SetUint64(v.chainId)), sent.tx)
+		if err != nil {
+			return "", err
 		}
-		weth, err := erc20.NewErc20(common.HexToAddress(v.cfg.WrapNativeToken), nil)
+		nonce, err := v.client.NonceAt(v.ctx, from, nil)
 		if err != nil {
-			return nil, err
+			return "", err
 		}
-		wethTransfer, err := weth.ParseTransfer(*wethLog)
+		if nonce > sent.tx.Nonce() {
+			return "", ErrTxNotPending
+		}
+		original, submitter = sent.tx, sent.submitter
+	} else {
+		tx, pending, err := v.client.TransactionByHash(v.ctx, hash)
 		if err != nil {
-			return nil, err
+			return "", err
 		}
-		if value != nil && value.Cmp(big.NewInt(0)) > 0 {
-			balanceChanged = new(big.Int).Neg(value)
-			botFee = new(big.Int).Sub(value, wethTransfer.Value)
-		} else {
-			botFee = new(big.Int).Div(wethTransfer.Value, big.NewInt(100))
-			balanceChanged = new(big.Int).Sub(wethTransfer.Value, botFee)
+		if !pending {
+			return "", ErrTxNotPending
 		}
+		original, submitter = tx, &publicSubmitter{client: v.client}
 	}
 
-	return &types.GetTransactionResponse{
-		BalanceChanged: balanceChanged,
-		TokenChanged:   tokenChanged,
-		Fee:            fee,
-		BotFee:         botFee,
-	}, nil
+	tx, err := BuildReplacement(v.client, v.chainId, original, bumpPercent, cancel, privateKey)
+	if err != nil {
+		return "", err
+	}
+	err = submitter.Submit(v.ctx, tx)
+	if err != nil {
+		return "", err
+	}
+	// replacements of replacements take the same route
+	v.sent.add(tx, submitter)
+	return tx.Hash().String(), nil
+}
+
+// WatchReplacements reports which of the original and replacement
+// transactions landed.
+func (v *EVM) WatchReplacements(txHashes []string, duration time.Duration) (*Inclusion, error) {
+	hashes := lo.Map(txHashes, func(h string, _ int) common.Hash { return common.HexToHash(h) })
+	return WatchReplacements(v.ctx, v.client, v.chainId, hashes, duration)
 }
 
 func (v *EVM) CheckAddress(text string) bool {
@@ -398,12 +459,13 @@ func (v *EVM) GetAddressFromInput(text string) string {
 }
 
 func (v *EVM) Withdraw(to string, amount decimal.Decimal, privateKey string) (string, error) {
-	txHash, err := TransferETH(
+	txHash, err := TransferETHWithGasMargin(
 		v.client,
 		v.chainId,
 		common.HexToAddress(to),
 		amount.Mul(decimal.New(1, int32(v.cfg.NativeTokenDecimals))).BigInt(),
 		v.GetGasPrice(),
+		v.gasMargin(),
 		privateKey,
 	)
 	if err != nil {
@@ -433,31 +495,36 @@ func (v *EVM) Transact(req *types.Transact, feeRecipient string, feeRatio uint64
 
 	gasPrice := new(big.Int).Add(req.Gas, req.Tip)
 
+	withFee := v.cfg.FeeRouter != ""
+	if feeRecipient == "" {
+		feeRatio = 0
+	}
+
+	pool := &Pool{
+		Address:      common.HexToAddress(req.PoolID),
+		Token:        common.HexToAddress(token),
+		TokenReserve: req.TokenReserve,
+		QuoteReserve: req.QuoteReserve,
+	}
+	var minAmountOut *big.Int
 	if buy {
 		inAmount := new(big.Int).Div(new(big.Int).Mul(req.InAmount, big.NewInt(99)), big.NewInt(100))
-		minAmountOut := utils.CalculateOutputBigInt(inAmount, req.QuoteReserve, req.TokenReserve)
-		slip := new(big.Int).Div(new(big.Int).Mul(minAmountOut, big.NewInt(int64(req.SlipPage))), big.NewInt(10000))
-		minAmountOut = new(big.Int).Sub(minAmountOut, slip)
-
-		txHash, err = SwapBuy(
-			v.client,
-			v.chainId,
-			common.HexToAddress(v.cfg.Router),
-			common.HexToAddress(v.cfg.WrapNativeToken),
-			common.HexToAddress(req.TokenOut),
-			req.InAmount,
-			minAmountOut,
-			gasPrice,
-			privateKey,
-		)
+		if withFee {
+			inAmount = new(big.Int).Sub(req.InAmount, new(big.Int).Div(new(big.Int).Mul(req.InAmount, new(big.Int).SetUint64(feeRatio)), big.NewInt(10000)))
+		}
+		minAmountOut, err = v.dex.Quote(v.ctx, pool, inAmount, true)
+		if err != nil {
+			return nil, err
+		}
 	} else {
 		if req.InAmount.Cmp(req.Allowance) > 0 {
-			tx, err := Approve(
+			tx, err := ApproveWithGasMargin(
 				v.client,
 				v.chainId,
 				common.HexToAddress(req.TokenIn),
-				common.HexToAddress(v.cfg.Router),
+				v.dex.Spender(),
 				v.GetGasPrice(),
+				v.gasMargin(),
 				privateKey,
 			)
 			if err != nil {
@@ -469,27 +536,32 @@ func (v *EVM) Transact(req *types.Transact, feeRecipient string, feeRatio uint64
 			}
 		}
 
-		minAmountOut := utils.CalculateOutputBigInt(req.InAmount, req.TokenReserve, req.QuoteReserve)
-		slip := new(big.Int).Div(new(big.Int).Mul(minAmountOut, big.NewInt(int64(req.SlipPage))), big.NewInt(10000))
-		minAmountOut = new(big.Int).Sub(minAmountOut, slip)
+		minAmountOut, err = v.dex.Quote(v.ctx, pool, req.InAmount, false)
+		if err != nil {
+			return nil, err
+		}
 
 		if req.InAmount.Cmp(initialTokenBalance) == 0 {
 			positionClosed = true
 		}
-
-		txHash, err = SwapSell(
-			v.client,
-			v.chainId,
-			common.HexToAddress(v.cfg.Router),
-			common.HexToAddress(req.TokenIn),
-			common.HexToAddress(v.cfg.WrapNativeToken),
-			req.InAmount,
-			minAmountOut,
-			gasPrice,
-			privateKey,
-		)
 	}
-
+	slip := new(big.Int).Div(new(big.Int).Mul(minAmountOut, big.NewInt(int64(req.SlipPage))), big.NewInt(10000))
+	minAmountOut = new(big.Int).Sub(minAmountOut, slip)
+
+	call, err := v.dex.SwapCall(&SwapRequest{
+		Owner:        common.HexToAddress(req.Owner),
+		Token:        common.HexToAddress(token),
+		Buy:          buy,
+		AmountIn:     req.InAmount,
+		MinAmountOut: minAmountOut,
+		FeeRecipient: common.HexToAddress(feeRecipient),
+		FeeRatio:     feeRatio,
+		Deadline:     big.NewInt(time.Now().Unix() + 3600),
+	})
+	if err != nil {
+		return nil, err
+	}
+	txHash, err = SendSwap(v.client, v.chainId, call, gasPrice, v.gasMargin(), privateKey, v.submitter)
 	if err != nil {
 		return nil, err
 	}
@@ -501,17 +573,69 @@ func (v *EVM) Transact(req *types.Transact, feeRecipient string, feeRatio uint64
 	}, nil
 }
 
+// GetBaseGas is the cost of a swap at the current gas price, at the fixed
+// fallback gas of the costlier direction.
+//
+// Deprecated: Use EstimateTransactFee, which estimates the gas of the swap
+// with the configured margin.
 func (v *EVM) GetBaseGas() *big.Int {
-	return new(big.Int).Mul(v.GetGasPrice(), big.NewInt(21000))
+	return new(big.Int).Mul(v.GetGasPrice(), new(big.Int).SetUint64(max(BUY_GAS, SELL_GAS)))
+}
+
+// EstimateTransactFee estimates the network fee of Transact for req, including
+// the approval a sell may need.
+func (v *EVM) EstimateTransactFee(req *types.Transact) (*big.Int, error) {
+	buy := req.TokenIn == v.cfg.WrapNativeToken
+	owner := common.HexToAddress(req.Owner)
+
+	gasPrice := v.GetGasPrice()
+	if req.Gas != nil && req.Gas.Sign() > 0 {
+		gasPrice = new(big.Int).Add(req.Gas, lo.If(req.Tip != nil, req.Tip).Else(big.NewInt(0)))
+	}
+
+	// the fee transfer itself is left out, the gas margin covers it
+	call, err := v.dex.SwapCall(&SwapRequest{
+		Owner:        owner,
+		Token:        common.HexToAddress(lo.If(buy, req.TokenOut).Else(req.TokenIn)),
+		Buy:          buy,
+		AmountIn:     req.InAmount,
+		MinAmountOut: big.NewInt(0),
+		Deadline:     big.NewInt(time.Now().Unix() + 3600),
+	})
+	if err != nil {
+		return nil, err
+	}
+	gas, err := EstimateGasLimit(v.client, owner, &call.To, call.Value, call.Data, v.gasMargin())
+	if err != nil {
+		gas = lo.If(buy, BUY_GAS).Else(SELL_GAS)
+		if !buy && (req.Allowance == nil || req.InAmount.Cmp(req.Allowance) > 0) {
+			gas += APPROVE_GAS
+		}
+	}
+
+	fee := new(big.Int).Mul(gasPrice, new(big.Int).SetUint64(gas))
+	if v.cfg.OPStack {
+		rawTx, err := t.NewTx(&t.LegacyTx{GasPrice: gasPrice, Gas: gas, To: &call.To, Value: call.Value, Data: call.Data}).MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		l1Fee, err := EstimateL1Fee(v.ctx, v.client, rawTx)
+		if err != nil {
+			return nil, err
+		}
+		fee.Add(fee, l1Fee)
+	}
+	return fee, nil
 }
 
 func (v *EVM) SendNative(bill *types.TransferBill, privateKey string) (string, error) {
-	txHash, err := TransferETH(
+	txHash, err := TransferETHWithGasMargin(
 		v.client,
 		v.chainId,
 		common.HexToAddress(bill.Recipient),
 		bill.Amount,
 		v.GetGasPrice(),
+		v.gasMargin(),
 		privateKey,
 	)
 	if err != nil {
@@ -521,10 +645,11 @@ func (v *EVM) SendNative(bill *types.TransferBill, privateKey string) (string, e
 }
 
 func (v *EVM) SendNativeBatch(bills []*types.TransferBill, privateKey string) (string, error) {
-	txHash, err := TransferETHBatch(
+	txHash, err := TransferETHBatchWithGasMargin(
 		v.client,
 		v.chainId,
 		v.GetGasPrice(),
+		v.gasMargin(),
 		privateKey,
 		bills,
 	)
@@ -533,3 +658,80 @@ func (v *EVM) SendNativeBatch(bills []*types.TransferBill, privateKey string) (s
 	}
 	return txHash.String(), nil
 }
+
+func (v *EVM) SendToken(bill *types.TransferBill, token string, privateKey string) (string, error) {
+	txHash, err := TransferToken(
+		v.client,
+		v.chainId,
+		common.HexToAddress(token),
+		common.HexToAddress(bill.Recipient),
+		bill.Amount,
+		v.GetGasPrice(),
+		v.gasMargin(),
+		privateKey,
+	)
+	if err != nil {
+		return "", err
+	}
+	return txHash.String(), nil
+}
+
+func (v *EVM) SendTokenBatch(bills []*types.TransferBill, token string, privateKey string) ([]*types.TransferResult, error) {
+	pk, err := crypto.HexToECDSA(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	owner := crypto.PubkeyToAddress(pk.PublicKey)
+	disperse, err := DisperseAddress(v.client, v.cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	totalAmount := big.NewInt(0)
+	for _, bill := range bills {
+		totalAmount = new(big.Int).Add(totalAmount, bill.Amount)
+	}
+
+	balance, err := v.GetTokenBalance(&types.GetTokenBalanceRequest{Owner: owner.String(), Token: token})
+	if err != nil {
+		return nil, err
+	}
+	if balance.Cmp(totalAmount) < 0 {
+		return nil, ErrInsufficientTokenBalance
+	}
+
+	allowance, err := Allowerance(v.client, disperse, common.HexToAddress(token), owner)
+	if err != nil {
+		return nil, err
+	}
+	if allowance.Cmp(totalAmount) < 0 {
+		tx, err := ApproveWithGasMargin(
+			v.client,
+			v.chainId,
+			common.HexToAddress(token),
+			disperse,
+			v.GetGasPrice(),
+			v.gasMargin(),
+			privateKey,
+		)
+		if err != nil {
+			return nil, err
+		}
+		_, err = v.WatchTransaction(&types.WatchTransactionRequest{TxHash: tx.String(), Duration: 30 * time.Second})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return TransferTokenBatch(
+		v.client,
+		v.chainId,
+		disperse,
+		common.HexToAddress(token),
+		v.GetGasPrice(),
+		v.gasMargin(),
+		privateKey,
+		bills,
+		v.GetMaxMultiSendCount(),
+	)
+}
@@ -0,0 +1,85 @@
+package evm
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/meme-bots/go-web3/evm/feerouter"
+)
+
+var (
+	feeCollectedTopic common.Hash
+)
+
+func init() {
+	feeRouterABI, err := feerouter.FeeRouterMetaData.GetAbi()
+	if err != nil {
+		panic(err)
+	}
+	feeCollectedTopic = feeRouterABI.Events["FeeCollected"].ID
+}
+
+// DeployFeeRouter deploys a fee router in front of the Uniswap V2 router
+// routerAddr. The returned address goes into Config.FeeRouter.
+func DeployFeeRouter(
+	cli *ethclient.Client,
+	chainId uint64,
+	routerAddr common.Address,
+	gasPrice *big.Int,
+	privateKey string,
+) (common.Address, common.Hash, error) {
+	senderPriKey, err := crypto.HexToECDSA(privateKey)
+	if err != nil {
+		return common.Address{}, common.Hash{}, err
+	}
+
+	auth, err := bind.NewKeyedTransactorWithChainID(senderPriKey, new(big.Int).SetUint64(chainId))
+	if err != nil {
+		return common.Address{}, common.Hash{}, err
+	}
+	auth.GasPrice = gasPrice
+
+	addr, tx, _, err := feerouter.DeployFeeRouter(auth, cli, routerAddr)
+	if err != nil {
+		return common.Address{}, common.Hash{}, err
+	}
+	return addr, tx.Hash(), nil
+}
+
+func packFeeRouterCall(method string, args ...interface{}) ([]byte, error) {
+	feeRouterABI, err := feerouter.FeeRouterMetaData.GetAbi()
+	if err != nil {
+		return nil, err
+	}
+	return feeRouterABI.Pack(method, args...)
+}
+
+// unpackFeeRouterCall returns the router call data wrapped in a fee router
+// buy or sell, or nil if data is not one.
+func unpackFeeRouterCall(data []byte) []byte {
+	if len(data) < 4 {
+		return nil
+	}
+	feeRouterABI, err := feerouter.FeeRouterMetaData.GetAbi()
+	if err != nil {
+		return nil
+	}
+	method, err := feeRouterABI.MethodById(data[:4])
+	if err != nil || (method.Name != "buy" && method.Name != "sell") {
+		return nil
+	}
+	args, err := method.Inputs.Unpack(data[4:])
+	if err != nil {
+		return nil
+	}
+	for i, input := range method.Inputs {
+		if input.Name == "data" {
+			inner, _ := args[i].([]byte)
+			return inner
+		}
+	}
+	return nil
+}
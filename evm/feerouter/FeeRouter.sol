@@ -0,0 +1,93 @@
+// SPDX-License-Identifier: MIT
+pragma solidity ^0.8.20;
+
+interface IERC20 {
+    function balanceOf(address account) external view returns (uint256);
+    function transferFrom(address from, address to, uint256 amount) external returns (bool);
+    function approve(address spender, uint256 amount) external returns (bool);
+}
+
+/// @title FeeRouter
+/// @notice Takes the bot fee of Uniswap V2 router swaps in the native token.
+contract FeeRouter {
+    /// @notice The Uniswap V2 router swaps are sent to.
+    address public immutable router;
+
+    event FeeCollected(address indexed payer, address indexed recipient, uint256 amount);
+
+    constructor(address router_) {
+        router = router_;
+    }
+
+    /// @notice Sends fee to feeRecipient and calls the router with data and
+    /// the rest of msg.value. data must send the bought tokens to the caller.
+    function buy(bytes calldata data, address feeRecipient, uint256 fee) external payable {
+        require(fee <= msg.value);
+        if (fee != 0) {
+            _send(feeRecipient, fee);
+        }
+        _call(router, msg.value - fee, data);
+        emit FeeCollected(msg.sender, feeRecipient, fee);
+    }
+
+    /// @notice Pulls the first argument of data worth of token from the
+    /// caller, rewrites that argument to the amount actually received, and
+    /// approves and calls the router. data must send the native token to this
+    /// contract, which keeps feeBps of what it received for feeRecipient and
+    /// pays the rest to the caller.
+    /// @dev The amount received is the change in this contract's balance, so
+    /// that taxed tokens are sold net of the tax and tokens left here by
+    /// anyone else are never sold on the caller's behalf.
+    function sell(address token, bytes calldata data, address feeRecipient, uint256 feeBps) external {
+        require(feeBps <= 10000);
+        require(data.length >= 36);
+        bytes memory call = data;
+        uint256 amountIn;
+        assembly {
+            amountIn := mload(add(call, 36))
+        }
+
+        uint256 held = IERC20(token).balanceOf(address(this));
+        _callToken(token, abi.encodeCall(IERC20.transferFrom, (msg.sender, address(this), amountIn)));
+        uint256 received = IERC20(token).balanceOf(address(this)) - held;
+        assembly {
+            mstore(add(call, 36), received)
+        }
+        _callToken(token, abi.encodeCall(IERC20.approve, (router, received)));
+
+        uint256 balance = address(this).balance;
+        _call(router, 0, call);
+        uint256 out = address(this).balance - balance;
+        uint256 fee = out * feeBps / 10000;
+        if (fee != 0) {
+            _send(feeRecipient, fee);
+        }
+        _send(msg.sender, out - fee);
+        emit FeeCollected(msg.sender, feeRecipient, fee);
+    }
+
+    /// @notice Takes the native token the router pays out on sells.
+    receive() external payable {}
+
+    function _send(address to, uint256 amount) private {
+        (bool ok,) = to.call{value: amount}("");
+        require(ok);
+    }
+
+    /// @dev Calls target, bubbling up its revert.
+    function _call(address target, uint256 value, bytes memory data) private returns (bytes memory) {
+        (bool ok, bytes memory result) = target.call{value: value}(data);
+        if (!ok) {
+            assembly {
+                revert(add(result, 32), mload(result))
+            }
+        }
+        return result;
+    }
+
+    /// @dev Calls a token that may or may not return a success flag.
+    function _callToken(address token, bytes memory data) private {
+        bytes memory result = _call(token, 0, data);
+        require(result.length == 0 || abi.decode(result, (bool)));
+    }
+}
@@ -0,0 +1,451 @@
+// Code generated - DO NOT EDIT.
+// This file is a generated binding and any manual changes will be lost.
+
+package feerouter
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var (
+	_ = errors.New
+	_ = big.NewInt
+	_ = strings.NewReader
+	_ = ethereum.NotFound
+	_ = bind.Bind
+	_ = common.Big1
+	_ = types.BloomLookup
+	_ = event.NewSubscription
+	_ = abi.ConvertType
+)
+
+// FeeRouterMetaData contains all meta data concerning the FeeRouter contract.
+var FeeRouterMetaData = &bind.MetaData{
+	ABI: "[{\"inputs\":[{\"internalType\":\"address\",\"name\":\"router\",\"type\":\"address\"}],\"stateMutability\":\"nonpayable\",\"type\":\"constructor\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"address\",\"name\":\"payer\",\"type\":\"address\"},{\"indexed\":true,\"internalType\":\"address\",\"name\":\"recipient\",\"type\":\"address\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"amount\",\"type\":\"uint256\"}],\"name\":\"FeeCollected\",\"type\":\"event\"},{\"inputs\":[{\"internalType\":\"bytes\",\"name\":\"data\",\"type\":\"bytes\"},{\"internalType\":\"address\",\"name\":\"feeRecipient\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"fee\",\"type\":\"uint256\"}],\"name\":\"buy\",\"outputs\":[],\"stateMutability\":\"payable\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"router\",\"outputs\":[{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"token\",\"type\":\"address\"},{\"internalType\":\"bytes\",\"name\":\"data\",\"type\":\"bytes\"},{\"internalType\":\"address\",\"name\":\"feeRecipient\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"feeBps\",\"type\":\"uint256\"}],\"name\":\"sell\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"stateMutability\":\"payable\",\"type\":\"receive\"}]",
+	Bin: "0x60208038036000396000516000556102b68063000000206001016000396000f35b361563000000395760003560e01c8063f810b8f2146300000051578063467cd7251463000000f85763f887ea40146300000045575b600080fd5b005b3d6000803e3d6000fd5b60005460005260206000f35b6044358034106300000034578015630000008e5760008080808460243573ffffffffffffffffffffffffffffffffffffffff165af1156300000034575b6004356004018035809160200160003760008082818534036000545af115630000003b575060005260243573ffffffffffffffffffffffffffffffffffffffff16337ff228de527fc1b9843baac03b9a04565473a263375950e63435d4138464386f4660206000a3005b6064356127101063000000345760243560040180358082602001610100376370a0823160e01b6000523060045260206000602460006004355afa15630000003b5760203d1063000000345760005160e0526323b872dd60e01b600052336004523060245261010451604452602060006064600060006004355af115630000003b573d15630000018c57600051156300000034575b6370a0823160e01b6000523060045260206000602460006004355afa15630000003b5760203d1063000000345760005160e0518181116300000034579003806101045263095ea7b360e01b600052600054600452602452602060006044600060006004355af115630000003b573d15630000020c57600051156300000034575b47600060008361010060006000545af115630000003b5747036127106064358202048015630000025f5760008080808460443573ffffffffffffffffffffffffffffffffffffffff165af1156300000034575b6000808080848603335af11563000000345760005260443573ffffffffffffffffffffffffffffffffffffffff16337ff228de527fc1b9843baac03b9a04565473a263375950e63435d4138464386f4660206000a300",
+}
+
+// FeeRouterABI is the input ABI used to generate the binding from.
+// Deprecated: Use FeeRouterMetaData.ABI instead.
+var FeeRouterABI = FeeRouterMetaData.ABI
+
+// FeeRouterBin is the compiled bytecode used for deploying new contracts.
+// Deprecated: Use FeeRouterMetaData.Bin instead.
+var FeeRouterBin = FeeRouterMetaData.Bin
+
+// DeployFeeRouter deploys a new Ethereum contract, binding an instance of FeeRouter to it.
+func DeployFeeRouter(auth *bind.TransactOpts, backend bind.ContractBackend, router common.Address) (common.Address, *types.Transaction, *FeeRouter, error) {
+	parsed, err := FeeRouterMetaData.GetAbi()
+	if err != nil {
+		return common.Address{}, nil, nil, err
+	}
+	if parsed == nil {
+		return common.Address{}, nil, nil, errors.New("GetABI returned nil")
+	}
+
+	address, tx, contract, err := bind.DeployContract(auth, *parsed, common.FromHex(FeeRouterBin), backend, router)
+	if err != nil {
+		return common.Address{}, nil, nil, err
+	}
+	return address, tx, &FeeRouter{FeeRouterCaller: FeeRouterCaller{contract: contract}, FeeRouterTransactor: FeeRouterTransactor{contract: contract}, FeeRouterFilterer: FeeRouterFilterer{contract: contract}}, nil
+}
+
+// FeeRouter is an auto generated Go binding around an Ethereum contract.
+type FeeRouter struct {
+	FeeRouterCaller     // Read-only binding to the contract
+	FeeRouterTransactor // Write-only binding to the contract
+	FeeRouterFilterer   // Log filterer for contract events
+}
+
+// FeeRouterCaller is an auto generated read-only Go binding around an Ethereum contract.
+type FeeRouterCaller struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// FeeRouterTransactor is an auto generated write-only Go binding around an Ethereum contract.
+type FeeRouterTransactor struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// FeeRouterFilterer is an auto generated log filtering Go binding around an Ethereum contract events.
+type FeeRouterFilterer struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// FeeRouterSession is an auto generated Go binding around an Ethereum contract,
+// with pre-set call and transact options.
+type FeeRouterSession struct {
+	Contract     *FeeRouter        // Generic contract binding to set the session for
+	CallOpts     bind.CallOpts     // Call options to use throughout this session
+	TransactOpts bind.TransactOpts // Transaction auth options to use throughout this session
+}
+
+// FeeRouterCallerSession is an auto generated read-only Go binding around an Ethereum contract,
+// with pre-set call options.
+type FeeRouterCallerSession struct {
+	Contract *FeeRouterCaller // Generic contract caller binding to set the session for
+	CallOpts bind.CallOpts    // Call options to use throughout this session
+}
+
+// FeeRouterTransactorSession is an auto generated write-only Go binding around an Ethereum contract,
+// with pre-set transact options.
+type FeeRouterTransactorSession struct {
+	Contract     *FeeRouterTransactor // Generic contract transactor binding to set the session for
+	TransactOpts bind.TransactOpts    // Transaction auth options to use throughout this session
+}
+
+// FeeRouterRaw is an auto generated low-level Go binding around an Ethereum contract.
+type FeeRouterRaw struct {
+	Contract *FeeRouter // Generic contract binding to access the raw methods on
+}
+
+// FeeRouterCallerRaw is an auto generated low-level read-only Go binding around an Ethereum contract.
+type FeeRouterCallerRaw struct {
+	Contract *FeeRouterCaller // Generic read-only contract binding to access the raw methods on
+}
+
+// FeeRouterTransactorRaw is an auto generated low-level write-only Go binding around an Ethereum contract.
+type FeeRouterTransactorRaw struct {
+	Contract *FeeRouterTransactor // Generic write-only contract binding to access the raw methods on
+}
+
+// NewFeeRouter creates a new instance of FeeRouter, bound to a specific deployed contract.
+func NewFeeRouter(address common.Address, backend bind.ContractBackend) (*FeeRouter, error) {
+	contract, err := bindFeeRouter(address, backend, backend, backend)
+	if err != nil {
+		return nil, err
+	}
+	return &FeeRouter{FeeRouterCaller: FeeRouterCaller{contract: contract}, FeeRouterTransactor: FeeRouterTransactor{contract: contract}, FeeRouterFilterer: FeeRouterFilterer{contract: contract}}, nil
+}
+
+// NewFeeRouterCaller creates a new read-only instance of FeeRouter, bound to a specific deployed contract.
+func NewFeeRouterCaller(address common.Address, caller bind.ContractCaller) (*FeeRouterCaller, error) {
+	contract, err := bindFeeRouter(address, caller, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &FeeRouterCaller{contract: contract}, nil
+}
+
+// NewFeeRouterTransactor creates a new write-only instance of FeeRouter, bound to a specific deployed contract.
+func NewFeeRouterTransactor(address common.Address, transactor bind.ContractTransactor) (*FeeRouterTransactor, error) {
+	contract, err := bindFeeRouter(address, nil, transactor, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &FeeRouterTransactor{contract: contract}, nil
+}
+
+// NewFeeRouterFilterer creates a new log filterer instance of FeeRouter, bound to a specific deployed contract.
+func NewFeeRouterFilterer(address common.Address, filterer bind.ContractFilterer) (*FeeRouterFilterer, error) {
+	contract, err := bindFeeRouter(address, nil, nil, filterer)
+	if err != nil {
+		return nil, err
+	}
+	return &FeeRouterFilterer{contract: contract}, nil
+}
+
+// bindFeeRouter binds a generic wrapper to an already deployed contract.
+func bindFeeRouter(address common.Address, caller bind.ContractCaller, transactor bind.ContractTransactor, filterer bind.ContractFilterer) (*bind.BoundContract, error) {
+	parsed, err := FeeRouterMetaData.GetAbi()
+	if err != nil {
+		return nil, err
+	}
+	return bind.NewBoundContract(address, *parsed, caller, transactor, filterer), nil
+}
+
+// Call invokes the (constant) contract method with params as input values and
+// sets the output to result. The result type might be a single field for simple
+// returns, a slice of interfaces for anonymous returns and a struct for named
+// returns.
+func (_FeeRouter *FeeRouterRaw) Call(opts *bind.CallOpts, result *[]interface{}, method string, params ...interface{}) error {
+	return _FeeRouter.Contract.FeeRouterCaller.contract.Call(opts, result, method, params...)
+}
+
+// Transfer initiates a plain transaction to move funds to the contract, calling
+// its default method if one is available.
+func (_FeeRouter *FeeRouterRaw) Transfer(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _FeeRouter.Contract.FeeRouterTransactor.contract.Transfer(opts)
+}
+
+// Transact invokes the (paid) contract method with params as input values.
+func (_FeeRouter *FeeRouterRaw) Transact(opts *bind.TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+	return _FeeRouter.Contract.FeeRouterTransactor.contract.Transact(opts, method, params...)
+}
+
+// Call invokes the (constant) contract method with params as input values and
+// sets the output to result. The result type might be a single field for simple
+// returns, a slice of interfaces for anonymous returns and a struct for named
+// returns.
+func (_FeeRouter *FeeRouterCallerRaw) Call(opts *bind.CallOpts, result *[]interface{}, method string, params ...interface{}) error {
+	return _FeeRouter.Contract.contract.Call(opts, result, method, params...)
+}
+
+// Transfer initiates a plain transaction to move funds to the contract, calling
+// its default method if one is available.
+func (_FeeRouter *FeeRouterTransactorRaw) Transfer(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _FeeRouter.Contract.contract.Transfer(opts)
+}
+
+// Transact invokes the (paid) contract method with params as input values.
+func (_FeeRouter *FeeRouterTransactorRaw) Transact(opts *bind.TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+	return _FeeRouter.Contract.contract.Transact(opts, method, params...)
+}
+
+// Router is a free data retrieval call binding the contract method 0xf887ea40.
+//
+// Solidity: function router() view returns(address)
+func (_FeeRouter *FeeRouterCaller) Router(opts *bind.CallOpts) (common.Address, error) {
+	var out []interface{}
+	err := _FeeRouter.contract.Call(opts, &out, "router")
+
+	if err != nil {
+		return *new(common.Address), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(common.Address)).(*common.Address)
+
+	return out0, err
+
+}
+
+// Router is a free data retrieval call binding the contract method 0xf887ea40.
+//
+// Solidity: function router() view returns(address)
+func (_FeeRouter *FeeRouterSession) Router() (common.Address, error) {
+	return _FeeRouter.Contract.Router(&_FeeRouter.CallOpts)
+}
+
+// Router is a free data retrieval call binding the contract method 0xf887ea40.
+//
+// Solidity: function router() view returns(address)
+func (_FeeRouter *FeeRouterCallerSession) Router() (common.Address, error) {
+	return _FeeRouter.Contract.Router(&_FeeRouter.CallOpts)
+}
+
+// Buy is a paid mutator transaction binding the contract method 0xf810b8f2.
+//
+// Solidity: function buy(bytes data, address feeRecipient, uint256 fee) payable returns()
+func (_FeeRouter *FeeRouterTransactor) Buy(opts *bind.TransactOpts, data []byte, feeRecipient common.Address, fee *big.Int) (*types.Transaction, error) {
+	return _FeeRouter.contract.Transact(opts, "buy", data, feeRecipient, fee)
+}
+
+// Buy is a paid mutator transaction binding the contract method 0xf810b8f2.
+//
+// Solidity: function buy(bytes data, address feeRecipient, uint256 fee) payable returns()
+func (_FeeRouter *FeeRouterSession) Buy(data []byte, feeRecipient common.Address, fee *big.Int) (*types.Transaction, error) {
+	return _FeeRouter.Contract.Buy(&_FeeRouter.TransactOpts, data, feeRecipient, fee)
+}
+
+// Buy is a paid mutator transaction binding the contract method 0xf810b8f2.
+//
+// Solidity: function buy(bytes data, address feeRecipient, uint256 fee) payable returns()
+func (_FeeRouter *FeeRouterTransactorSession) Buy(data []byte, feeRecipient common.Address, fee *big.Int) (*types.Transaction, error) {
+	return _FeeRouter.Contract.Buy(&_FeeRouter.TransactOpts, data, feeRecipient, fee)
+}
+
+// Sell is a paid mutator transaction binding the contract method 0x467cd725.
+//
+// Solidity: function sell(address token, bytes data, address feeRecipient, uint256 feeBps) returns()
+func (_FeeRouter *FeeRouterTransactor) Sell(opts *bind.TransactOpts, token common.Address, data []byte, feeRecipient common.Address, feeBps *big.Int) (*types.Transaction, error) {
+	return _FeeRouter.contract.Transact(opts, "sell", token, data, feeRecipient, feeBps)
+}
+
+// Sell is a paid mutator transaction binding the contract method 0x467cd725.
+//
+// Solidity: function sell(address token, bytes data, address feeRecipient, uint256 feeBps) returns()
+func (_FeeRouter *FeeRouterSession) Sell(token common.Address, data []byte, feeRecipient common.Address, feeBps *big.Int) (*types.Transaction, error) {
+	return _FeeRouter.Contract.Sell(&_FeeRouter.TransactOpts, token, data, feeRecipient, feeBps)
+}
+
+// Sell is a paid mutator transaction binding the contract method 0x467cd725.
+//
+// Solidity: function sell(address token, bytes data, address feeRecipient, uint256 feeBps) returns()
+func (_FeeRouter *FeeRouterTransactorSession) Sell(token common.Address, data []byte, feeRecipient common.Address, feeBps *big.Int) (*types.Transaction, error) {
+	return _FeeRouter.Contract.Sell(&_FeeRouter.TransactOpts, token, data, feeRecipient, feeBps)
+}
+
+// Receive is a paid mutator transaction binding the contract receive function.
+//
+// Solidity: receive() payable returns()
+func (_FeeRouter *FeeRouterTransactor) Receive(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _FeeRouter.contract.RawTransact(opts, nil) // calldata is disallowed for receive function
+}
+
+// Receive is a paid mutator transaction binding the contract receive function.
+//
+// Solidity: receive() payable returns()
+func (_FeeRouter *FeeRouterSession) Receive() (*types.Transaction, error) {
+	return _FeeRouter.Contract.Receive(&_FeeRouter.TransactOpts)
+}
+
+// Receive is a paid mutator transaction binding the contract receive function.
+//
+// Solidity: receive() payable returns()
+func (_FeeRouter *FeeRouterTransactorSession) Receive() (*types.Transaction, error) {
+	return _FeeRouter.Contract.Receive(&_FeeRouter.TransactOpts)
+}
+
+// FeeRouterFeeCollectedIterator is returned from FilterFeeCollected and is used to iterate over the raw logs and unpacked data for FeeCollected events raised by the FeeRouter contract.
+type FeeRouterFeeCollectedIterator struct {
+	Event *FeeRouterFeeCollected // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *FeeRouterFeeCollectedIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(FeeRouterFeeCollected)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(FeeRouterFeeCollected)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *FeeRouterFeeCollectedIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *FeeRouterFeeCollectedIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// FeeRouterFeeCollected represents a FeeCollected event raised by the FeeRouter contract.
+type FeeRouterFeeCollected struct {
+	Payer     common.Address
+	Recipient common.Address
+	Amount    *big.Int
+	Raw       types.Log // Blockchain specific contextual infos
+}
+
+// FilterFeeCollected is a free log retrieval operation binding the contract event 0xf228de527fc1b9843baac03b9a04565473a263375950e63435d4138464386f46.
+//
+// Solidity: event FeeCollected(address indexed payer, address indexed recipient, uint256 amount)
+func (_FeeRouter *FeeRouterFilterer) FilterFeeCollected(opts *bind.FilterOpts, payer []common.Address, recipient []common.Address) (*FeeRouterFeeCollectedIterator, error) {
+
+	var payerRule []interface{}
+	for _, payerItem := range payer {
+		payerRule = append(payerRule, payerItem)
+	}
+	var recipientRule []interface{}
+	for _, recipientItem := range recipient {
+		recipientRule = append(recipientRule, recipientItem)
+	}
+
+	logs, sub, err := _FeeRouter.contract.FilterLogs(opts, "FeeCollected", payerRule, recipientRule)
+	if err != nil {
+		return nil, err
+	}
+	return &FeeRouterFeeCollectedIterator{contract: _FeeRouter.contract, event: "FeeCollected", logs: logs, sub: sub}, nil
+}
+
+// WatchFeeCollected is a free log subscription operation binding the contract event 0xf228de527fc1b9843baac03b9a04565473a263375950e63435d4138464386f46.
+//
+// Solidity: event FeeCollected(address indexed payer, address indexed recipient, uint256 amount)
+func (_FeeRouter *FeeRouterFilterer) WatchFeeCollected(opts *bind.WatchOpts, sink chan<- *FeeRouterFeeCollected, payer []common.Address, recipient []common.Address) (event.Subscription, error) {
+
+	var payerRule []interface{}
+	for _, payerItem := range payer {
+		payerRule = append(payerRule, payerItem)
+	}
+	var recipientRule []interface{}
+	for _, recipientItem := range recipient {
+		recipientRule = append(recipientRule, recipientItem)
+	}
+
+	logs, sub, err := _FeeRouter.contract.WatchLogs(opts, "FeeCollected", payerRule, recipientRule)
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(FeeRouterFeeCollected)
+				if err := _FeeRouter.contract.UnpackLog(event, "FeeCollected", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseFeeCollected is a log parse operation binding the contract event 0xf228de527fc1b9843baac03b9a04565473a263375950e63435d4138464386f46.
+//
+// Solidity: event FeeCollected(address indexed payer, address indexed recipient, uint256 amount)
+func (_FeeRouter *FeeRouterFilterer) ParseFeeCollected(log types.Log) (*FeeRouterFeeCollected, error) {
+	event := new(FeeRouterFeeCollected)
+	if err := _FeeRouter.contract.UnpackLog(event, "FeeCollected", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
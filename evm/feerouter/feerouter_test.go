@@ -0,0 +1,200 @@
+package feerouter
+
+import (
+	"context"
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/asm"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient/simulated"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+var (
+	mockRouter = common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	mockToken  = common.HexToAddress("0x00000000000000000000000000000000000000bb")
+	feeWallet  = common.HexToAddress("0x00000000000000000000000000000000000000cc")
+)
+
+// routerCode records the first call argument and pays 1 ether to callers
+// that send no value, like swapExactTokensForETH does.
+const routerCode = `
+	PUSH 0x04
+	CALLDATALOAD
+	PUSH 0x00
+	SSTORE
+	CALLVALUE
+	JUMPI @done
+	PUSH 0x00
+	DUP1
+	DUP1
+	DUP1
+	PUSH 0x0de0b6b3a7640000
+	CALLER
+	GAS
+	CALL
+	POP
+done:
+	STOP
+`
+
+// tokenCode charges a 10% tax: transferFrom credits 90% of the amount to
+// the balance in slot 0, which balanceOf answers, and everything else
+// returns true.
+const tokenCode = `
+	PUSH 0x00
+	CALLDATALOAD
+	PUSH 0xe0
+	SHR
+	DUP1
+	PUSH 0x70a08231
+	EQ
+	JUMPI @balance
+	PUSH 0x23b872dd
+	EQ
+	ISZERO
+	JUMPI @ret
+	PUSH 0x0a
+	PUSH 0x09
+	PUSH 0x44
+	CALLDATALOAD
+	MUL
+	DIV
+	PUSH 0x00
+	SLOAD
+	ADD
+	PUSH 0x00
+	SSTORE
+ret:
+	PUSH 0x01
+	PUSH 0x00
+	MSTORE
+	PUSH 0x20
+	PUSH 0x00
+	RETURN
+balance:
+	PUSH 0x00
+	SLOAD
+	PUSH 0x00
+	MSTORE
+	PUSH 0x20
+	PUSH 0x00
+	RETURN
+`
+
+func assemble(t *testing.T, src string) []byte {
+	c := asm.NewCompiler(false)
+	c.Feed(asm.Lex([]byte(src), false))
+	out, errs := c.Compile()
+	if len(errs) != 0 {
+		t.Fatal(errs)
+	}
+	code, err := hex.DecodeString(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
+
+func TestFeeRouter(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	sender := crypto.PubkeyToAddress(key.PublicKey)
+	backend := simulated.NewBackend(types.GenesisAlloc{
+		sender:     {Balance: new(big.Int).Mul(big.NewInt(100), big.NewInt(params.Ether))},
+		mockRouter: {Balance: new(big.Int).Mul(big.NewInt(10), big.NewInt(params.Ether)), Code: assemble(t, routerCode)},
+		// 500 tokens left in the router by someone else
+		mockToken: {Code: assemble(t, tokenCode), Storage: map[common.Hash]common.Hash{{}: common.BigToHash(big.NewInt(500))}},
+	})
+	defer backend.Close()
+	client := backend.Client()
+	ctx := context.Background()
+
+	auth, _ := bind.NewKeyedTransactorWithChainID(key, big.NewInt(1337))
+	addr, _, contract, err := DeployFeeRouter(auth, client, mockRouter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	backend.Commit()
+
+	router, err := contract.Router(&bind.CallOpts{})
+	if err != nil || router != mockRouter {
+		t.Fatalf("router = %v, %v", router, err)
+	}
+
+	receipt := func(tx *types.Transaction) *types.Receipt {
+		backend.Commit()
+		r, err := client.TransactionReceipt(ctx, tx.Hash())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r.Status != types.ReceiptStatusSuccessful {
+			t.Fatal("transaction reverted")
+		}
+		return r
+	}
+	feeOf := func(r *types.Receipt) *big.Int {
+		for _, log := range r.Logs {
+			event, err := contract.ParseFeeCollected(*log)
+			if err == nil {
+				if event.Payer != sender || event.Recipient != feeWallet {
+					t.Fatalf("unexpected event %+v", event)
+				}
+				return event.Amount
+			}
+		}
+		t.Fatal("no FeeCollected event")
+		return nil
+	}
+
+	// buy: 1 ether in, 0.01 ether fee, the rest forwarded to the router
+	data := common.LeftPadBytes(big.NewInt(7).Bytes(), 36)
+	fee := big.NewInt(1e16)
+	auth.Value = big.NewInt(1e18)
+	tx, err := contract.Buy(auth, data, feeWallet, fee)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := feeOf(receipt(tx)); got.Cmp(fee) != 0 {
+		t.Fatalf("buy fee = %v", got)
+	}
+	balance, _ := client.BalanceAt(ctx, feeWallet, nil)
+	if balance.Cmp(fee) != 0 {
+		t.Fatalf("fee wallet = %v", balance)
+	}
+	balance, _ = client.BalanceAt(ctx, mockRouter, nil)
+	if want := new(big.Int).Add(new(big.Int).Mul(big.NewInt(10), big.NewInt(params.Ether)), big.NewInt(99e16)); balance.Cmp(want) != 0 {
+		t.Fatalf("router = %v, want %v", balance, want)
+	}
+
+	// sell: 1000 tokens, 900 received and sold, 1 ether out, 1% fee
+	data = common.LeftPadBytes(big.NewInt(1000).Bytes(), 36)
+	auth.Value = nil
+	before, _ := client.BalanceAt(ctx, sender, nil)
+	tx, err = contract.Sell(auth, mockToken, data, feeWallet, big.NewInt(100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := receipt(tx)
+	if got := feeOf(r); got.Cmp(big.NewInt(1e16)) != 0 {
+		t.Fatalf("sell fee = %v", got)
+	}
+	amountIn, _ := client.StorageAt(ctx, mockRouter, common.Hash{}, nil)
+	if new(big.Int).SetBytes(amountIn).Int64() != 900 {
+		t.Fatalf("router amountIn = %x", amountIn)
+	}
+	after, _ := client.BalanceAt(ctx, sender, nil)
+	gas := new(big.Int).Mul(new(big.Int).SetUint64(r.GasUsed), r.EffectiveGasPrice)
+	got := new(big.Int).Add(new(big.Int).Sub(after, before), gas)
+	if got.Cmp(big.NewInt(99e16)) != 0 {
+		t.Fatalf("seller received %v", got)
+	}
+	balance, _ = client.BalanceAt(ctx, addr, nil)
+	if balance.Sign() != 0 {
+		t.Fatalf("router kept %v", balance)
+	}
+}
@@ -0,0 +1,69 @@
+//go:build ignore
+
+// FeeRouter.sol is the source of the contract. The binding is meant to be
+// generated from its solc build with
+//
+//	solc --optimize --abi --bin --overwrite -o . FeeRouter.sol
+//	abigen --abi FeeRouter.abi --bin FeeRouter.bin --pkg feerouter --type FeeRouter --out feerouter.go
+//
+// Until that build is checked in, feerouter.evm is a hand assembly of the
+// same contract, which gen assembles into feerouter.bin:
+//
+//	go run gen.go
+//	abigen --abi feerouter.abi --bin feerouter.bin --pkg feerouter --type FeeRouter --out feerouter.go
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/ethereum/go-ethereum/core/asm"
+)
+
+// constructor stores the router passed as the only argument in slot 0 and
+// returns the runtime code that follows the code label.
+const constructor = `
+	PUSH 0x20
+	DUP1
+	CODESIZE
+	SUB
+	PUSH 0x00
+	CODECOPY
+	PUSH 0x00
+	MLOAD
+	PUSH 0x00
+	SSTORE
+	PUSH %d
+	DUP1
+	PUSH @code
+	PUSH 0x01
+	ADD
+	PUSH 0x00
+	CODECOPY
+	PUSH 0x00
+	RETURN
+code:
+`
+
+func compile(src []byte) string {
+	c := asm.NewCompiler(false)
+	c.Feed(asm.Lex(src, false))
+	out, errs := c.Compile()
+	if len(errs) != 0 {
+		panic(fmt.Sprint(errs))
+	}
+	return out
+}
+
+func main() {
+	src, err := os.ReadFile("feerouter.evm")
+	if err != nil {
+		panic(err)
+	}
+	runtime := compile(src)
+	init := compile([]byte(fmt.Sprintf(constructor, len(runtime)/2)))
+	err = os.WriteFile("feerouter.bin", []byte(init+runtime), 0o644)
+	if err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,48 @@
+package evm
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+const (
+	// DEFAULT_GAS_MARGIN is the percentage added on top of eth_estimateGas
+	// when the config does not set one.
+	DEFAULT_GAS_MARGIN uint64 = 20
+
+	// APPROVE_GAS is the fallback for an ERC-20 approve when it cannot be
+	// estimated.
+	APPROVE_GAS uint64 = 46000
+)
+
+// EstimateGasLimit estimates the gas of the call and adds margin percent.
+func EstimateGasLimit(
+	client *ethclient.Client,
+	from common.Address,
+	to *common.Address,
+	value *big.Int,
+	data []byte,
+	margin uint64,
+) (uint64, error) {
+	gas, err := client.EstimateGas(context.Background(), ethereum.CallMsg{
+		From:  from,
+		To:    to,
+		Value: value,
+		Data:  data,
+	})
+	if err != nil {
+		return 0, err
+	}
+	return gas + gas*margin/100, nil
+}
+
+func (v *EVM) gasMargin() uint64 {
+	if v.cfg.GasLimitMargin > 0 {
+		return uint64(v.cfg.GasLimitMargin)
+	}
+	return DEFAULT_GAS_MARGIN
+}
@@ -0,0 +1,96 @@
+package evm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/meme-bots/go-web3/types"
+)
+
+// newGasNode estimates every call at gas, or fails estimating when gas is
+// zero, and has the GasPriceOracle charge l1Fee.
+func newGasNode(tb testing.TB, gas uint64, l1Fee int64) *EVM {
+	client := newStubNode(tb, func(method string, params []json.RawMessage) (interface{}, error) {
+		switch method {
+		case "eth_estimateGas":
+			if gas == 0 {
+				return nil, errors.New("execution reverted: TRANSFER_FROM_FAILED")
+			}
+			return hexutil.Uint64(gas), nil
+		case "eth_call":
+			var call struct{ To common.Address }
+			_ = json.Unmarshal(params[0], &call)
+			if call.To != GAS_PRICE_ORACLE_ADDRESS {
+				return nil, errors.New("unexpected call")
+			}
+			return hexutil.Bytes(math.U256Bytes(big.NewInt(l1Fee))), nil
+		}
+		return nil, errors.New("unexpected " + method)
+	})
+	return &EVM{
+		ctx:     context.Background(),
+		cfg:     &types.Config{WrapNativeToken: "0x00000000000000000000000000000000000000ee"},
+		client:  client,
+		watcher: &Watcher{gasPrice: big.NewInt(10)},
+		dex: &UniswapV2{
+			router:  common.HexToAddress("0x00000000000000000000000000000000000000aa"),
+			wrapped: common.HexToAddress("0x00000000000000000000000000000000000000ee"),
+		},
+	}
+}
+
+func TestEstimateGasLimit(t *testing.T) {
+	v := newGasNode(t, 21000, 0)
+	to := common.HexToAddress("0x01")
+	gas, err := EstimateGasLimit(v.client, common.Address{}, &to, big.NewInt(1), nil, 20)
+	if err != nil || gas != 25200 {
+		t.Errorf("gas %d, err %v", gas, err)
+	}
+
+	_, err = EstimateGasLimit(newGasNode(t, 0, 0).client, common.Address{}, &to, big.NewInt(1), nil, 20)
+	if err == nil {
+		t.Error("a failed estimate is not reported")
+	}
+}
+
+func TestEVM_EstimateTransactFee(t *testing.T) {
+	buy := &types.Transact{
+		TokenIn:  "0x00000000000000000000000000000000000000ee",
+		TokenOut: "0x00000000000000000000000000000000000000bb",
+		InAmount: big.NewInt(1000),
+	}
+	sell := &types.Transact{
+		TokenIn:  "0x00000000000000000000000000000000000000bb",
+		TokenOut: "0x00000000000000000000000000000000000000ee",
+		InAmount: big.NewInt(1000),
+	}
+
+	// the estimate plus the default margin, at the watched gas price
+	v := newGasNode(t, 100000, 0)
+	if fee, err := v.EstimateTransactFee(buy); err != nil || fee.Int64() != 10*120000 {
+		t.Errorf("estimated: fee %v, err %v", fee, err)
+	}
+	v.cfg.GasLimitMargin = 50
+	if fee, err := v.EstimateTransactFee(buy); err != nil || fee.Int64() != 10*150000 {
+		t.Errorf("configured margin: fee %v, err %v", fee, err)
+	}
+
+	// an unapproved sell cannot be estimated and is priced with its approval
+	v = newGasNode(t, 0, 0)
+	if fee, err := v.EstimateTransactFee(sell); err != nil || fee.Uint64() != 10*(SELL_GAS+APPROVE_GAS) {
+		t.Errorf("fallback: fee %v, err %v", fee, err)
+	}
+
+	// OP-stack chains add the L1 data fee
+	v = newGasNode(t, 100000, 5000)
+	v.cfg.OPStack = true
+	if fee, err := v.EstimateTransactFee(buy); err != nil || fee.Int64() != 10*120000+5000 {
+		t.Errorf("op stack: fee %v, err %v", fee, err)
+	}
+}
@@ -0,0 +1,48 @@
+package evm
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/meme-bots/go-web3/evm/optimism"
+)
+
+var (
+	// GAS_PRICE_ORACLE_ADDRESS is the GasPriceOracle predeploy of OP-stack chains.
+	GAS_PRICE_ORACLE_ADDRESS = common.HexToAddress("0x420000000000000000000000000000000000000F")
+)
+
+// opReceipt holds the L1 data fee fields OP-stack nodes add to receipts.
+type opReceipt struct {
+	L1Fee      *hexutil.Big `json:"l1Fee"`
+	L1GasUsed  *hexutil.Big `json:"l1GasUsed"`
+	L1GasPrice *hexutil.Big `json:"l1GasPrice"`
+}
+
+// GetL1Fee returns the L1 data fee charged to txHash on an OP-stack chain.
+// Deposit transactions carry no L1 fee and return zero.
+func GetL1Fee(ctx context.Context, client *ethclient.Client, txHash common.Hash) (*big.Int, error) {
+	var receipt *opReceipt
+	err := client.Client().CallContext(ctx, &receipt, "eth_getTransactionReceipt", txHash)
+	if err != nil {
+		return nil, err
+	}
+	if receipt == nil || receipt.L1Fee == nil {
+		return big.NewInt(0), nil
+	}
+	return receipt.L1Fee.ToInt(), nil
+}
+
+// EstimateL1Fee asks the GasPriceOracle for the L1 data fee of a serialized
+// transaction. The oracle pads for the signature, so unsigned data is fine.
+func EstimateL1Fee(ctx context.Context, client *ethclient.Client, rawTx []byte) (*big.Int, error) {
+	oracle, err := optimism.NewGasPriceOracle(GAS_PRICE_ORACLE_ADDRESS, client)
+	if err != nil {
+		return nil, err
+	}
+	return oracle.GetL1Fee(&bind.CallOpts{Context: ctx}, rawTx)
+}
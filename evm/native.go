@@ -27,6 +27,12 @@ func ChainID(client *ethclient.Client) (uint64, error) {
 }
 
 func TransferETH(client *ethclient.Client, chainID uint64, recipient common.Address, amount, gasPrice *big.Int, privateKey string) (common.Hash, error) {
+	return TransferETHWithGasMargin(client, chainID, recipient, amount, gasPrice, DEFAULT_GAS_MARGIN, privateKey)
+}
+
+// TransferETHWithGasMargin is TransferETH with gasMargin percent added to the
+// estimated gas.
+func TransferETHWithGasMargin(client *ethclient.Client, chainID uint64, recipient common.Address, amount, gasPrice *big.Int, gasMargin uint64, privateKey string) (common.Hash, error) {
 	ctx := context.Background()
 	fromPrivateKey, err := crypto.HexToECDSA(privateKey)
 	if err != nil {
@@ -38,7 +44,13 @@ func TransferETH(client *ethclient.Client, chainID uint64, recipient common.Addr
 		return common.Hash{}, err
 	}
 
-	tx := types.NewTx(&types.LegacyTx{Nonce: nonce, GasPrice: gasPrice, Gas: 21000, To: &recipient, Value: amount})
+	// contract recipients need more than the 21000 of a plain transfer
+	gas, err := EstimateGasLimit(client, fromAddr, &recipient, amount, nil, gasMargin)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	tx := types.NewTx(&types.LegacyTx{Nonce: nonce, GasPrice: gasPrice, Gas: gas, To: &recipient, Value: amount})
 
 	signedTx, err := types.SignTx(tx, types.NewEIP155Signer(new(big.Int).SetUint64(chainID)), fromPrivateKey)
 	if err != nil {
@@ -59,6 +71,19 @@ func TransferETHBatch(
 	gasPrice *big.Int,
 	privateKey string,
 	bills []*t.TransferBill,
+) (common.Hash, error) {
+	return TransferETHBatchWithGasMargin(client, chainID, gasPrice, DEFAULT_GAS_MARGIN, privateKey, bills)
+}
+
+// TransferETHBatchWithGasMargin is TransferETHBatch with gasMargin percent
+// added to the estimated gas.
+func TransferETHBatchWithGasMargin(
+	client *ethclient.Client,
+	chainID uint64,
+	gasPrice *big.Int,
+	gasMargin uint64,
+	privateKey string,
+	bills []*t.TransferBill,
 ) (common.Hash, error) {
 	fromPrivateKey, err := crypto.HexToECDSA(privateKey)
 	if err != nil {
@@ -75,7 +100,6 @@ func TransferETHBatch(
 	}
 	auth.Nonce = new(big.Int).SetUint64(nonce)
 	auth.Value = big.NewInt(0)
-	auth.GasLimit = uint64(21000 * len(bills))
 	auth.GasPrice = gasPrice
 
 	ms, err := multisend.NewMultisend(MULTISEND_ADDRESS, client)
@@ -93,6 +117,19 @@ func TransferETHBatch(
 	addresses := lo.Map(bills, func(b *t.TransferBill, _ int) common.Address { return common.HexToAddress(b.Recipient) })
 	amounts := lo.Map(bills, func(b *t.TransferBill, _ int) *big.Int { return b.Amount })
 
+	multisendABI, err := multisend.MultisendMetaData.GetAbi()
+	if err != nil {
+		return common.Hash{}, err
+	}
+	data, err := multisendABI.Pack("multiTransfer", addresses, amounts)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	auth.GasLimit, err = EstimateGasLimit(client, fromAddr, &MULTISEND_ADDRESS, totalAmount, data, gasMargin)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
 	tx, err := ms.MultiTransfer(auth, addresses, amounts)
 	if err != nil {
 		return common.Hash{}, err
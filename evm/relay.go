@@ -0,0 +1,256 @@
+package evm
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	t "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/meme-bots/go-web3/types"
+)
+
+const (
+	TxRoutePublic int = iota
+	TxRoutePrivate
+	TxRouteBundle
+)
+
+const (
+	// private transactions are dropped by the relay after this many blocks
+	privateTxMaxBlocks = 25
+	// bundles are submitted for each of the next bundleBlocks blocks
+	bundleBlocks = 3
+)
+
+type (
+	// Submitter sends signed transactions over one route.
+	Submitter interface {
+		Submit(ctx context.Context, tx *t.Transaction) error
+		Route() int
+	}
+
+	Inclusion struct {
+		TxHash      common.Hash
+		Included    bool
+		Succeeded   bool
+		BlockNumber uint64
+	}
+
+	blockNumberReader interface {
+		BlockNumber(ctx context.Context) (uint64, error)
+	}
+
+	publicSubmitter struct {
+		client *ethclient.Client
+	}
+
+	relaySubmitter struct {
+		url        string
+		route      int
+		authKey    *ecdsa.PrivateKey
+		chain      blockNumberReader
+		httpClient *http.Client
+	}
+
+	relayRequest struct {
+		JsonRPC string        `json:"jsonrpc"`
+		ID      int           `json:"id"`
+		Method  string        `json:"method"`
+		Params  []interface{} `json:"params"`
+	}
+
+	relayResponse struct {
+		Result json.RawMessage `json:"result"`
+		Error  *struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+
+	privateTxParams struct {
+		Tx             string `json:"tx"`
+		MaxBlockNumber string `json:"maxBlockNumber"`
+	}
+
+	bundleParams struct {
+		Txs         []string `json:"txs"`
+		BlockNumber string   `json:"blockNumber"`
+	}
+)
+
+var ErrRelayNotConfigured = errors.New("relay not configured")
+
+// NewSubmitter returns the submitter for cfg.TxRoute. authKey signs relay
+// requests (X-Flashbots-Signature); a random key is used when it is empty.
+func NewSubmitter(cfg *types.Config, client *ethclient.Client) (Submitter, error) {
+	if cfg.TxRoute == TxRoutePublic {
+		return &publicSubmitter{client: client}, nil
+	}
+	return NewRelaySubmitter(cfg.RelayRPC, cfg.TxRoute, cfg.RelayAuthKey, client)
+}
+
+func NewRelaySubmitter(url string, route int, authKey string, chain blockNumberReader) (Submitter, error) {
+	if len(url) == 0 {
+		return nil, ErrRelayNotConfigured
+	}
+	if route != TxRoutePrivate && route != TxRouteBundle {
+		return nil, types.ErrNotImplemented
+	}
+
+	var key *ecdsa.PrivateKey
+	var err error
+	if len(authKey) > 0 {
+		key, err = crypto.HexToECDSA(authKey)
+	} else {
+		key, err = crypto.GenerateKey()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &relaySubmitter{
+		url:        url,
+		route:      route,
+		authKey:    key,
+		chain:      chain,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+func (s *publicSubmitter) Submit(ctx context.Context, tx *t.Transaction) error {
+	return s.client.SendTransaction(ctx, tx)
+}
+
+func (s *publicSubmitter) Route() int {
+	return TxRoutePublic
+}
+
+func (s *relaySubmitter) Submit(ctx context.Context, tx *t.Transaction) error {
+	raw, err := tx.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	blockNumber, err := s.chain.BlockNumber(ctx)
+	if err != nil {
+		return err
+	}
+
+	if s.route == TxRoutePrivate {
+		return s.call(ctx, "eth_sendPrivateTransaction", privateTxParams{
+			Tx:             hexutil.Encode(raw),
+			MaxBlockNumber: hexutil.EncodeUint64(blockNumber + privateTxMaxBlocks),
+		})
+	}
+
+	// a bundle only targets one block, so cover the next few
+	var lastErr error
+	sent := false
+	for i := uint64(1); i <= bundleBlocks; i++ {
+		err = s.call(ctx, "eth_sendBundle", bundleParams{
+			Txs:         []string{hexutil.Encode(raw)},
+			BlockNumber: hexutil.EncodeUint64(blockNumber + i),
+		})
+		if err != nil {
+			lastErr = err
+		} else {
+			sent = true
+		}
+	}
+	if !sent {
+		return lastErr
+	}
+	return nil
+}
+
+func (s *relaySubmitter) Route() int {
+	return s.route
+}
+
+func (s *relaySubmitter) call(ctx context.Context, method string, params interface{}) error {
+	body, err := json.Marshal(relayRequest{JsonRPC: "2.0", ID: 1, Method: method, Params: []interface{}{params}})
+	if err != nil {
+		return err
+	}
+
+	hash := hexutil.Encode(crypto.Keccak256(body))
+	signature, err := crypto.Sign(accounts.TextHash([]byte(hash)), s.authKey)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Flashbots-Signature", crypto.PubkeyToAddress(s.authKey.PublicKey).Hex()+":"+hexutil.Encode(signature))
+
+	ret, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer ret.Body.Close()
+
+	data, err := io.ReadAll(ret.Body)
+	if err != nil {
+		return err
+	}
+	if ret.StatusCode != http.StatusOK {
+		return fmt.Errorf("relay %s: %s", ret.Status, string(data))
+	}
+
+	var resp relayResponse
+	err = json.Unmarshal(data, &resp)
+	if err != nil {
+		return err
+	}
+	if resp.Error != nil {
+		return fmt.Errorf("relay error %d: %s", resp.Error.Code, resp.Error.Message)
+	}
+	return nil
+}
+
+// TrackInclusion polls for the receipt of txHash until it lands or duration
+// passes. Private and bundled transactions never show up as pending, so the
+// receipt is the only inclusion signal for every route.
+func TrackInclusion(ctx context.Context, client *ethclient.Client, txHash common.Hash, duration time.Duration) (*Inclusion, error) {
+	ctx, cancel := context.WithTimeout(ctx, duration)
+	defer cancel()
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		receipt, err := client.TransactionReceipt(ctx, txHash)
+		if err == nil {
+			return &Inclusion{
+				TxHash:      txHash,
+				Included:    true,
+				Succeeded:   receipt.Status == t.ReceiptStatusSuccessful,
+				BlockNumber: receipt.BlockNumber.Uint64(),
+			}, nil
+		}
+		if !errors.Is(err, ethereum.NotFound) && ctx.Err() == nil {
+			return nil, err
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return &Inclusion{TxHash: txHash}, types.ErrTxNotLand
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package evm
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	t "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+type stubChain uint64
+
+func (c stubChain) BlockNumber(ctx context.Context) (uint64, error) {
+	return uint64(c), nil
+}
+
+type relayCall struct {
+	Method string
+	Params []json.RawMessage
+}
+
+func newStubRelay(tb testing.TB, signer common.Address) (*httptest.Server, func() []relayCall) {
+	var mu sync.Mutex
+	var calls []relayCall
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		parts := strings.Split(r.Header.Get("X-Flashbots-Signature"), ":")
+		if len(parts) != 2 || common.HexToAddress(parts[0]) != signer {
+			http.Error(w, "bad signature header", http.StatusForbidden)
+			return
+		}
+		signature, err := hexutil.Decode(parts[1])
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		pub, err := crypto.SigToPub(accounts.TextHash([]byte(hexutil.Encode(crypto.Keccak256(body)))), signature)
+		if err != nil || crypto.PubkeyToAddress(*pub) != signer {
+			http.Error(w, "signature mismatch", http.StatusForbidden)
+			return
+		}
+
+		var call relayCall
+		if err := json.Unmarshal(body, &call); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		calls = append(calls, call)
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":"0x01"}`))
+	}))
+	tb.Cleanup(server.Close)
+
+	return server, func() []relayCall {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]relayCall(nil), calls...)
+	}
+}
+
+func newSignedTx(tb testing.TB) *t.Transaction {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		tb.Fatal(err)
+	}
+	to := common.HexToAddress("0x000000000000000000000000000000000000dEaD")
+	tx, err := t.SignTx(
+		t.NewTx(&t.LegacyTx{Nonce: 1, GasPrice: big.NewInt(1e9), Gas: 21000, To: &to, Value: big.NewInt(1)}),
+		t.NewEIP155Signer(big.NewInt(1)),
+		key,
+	)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return tx
+}
+
+func TestRelaySubmitter_PrivateTransaction(t *testing.T) {
+	authKey, _ := crypto.GenerateKey()
+	server, calls := newStubRelay(t, crypto.PubkeyToAddress(authKey.PublicKey))
+
+	submitter, err := NewRelaySubmitter(server.URL, TxRoutePrivate, hexutil.Encode(crypto.FromECDSA(authKey))[2:], stubChain(100))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tx := newSignedTx(t)
+	if err := submitter.Submit(context.Background(), tx); err != nil {
+		t.Fatal(err)
+	}
+
+	got := calls()
+	if len(got) != 1 || got[0].Method != "eth_sendPrivateTransaction" {
+		t.Fatalf("unexpected relay calls: %+v", got)
+	}
+	var params privateTxParams
+	if err := json.Unmarshal(got[0].Params[0], &params); err != nil {
+		t.Fatal(err)
+	}
+	raw, _ := tx.MarshalBinary()
+	if params.Tx != hexutil.Encode(raw) {
+		t.Errorf("tx = %s, want %s", params.Tx, hexutil.Encode(raw))
+	}
+	if params.MaxBlockNumber != hexutil.EncodeUint64(100+privateTxMaxBlocks) {
+		t.Errorf("maxBlockNumber = %s", params.MaxBlockNumber)
+	}
+}
+
+func TestRelaySubmitter_Bundle(t *testing.T) {
+	authKey, _ := crypto.GenerateKey()
+	server, calls := newStubRelay(t, crypto.PubkeyToAddress(authKey.PublicKey))
+
+	submitter, err := NewRelaySubmitter(server.URL, TxRouteBundle, hexutil.Encode(crypto.FromECDSA(authKey))[2:], stubChain(100))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := submitter.Submit(context.Background(), newSignedTx(t)); err != nil {
+		t.Fatal(err)
+	}
+
+	got := calls()
+	if len(got) != bundleBlocks {
+		t.Fatalf("got %d bundles, want %d", len(got), bundleBlocks)
+	}
+	for i, call := range got {
+		var params bundleParams
+		if err := json.Unmarshal(call.Params[0], &params); err != nil {
+			t.Fatal(err)
+		}
+		if call.Method != "eth_sendBundle" || len(params.Txs) != 1 {
+			t.Errorf("unexpected bundle call: %+v", call)
+		}
+		if want := hexutil.EncodeUint64(uint64(101 + i)); params.BlockNumber != want {
+			t.Errorf("blockNumber = %s, want %s", params.BlockNumber, want)
+		}
+	}
+}
+
+func TestRelaySubmitter_RelayError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"error":{"code":-32000,"message":"nonce too low"}}`))
+	}))
+	defer server.Close()
+
+	submitter, err := NewRelaySubmitter(server.URL, TxRoutePrivate, "", stubChain(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = submitter.Submit(context.Background(), newSignedTx(t))
+	if err == nil || !strings.Contains(err.Error(), "nonce too low") {
+		t.Fatalf("err = %v, want relay error", err)
+	}
+}
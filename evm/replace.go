@@ -0,0 +1,300 @@
+package evm
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	t "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/meme-bots/go-web3/types"
+)
+
+const (
+	// MIN_REPLACEMENT_BUMP is the fee increase in percent nodes require before
+	// they accept a transaction with the same nonce.
+	MIN_REPLACEMENT_BUMP uint64 = 10
+	// DEFAULT_REPLACEMENT_BUMP leaves headroom above the minimum so a second
+	// replacement is not needed right away.
+	DEFAULT_REPLACEMENT_BUMP uint64 = 25
+)
+
+var (
+	ErrTxNotPending           = errors.New("transaction is not pending")
+	ErrTxNotOwned             = errors.New("transaction was not sent by this key")
+	ErrReplacementUnderpriced = errors.New("replacement fee bump below the minimum")
+)
+
+// sentTxTTL is how long submitted transactions are kept for replacement,
+// far longer than a relay holds on to them.
+const sentTxTTL = time.Hour
+
+type (
+	sentTx struct {
+		tx        *t.Transaction
+		submitter Submitter
+		sentAt    time.Time
+	}
+
+	// sentTransactions are the transactions submitted lately, so that those
+	// sent privately, which never show up as pending, can still be replaced
+	// over the route they took.
+	sentTransactions struct {
+		mu  sync.Mutex
+		txs map[common.Hash]*sentTx
+	}
+
+	// recordingSubmitter records in sent what it submits.
+	recordingSubmitter struct {
+		Submitter
+		sent *sentTransactions
+	}
+)
+
+func newSentTransactions() *sentTransactions {
+	return &sentTransactions{txs: map[common.Hash]*sentTx{}}
+}
+
+func (s *sentTransactions) add(tx *t.Transaction, submitter Submitter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for hash, sent := range s.txs {
+		if now.Sub(sent.sentAt) > sentTxTTL {
+			delete(s.txs, hash)
+		}
+	}
+	s.txs[tx.Hash()] = &sentTx{tx: tx, submitter: submitter, sentAt: now}
+}
+
+func (s *sentTransactions) get(hash common.Hash) (*sentTx, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sent, ok := s.txs[hash]
+	return sent, ok
+}
+
+func (s *recordingSubmitter) Submit(ctx context.Context, tx *t.Transaction) error {
+	err := s.Submitter.Submit(ctx, tx)
+	if err == nil {
+		s.sent.add(tx, s.Submitter)
+	}
+	return err
+}
+
+func bumpFee(fee *big.Int, percent uint64) *big.Int {
+	bumped := new(big.Int).Mul(fee, new(big.Int).SetUint64(100+percent))
+	bumped.Add(bumped, big.NewInt(99))
+	return bumped.Div(bumped, big.NewInt(100))
+}
+
+func maxBig(a, b *big.Int) *big.Int {
+	if a.Cmp(b) >= 0 {
+		return a
+	}
+	return b
+}
+
+// ReplaceTransaction re-signs the nonce of the pending transaction txHash with
+// fees bumped by bumpPercent (and at least the current network price). With
+// cancel set the replacement is a zero-value transfer to the sender, otherwise
+// it repeats the original call. The signed replacement is not sent. Only
+// transactions seen in the public mempool are found; those sent privately
+// are replaced with BuildReplacement.
+func ReplaceTransaction(
+	client *ethclient.Client,
+	chainID uint64,
+	txHash common.Hash,
+	bumpPercent uint64,
+	cancel bool,
+	privateKey string,
+) (*t.Transaction, error) {
+	original, pending, err := client.TransactionByHash(context.Background(), txHash)
+	if err != nil {
+		return nil, err
+	}
+	if !pending {
+		return nil, ErrTxNotPending
+	}
+	return BuildReplacement(client, chainID, original, bumpPercent, cancel, privateKey)
+}
+
+// BuildReplacement signs the replacement of original, as ReplaceTransaction
+// does, keeping its transaction type and access list.
+func BuildReplacement(
+	client *ethclient.Client,
+	chainID uint64,
+	original *t.Transaction,
+	bumpPercent uint64,
+	cancel bool,
+	privateKey string,
+) (*t.Transaction, error) {
+	ctx := context.Background()
+	if bumpPercent < MIN_REPLACEMENT_BUMP {
+		return nil, ErrReplacementUnderpriced
+	}
+
+	senderPriKey, err := crypto.HexToECDSA(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	senderAddress := crypto.PubkeyToAddress(senderPriKey.PublicKey)
+
+	signer := t.LatestSignerForChainID(new(big.Int).SetUint64(chainID))
+	from, err := t.Sender(signer, original)
+	if err != nil {
+		return nil, err
+	}
+	if from != senderAddress {
+		return nil, ErrTxNotOwned
+	}
+
+	to, value, data, gas, accessList := original.To(), original.Value(), original.Data(), original.Gas(), original.AccessList()
+	if cancel {
+		to, value, data, gas, accessList = &senderAddress, big.NewInt(0), nil, 21000, nil
+	}
+
+	var replacement t.TxData
+	switch original.Type() {
+	case t.LegacyTxType, t.AccessListTxType:
+		suggested, err := client.SuggestGasPrice(ctx)
+		if err != nil {
+			return nil, err
+		}
+		gasPrice := maxBig(bumpFee(original.GasPrice(), bumpPercent), suggested)
+		if original.Type() == t.LegacyTxType {
+			replacement = &t.LegacyTx{
+				Nonce:    original.Nonce(),
+				GasPrice: gasPrice,
+				Gas:      gas,
+				To:       to,
+				Value:    value,
+				Data:     data,
+			}
+		} else {
+			replacement = &t.AccessListTx{
+				ChainID:    new(big.Int).SetUint64(chainID),
+				Nonce:      original.Nonce(),
+				GasPrice:   gasPrice,
+				Gas:        gas,
+				To:         to,
+				Value:      value,
+				Data:       data,
+				AccessList: accessList,
+			}
+		}
+	case t.DynamicFeeTxType:
+		suggestedTip, err := client.SuggestGasTipCap(ctx)
+		if err != nil {
+			return nil, err
+		}
+		head, err := client.HeaderByNumber(ctx, nil)
+		if err != nil {
+			return nil, err
+		}
+		tip := maxBig(bumpFee(original.GasTipCap(), bumpPercent), suggestedTip)
+		feeCap := bumpFee(original.GasFeeCap(), bumpPercent)
+		if head.BaseFee != nil {
+			feeCap = maxBig(feeCap, new(big.Int).Add(tip, new(big.Int).Mul(head.BaseFee, big.NewInt(2))))
+		}
+		replacement = &t.DynamicFeeTx{
+			ChainID:    new(big.Int).SetUint64(chainID),
+			Nonce:      original.Nonce(),
+			GasTipCap:  tip,
+			GasFeeCap:  maxBig(feeCap, tip),
+			Gas:        gas,
+			To:         to,
+			Value:      value,
+			Data:       data,
+			AccessList: accessList,
+		}
+	default:
+		// blob and set-code transactions carry more than can be resent here
+		return nil, types.ErrNotImplemented
+	}
+
+	tx := t.NewTx(replacement)
+	if !coversReplacement(original, tx) {
+		return nil, ErrReplacementUnderpriced
+	}
+
+	return t.SignTx(tx, signer, senderPriKey)
+}
+
+// coversReplacement applies the txpool rule: every fee field of the
+// replacement must be at least MIN_REPLACEMENT_BUMP percent higher.
+func coversReplacement(original, replacement *t.Transaction) bool {
+	if replacement.GasFeeCap().Cmp(bumpFee(original.GasFeeCap(), MIN_REPLACEMENT_BUMP)) < 0 {
+		return false
+	}
+	return replacement.GasTipCap().Cmp(bumpFee(original.GasTipCap(), MIN_REPLACEMENT_BUMP)) >= 0
+}
+
+// WatchReplacements waits until one of the versions of a replaced transaction
+// lands and reports which one. If the nonce gets used by a transaction that is
+// not in txHashes, ErrTransactionInvalid is returned.
+func WatchReplacements(ctx context.Context, client *ethclient.Client, chainID uint64, txHashes []common.Hash, duration time.Duration) (*Inclusion, error) {
+	ctx, cancel := context.WithTimeout(ctx, duration)
+	defer cancel()
+
+	var from common.Address
+	var nonce uint64
+	known := false
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		for _, txHash := range txHashes {
+			receipt, err := client.TransactionReceipt(ctx, txHash)
+			if err == nil {
+				return &Inclusion{
+					TxHash:      txHash,
+					Included:    true,
+					Succeeded:   receipt.Status == t.ReceiptStatusSuccessful,
+					BlockNumber: receipt.BlockNumber.Uint64(),
+				}, nil
+			}
+			if !errors.Is(err, ethereum.NotFound) && ctx.Err() == nil {
+				return nil, err
+			}
+
+			if !known {
+				tx, _, err := client.TransactionByHash(ctx, txHash)
+				if err == nil {
+					from, err = t.Sender(t.LatestSignerForChainID(new(big.Int).SetUint64(chainID)), tx)
+					known = err == nil
+					nonce = tx.Nonce()
+				}
+			}
+		}
+
+		if known {
+			// a receipt may lag behind the nonce, check once more before giving up
+			latest, err := client.NonceAt(ctx, from, nil)
+			if err == nil && latest > nonce {
+				found := false
+				for _, txHash := range txHashes {
+					if _, err := client.TransactionReceipt(ctx, txHash); err == nil {
+						found = true
+					}
+				}
+				if !found {
+					return nil, types.ErrTransactionInvalid
+				}
+				continue
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil, types.ErrTxNotLand
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package evm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+type stubSubmitter struct {
+	submitted []*ethtypes.Transaction
+}
+
+func (s *stubSubmitter) Submit(ctx context.Context, tx *ethtypes.Transaction) error {
+	s.submitted = append(s.submitted, tx)
+	return nil
+}
+
+func (s *stubSubmitter) Route() int {
+	return TxRoutePrivate
+}
+
+func TestBumpFee(t *testing.T) {
+	if fee := bumpFee(big.NewInt(100), 10); fee.Int64() != 110 {
+		t.Errorf("bumpFee(100, 10) = %s", fee)
+	}
+	// rounds up so that the bump is never short of the percentage
+	if fee := bumpFee(big.NewInt(101), 10); fee.Int64() != 112 {
+		t.Errorf("bumpFee(101, 10) = %s", fee)
+	}
+}
+
+func TestCoversReplacement(t *testing.T) {
+	legacy := func(gasPrice int64) *ethtypes.Transaction {
+		return ethtypes.NewTx(&ethtypes.LegacyTx{GasPrice: big.NewInt(gasPrice)})
+	}
+	if !coversReplacement(legacy(100), legacy(110)) || coversReplacement(legacy(100), legacy(109)) {
+		t.Error("legacy gas price must rise by 10%")
+	}
+
+	dynamic := func(tip, feeCap int64) *ethtypes.Transaction {
+		return ethtypes.NewTx(&ethtypes.DynamicFeeTx{GasTipCap: big.NewInt(tip), GasFeeCap: big.NewInt(feeCap)})
+	}
+	if !coversReplacement(dynamic(10, 100), dynamic(11, 110)) {
+		t.Error("both fees bumped")
+	}
+	if coversReplacement(dynamic(10, 100), dynamic(10, 200)) || coversReplacement(dynamic(10, 100), dynamic(20, 100)) {
+		t.Error("both fees must rise")
+	}
+}
+
+func TestBuildReplacement(t *testing.T) {
+	client := newStubNode(t, func(method string, params []json.RawMessage) (interface{}, error) {
+		switch method {
+		case "eth_gasPrice":
+			return "0x1", nil
+		case "eth_maxPriorityFeePerGas":
+			return "0x1", nil
+		case "eth_getBlockByNumber":
+			return &ethtypes.Header{Number: big.NewInt(1), Difficulty: big.NewInt(0), BaseFee: big.NewInt(1e9)}, nil
+		}
+		return nil, errors.New("unexpected " + method)
+	})
+
+	key, _ := crypto.GenerateKey()
+	privateKey := hexutil.Encode(crypto.FromECDSA(key))[2:]
+	self := crypto.PubkeyToAddress(key.PublicKey)
+	to := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	accessList := ethtypes.AccessList{{Address: to, StorageKeys: []common.Hash{{1}}}}
+	signer := ethtypes.LatestSignerForChainID(big.NewInt(1))
+
+	original, err := ethtypes.SignNewTx(key, signer, &ethtypes.AccessListTx{
+		ChainID: big.NewInt(1), Nonce: 3, GasPrice: big.NewInt(1e9), Gas: 100000, To: &to, Data: []byte{1}, AccessList: accessList,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	replacement, err := BuildReplacement(client, 1, original, DEFAULT_REPLACEMENT_BUMP, false, privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replacement.Type() != ethtypes.AccessListTxType || len(replacement.AccessList()) != 1 || replacement.Nonce() != 3 || replacement.GasPrice().Int64() != 1.25e9 {
+		t.Errorf("speed up: type %d, access list %v, nonce %d, gas price %s", replacement.Type(), replacement.AccessList(), replacement.Nonce(), replacement.GasPrice())
+	}
+
+	replacement, err = BuildReplacement(client, 1, original, DEFAULT_REPLACEMENT_BUMP, true, privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *replacement.To() != self || replacement.Value().Sign() != 0 || len(replacement.Data()) != 0 || len(replacement.AccessList()) != 0 {
+		t.Errorf("cancel: to %s, value %s, data %x, access list %v", replacement.To(), replacement.Value(), replacement.Data(), replacement.AccessList())
+	}
+
+	original, err = ethtypes.SignNewTx(key, signer, &ethtypes.DynamicFeeTx{
+		ChainID: big.NewInt(1), Nonce: 3, GasTipCap: big.NewInt(1e9), GasFeeCap: big.NewInt(2e9), Gas: 100000, To: &to, AccessList: accessList,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	replacement, err = BuildReplacement(client, 1, original, DEFAULT_REPLACEMENT_BUMP, false, privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the fee cap covers twice the base fee on top of the tip
+	if replacement.Type() != ethtypes.DynamicFeeTxType || len(replacement.AccessList()) != 1 || replacement.GasTipCap().Int64() != 1.25e9 || replacement.GasFeeCap().Int64() != 3.25e9 {
+		t.Errorf("dynamic: type %d, tip %s, fee cap %s", replacement.Type(), replacement.GasTipCap(), replacement.GasFeeCap())
+	}
+
+	if _, err = BuildReplacement(client, 1, original, MIN_REPLACEMENT_BUMP-1, false, privateKey); !errors.Is(err, ErrReplacementUnderpriced) {
+		t.Errorf("small bump: err = %v", err)
+	}
+	other, _ := crypto.GenerateKey()
+	if _, err = BuildReplacement(client, 1, original, DEFAULT_REPLACEMENT_BUMP, false, hexutil.Encode(crypto.FromECDSA(other))[2:]); !errors.Is(err, ErrTxNotOwned) {
+		t.Errorf("other key: err = %v", err)
+	}
+}
+
+func TestEVM_replacePrivateTransaction(t *testing.T) {
+	nonce := uint64(3)
+	client := newStubNode(t, func(method string, params []json.RawMessage) (interface{}, error) {
+		switch method {
+		case "eth_getTransactionCount":
+			return hexutil.Uint64(nonce), nil
+		case "eth_gasPrice":
+			return "0x1", nil
+		}
+		// the relay's transactions are not found on the node
+		return nil, errors.New("unexpected " + method)
+	})
+
+	key, _ := crypto.GenerateKey()
+	privateKey := hexutil.Encode(crypto.FromECDSA(key))[2:]
+	to := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	original, err := ethtypes.SignNewTx(key, ethtypes.LatestSignerForChainID(big.NewInt(1)), &ethtypes.LegacyTx{Nonce: 3, GasPrice: big.NewInt(1e9), Gas: 21000, To: &to})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	relay := &stubSubmitter{}
+	v := &EVM{ctx: context.Background(), client: client, chainId: 1, sent: newSentTransactions()}
+	v.submitter = &recordingSubmitter{Submitter: relay, sent: v.sent}
+	if err = v.submitter.Submit(v.ctx, original); err != nil {
+		t.Fatal(err)
+	}
+
+	txHash, err := v.SpeedUpTransaction(original.Hash().String(), DEFAULT_REPLACEMENT_BUMP, privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(relay.submitted) != 2 || relay.submitted[1].Hash().String() != txHash || relay.submitted[1].Nonce() != 3 {
+		t.Fatalf("replacement not resent through the relay: %d submitted", len(relay.submitted))
+	}
+
+	// the replacement is replaced the same way
+	if _, err = v.CancelTransaction(txHash, privateKey); err != nil || len(relay.submitted) != 3 {
+		t.Fatalf("cancel: %d submitted, err = %v", len(relay.submitted), err)
+	}
+
+	nonce = 4
+	if _, err = v.SpeedUpTransaction(txHash, DEFAULT_REPLACEMENT_BUMP, privateKey); !errors.Is(err, ErrTxNotPending) {
+		t.Errorf("landed: err = %v", err)
+	}
+}
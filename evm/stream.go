@@ -0,0 +1,323 @@
+package evm
+
+import (
+	"context"
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	t "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/meme-bots/go-web3/evm/erc20"
+	"github.com/meme-bots/go-web3/evm/uniswap"
+	"github.com/meme-bots/go-web3/types"
+	"github.com/shopspring/decimal"
+)
+
+type (
+	TradeEvent struct {
+		Pool               common.Address
+		Token              common.Address
+		Trader             common.Address // sender of the tx, the recipient of the output if it could not be read
+		IsBuy              bool
+		TokenAmount        *big.Int
+		NativeAmount       *big.Int
+		PriceInNativeToken decimal.Decimal
+		TxHash             common.Hash
+		BlockNumber        uint64
+		LogIndex           uint
+	}
+
+	ReservesEvent struct {
+		Pool               common.Address
+		Token              common.Address
+		TokenReserve       *big.Int
+		QuoteReserve       *big.Int
+		PriceInNativeToken decimal.Decimal
+		TxHash             common.Hash
+		BlockNumber        uint64
+	}
+
+	// PoolEvent carries exactly one of Trade or Reserves.
+	PoolEvent struct {
+		Trade    *TradeEvent
+		Reserves *ReservesEvent
+	}
+
+	streamPool struct {
+		token    common.Address
+		isToken0 bool
+		decimals uint8
+	}
+)
+
+// streamLookahead is how many events may wait on their trader being resolved
+// before the stream stops reading logs.
+const streamLookahead = 64
+
+var (
+	// ErrStreamUnsupported is returned streaming the pools of a venue other
+	// than Uniswap V2, whose Swap and Sync events the stream decodes.
+	ErrStreamUnsupported = errors.New("pool stream only supports uniswap v2")
+
+	swapTopic common.Hash
+	syncTopic common.Hash
+)
+
+func init() {
+	pairABI, err := uniswap.PairMetaData.GetAbi()
+	if err != nil {
+		panic(err)
+	}
+	swapTopic = pairABI.Events["Swap"].ID
+	syncTopic = pairABI.Events["Sync"].ID
+}
+
+// SubscribePools streams normalized Swap and Sync events of the given pairs
+// into sink until the subscription is unsubscribed or ctx is done. Every
+// pair must trade a token against the wrapped native token, on Uniswap V2.
+func (v *EVM) SubscribePools(ctx context.Context, pools []string, sink chan<- *PoolEvent) (event.Subscription, error) {
+	if v.dex.ID() != types.DexUniswapV2 {
+		return nil, ErrStreamUnsupported
+	}
+
+	client := v.client
+	if len(v.cfg.WSRPC) > 0 {
+		var err error
+		client, err = ethclient.DialContext(ctx, v.cfg.WSRPC)
+		if err != nil {
+			return nil, err
+		}
+	}
+	closeClient := func() {
+		if client != v.client {
+			client.Close()
+		}
+	}
+
+	streamPools := make(map[common.Address]*streamPool, len(pools))
+	addresses := make([]common.Address, 0, len(pools))
+	for _, pool := range pools {
+		poolAddr := common.HexToAddress(pool)
+		p, err := v.loadStreamPool(ctx, poolAddr)
+		if err != nil {
+			closeClient()
+			return nil, err
+		}
+		streamPools[poolAddr] = p
+		addresses = append(addresses, poolAddr)
+	}
+
+	logs := make(chan t.Log)
+	sub, err := client.SubscribeFilterLogs(ctx, ethereum.FilterQuery{
+		Addresses: addresses,
+		Topics:    [][]common.Hash{{swapTopic, syncTopic}},
+	}, logs)
+	if err != nil {
+		closeClient()
+		return nil, err
+	}
+
+	filterer, err := uniswap.NewPairFilterer(common.Address{}, nil)
+	if err != nil {
+		sub.Unsubscribe()
+		closeClient()
+		return nil, err
+	}
+
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer closeClient()
+		defer sub.Unsubscribe()
+
+		// traders are resolved concurrently, events still reach sink in log
+		// order
+		pending := make(chan chan *PoolEvent, streamLookahead)
+		done := make(chan struct{})
+		defer close(done)
+		go deliverPoolEvents(pending, sink, done)
+
+		for {
+			select {
+			case log := <-logs:
+				pool, ok := streamPools[log.Address]
+				if !ok || len(log.Topics) == 0 || log.Removed {
+					continue
+				}
+
+				ev := make(chan *PoolEvent, 1)
+				switch log.Topics[0] {
+				case swapTopic:
+					swap, err := filterer.ParseSwap(log)
+					if err != nil {
+						return err
+					}
+					trade := v.newTradeEvent(pool, swap)
+					go func() {
+						v.resolveTrader(ctx, trade)
+						ev <- &PoolEvent{Trade: trade}
+					}()
+				case syncTopic:
+					sync, err := filterer.ParseSync(log)
+					if err != nil {
+						return err
+					}
+					ev <- &PoolEvent{Reserves: v.newReservesEvent(pool, sync)}
+				default:
+					continue
+				}
+
+				select {
+				case pending <- ev:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}), nil
+}
+
+// loadStreamPool reads which side of the pair is the token, and its
+// decimals.
+func (v *EVM) loadStreamPool(ctx context.Context, poolAddr common.Address) (*streamPool, error) {
+	pair, err := uniswap.NewPairCaller(poolAddr, v.client)
+	if err != nil {
+		return nil, err
+	}
+	opts := &bind.CallOpts{Context: ctx}
+	token0, err := pair.Token0(opts)
+	if err != nil {
+		return nil, err
+	}
+	token1, err := pair.Token1(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	wrapped := common.HexToAddress(v.cfg.WrapNativeToken)
+	var tokenAddr common.Address
+	switch wrapped {
+	case token1:
+		tokenAddr = token0
+	case token0:
+		tokenAddr = token1
+	default:
+		return nil, types.ErrInvalidPool
+	}
+
+	erc20Token, err := erc20.NewErc20(tokenAddr, v.client)
+	if err != nil {
+		return nil, err
+	}
+	decimals, err := erc20Token.Decimals(opts)
+	if err != nil {
+		return nil, err
+	}
+	return &streamPool{token: tokenAddr, isToken0: tokenAddr == token0, decimals: decimals}, nil
+}
+
+// deliverPoolEvents sends the events of pending to sink in order, each once
+// it is ready, until done.
+func deliverPoolEvents(pending <-chan chan *PoolEvent, sink chan<- *PoolEvent, done <-chan struct{}) {
+	for {
+		select {
+		case ev := <-pending:
+			select {
+			case e := <-ev:
+				select {
+				case sink <- e:
+				case <-done:
+					return
+				}
+			case <-done:
+				return
+			}
+		case <-done:
+			return
+		}
+	}
+}
+
+// newTradeEvent decodes swap. Its Trader is the recipient of the swap's
+// output, until resolveTrader replaces it with the sender of the transaction.
+func (v *EVM) newTradeEvent(pool *streamPool, swap *uniswap.PairSwap) *TradeEvent {
+	var tokenIn, tokenOut, nativeIn, nativeOut *big.Int
+	if pool.isToken0 {
+		tokenIn, tokenOut, nativeIn, nativeOut = swap.Amount0In, swap.Amount0Out, swap.Amount1In, swap.Amount1Out
+	} else {
+		tokenIn, tokenOut, nativeIn, nativeOut = swap.Amount1In, swap.Amount1Out, swap.Amount0In, swap.Amount0Out
+	}
+
+	isBuy := nativeIn.Sign() > 0
+	tokenAmount := new(big.Int).Sub(tokenIn, tokenOut)
+	nativeAmount := new(big.Int).Sub(nativeOut, nativeIn)
+	if isBuy {
+		tokenAmount.Neg(tokenAmount)
+		nativeAmount.Neg(nativeAmount)
+	}
+
+	return &TradeEvent{
+		Pool:               swap.Raw.Address,
+		Token:              pool.token,
+		Trader:             swap.To,
+		IsBuy:              isBuy,
+		TokenAmount:        tokenAmount,
+		NativeAmount:       nativeAmount,
+		PriceInNativeToken: v.priceInNativeToken(nativeAmount, tokenAmount, pool.decimals),
+		TxHash:             swap.Raw.TxHash,
+		BlockNumber:        swap.Raw.BlockNumber,
+		LogIndex:           swap.Raw.Index,
+	}
+}
+
+// resolveTrader sets the trader of ev to the sender of its transaction, as
+// the pair only sees the router. It is left as is if the transaction cannot
+// be read.
+func (v *EVM) resolveTrader(ctx context.Context, ev *TradeEvent) {
+	tx, _, err := v.client.TransactionByHash(ctx, ev.TxHash)
+	if err != nil {
+		return
+	}
+	from, err := t.Sender(t.LatestSignerForChainID(new(big.Int).SetUint64(v.chainId)), tx)
+	if err == nil {
+		ev.Trader = from
+	}
+}
+
+func (v *EVM) newReservesEvent(pool *streamPool, sync *uniswap.PairSync) *ReservesEvent {
+	tokenReserve, quoteReserve := sync.Reserve1, sync.Reserve0
+	if pool.isToken0 {
+		tokenReserve, quoteReserve = sync.Reserve0, sync.Reserve1
+	}
+
+	return &ReservesEvent{
+		Pool:               sync.Raw.Address,
+		Token:              pool.token,
+		TokenReserve:       tokenReserve,
+		QuoteReserve:       quoteReserve,
+		PriceInNativeToken: v.priceInNativeToken(quoteReserve, tokenReserve, pool.decimals),
+		TxHash:             sync.Raw.TxHash,
+		BlockNumber:        sync.Raw.BlockNumber,
+	}
+}
+
+func (v *EVM) priceInNativeToken(nativeAmount, tokenAmount *big.Int, decimals uint8) decimal.Decimal {
+	if tokenAmount.Sign() == 0 {
+		return decimal.Zero
+	}
+	native := decimal.NewFromBigInt(nativeAmount, 0-int32(v.GetNativeTokenDecimals()))
+	token := decimal.NewFromBigInt(tokenAmount, 0-int32(decimals))
+	return native.Div(token)
+}
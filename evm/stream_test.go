@@ -0,0 +1,95 @@
+package evm
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/meme-bots/go-web3/evm/uniswap"
+	"github.com/meme-bots/go-web3/types"
+)
+
+func TestEVM_newTradeEvent(t *testing.T) {
+	v := &EVM{cfg: &types.Config{NativeTokenDecimals: 18}}
+	to := common.HexToAddress("0x00000000000000000000000000000000000000cc")
+	zero := big.NewInt(0)
+
+	// token1 bought with 1 native token for 2000 tokens of 9 decimals
+	pool := &streamPool{isToken0: false, decimals: 9}
+	buy := v.newTradeEvent(pool, &uniswap.PairSwap{
+		Amount0In:  big.NewInt(1e18),
+		Amount1In:  zero,
+		Amount0Out: zero,
+		Amount1Out: big.NewInt(2000e9),
+		To:         to,
+	})
+	if !buy.IsBuy || buy.Trader != to || buy.TokenAmount.Int64() != 2000e9 || buy.NativeAmount.Int64() != 1e18 {
+		t.Errorf("buy: %+v", buy)
+	}
+	if price := buy.PriceInNativeToken.String(); price != "0.0005" {
+		t.Errorf("buy price %s", price)
+	}
+
+	// token0 sold
+	pool = &streamPool{isToken0: true, decimals: 9}
+	sell := v.newTradeEvent(pool, &uniswap.PairSwap{
+		Amount0In:  big.NewInt(1000e9),
+		Amount1In:  zero,
+		Amount0Out: zero,
+		Amount1Out: big.NewInt(5e17),
+	})
+	if sell.IsBuy || sell.TokenAmount.Int64() != 1000e9 || sell.NativeAmount.Int64() != 5e17 {
+		t.Errorf("sell: %+v", sell)
+	}
+}
+
+func TestEVM_newReservesEvent(t *testing.T) {
+	v := &EVM{cfg: &types.Config{NativeTokenDecimals: 18}}
+	sync := &uniswap.PairSync{Reserve0: big.NewInt(4000e9), Reserve1: big.NewInt(2e18)}
+
+	ev := v.newReservesEvent(&streamPool{isToken0: true, decimals: 9}, sync)
+	if ev.TokenReserve.Int64() != 4000e9 || ev.QuoteReserve.Int64() != 2e18 || ev.PriceInNativeToken.String() != "0.0005" {
+		t.Errorf("token0: %+v", ev)
+	}
+	ev = v.newReservesEvent(&streamPool{isToken0: false, decimals: 18}, sync)
+	if ev.TokenReserve.Int64() != 2e18 || ev.QuoteReserve.Int64() != 4000e9 {
+		t.Errorf("token1: %+v", ev)
+	}
+}
+
+func TestDeliverPoolEvents(t *testing.T) {
+	pending := make(chan chan *PoolEvent, 2)
+	sink := make(chan *PoolEvent)
+	done := make(chan struct{})
+	defer close(done)
+	go deliverPoolEvents(pending, sink, done)
+
+	first, second := make(chan *PoolEvent, 1), make(chan *PoolEvent, 1)
+	pending <- first
+	pending <- second
+
+	// the second event is ready first but waits for the first
+	second <- &PoolEvent{Reserves: &ReservesEvent{BlockNumber: 2}}
+	first <- &PoolEvent{Trade: &TradeEvent{BlockNumber: 1}}
+	if ev := <-sink; ev.Trade == nil || ev.Trade.BlockNumber != 1 {
+		t.Fatalf("first delivered %+v", ev)
+	}
+	if ev := <-sink; ev.Reserves == nil || ev.Reserves.BlockNumber != 2 {
+		t.Fatalf("second delivered %+v", ev)
+	}
+}
+
+// otherDex is a venue other than Uniswap V2.
+type otherDex struct{ Dex }
+
+func (otherDex) ID() int { return types.DexUniswapV2 + 1 }
+
+func TestEVM_SubscribePools_UniswapV2Only(t *testing.T) {
+	v := &EVM{cfg: &types.Config{}, dex: otherDex{}}
+	_, err := v.SubscribePools(context.Background(), []string{"0x0000000000000000000000000000000000000001"}, make(chan *PoolEvent))
+	if !errors.Is(err, ErrStreamUnsupported) {
+		t.Fatalf("err = %v", err)
+	}
+}
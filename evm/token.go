@@ -64,6 +64,9 @@ var (
 	// }
 )
 
+// SwapBuy swaps in native token for tokenAddr on the Uniswap V2 router.
+//
+// Deprecated: Use EVM.Transact, which goes through the venue of Config.Dex.
 func SwapBuy(
 	cli *ethclient.Client,
 	chainId uint64,
@@ -116,6 +119,19 @@ func Approve(
 	tokenAddr, spenderAddr common.Address,
 	gasPrice *big.Int,
 	privateKey string,
+) (common.Hash, error) {
+	return ApproveWithGasMargin(cli, chainId, tokenAddr, spenderAddr, gasPrice, DEFAULT_GAS_MARGIN, privateKey)
+}
+
+// ApproveWithGasMargin is Approve with gasMargin percent added to the
+// estimated gas.
+func ApproveWithGasMargin(
+	cli *ethclient.Client,
+	chainId uint64,
+	tokenAddr, spenderAddr common.Address,
+	gasPrice *big.Int,
+	gasMargin uint64,
+	privateKey string,
 ) (common.Hash, error) {
 	token, err := erc20.NewErc20(tokenAddr, cli)
 	if err != nil {
@@ -132,9 +148,23 @@ func Approve(
 		return common.Hash{}, err
 	}
 
+	erc20ABI, err := erc20.Erc20MetaData.GetAbi()
+	if err != nil {
+		return common.Hash{}, err
+	}
+	data, err := erc20ABI.Pack("approve", spenderAddr, unlimitedApproveAmount)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
 	senderAddress := crypto.PubkeyToAddress(senderPriKey.PublicKey)
+	gas, err := EstimateGasLimit(cli, senderAddress, &tokenAddr, nil, data, gasMargin)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
 	tx, err := token.Approve(
-		&bind.TransactOpts{From: senderAddress, Signer: auth.Signer, GasPrice: gasPrice},
+		&bind.TransactOpts{From: senderAddress, Signer: auth.Signer, GasPrice: gasPrice, GasLimit: gas},
 		spenderAddr,
 		unlimitedApproveAmount,
 	)
@@ -145,6 +175,9 @@ func Approve(
 	return tx.Hash(), err
 }
 
+// SwapSell swaps in of tokenAddr for native token on the Uniswap V2 router.
+//
+// Deprecated: Use EVM.Transact, which goes through the venue of Config.Dex.
 func SwapSell(
 	cli *ethclient.Client,
 	chainId uint64,
@@ -0,0 +1,234 @@
+package evm
+
+import (
+	"context"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	t "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/meme-bots/go-web3/evm/erc20"
+	"github.com/meme-bots/go-web3/evm/uniswap"
+)
+
+type (
+	// Trade is a swap decoded from a receipt, seen from the owner.
+	Trade struct {
+		Method       string // router method, empty if the call was not to the router
+		Path         []common.Address
+		Buy          bool
+		Hops         int
+		NativeIn     *big.Int // wrapped native token paid into pairs
+		NativeOut    *big.Int // wrapped native token taken out of pairs
+		TokenChanged *big.Int // net token balance change of the owner
+		FeePaid      *big.Int // fee router fees and transfers to the fee recipient
+	}
+)
+
+var (
+	transferTopic common.Hash
+)
+
+func init() {
+	erc20ABI, err := erc20.Erc20MetaData.GetAbi()
+	if err != nil {
+		panic(err)
+	}
+	transferTopic = erc20ABI.Events["Transfer"].ID
+}
+
+// decodeRouterCall returns the router method and swap path of call data, or
+// an empty method if data is not a router swap.
+func decodeRouterCall(data []byte) (string, []common.Address) {
+	if len(data) < 4 {
+		return "", nil
+	}
+	routerABI, err := uniswap.Routerv2MetaData.GetAbi()
+	if err != nil {
+		return "", nil
+	}
+	method, err := routerABI.MethodById(data[:4])
+	if err != nil {
+		return "", nil
+	}
+	args, err := method.Inputs.Unpack(data[4:])
+	if err != nil {
+		return method.Name, nil
+	}
+	for i, input := range method.Inputs {
+		if input.Name == "path" {
+			path, _ := args[i].([]common.Address)
+			return method.Name, path
+		}
+	}
+	return method.Name, nil
+}
+
+// DecodeTrade decodes the swap of token against the wrapped native token in
+// receipt. Native amounts come from the pairs' Swap events so multi-hop
+// routes and unspent input are accounted for; the token amount is the
+// owner's net transfer so transfer taxes are already deducted. Only swaps of
+// pairs on the decoded path paying the owner, router, feeRouter or the next
+// pair count, so that swaps a taxed token makes of its own tax are left out.
+// Fee router fees count only when logged by feeRouter.
+func DecodeTrade(
+	ctx context.Context,
+	client *ethclient.Client,
+	tx *t.Transaction,
+	receipt *t.Receipt,
+	owner, token, wrapped, router, feeRouter, feeRecipient common.Address,
+) (*Trade, error) {
+	trade := &Trade{
+		NativeIn:     big.NewInt(0),
+		NativeOut:    big.NewInt(0),
+		TokenChanged: big.NewInt(0),
+		FeePaid:      big.NewInt(0),
+	}
+	if tx != nil {
+		data := tx.Data()
+		if inner := unpackFeeRouterCall(data); inner != nil {
+			data = inner
+		}
+		trade.Method, trade.Path = decodeRouterCall(data)
+	}
+	path := trade.Path
+	if len(path) == 0 {
+		path = []common.Address{wrapped, token}
+	}
+
+	filterer, err := uniswap.NewPairFilterer(common.Address{}, nil)
+	if err != nil {
+		return nil, err
+	}
+	tokenFilterer, err := erc20.NewErc20Filterer(common.Address{}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// the pairs swapping along path, and so the recipients a hop may pay
+	pairs := make(map[common.Address][2]common.Address)
+	recipients := map[common.Address]bool{owner: true, router: true}
+	if feeRouter != (common.Address{}) {
+		recipients[feeRouter] = true
+	}
+	var swaps []*uniswap.PairSwap
+	for _, log := range receipt.Logs {
+		if len(log.Topics) == 0 || log.Topics[0] != swapTopic {
+			continue
+		}
+		swap, err := filterer.ParseSwap(*log)
+		if err != nil {
+			return nil, err
+		}
+		tokens, ok := pairs[log.Address]
+		if !ok {
+			tokens, err = pairTokens(ctx, client, log.Address, receipt.BlockNumber)
+			if err != nil {
+				return nil, err
+			}
+			if !onPath(path, tokens) {
+				continue
+			}
+			pairs[log.Address] = tokens
+			recipients[log.Address] = true
+		}
+		swaps = append(swaps, swap)
+	}
+
+	for _, swap := range swaps {
+		if !recipients[swap.To] {
+			continue
+		}
+		trade.Hops++
+
+		tokens := pairs[swap.Raw.Address]
+		if tokens[0] == wrapped {
+			trade.NativeIn.Add(trade.NativeIn, swap.Amount0In)
+			trade.NativeOut.Add(trade.NativeOut, swap.Amount0Out)
+		} else if tokens[1] == wrapped {
+			trade.NativeIn.Add(trade.NativeIn, swap.Amount1In)
+			trade.NativeOut.Add(trade.NativeOut, swap.Amount1Out)
+		}
+	}
+
+	for _, log := range receipt.Logs {
+		if len(log.Topics) == 0 {
+			continue
+		}
+
+		switch log.Topics[0] {
+		case transferTopic:
+			if log.Address != token && log.Address != wrapped {
+				continue
+			}
+			// ERC-721 transfers share the topic but index the token id
+			if len(log.Topics) != 3 {
+				continue
+			}
+			transfer, err := tokenFilterer.ParseTransfer(*log)
+			if err != nil {
+				return nil, err
+			}
+			if log.Address == token {
+				if transfer.To == owner {
+					trade.TokenChanged.Add(trade.TokenChanged, transfer.Value)
+				}
+				if transfer.From == owner {
+					trade.TokenChanged.Sub(trade.TokenChanged, transfer.Value)
+				}
+			}
+			if feeRecipient != (common.Address{}) && transfer.To == feeRecipient && transfer.From != feeRecipient {
+				trade.FeePaid.Add(trade.FeePaid, transfer.Value)
+			}
+		case feeCollectedTopic:
+			// native fees taken by the fee router never show up as transfers
+			if feeRouter == (common.Address{}) || log.Address != feeRouter || len(log.Topics) != 3 || len(log.Data) != 32 {
+				continue
+			}
+			recipient := common.BytesToAddress(log.Topics[2].Bytes())
+			if feeRecipient == (common.Address{}) || recipient == feeRecipient {
+				trade.FeePaid.Add(trade.FeePaid, new(big.Int).SetBytes(log.Data))
+			}
+		}
+	}
+
+	switch {
+	case strings.HasPrefix(trade.Method, "swapExactETH") || strings.HasPrefix(trade.Method, "swapETH"):
+		trade.Buy = true
+	case strings.Contains(trade.Method, "ForETH"):
+		trade.Buy = false
+	default:
+		trade.Buy = trade.TokenChanged.Sign() > 0
+	}
+
+	return trade, nil
+}
+
+// onPath reports whether a pair of tokens swaps two neighbours of path.
+func onPath(path []common.Address, tokens [2]common.Address) bool {
+	for i := 1; i < len(path); i++ {
+		if (path[i-1] == tokens[0] && path[i] == tokens[1]) || (path[i-1] == tokens[1] && path[i] == tokens[0]) {
+			return true
+		}
+	}
+	return false
+}
+
+func pairTokens(ctx context.Context, client *ethclient.Client, pairAddr common.Address, block *big.Int) ([2]common.Address, error) {
+	pair, err := uniswap.NewPairCaller(pairAddr, client)
+	if err != nil {
+		return [2]common.Address{}, err
+	}
+	opts := &bind.CallOpts{Context: ctx, BlockNumber: block}
+	token0, err := pair.Token0(opts)
+	if err != nil {
+		return [2]common.Address{}, err
+	}
+	token1, err := pair.Token1(opts)
+	if err != nil {
+		return [2]common.Address{}, err
+	}
+	return [2]common.Address{token0, token1}, nil
+}
@@ -0,0 +1,262 @@
+package evm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	t "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/meme-bots/go-web3/types"
+)
+
+var (
+	tradeOwner        = common.HexToAddress("0x0000000000000000000000000000000000000011")
+	tradeRouter       = common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	tradeFeeRouter    = common.HexToAddress("0x00000000000000000000000000000000000000ff")
+	tradeFeeRecipient = common.HexToAddress("0x0000000000000000000000000000000000000022")
+	tradeWrapped      = common.HexToAddress("0x00000000000000000000000000000000000000ee")
+	tradeToken        = common.HexToAddress("0x00000000000000000000000000000000000000bb")
+	tradeUSDC         = common.HexToAddress("0x00000000000000000000000000000000000000cc")
+	tradeOther        = common.HexToAddress("0x00000000000000000000000000000000000000dd")
+
+	// pairs and their token0, token1
+	tradePairs = map[common.Address][2]common.Address{
+		common.HexToAddress("0x0000000000000000000000000000000000000101"): {tradeUSDC, tradeWrapped},
+		common.HexToAddress("0x0000000000000000000000000000000000000102"): {tradeToken, tradeUSDC},
+		common.HexToAddress("0x0000000000000000000000000000000000000103"): {tradeToken, tradeWrapped},
+		common.HexToAddress("0x0000000000000000000000000000000000000104"): {tradeOther, tradeWrapped},
+	}
+)
+
+// newPairNode answers token0() and token1() of tradePairs.
+func newPairNode(tb testing.TB) *ethclient.Client {
+	return newStubNode(tb, func(method string, params []json.RawMessage) (interface{}, error) {
+		if method != "eth_call" {
+			return nil, errors.New("unexpected " + method)
+		}
+		var call struct {
+			To    common.Address
+			Input hexutil.Bytes
+			Data  hexutil.Bytes
+		}
+		_ = json.Unmarshal(params[0], &call)
+		input := call.Input
+		if len(input) == 0 {
+			input = call.Data
+		}
+		tokens, ok := tradePairs[call.To]
+		switch {
+		case !ok || len(input) < 4:
+			return nil, errors.New("unexpected call")
+		case bytes.Equal(input[:4], hexutil.MustDecode("0x0dfe1681")):
+			return hexutil.Bytes(common.LeftPadBytes(tokens[0].Bytes(), 32)), nil
+		case bytes.Equal(input[:4], hexutil.MustDecode("0xd21220a7")):
+			return hexutil.Bytes(common.LeftPadBytes(tokens[1].Bytes(), 32)), nil
+		}
+		return nil, errors.New("unexpected call")
+	})
+}
+
+func uint256s(values ...int64) []byte {
+	var data []byte
+	for _, value := range values {
+		data = append(data, common.LeftPadBytes(big.NewInt(value).Bytes(), 32)...)
+	}
+	return data
+}
+
+func swapLog(pair string, to common.Address, amount0In, amount1In, amount0Out, amount1Out int64) *t.Log {
+	return &t.Log{
+		Address: common.HexToAddress(pair),
+		Topics:  []common.Hash{swapTopic, common.BytesToHash(tradeRouter.Bytes()), common.BytesToHash(to.Bytes())},
+		Data:    uint256s(amount0In, amount1In, amount0Out, amount1Out),
+	}
+}
+
+func transferLog(token, from, to common.Address, value int64) *t.Log {
+	return &t.Log{
+		Address: token,
+		Topics:  []common.Hash{transferTopic, common.BytesToHash(from.Bytes()), common.BytesToHash(to.Bytes())},
+		Data:    uint256s(value),
+	}
+}
+
+func feeLog(emitter common.Address, value int64) *t.Log {
+	return &t.Log{
+		Address: emitter,
+		Topics:  []common.Hash{feeCollectedTopic, common.BytesToHash(tradeOwner.Bytes()), common.BytesToHash(tradeFeeRecipient.Bytes())},
+		Data:    uint256s(value),
+	}
+}
+
+func routerTx(tb testing.TB, method string, args ...interface{}) *t.Transaction {
+	data, err := packRouterCall(method, args...)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return t.NewTx(&t.LegacyTx{To: &tradeRouter, Data: data})
+}
+
+func TestDecodeTrade(tt *testing.T) {
+	zero := big.NewInt(0)
+	deadline := big.NewInt(0)
+	buyPath := []common.Address{tradeWrapped, tradeToken}
+	sellPath := []common.Address{tradeToken, tradeWrapped}
+
+	tests := []struct {
+		name         string
+		tx           *t.Transaction
+		logs         []*t.Log
+		buy          bool
+		hops         int
+		nativeIn     int64
+		nativeOut    int64
+		tokenChanged int64
+		feePaid      int64
+	}{
+		{
+			name: "multi-hop buy",
+			tx: routerTx(tt, "swapExactETHForTokens", zero,
+				[]common.Address{tradeWrapped, tradeUSDC, tradeToken}, tradeOwner, deadline),
+			logs: []*t.Log{
+				swapLog("0x0101", common.HexToAddress("0x0102"), 0, 1000, 500, 0),
+				swapLog("0x0102", tradeOwner, 0, 500, 300, 0),
+				transferLog(tradeToken, common.HexToAddress("0x0102"), tradeOwner, 300),
+			},
+			buy:          true,
+			hops:         2,
+			nativeIn:     1000,
+			tokenChanged: 300,
+		},
+		{
+			name: "taxed sell through the fee router",
+			tx:   routerTx(tt, "swapExactTokensForETHSupportingFeeOnTransferTokens", big.NewInt(1000), zero, sellPath, tradeFeeRouter, deadline),
+			logs: []*t.Log{
+				transferLog(tradeToken, tradeOwner, tradeFeeRouter, 1000),
+				// the token sells its tax for itself in the same pair
+				transferLog(tradeToken, tradeToken, common.HexToAddress("0x0103"), 50),
+				swapLog("0x0103", tradeToken, 50, 0, 0, 20),
+				transferLog(tradeToken, tradeFeeRouter, common.HexToAddress("0x0103"), 950),
+				swapLog("0x0103", tradeFeeRouter, 950, 0, 0, 400),
+				feeLog(tradeFeeRouter, 4),
+			},
+			hops:         1,
+			nativeOut:    400,
+			tokenChanged: -1000,
+			feePaid:      4,
+		},
+		{
+			name: "unrelated swaps",
+			tx:   routerTx(tt, "swapExactETHForTokens", zero, buyPath, tradeOwner, deadline),
+			logs: []*t.Log{
+				swapLog("0x0104", tradeOther, 0, 777, 10, 0),
+				swapLog("0x0103", tradeOwner, 0, 1000, 300, 0),
+				swapLog("0x0101", tradeOther, 0, 555, 10, 0),
+				transferLog(tradeToken, common.HexToAddress("0x0103"), tradeOwner, 300),
+			},
+			buy:          true,
+			hops:         1,
+			nativeIn:     1000,
+			tokenChanged: 300,
+		},
+		{
+			name: "spoofed fee log",
+			tx:   routerTx(tt, "swapExactETHForTokensSupportingFeeOnTransferTokens", zero, buyPath, tradeOwner, deadline),
+			logs: []*t.Log{
+				feeLog(tradeFeeRouter, 10),
+				feeLog(tradeOther, 1000),
+				swapLog("0x0103", tradeOwner, 0, 990, 300, 0),
+				transferLog(tradeToken, common.HexToAddress("0x0103"), tradeOwner, 300),
+			},
+			buy:          true,
+			hops:         1,
+			nativeIn:     990,
+			tokenChanged: 300,
+			feePaid:      10,
+		},
+	}
+
+	client := newPairNode(tt)
+	for _, test := range tests {
+		receipt := &t.Receipt{Logs: test.logs, BlockNumber: big.NewInt(1)}
+		trade, err := DecodeTrade(context.Background(), client, test.tx, receipt,
+			tradeOwner, tradeToken, tradeWrapped, tradeRouter, tradeFeeRouter, tradeFeeRecipient)
+		if err != nil {
+			tt.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if trade.Buy != test.buy || trade.Hops != test.hops ||
+			trade.NativeIn.Int64() != test.nativeIn || trade.NativeOut.Int64() != test.nativeOut ||
+			trade.TokenChanged.Int64() != test.tokenChanged || trade.FeePaid.Int64() != test.feePaid {
+			tt.Errorf("%s: buy %v, hops %d, in %s, out %s, token %s, fee %s", test.name,
+				trade.Buy, trade.Hops, trade.NativeIn, trade.NativeOut, trade.TokenChanged, trade.FeePaid)
+		}
+	}
+}
+
+func TestEVM_GetTransaction_L1Fee(tt *testing.T) {
+	pairs := newPairNode(tt)
+	tx := routerTx(tt, "swapExactETHForTokens", big.NewInt(0), []common.Address{tradeWrapped, tradeToken}, tradeOwner, big.NewInt(0))
+	receipt := &t.Receipt{
+		Status:            t.ReceiptStatusSuccessful,
+		TxHash:            tx.Hash(),
+		GasUsed:           100000,
+		EffectiveGasPrice: big.NewInt(10),
+		BlockNumber:       big.NewInt(1),
+		Logs: []*t.Log{
+			swapLog("0x0103", tradeOwner, 0, 1000, 300, 0),
+			transferLog(tradeToken, common.HexToAddress("0x0103"), tradeOwner, 300),
+		},
+	}
+	client := newStubNode(tt, func(method string, params []json.RawMessage) (interface{}, error) {
+		switch method {
+		case "eth_getTransactionReceipt":
+			// OP-stack nodes add the L1 data fee to the receipt
+			data, err := json.Marshal(receipt)
+			if err != nil {
+				return nil, err
+			}
+			var fields map[string]interface{}
+			if err := json.Unmarshal(data, &fields); err != nil {
+				return nil, err
+			}
+			fields["l1Fee"] = "0x1388"
+			return fields, nil
+		case "eth_getBlockByHash":
+			return &t.Header{Difficulty: big.NewInt(0), Number: big.NewInt(1), Time: 1700000000}, nil
+		case "eth_call":
+			var result hexutil.Bytes
+			err := pairs.Client().Call(&result, method, params[0], params[1])
+			return result, err
+		}
+		return nil, errors.New("unexpected " + method)
+	})
+
+	v := &EVM{
+		ctx:    context.Background(),
+		cfg:    &types.Config{OPStack: true},
+		client: client,
+		dex:    &UniswapV2{client: client, router: tradeRouter, wrapped: tradeWrapped},
+	}
+	resp, err := v.GetTransaction(&types.GetTransactionRequest{
+		TxHash: tx.Hash().Hex(),
+		Tx:     tx,
+		Owner:  tradeOwner.Hex(),
+		Token:  tradeToken.Hex(),
+	})
+	if err != nil {
+		tt.Fatal(err)
+	}
+	if resp.L1Fee.Int64() != 5000 || resp.Fee.Int64() != 10*100000+5000 {
+		tt.Errorf("fee %s, l1 fee %s", resp.Fee, resp.L1Fee)
+	}
+	if resp.BalanceChanged.Int64() != -1000 || resp.TokenChanged.Int64() != 300 || resp.Timestamp.Unix() != 1700000000 {
+		tt.Errorf("balance %s, token %s, at %s", resp.BalanceChanged, resp.TokenChanged, resp.Timestamp)
+	}
+}
@@ -0,0 +1,194 @@
+package evm
+
+import (
+	"context"
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/meme-bots/go-web3/evm/erc20"
+	"github.com/meme-bots/go-web3/evm/multisend"
+	t "github.com/meme-bots/go-web3/types"
+	"github.com/samber/lo"
+)
+
+const (
+	// MAX_BATCH_GAS caps the gas of a single token batch so that chunks stay
+	// well below the block gas limit of every supported chain.
+	MAX_BATCH_GAS uint64 = 8000000
+)
+
+var (
+	// DISPERSE_ADDRESS is the Disperse deployment shared by most chains,
+	// used when Config.Disperse is unset.
+	DISPERSE_ADDRESS = common.HexToAddress("0xD152f549545093347A162Dce210e7293f1452150")
+
+	ErrInsufficientTokenBalance = errors.New("insufficient token balance")
+	ErrNoDisperse               = errors.New("no disperse contract deployed")
+)
+
+func TransferToken(
+	client *ethclient.Client,
+	chainID uint64,
+	tokenAddr, recipient common.Address,
+	amount, gasPrice *big.Int,
+	gasMargin uint64,
+	privateKey string,
+) (common.Hash, error) {
+	token, err := erc20.NewErc20(tokenAddr, client)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	senderPriKey, err := crypto.HexToECDSA(privateKey)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	auth, err := bind.NewKeyedTransactorWithChainID(senderPriKey, new(big.Int).SetUint64(chainID))
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	erc20ABI, err := erc20.Erc20MetaData.GetAbi()
+	if err != nil {
+		return common.Hash{}, err
+	}
+	data, err := erc20ABI.Pack("transfer", recipient, amount)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	senderAddress := crypto.PubkeyToAddress(senderPriKey.PublicKey)
+	gas, err := EstimateGasLimit(client, senderAddress, &tokenAddr, nil, data, gasMargin)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	tx, err := token.Transfer(
+		&bind.TransactOpts{From: senderAddress, Signer: auth.Signer, GasPrice: gasPrice, GasLimit: gas},
+		recipient,
+		amount,
+	)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	return tx.Hash(), nil
+}
+
+// TransferTokenBatch distributes tokenAddr to bills through the disperse
+// contract, which must already be approved for the total amount. Bills are
+// sent in chunks of at most maxCount recipients; a chunk is halved until its
+// estimated gas fits in MAX_BATCH_GAS. Every bill gets a result, failed chunks
+// carry the error instead of a hash.
+func TransferTokenBatch(
+	client *ethclient.Client,
+	chainID uint64,
+	disperse, tokenAddr common.Address,
+	gasPrice *big.Int,
+	gasMargin uint64,
+	privateKey string,
+	bills []*t.TransferBill,
+	maxCount int,
+) ([]*t.TransferResult, error) {
+	ctx := context.Background()
+	fromPrivateKey, err := crypto.HexToECDSA(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	fromAddr := crypto.PubkeyToAddress(fromPrivateKey.PublicKey)
+	nonce, err := client.PendingNonceAt(ctx, fromAddr)
+	if err != nil {
+		return nil, err
+	}
+	auth, err := bind.NewKeyedTransactorWithChainID(fromPrivateKey, new(big.Int).SetUint64(chainID))
+	if err != nil {
+		return nil, err
+	}
+
+	ds, err := multisend.NewDisperse(disperse, client)
+	if err != nil {
+		return nil, err
+	}
+
+	disperseABI, err := multisend.DisperseMetaData.GetAbi()
+	if err != nil {
+		return nil, err
+	}
+
+	results := lo.Map(bills, func(b *t.TransferBill, _ int) *t.TransferResult {
+		return &t.TransferResult{Recipient: b.Recipient, Amount: b.Amount}
+	})
+
+	size := lo.Max([]int{maxCount, 1})
+	for start := 0; start < len(bills); {
+		end := lo.Min([]int{start + size, len(bills)})
+		chunk := bills[start:end]
+		addresses := lo.Map(chunk, func(b *t.TransferBill, _ int) common.Address { return common.HexToAddress(b.Recipient) })
+		amounts := lo.Map(chunk, func(b *t.TransferBill, _ int) *big.Int { return b.Amount })
+
+		data, err := disperseABI.Pack("disperseTokenSimple", tokenAddr, addresses, amounts)
+		if err != nil {
+			return nil, err
+		}
+
+		gas, err := EstimateGasLimit(client, fromAddr, &disperse, nil, data, gasMargin)
+		if err == nil && gas > MAX_BATCH_GAS && size > 1 {
+			size = size / 2
+			continue
+		}
+
+		var txHash common.Hash
+		if err == nil {
+			tx, sendErr := ds.DisperseTokenSimple(
+				&bind.TransactOpts{
+					From:     fromAddr,
+					Signer:   auth.Signer,
+					Nonce:    new(big.Int).SetUint64(nonce),
+					GasPrice: gasPrice,
+					GasLimit: gas,
+				},
+				tokenAddr,
+				addresses,
+				amounts,
+			)
+			if sendErr == nil {
+				txHash = tx.Hash()
+				nonce++
+			}
+			err = sendErr
+		}
+
+		for i := start; i < end; i++ {
+			if err != nil {
+				results[i].Error = err
+			} else {
+				results[i].TxHash = txHash.String()
+			}
+		}
+		start = end
+	}
+
+	return results, nil
+}
+
+// DisperseAddress returns the disperse contract of cfg, once it is seen
+// deployed.
+func DisperseAddress(client *ethclient.Client, cfg *t.Config) (common.Address, error) {
+	disperse := DISPERSE_ADDRESS
+	if cfg.Disperse != "" {
+		disperse = common.HexToAddress(cfg.Disperse)
+	}
+	code, err := client.CodeAt(context.Background(), disperse, nil)
+	if err != nil {
+		return common.Address{}, err
+	}
+	if len(code) == 0 {
+		return common.Address{}, ErrNoDisperse
+	}
+	return disperse, nil
+}
@@ -0,0 +1,170 @@
+package evm
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	t "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/meme-bots/go-web3/evm/multisend"
+	"github.com/meme-bots/go-web3/types"
+)
+
+// newStubNode serves JSON-RPC calls with handle, an error result turning
+// into an RPC error.
+func newStubNode(tb testing.TB, handle func(method string, params []json.RawMessage) (interface{}, error)) *ethclient.Client {
+	var mu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var call struct {
+			ID     json.RawMessage
+			Method string
+			Params []json.RawMessage
+		}
+		if err := json.NewDecoder(r.Body).Decode(&call); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		mu.Lock()
+		result, err := handle(call.Method, call.Params)
+		mu.Unlock()
+
+		response := map[string]interface{}{"jsonrpc": "2.0", "id": call.ID}
+		if err != nil {
+			response["error"] = map[string]interface{}{"code": -32000, "message": err.Error()}
+		} else {
+			response["result"] = result
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(response)
+	}))
+	tb.Cleanup(server.Close)
+
+	client, err := ethclient.Dial(server.URL)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(client.Close)
+	return client
+}
+
+func decodeRawTx(raw []byte) (*t.Transaction, error) {
+	tx := new(t.Transaction)
+	return tx, tx.UnmarshalBinary(raw)
+}
+
+func TestDisperseAddress(t *testing.T) {
+	custom := common.HexToAddress("0x00000000000000000000000000000000000000d1")
+	var deployed common.Address
+	client := newStubNode(t, func(method string, params []json.RawMessage) (interface{}, error) {
+		var addr common.Address
+		_ = json.Unmarshal(params[0], &addr)
+		if addr == deployed {
+			return "0x6080", nil
+		}
+		return "0x", nil
+	})
+
+	deployed = custom
+	if _, err := DisperseAddress(client, &types.Config{}); !errors.Is(err, ErrNoDisperse) {
+		t.Errorf("default not deployed: err = %v", err)
+	}
+	if addr, err := DisperseAddress(client, &types.Config{Disperse: custom.Hex()}); err != nil || addr != custom {
+		t.Errorf("configured: %s, %v", addr, err)
+	}
+	deployed = DISPERSE_ADDRESS
+	if addr, err := DisperseAddress(client, &types.Config{}); err != nil || addr != DISPERSE_ADDRESS {
+		t.Errorf("default: %s, %v", addr, err)
+	}
+}
+
+func TestTransferTokenBatch(t *testing.T) {
+	disperseABI, _ := multisend.DisperseMetaData.GetAbi()
+	method := disperseABI.Methods["disperseTokenSimple"]
+	failing := common.HexToAddress("0x0000000000000000000000000000000000000bad")
+	recipientsOf := func(data hexutil.Bytes) []common.Address {
+		args, err := method.Inputs.Unpack(data[4:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		return args[1].([]common.Address)
+	}
+
+	var sent [][]common.Address
+	client := newStubNode(t, func(rpcMethod string, params []json.RawMessage) (interface{}, error) {
+		switch rpcMethod {
+		case "eth_getTransactionCount":
+			return "0x7", nil
+		case "eth_estimateGas":
+			var call struct{ Input hexutil.Bytes }
+			_ = json.Unmarshal(params[0], &call)
+			recipients := recipientsOf(call.Input)
+			for _, r := range recipients {
+				if r == failing {
+					return nil, errors.New("execution reverted")
+				}
+			}
+			// a recipient costs 3M gas, so only two fit in MAX_BATCH_GAS
+			return hexutil.Uint64(3000000 * len(recipients)), nil
+		case "eth_sendRawTransaction":
+			var raw hexutil.Bytes
+			_ = json.Unmarshal(params[0], &raw)
+			tx, err := decodeRawTx(raw)
+			if err != nil {
+				return nil, err
+			}
+			if tx.Nonce() != 7+uint64(len(sent)) {
+				return nil, errors.New("nonce too low")
+			}
+			sent = append(sent, recipientsOf(tx.Data()))
+			return tx.Hash(), nil
+		}
+		return nil, errors.New("unexpected " + rpcMethod)
+	})
+
+	key, _ := crypto.GenerateKey()
+	bills := make([]*types.TransferBill, 7)
+	for i := range bills {
+		bills[i] = &types.TransferBill{Recipient: common.BigToAddress(big.NewInt(int64(i + 1))).Hex(), Amount: big.NewInt(1)}
+	}
+	bills[6].Recipient = failing.Hex()
+
+	results, err := TransferTokenBatch(
+		client,
+		1,
+		DISPERSE_ADDRESS,
+		common.HexToAddress("0x00000000000000000000000000000000000000bb"),
+		big.NewInt(1e9),
+		0,
+		hexutil.Encode(crypto.FromECDSA(key))[2:],
+		bills,
+		4,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// four do not fit, the chunks halve to two
+	if len(sent) != 3 || len(sent[0]) != 2 || len(sent[1]) != 2 || len(sent[2]) != 2 {
+		t.Fatalf("sent %v", sent)
+	}
+	for i, result := range results {
+		if result.Recipient != bills[i].Recipient {
+			t.Errorf("result %d is of %s", i, result.Recipient)
+		}
+		if failed := i == 6; failed != (result.Error != nil) || failed == (result.TxHash != "") {
+			t.Errorf("result %d: hash %q, err %v", i, result.TxHash, result.Error)
+		}
+	}
+	if results[0].TxHash != results[1].TxHash || results[1].TxHash == results[2].TxHash {
+		t.Error("a chunk shares its hash")
+	}
+}
@@ -0,0 +1,163 @@
+package evm
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	t "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/forta-network/go-multicall"
+	"github.com/meme-bots/go-web3/evm/uniswap"
+	"github.com/meme-bots/go-web3/types"
+	"github.com/meme-bots/go-web3/utils"
+)
+
+type (
+	// UniswapV2 trades through a Uniswap V2 router, behind the fee router
+	// if one is configured.
+	UniswapV2 struct {
+		client       *ethclient.Client
+		router       common.Address
+		factory      common.Address
+		wrapped      common.Address
+		initCodeHash string
+		feeRouter    common.Address // zero without one
+	}
+
+	reservesOutput struct {
+		Reserve0           *big.Int
+		Reserve1           *big.Int
+		BlockTimestampLast uint32
+	}
+)
+
+func NewUniswapV2(cfg *types.Config, client *ethclient.Client) (Dex, error) {
+	routerAddr := common.HexToAddress(cfg.Router)
+	router, err := uniswap.NewRouterv2(routerAddr, client)
+	if err != nil {
+		return nil, err
+	}
+
+	factory, err := router.Factory(&bind.CallOpts{})
+	if err != nil {
+		return nil, err
+	}
+
+	var feeRouter common.Address
+	if cfg.FeeRouter != "" {
+		feeRouter = common.HexToAddress(cfg.FeeRouter)
+	}
+
+	return &UniswapV2{
+		client:       client,
+		router:       routerAddr,
+		factory:      factory,
+		wrapped:      common.HexToAddress(cfg.WrapNativeToken),
+		initCodeHash: cfg.UniswapPairInitCodeHash,
+		feeRouter:    feeRouter,
+	}, nil
+}
+
+func (u *UniswapV2) ID() int {
+	return types.DexUniswapV2
+}
+
+// FindPool derives the pair address, whether or not the pair was created.
+func (u *UniswapV2) FindPool(ctx context.Context, token common.Address) (common.Address, error) {
+	return CalculatePoolAddress(u.wrapped, token, u.factory, u.initCodeHash)
+}
+
+func (u *UniswapV2) PoolCalls(pool *Pool) ([]*multicall.Call, error) {
+	pairContract, err := multicall.NewContract(uniswap.PairABI, pool.Address.String())
+	if err != nil {
+		return nil, err
+	}
+	return []*multicall.Call{pairContract.NewCall(new(reservesOutput), "getReserves")}, nil
+}
+
+func (u *UniswapV2) DecodePool(pool *Pool, calls []*multicall.Call) error {
+	reserves := calls[0].Outputs.(*reservesOutput)
+	token0, _ := sortAddressess(pool.Token, u.wrapped)
+	if token0 == pool.Token {
+		pool.TokenReserve, pool.QuoteReserve = reserves.Reserve0, reserves.Reserve1
+	} else {
+		pool.TokenReserve, pool.QuoteReserve = reserves.Reserve1, reserves.Reserve0
+	}
+	return nil
+}
+
+func (u *UniswapV2) Quote(ctx context.Context, pool *Pool, amountIn *big.Int, buy bool) (*big.Int, error) {
+	if buy {
+		return utils.CalculateOutputBigInt(amountIn, pool.QuoteReserve, pool.TokenReserve), nil
+	}
+	return utils.CalculateOutputBigInt(amountIn, pool.TokenReserve, pool.QuoteReserve), nil
+}
+
+func (u *UniswapV2) Spender() common.Address {
+	if u.feeRouter != (common.Address{}) {
+		return u.feeRouter
+	}
+	return u.router
+}
+
+// SwapCall calls the router directly, or through the fee router, which
+// takes FeeRatio basis points of what is paid or received. A sell's
+// MinAmountOut then applies before the fee.
+func (u *UniswapV2) SwapCall(req *SwapRequest) (*SwapCall, error) {
+	withFee := u.feeRouter != (common.Address{})
+	if req.Buy {
+		path := []common.Address{u.wrapped, req.Token}
+		if !withFee {
+			data, err := packRouterCall("swapExactETHForTokens", req.MinAmountOut, path, req.Owner, req.Deadline)
+			if err != nil {
+				return nil, err
+			}
+			return &SwapCall{To: u.router, Value: req.AmountIn, Data: data}, nil
+		}
+
+		data, err := packRouterCall("swapExactETHForTokensSupportingFeeOnTransferTokens", req.MinAmountOut, path, req.Owner, req.Deadline)
+		if err != nil {
+			return nil, err
+		}
+		fee := new(big.Int).Div(new(big.Int).Mul(req.AmountIn, new(big.Int).SetUint64(req.FeeRatio)), big.NewInt(10000))
+		data, err = packFeeRouterCall("buy", data, req.FeeRecipient, fee)
+		if err != nil {
+			return nil, err
+		}
+		return &SwapCall{To: u.feeRouter, Value: req.AmountIn, Data: data}, nil
+	}
+
+	path := []common.Address{req.Token, u.wrapped}
+	if !withFee {
+		data, err := packRouterCall("swapExactTokensForETH", req.AmountIn, req.MinAmountOut, path, req.Owner, req.Deadline)
+		if err != nil {
+			return nil, err
+		}
+		return &SwapCall{To: u.router, Data: data}, nil
+	}
+
+	// the fee router replaces the amount in with what it actually received
+	data, err := packRouterCall("swapExactTokensForETHSupportingFeeOnTransferTokens", req.AmountIn, req.MinAmountOut, path, u.feeRouter, req.Deadline)
+	if err != nil {
+		return nil, err
+	}
+	data, err = packFeeRouterCall("sell", req.Token, data, req.FeeRecipient, new(big.Int).SetUint64(req.FeeRatio))
+	if err != nil {
+		return nil, err
+	}
+	return &SwapCall{To: u.feeRouter, Data: data}, nil
+}
+
+func (u *UniswapV2) DecodeTrade(ctx context.Context, tx *t.Transaction, receipt *t.Receipt, owner, token, feeRecipient common.Address) (*Trade, error) {
+	return DecodeTrade(ctx, u.client, tx, receipt, owner, token, u.wrapped, u.router, u.feeRouter, feeRecipient)
+}
+
+func packRouterCall(method string, args ...interface{}) ([]byte, error) {
+	routerABI, err := uniswap.Routerv2MetaData.GetAbi()
+	if err != nil {
+		return nil, err
+	}
+	return routerABI.Pack(method, args...)
+}
@@ -0,0 +1,72 @@
+package evm
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/forta-network/go-multicall"
+)
+
+func TestUniswapV2_SwapCall(t *testing.T) {
+	router := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	feeRouter := common.HexToAddress("0x00000000000000000000000000000000000000ff")
+	wrapped := common.HexToAddress("0x00000000000000000000000000000000000000ee")
+	token := common.HexToAddress("0x00000000000000000000000000000000000000bb")
+	req := &SwapRequest{
+		Token:        token,
+		Buy:          true,
+		AmountIn:     big.NewInt(1000),
+		MinAmountOut: big.NewInt(10),
+		FeeRatio:     100,
+		Deadline:     big.NewInt(0),
+	}
+
+	direct := &UniswapV2{router: router, wrapped: wrapped}
+	call, err := direct.SwapCall(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	method, path := decodeRouterCall(call.Data)
+	if call.To != router || call.Value.Int64() != 1000 || method != "swapExactETHForTokens" || len(path) != 2 || path[1] != token {
+		t.Errorf("direct buy: to %s, value %s, %s %v", call.To, call.Value, method, path)
+	}
+
+	withFee := &UniswapV2{router: router, wrapped: wrapped, feeRouter: feeRouter}
+	req.Buy = false
+	call, err = withFee.SwapCall(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inner := unpackFeeRouterCall(call.Data)
+	method, path = decodeRouterCall(inner)
+	if call.To != feeRouter || call.Value != nil || method != "swapExactTokensForETHSupportingFeeOnTransferTokens" || path[0] != token {
+		t.Errorf("sell with fee: to %s, value %v, %s %v", call.To, call.Value, method, path)
+	}
+	if withFee.Spender() != feeRouter || direct.Spender() != router {
+		t.Error("sells approve the contract they call")
+	}
+}
+
+func TestUniswapV2_DecodePool(t *testing.T) {
+	wrapped := common.HexToAddress("0x00000000000000000000000000000000000000ee")
+	u := &UniswapV2{wrapped: wrapped}
+	calls := []*multicall.Call{{Outputs: &reservesOutput{Reserve0: big.NewInt(1), Reserve1: big.NewInt(2)}}}
+
+	// the lower address is token0
+	pool := &Pool{Token: common.HexToAddress("0x00000000000000000000000000000000000000bb")}
+	if err := u.DecodePool(pool, calls); err != nil {
+		t.Fatal(err)
+	}
+	if pool.TokenReserve.Int64() != 1 || pool.QuoteReserve.Int64() != 2 {
+		t.Errorf("token0: reserves %s, %s", pool.TokenReserve, pool.QuoteReserve)
+	}
+
+	pool = &Pool{Token: common.HexToAddress("0x00000000000000000000000000000000000000ff")}
+	if err := u.DecodePool(pool, calls); err != nil {
+		t.Fatal(err)
+	}
+	if pool.TokenReserve.Int64() != 2 || pool.QuoteReserve.Int64() != 1 {
+		t.Errorf("token1: reserves %s, %s", pool.TokenReserve, pool.QuoteReserve)
+	}
+}
@@ -12,7 +12,11 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/meme-bots/go-web3/evm/chainlink"
+	"github.com/meme-bots/go-web3/evm/erc20"
+	"github.com/meme-bots/go-web3/evm/uniswap"
+	"github.com/meme-bots/go-web3/types"
 	"github.com/meme-bots/go-web3/utils"
+	"github.com/samber/lo"
 	"github.com/shopspring/decimal"
 )
 
@@ -21,11 +25,18 @@ type (
 
 	Watcher struct {
 		client       *ethclient.Client
-		ethPrice     decimal.Decimal
+		ethPrice     *types.NativeTokenPrice
+		ethPriceErr  error // of the latest refresh
 		ethPriceLock sync.RWMutex
 		gasPrice     *big.Int
 		gasPriceLock sync.RWMutex
 		oracle       *chainlink.AggregatorV3Interface
+		stablePool   *uniswap.Pair
+		wrapped      common.Address
+
+		// decimals of the oracle and of tokens, read once
+		decimals     map[common.Address]uint8
+		decimalsLock sync.Mutex
 
 		ctx          context.Context
 		cancel       context.CancelFunc
@@ -43,7 +54,13 @@ const (
 	watcherStateClosed
 )
 
-func NewWatcher(url string, ethPriceOracle common.Address) (*Watcher, error) {
+const (
+	priceMaxAge       = 2 * time.Hour
+	priceMaxDeviation = 0.02
+	priceSourceCount  = 2
+)
+
+func NewWatcher(url string, ethPriceOracle, stablePool, wrapped common.Address) (*Watcher, error) {
 	client, err := ethclient.Dial(url)
 	if err != nil {
 		return nil, err
@@ -53,11 +70,26 @@ func NewWatcher(url string, ethPriceOracle common.Address) (*Watcher, error) {
 		return nil, err
 	}
 
+	var pool *uniswap.Pair
+	if stablePool != (common.Address{}) {
+		pool, err = uniswap.NewPair(stablePool, client)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
 	return &Watcher{
-		client:   client,
-		ethPrice: decimal.Zero,
-		gasPrice: big.NewInt(0),
-		oracle:   oracle,
+		client:     client,
+		ethPrice:   &types.NativeTokenPrice{Price: decimal.Zero, Confidence: decimal.Zero},
+		gasPrice:   big.NewInt(0),
+		oracle:     oracle,
+		stablePool: pool,
+		wrapped:    wrapped,
+		decimals:   map[common.Address]uint8{},
+		ctx:        ctx,
+		cancel:     cancel,
+		state:      watcherStatePending,
 	}, nil
 }
 
@@ -105,28 +137,139 @@ func (w *Watcher) Close() error {
 }
 
 func (w *Watcher) WatchETHPrice(interval time.Duration) {
-	decimals, err := w.oracle.Decimals(&bind.CallOpts{})
-	if err != nil {
-		fmt.Println(err)
-		return
-	}
-
 	for {
+		price, err := w.QueryETHPrice()
+		w.ethPriceLock.Lock()
+		if err == nil {
+			w.ethPrice = price
+		}
+		w.ethPriceErr = err
+		w.ethPriceLock.Unlock()
+
 		select {
 		case <-time.After(interval):
 		case <-w.ctx.Done():
 			return
 		}
+	}
+}
+
+// QueryETHPrice reads every configured price source and aggregates the fresh,
+// agreeing ones, preferring the oracle. The errors of failed sources are
+// returned along with the one of aggregating.
+func (w *Watcher) QueryETHPrice() (*types.NativeTokenPrice, error) {
+	samples := make([]utils.PriceSample, 0, priceSourceCount)
+	total := 1
+	var errs []error
+
+	sample, err := w.queryOraclePrice()
+	if err != nil {
+		errs = append(errs, fmt.Errorf("chainlink: %w", err))
+	} else {
+		samples = append(samples, *sample)
+	}
 
-		data, err := w.oracle.LatestRoundData(&bind.CallOpts{})
+	if w.stablePool != nil {
+		total++
+		sample, err = w.queryPoolPrice()
 		if err != nil {
-			fmt.Println(err)
+			errs = append(errs, fmt.Errorf("pool: %w", err))
 		} else {
-			w.ethPriceLock.Lock()
-			w.ethPrice = decimal.NewFromBigInt(data.Answer, 0-int32(decimals))
-			w.ethPriceLock.Unlock()
+			samples = append(samples, *sample)
 		}
 	}
+
+	price, err := utils.AggregatePrices(samples, total, priceMaxAge, decimal.NewFromFloat(priceMaxDeviation))
+	if err != nil {
+		return nil, errors.Join(append([]error{err}, errs...)...)
+	}
+	return price, nil
+}
+
+// cachedDecimals returns the decimals of addr, reading them with read the
+// first time.
+func (w *Watcher) cachedDecimals(addr common.Address, read func(opts *bind.CallOpts) (uint8, error)) (uint8, error) {
+	w.decimalsLock.Lock()
+	defer w.decimalsLock.Unlock()
+	if decimals, ok := w.decimals[addr]; ok {
+		return decimals, nil
+	}
+	decimals, err := read(&bind.CallOpts{})
+	if err != nil {
+		return 0, err
+	}
+	w.decimals[addr] = decimals
+	return decimals, nil
+}
+
+func (w *Watcher) tokenDecimals(addr common.Address) (uint8, error) {
+	token, err := erc20.NewErc20(addr, w.client)
+	if err != nil {
+		return 0, err
+	}
+	return w.cachedDecimals(addr, token.Decimals)
+}
+
+func (w *Watcher) queryOraclePrice() (*utils.PriceSample, error) {
+	decimals, err := w.cachedDecimals(w.oracle.Address(), w.oracle.Decimals)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := w.oracle.LatestRoundData(&bind.CallOpts{})
+	if err != nil {
+		return nil, err
+	}
+	if data.Answer.Sign() <= 0 || data.AnsweredInRound.Cmp(data.RoundId) < 0 {
+		return nil, types.ErrStalePrice
+	}
+
+	return &utils.PriceSample{
+		Source:    "chainlink",
+		Price:     decimal.NewFromBigInt(data.Answer, 0-int32(decimals)),
+		UpdatedAt: time.Unix(data.UpdatedAt.Int64(), 0),
+	}, nil
+}
+
+func (w *Watcher) queryPoolPrice() (*utils.PriceSample, error) {
+	token0, err := w.stablePool.Token0(&bind.CallOpts{})
+	if err != nil {
+		return nil, err
+	}
+	token1, err := w.stablePool.Token1(&bind.CallOpts{})
+	if err != nil {
+		return nil, err
+	}
+	stable := lo.If(token0 == w.wrapped, token1).Else(token0)
+
+	stableDecimals, err := w.tokenDecimals(stable)
+	if err != nil {
+		return nil, err
+	}
+	wrappedDecimals, err := w.tokenDecimals(w.wrapped)
+	if err != nil {
+		return nil, err
+	}
+
+	reserves, err := w.stablePool.GetReserves(&bind.CallOpts{})
+	if err != nil {
+		return nil, err
+	}
+
+	wrappedReserve, stableReserve := reserves.Reserve0, reserves.Reserve1
+	if token0 == stable {
+		wrappedReserve, stableReserve = reserves.Reserve1, reserves.Reserve0
+	}
+	if wrappedReserve.Sign() == 0 {
+		return nil, types.ErrInvalidPool
+	}
+
+	// reserves are current as of the latest block, BlockTimestampLast is the last trade
+	return &utils.PriceSample{
+		Source:    "pool",
+		Price:     decimal.NewFromBigInt(stableReserve, 0-int32(stableDecimals)).Div(decimal.NewFromBigInt(wrappedReserve, 0-int32(wrappedDecimals))),
+		UpdatedAt: time.Now(),
+	}, nil
 }
 
 func (w *Watcher) WatchGasPrice(interval time.Duration) {
@@ -151,11 +294,17 @@ func (w *Watcher) WatchGasPrice(interval time.Duration) {
 func (w *Watcher) GetETHPrice() decimal.Decimal {
 	var price decimal.Decimal
 	w.ethPriceLock.RLock()
-	price = w.ethPrice.Copy()
+	price = utils.FreshPrice(w.ethPrice, priceMaxAge).Copy()
 	w.ethPriceLock.RUnlock()
 	return price
 }
 
+func (w *Watcher) GetETHPriceInfo() *types.NativeTokenPrice {
+	w.ethPriceLock.RLock()
+	defer w.ethPriceLock.RUnlock()
+	return utils.CurrentPrice(w.ethPrice, w.ethPriceErr, priceMaxAge)
+}
+
 func (w *Watcher) GetGasPrice() *big.Int {
 	var price *big.Int
 	w.gasPriceLock.RLock()
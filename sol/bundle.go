@@ -0,0 +1,21 @@
+package sol
+
+import (
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/meme-bots/go-web3/sol/common"
+)
+
+// WatchBundle waits for the Jito bundle that carried the transaction txHash
+// to land. txHash may also be a bundle id.
+func (s *Solana) WatchBundle(txHash string, duration time.Duration) (*common.BundleStatus, error) {
+	bundleID := txHash
+	signature, err := solana.SignatureFromBase58(txHash)
+	if err == nil {
+		if id, ok := s.jito.BundleOf(signature); ok {
+			bundleID = id
+		}
+	}
+	return s.jito.WaitForBundle(s.ctx, bundleID, duration)
+}
@@ -0,0 +1,118 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gagliardetto/solana-go"
+	computebudget "github.com/gagliardetto/solana-go/programs/compute-budget"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/meme-bots/go-web3/types"
+)
+
+const (
+	MaxComputeUnits uint32 = 1400000
+
+	// ComputeUnitMargin is the percentage added to the simulated units so
+	// that state changing between simulation and execution does not exhaust
+	// the limit.
+	ComputeUnitMargin uint64 = 15
+)
+
+// Compute units transactions typically need. They are the limits used when
+// a simulation cannot run and turn estimated prices into priority fees.
+const (
+	SwapComputeUnits         uint32 = 140000
+	LaunchComputeUnits       uint32 = 200000
+	LaunchAndBuyComputeUnits uint32 = 240000
+	TransferComputeUnits     uint32 = 40000
+)
+
+// WithComputeBudget prepends the compute budget instructions to
+// instructions. The limit is what a simulation consumed plus
+// ComputeUnitMargin and the price is what priorityFee pays at that limit. If
+// the simulation cannot be run, or fails outside the programs invoked,
+// fallbackLimit is used; if an instruction fails the error carries the
+// program logs.
+func WithComputeBudget(
+	ctx context.Context,
+	client *rpc.Client,
+	instructions []solana.Instruction,
+	payer solana.PublicKey,
+	lookupTables map[solana.PublicKey]solana.PublicKeySlice,
+	priorityFee PriorityFee,
+	fallbackLimit uint32,
+) ([]solana.Instruction, error) {
+	limit, err := SimulateComputeUnits(ctx, client, instructions, payer, lookupTables)
+	if err != nil {
+		if errors.Is(err, types.ErrSimulationFailed) {
+			return nil, err
+		}
+		limit = fallbackLimit
+	}
+
+	return append([]solana.Instruction{
+		computebudget.NewSetComputeUnitLimitInstruction(limit).Build(),
+		computebudget.NewSetComputeUnitPriceInstruction(priorityFee.UnitPrice(limit)).Build(),
+	}, instructions...), nil
+}
+
+// SimulateComputeUnits returns the compute unit limit instructions need,
+// margin included.
+func SimulateComputeUnits(
+	ctx context.Context,
+	client *rpc.Client,
+	instructions []solana.Instruction,
+	payer solana.PublicKey,
+	lookupTables map[solana.PublicKey]solana.PublicKeySlice,
+) (uint32, error) {
+	// the budget instructions are part of what gets metered
+	simulated := append([]solana.Instruction{
+		computebudget.NewSetComputeUnitLimitInstruction(MaxComputeUnits).Build(),
+		computebudget.NewSetComputeUnitPriceInstruction(0).Build(),
+	}, instructions...)
+
+	tx, err := NewTransaction(simulated, solana.Hash{}, payer, lookupTables)
+	if err != nil {
+		return 0, err
+	}
+	tx.Signatures = make([]solana.Signature, tx.Message.Header.NumRequiredSignatures)
+
+	result, err := client.SimulateTransactionWithOpts(ctx, tx, &rpc.SimulateTransactionOpts{
+		Commitment:             rpc.CommitmentProcessed,
+		ReplaceRecentBlockhash: true,
+	})
+	if err != nil {
+		return 0, err
+	}
+	if result.Value.Err != nil && programFailed(result.Value.Err) {
+		return 0, fmt.Errorf("%w: %v\n%s", types.ErrSimulationFailed, result.Value.Err, strings.Join(result.Value.Logs, "\n"))
+	}
+	if result.Value.Err != nil {
+		return 0, fmt.Errorf("simulation: %v", result.Value.Err)
+	}
+	if result.Value.UnitsConsumed == nil {
+		return 0, types.ErrNotFound
+	}
+
+	units := *result.Value.UnitsConsumed
+	units += units * ComputeUnitMargin / 100
+	if units > uint64(MaxComputeUnits) {
+		units = uint64(MaxComputeUnits)
+	}
+	return uint32(units), nil
+}
+
+// programFailed tells an instruction error, which the transaction would hit
+// on chain as well, from errors of the transaction as a whole, such as a
+// payer the simulating node does not know yet.
+func programFailed(err interface{}) bool {
+	fields, ok := err.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	_, ok = fields["InstructionError"]
+	return ok
+}
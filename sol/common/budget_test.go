@@ -0,0 +1,84 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	computebudget "github.com/gagliardetto/solana-go/programs/compute-budget"
+	"github.com/gagliardetto/solana-go/programs/system"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/meme-bots/go-web3/types"
+)
+
+// newSimulatingNode answers every simulation with result, or fails the
+// request when result is empty.
+func newSimulatingNode(tb testing.TB, result string) *rpc.Client {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if result == "" {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":{"context":{"slot":100},"value":` + result + `}}`))
+	}))
+	tb.Cleanup(server.Close)
+	return rpc.New(server.URL)
+}
+
+func TestWithComputeBudget(t *testing.T) {
+	payer := solana.NewWallet().PublicKey()
+	instructions := []solana.Instruction{system.NewTransferInstruction(1, payer, solana.NewWallet().PublicKey()).Build()}
+	limitOf := func(budgeted []solana.Instruction) uint32 {
+		data, _ := budgeted[0].Data()
+		inst, err := computebudget.DecodeInstruction(nil, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return inst.Impl.(*computebudget.SetComputeUnitLimit).Units
+	}
+
+	budgeted, err := WithComputeBudget(context.Background(), newSimulatingNode(t, `{"err":null,"logs":[],"unitsConsumed":1000}`), instructions, payer, nil, PriorityFee{}, SwapComputeUnits)
+	if err != nil || limitOf(budgeted) != 1150 {
+		t.Errorf("simulated: err = %v", err)
+	}
+
+	// the node failing, or the transaction failing outside of a program,
+	// does not tell how the swap fares
+	for _, result := range []string{"", `{"err":"AccountNotFound","logs":[]}`} {
+		budgeted, err = WithComputeBudget(context.Background(), newSimulatingNode(t, result), instructions, payer, nil, PriorityFee{}, SwapComputeUnits)
+		if err != nil || limitOf(budgeted) != SwapComputeUnits {
+			t.Errorf("%q: err = %v", result, err)
+		}
+	}
+
+	_, err = WithComputeBudget(context.Background(), newSimulatingNode(t, `{"err":{"InstructionError":[2,{"Custom":6001}]},"logs":["Program log: slippage"]}`), instructions, payer, nil, PriorityFee{}, SwapComputeUnits)
+	if !errors.Is(err, types.ErrSimulationFailed) {
+		t.Errorf("program error: err = %v", err)
+	}
+}
+
+func TestPriorityFee_UnitPrice(t *testing.T) {
+	// an estimated price is bid as is whatever the limit
+	fee := PriorityFee{Price: 5000}
+	if price := fee.UnitPrice(1150); price != 5000 {
+		t.Errorf("price %d", price)
+	}
+
+	// the cap applies to the price on the units the transaction gets
+	fee.MaxLamports = 500
+	if price := fee.UnitPrice(100000); price != 5000 {
+		t.Errorf("under the cap: price %d", price)
+	}
+	if price, lamports := fee.UnitPrice(200000), fee.Fee(200000); price != 2500 || lamports != 500 {
+		t.Errorf("over the cap: price %d, %d lamports", price, lamports)
+	}
+
+	// fixed lamports are spread over the limit
+	if price := FixedPriorityFee(1000).UnitPrice(200000); price != 5000 {
+		t.Errorf("fixed: price %d", price)
+	}
+}
@@ -6,9 +6,15 @@ const (
 	RentBase  = 128
 	RentPrice = 6960
 	RentATA   = uint64((RentBase + 165) * RentPrice)
+
+	// MaxTransactionSize is the most a serialized transaction may take.
+	MaxTransactionSize = 1232
 )
 
 var (
+	// JitoRpc is the transactions endpoint of the first block engine.
+	//
+	// Deprecated: Use NewJitoClient with JitoBlockEngines.
 	JitoRpc = "https://tokyo.mainnet.block-engine.jito.wtf/api/v1/transactions"
 
 	JitoTipPaymentAccounts = []solana.PublicKey{
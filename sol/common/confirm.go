@@ -0,0 +1,282 @@
+package common
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/samber/lo"
+)
+
+const (
+	TxPending int = iota // still unknown when the wait ended
+	TxConfirmed
+	TxFailed
+	TxExpired // the blockhash or nonce expired before the transaction landed
+)
+
+const (
+	RebroadcastInterval = 400 * time.Millisecond
+	expiryCheckInterval = 2 * time.Second
+	// sent transactions are forgotten once their blockhash must have expired
+	outboxTTL = 2 * time.Minute
+
+	// MaxBlockhashAge is how many blocks a blockhash stays valid for.
+	MaxBlockhashAge = 150
+
+	maxSignatureStatuses = 256
+)
+
+type (
+	Confirmation struct {
+		Signature  solana.Signature
+		Status     int
+		Slot       uint64
+		Commitment rpc.ConfirmationStatusType
+		Err        interface{} // transaction error of a failed transaction
+	}
+
+	sentTx struct {
+		tx                   *solana.Transaction
+		lastValidBlockHeight uint64
+		sentAt               time.Time
+	}
+)
+
+// Outbox keeps the transactions sent through it and, while Run runs,
+// rebroadcasts them every RebroadcastInterval until they land or expire.
+type Outbox struct {
+	client *rpc.Client
+
+	mu  sync.Mutex
+	txs map[solana.Signature]*sentTx
+}
+
+func NewOutbox(client *rpc.Client) *Outbox {
+	return &Outbox{client: client, txs: make(map[solana.Signature]*sentTx)}
+}
+
+// SendTransaction sends tx and, given an outbox, keeps it there to be
+// rebroadcast.
+func SendTransaction(ctx context.Context, client *rpc.Client, outbox *Outbox, tx *solana.Transaction, opts rpc.TransactionOpts) (solana.Signature, error) {
+	signature, err := client.SendTransactionWithOpts(ctx, tx, opts)
+	if err != nil {
+		return signature, err
+	}
+	if outbox != nil {
+		outbox.remember(ctx, tx)
+	}
+	return signature, nil
+}
+
+// SendAndConfirm sends tx and rebroadcasts it every RebroadcastInterval until
+// it reaches commitment, fails or expires. lastValidBlockHeight comes with
+// the blockhash; when zero, the blockhash is asked about instead.
+func SendAndConfirm(
+	ctx context.Context,
+	client *rpc.Client,
+	tx *solana.Transaction,
+	lastValidBlockHeight uint64,
+	commitment rpc.CommitmentType,
+) (*Confirmation, error) {
+	_, err := client.SendTransactionWithOpts(ctx, tx, rpc.TransactionOpts{SkipPreflight: true})
+	if err != nil {
+		return nil, err
+	}
+	return confirm(ctx, client, tx.Signatures[0], &sentTx{tx: tx, lastValidBlockHeight: lastValidBlockHeight}, true, commitment)
+}
+
+// Confirm waits for signature to reach commitment. Transactions in the
+// outbox are reported expired once their blockhash is; others are only
+// polled until ctx ends.
+func (o *Outbox) Confirm(ctx context.Context, signature solana.Signature, commitment rpc.CommitmentType) (*Confirmation, error) {
+	o.mu.Lock()
+	sent, ok := o.txs[signature]
+	o.mu.Unlock()
+
+	if !ok {
+		return confirm(ctx, o.client, signature, nil, false, commitment)
+	}
+	confirmation, err := confirm(ctx, o.client, signature, sent, false, commitment)
+	if err == nil && confirmation.Status != TxPending {
+		o.forget(signature)
+	}
+	return confirmation, err
+}
+
+// Run rebroadcasts the pending transactions until ctx ends. Landed and
+// expired ones are dropped every expiryCheckInterval.
+func (o *Outbox) Run(ctx context.Context) {
+	ticker := time.NewTicker(RebroadcastInterval)
+	defer ticker.Stop()
+
+	lastCheck := time.Now()
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+
+		o.mu.Lock()
+		pending := lo.Values(o.txs)
+		o.mu.Unlock()
+		for _, sent := range pending {
+			_, _ = o.client.SendTransactionWithOpts(ctx, sent.tx, rpc.TransactionOpts{SkipPreflight: true})
+		}
+
+		if time.Since(lastCheck) >= expiryCheckInterval {
+			lastCheck = time.Now()
+			o.settle(ctx, pending)
+		}
+	}
+}
+
+// settle drops the transactions that landed, expired or outlived outboxTTL.
+func (o *Outbox) settle(ctx context.Context, pending []*sentTx) {
+	if len(pending) == 0 {
+		return
+	}
+
+	var settled []solana.Signature
+	height, heightErr := o.client.GetBlockHeight(ctx, rpc.CommitmentConfirmed)
+	for _, chunk := range lo.Chunk(pending, maxSignatureStatuses) {
+		signatures := lo.Map(chunk, func(sent *sentTx, _ int) solana.Signature { return sent.tx.Signatures[0] })
+		statuses, err := o.client.GetSignatureStatuses(ctx, false, signatures...)
+		for i, sent := range chunk {
+			switch {
+			case err == nil && i < len(statuses.Value) && statuses.Value[i] != nil:
+			case time.Since(sent.sentAt) > outboxTTL:
+			case sent.lastValidBlockHeight > 0 && heightErr == nil && height > sent.lastValidBlockHeight:
+			case sent.lastValidBlockHeight == 0 && expired(ctx, o.client, sent):
+			default:
+				continue
+			}
+			settled = append(settled, signatures[i])
+		}
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for _, signature := range settled {
+		delete(o.txs, signature)
+	}
+}
+
+// confirm polls signature and, with resend, rebroadcasts sent meanwhile.
+func confirm(ctx context.Context, client *rpc.Client, signature solana.Signature, sent *sentTx, resend bool, commitment rpc.CommitmentType) (*Confirmation, error) {
+	ticker := time.NewTicker(RebroadcastInterval)
+	defer ticker.Stop()
+
+	landed := false
+	lastExpiryCheck := time.Now()
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return &Confirmation{Signature: signature, Status: TxPending}, nil
+		}
+
+		confirmation, err := signatureStatus(ctx, client, signature, false)
+		if err == nil && confirmation != nil {
+			if confirmation.Status == TxFailed || reached(confirmation.Commitment, commitment) {
+				return confirmation, nil
+			}
+			// landed, waiting for depth; resending is pointless now
+			landed = true
+		}
+		if sent == nil || landed {
+			continue
+		}
+
+		if resend {
+			_, _ = client.SendTransactionWithOpts(ctx, sent.tx, rpc.TransactionOpts{SkipPreflight: true})
+		}
+
+		if time.Since(lastExpiryCheck) < expiryCheckInterval {
+			continue
+		}
+		lastExpiryCheck = time.Now()
+		if expired(ctx, client, sent) {
+			// it may have landed right before the blockhash went stale
+			confirmation, err := signatureStatus(ctx, client, signature, true)
+			if err == nil && confirmation != nil && (confirmation.Status == TxFailed || reached(confirmation.Commitment, commitment)) {
+				return confirmation, nil
+			}
+			return &Confirmation{Signature: signature, Status: TxExpired}, nil
+		}
+	}
+}
+
+// signatureStatus returns nil while the signature is unknown.
+func signatureStatus(ctx context.Context, client *rpc.Client, signature solana.Signature, searchHistory bool) (*Confirmation, error) {
+	statuses, err := client.GetSignatureStatuses(ctx, searchHistory, signature)
+	if err != nil {
+		return nil, err
+	}
+	if len(statuses.Value) == 0 || statuses.Value[0] == nil {
+		return nil, nil
+	}
+
+	status := statuses.Value[0]
+	confirmation := &Confirmation{
+		Signature:  signature,
+		Status:     TxConfirmed,
+		Slot:       status.Slot,
+		Commitment: status.ConfirmationStatus,
+	}
+	if status.Err != nil {
+		confirmation.Status = TxFailed
+		confirmation.Err = status.Err
+	}
+	return confirmation, nil
+}
+
+func expired(ctx context.Context, client *rpc.Client, sent *sentTx) bool {
+	// a durable nonce only goes stale once something else advances it
+	if account, ok := nonceAccountOf(sent.tx); ok {
+		nonce, err := GetDurableNonce(ctx, client, account)
+		return err == nil && nonce.Nonce != sent.tx.Message.RecentBlockhash
+	}
+	if sent.lastValidBlockHeight > 0 {
+		height, err := client.GetBlockHeight(ctx, rpc.CommitmentConfirmed)
+		return err == nil && height > sent.lastValidBlockHeight
+	}
+
+	valid, err := client.IsBlockhashValid(ctx, sent.tx.Message.RecentBlockhash, rpc.CommitmentProcessed)
+	return err == nil && !valid.Value
+}
+
+func reached(status rpc.ConfirmationStatusType, commitment rpc.CommitmentType) bool {
+	levels := map[string]int{
+		string(rpc.ConfirmationStatusProcessed): 1,
+		string(rpc.ConfirmationStatusConfirmed): 2,
+		string(rpc.ConfirmationStatusFinalized): 3,
+	}
+	return levels[string(status)] > 0 && levels[string(status)] >= levels[string(commitment)]
+}
+
+// remember keeps tx with the height its blockhash expires at. Blockhashes
+// come from the last few slots, so MaxBlockhashAge blocks from now is at
+// most a few blocks late; a nonce does not expire by height.
+func (o *Outbox) remember(ctx context.Context, tx *solana.Transaction) {
+	sent := &sentTx{tx: tx, sentAt: time.Now()}
+	if _, ok := nonceAccountOf(tx); !ok {
+		height, err := o.client.GetBlockHeight(ctx, rpc.CommitmentProcessed)
+		if err == nil {
+			sent.lastValidBlockHeight = height + MaxBlockhashAge
+		}
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.txs[tx.Signatures[0]] = sent
+}
+
+func (o *Outbox) forget(signature solana.Signature) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	delete(o.txs, signature)
+}
@@ -0,0 +1,127 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// newStubNode stands in for an RPC node that drops the first drops sends
+// of a transaction and lands the next one. A node that drops everything
+// reports the blockhash expired.
+func newStubNode(tb testing.TB, signature solana.Signature, drops int) (*httptest.Server, func() int) {
+	var mu sync.Mutex
+	sends := 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var call engineCall
+		if err := json.Unmarshal(body, &call); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		var result string
+		switch call.Method {
+		case "sendTransaction":
+			sends++
+			result = `"` + signature.String() + `"`
+		case "getSignatureStatuses":
+			result = `{"context":{"slot":100},"value":[null]}`
+			if drops >= 0 && sends > drops {
+				result = `{"context":{"slot":100},"value":[{"slot":99,"confirmations":null,"err":null,"confirmationStatus":"confirmed"}]}`
+			}
+		case "getBlockHeight":
+			result = "1000"
+		case "isBlockhashValid":
+			result = `{"context":{"slot":100},"value":false}`
+		default:
+			http.Error(w, "unknown method", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":` + result + `}`))
+	}))
+	tb.Cleanup(server.Close)
+
+	return server, func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return sends
+	}
+}
+
+func TestSendAndConfirm_Rebroadcasts(t *testing.T) {
+	tx := newTransferTx(t, solana.NewWallet().PublicKey())
+	node, sends := newStubNode(t, tx.Signatures[0], 3)
+
+	confirmation, err := SendAndConfirm(context.Background(), rpc.New(node.URL), tx, 0, rpc.CommitmentConfirmed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if confirmation.Status != TxConfirmed || confirmation.Slot != 99 || confirmation.Commitment != rpc.ConfirmationStatusConfirmed {
+		t.Errorf("unexpected confirmation: %+v", confirmation)
+	}
+	if n := sends(); n != 4 {
+		t.Errorf("sent %d times, want 4", n)
+	}
+}
+
+func TestSendAndConfirm_Expires(t *testing.T) {
+	tx := newTransferTx(t, solana.NewWallet().PublicKey())
+	node, _ := newStubNode(t, tx.Signatures[0], -1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	confirmation, err := SendAndConfirm(ctx, rpc.New(node.URL), tx, 0, rpc.CommitmentConfirmed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if confirmation.Status != TxExpired {
+		t.Errorf("status = %d, want TxExpired", confirmation.Status)
+	}
+}
+
+func TestOutbox_RebroadcastsUnwatched(t *testing.T) {
+	tx := newTransferTx(t, solana.NewWallet().PublicKey())
+	node, sends := newStubNode(t, tx.Signatures[0], 3)
+	client := rpc.New(node.URL)
+	outbox := NewOutbox(client)
+
+	if _, err := SendTransaction(context.Background(), client, outbox, tx, rpc.TransactionOpts{}); err != nil {
+		t.Fatal(err)
+	}
+	if sent := outbox.txs[tx.Signatures[0]]; sent == nil || sent.lastValidBlockHeight != 1000+MaxBlockhashAge {
+		t.Fatalf("kept %+v", sent)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go outbox.Run(ctx)
+
+	// nobody confirms, yet the transaction is resent until it lands
+	pending := 1
+	for deadline := time.Now().Add(10 * time.Second); pending > 0 && time.Now().Before(deadline); {
+		time.Sleep(100 * time.Millisecond)
+		outbox.mu.Lock()
+		pending = len(outbox.txs)
+		outbox.mu.Unlock()
+	}
+	if n := sends(); n < 4 {
+		t.Errorf("sent %d times, want at least 4", n)
+	}
+	if pending != 0 {
+		t.Error("landed transaction still kept")
+	}
+}
@@ -0,0 +1,54 @@
+package common
+
+import (
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/token"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// TokenTransfer is a transfer of either token program made inside an
+// instruction. Mint is only known for transfer_checked.
+type TokenTransfer struct {
+	Source      solana.PublicKey
+	Destination solana.PublicKey
+	Mint        solana.PublicKey
+	Amount      uint64
+}
+
+// InnerTokenTransfers lists the token transfers the instruction at
+// instructionIndex made, in order.
+func InnerTokenTransfers(tx *rpc.GetTransactionResult, message *solana.Message, instructionIndex uint16) []TokenTransfer {
+	var transfers []TokenTransfer
+	for _, innerInstruction := range tx.Meta.InnerInstructions {
+		if innerInstruction.Index != instructionIndex {
+			continue
+		}
+		for _, inner := range innerInstruction.Instructions {
+			program, err := message.Account(inner.ProgramIDIndex)
+			if err != nil || (!program.Equals(solana.TokenProgramID) && !program.Equals(solana.Token2022ProgramID)) {
+				continue
+			}
+			if len(inner.Data) < 9 {
+				continue
+			}
+			accounts, err := inner.ResolveInstructionAccounts(message)
+			if err != nil {
+				continue
+			}
+
+			var transfer TokenTransfer
+			switch {
+			case inner.Data[0] == token.Instruction_Transfer && len(accounts) >= 2:
+				transfer.Source, transfer.Destination = accounts[0].PublicKey, accounts[1].PublicKey
+			case inner.Data[0] == token.Instruction_TransferChecked && len(accounts) >= 3:
+				transfer.Source, transfer.Mint, transfer.Destination = accounts[0].PublicKey, accounts[1].PublicKey, accounts[2].PublicKey
+			default:
+				continue
+			}
+			_ = bin.NewBorshDecoder(inner.Data[1:9]).Decode(&transfer.Amount)
+			transfers = append(transfers, transfer)
+		}
+	}
+	return transfers
+}
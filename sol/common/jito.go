@@ -0,0 +1,363 @@
+package common
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/meme-bots/go-web3/types"
+	"github.com/samber/lo"
+)
+
+const (
+	MaxBundleTransactions = 5
+
+	// BundleTTL is how long a bundle that was never seen to land is
+	// remembered. Engines only report bundles in flight for five minutes.
+	BundleTTL = 10 * time.Minute
+
+	BundleStatusInvalid = "Invalid"
+	BundleStatusPending = "Pending"
+	BundleStatusFailed  = "Failed"
+	BundleStatusLanded  = "Landed"
+)
+
+var (
+	// JitoBlockEngines are tried in order until one accepts a bundle.
+	JitoBlockEngines = []string{
+		"https://tokyo.mainnet.block-engine.jito.wtf",
+		"https://mainnet.block-engine.jito.wtf",
+		"https://amsterdam.mainnet.block-engine.jito.wtf",
+		"https://frankfurt.mainnet.block-engine.jito.wtf",
+		"https://ny.mainnet.block-engine.jito.wtf",
+		"https://slc.mainnet.block-engine.jito.wtf",
+	}
+
+	ErrBundleTip = errors.New("last bundle transaction pays no tip")
+)
+
+type (
+	JitoClient struct {
+		engines    []string
+		auth       string
+		httpClient *http.Client
+
+		mu       sync.Mutex
+		accepted map[string]*acceptedBundle
+		bundles  map[solana.Signature]string
+	}
+
+	acceptedBundle struct {
+		engine     string // the one that took the bundle
+		signatures []solana.Signature
+		sentAt     time.Time
+	}
+
+	InflightBundleStatus struct {
+		BundleID   string  `json:"bundle_id"`
+		Status     string  `json:"status"`
+		LandedSlot *uint64 `json:"landed_slot"`
+	}
+
+	BundleStatus struct {
+		BundleID           string          `json:"bundle_id"`
+		Transactions       []string        `json:"transactions"`
+		Slot               uint64          `json:"slot"`
+		ConfirmationStatus string          `json:"confirmation_status"`
+		Err                json.RawMessage `json:"err"`
+	}
+
+	jitoRequest struct {
+		JsonRPC string        `json:"jsonrpc"`
+		ID      int           `json:"id"`
+		Method  string        `json:"method"`
+		Params  []interface{} `json:"params"`
+	}
+
+	jitoResponse struct {
+		Result json.RawMessage `json:"result"`
+		Error  *struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+)
+
+// NewJitoClient talks to engines, the base URLs of block engines in order
+// of preference. auth is the x-jito-auth key, if any.
+func NewJitoClient(engines []string, auth string) *JitoClient {
+	return &JitoClient{
+		engines:    lo.Map(engines, func(engine string, _ int) string { return strings.TrimRight(engine, "/") }),
+		auth:       auth,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		accepted:   make(map[string]*acceptedBundle),
+		bundles:    make(map[solana.Signature]string),
+	}
+}
+
+// SendBundle submits txs to be executed atomically and in order. The last
+// transaction must pay the tip.
+func (c *JitoClient) SendBundle(ctx context.Context, txs []*solana.Transaction) (string, error) {
+	if len(txs) == 0 || len(txs) > MaxBundleTransactions {
+		return "", fmt.Errorf("bundle of %d transactions", len(txs))
+	}
+	if !PaysTip(txs[len(txs)-1]) {
+		return "", ErrBundleTip
+	}
+
+	encoded := make([]string, len(txs))
+	for i, tx := range txs {
+		data, err := tx.ToBase64()
+		if err != nil {
+			return "", err
+		}
+		encoded[i] = data
+	}
+
+	var bundleID string
+	engine, err := c.call(ctx, c.engines, "/api/v1/bundles", "sendBundle", []interface{}{encoded, map[string]string{"encoding": "base64"}}, &bundleID)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	c.mu.Lock()
+	for id, bundle := range c.accepted {
+		if now.Sub(bundle.sentAt) > BundleTTL {
+			c.forgetLocked(id)
+		}
+	}
+	bundle := &acceptedBundle{engine: engine, sentAt: now}
+	for _, tx := range txs {
+		bundle.signatures = append(bundle.signatures, tx.Signatures[0])
+		c.bundles[tx.Signatures[0]] = bundleID
+	}
+	c.accepted[bundleID] = bundle
+	c.mu.Unlock()
+	return bundleID, nil
+}
+
+// BundleOf returns the bundle a transaction was sent in, as long as the
+// bundle has not landed, failed or outlived BundleTTL.
+func (c *JitoClient) BundleOf(signature solana.Signature) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	bundleID, ok := c.bundles[signature]
+	return bundleID, ok
+}
+
+// GetInflightBundleStatuses reports on bundles sent in the last five
+// minutes.
+func (c *JitoClient) GetInflightBundleStatuses(ctx context.Context, bundleIDs []string) ([]*InflightBundleStatus, error) {
+	var result struct {
+		Value []*InflightBundleStatus `json:"value"`
+	}
+	_, err := c.call(ctx, c.enginesFor(bundleIDs), "/api/v1/getInflightBundleStatuses", "getInflightBundleStatuses", []interface{}{bundleIDs}, &result)
+	if err != nil {
+		return nil, err
+	}
+	return result.Value, nil
+}
+
+// GetBundleStatuses reports on landed bundles. Unknown bundles are nil.
+func (c *JitoClient) GetBundleStatuses(ctx context.Context, bundleIDs []string) ([]*BundleStatus, error) {
+	var result struct {
+		Value []*BundleStatus `json:"value"`
+	}
+	_, err := c.call(ctx, c.enginesFor(bundleIDs), "/api/v1/getBundleStatuses", "getBundleStatuses", []interface{}{bundleIDs}, &result)
+	if err != nil {
+		return nil, err
+	}
+	return result.Value, nil
+}
+
+// WaitForBundle polls bundleID until it lands, fails or duration passes. A
+// bundle an engine does not know yet is reported Invalid, so only Failed
+// ends the wait early.
+func (c *JitoClient) WaitForBundle(ctx context.Context, bundleID string, duration time.Duration) (*BundleStatus, error) {
+	ctx, cancel := context.WithTimeout(ctx, duration)
+	defer cancel()
+
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		inflight, err := c.GetInflightBundleStatuses(ctx, []string{bundleID})
+		if err == nil && len(inflight) > 0 && inflight[0] != nil {
+			switch inflight[0].Status {
+			case BundleStatusFailed:
+				c.forget(bundleID)
+				return nil, fmt.Errorf("%w: bundle %s failed", types.ErrTxNotLand, bundleID)
+			case BundleStatusLanded:
+				statuses, err := c.GetBundleStatuses(ctx, []string{bundleID})
+				if err == nil && len(statuses) > 0 && statuses[0] != nil {
+					c.forget(bundleID)
+					status := statuses[0]
+					if !status.Succeeded() {
+						return status, fmt.Errorf("%w: %s", types.ErrTransactionFailed, string(status.Err))
+					}
+					return status, nil
+				}
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil, types.ErrTxNotLand
+		}
+	}
+}
+
+// Succeeded reports whether the bundle executed without error.
+func (s *BundleStatus) Succeeded() bool {
+	if len(s.Err) == 0 || string(s.Err) == "null" {
+		return true
+	}
+	var result map[string]json.RawMessage
+	if json.Unmarshal(s.Err, &result) != nil {
+		return false
+	}
+	_, ok := result["Ok"]
+	return ok
+}
+
+// SendBundled sends tx, which pays a tip, as a bundle of its own through
+// jito. When no block engine takes it, or there is no jito, the transaction
+// goes through client instead.
+func SendBundled(ctx context.Context, jito *JitoClient, client *rpc.Client, tx *solana.Transaction) (solana.Signature, error) {
+	if jito != nil {
+		_, err := jito.SendBundle(ctx, []*solana.Transaction{tx})
+		if err == nil {
+			return tx.Signatures[0], nil
+		}
+	}
+	return client.SendTransactionWithOpts(ctx, tx, rpc.TransactionOpts{SkipPreflight: true})
+}
+
+// SendBundle submits txs through jito and, failing that, sends them one by
+// one through client, which gives up atomicity. The bundle id is empty in
+// that case.
+func SendBundle(ctx context.Context, jito *JitoClient, client *rpc.Client, txs []*solana.Transaction) (string, error) {
+	if jito != nil {
+		bundleID, err := jito.SendBundle(ctx, txs)
+		if err == nil || errors.Is(err, ErrBundleTip) {
+			return bundleID, err
+		}
+	}
+
+	for _, tx := range txs {
+		_, err := client.SendTransactionWithOpts(ctx, tx, rpc.TransactionOpts{SkipPreflight: true})
+		if err != nil {
+			return "", err
+		}
+	}
+	return "", nil
+}
+
+// PaysTip reports whether tx writes one of the tip accounts. Jito only
+// honors tips to accounts listed in the transaction itself.
+func PaysTip(tx *solana.Transaction) bool {
+	return lo.SomeBy(tx.Message.AccountKeys, func(key solana.PublicKey) bool {
+		return lo.Contains(JitoTipPaymentAccounts, key)
+	})
+}
+
+// enginesFor puts the engine that took bundleIDs first, as statuses are
+// kept per region.
+func (c *JitoClient) enginesFor(bundleIDs []string) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, bundleID := range bundleIDs {
+		if bundle, ok := c.accepted[bundleID]; ok {
+			return append([]string{bundle.engine}, lo.Without(c.engines, bundle.engine)...)
+		}
+	}
+	return c.engines
+}
+
+func (c *JitoClient) forget(bundleID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.forgetLocked(bundleID)
+}
+
+func (c *JitoClient) forgetLocked(bundleID string) {
+	bundle, ok := c.accepted[bundleID]
+	if !ok {
+		return
+	}
+	for _, signature := range bundle.signatures {
+		delete(c.bundles, signature)
+	}
+	delete(c.accepted, bundleID)
+}
+
+// call tries engines in order and returns the one that answered.
+func (c *JitoClient) call(ctx context.Context, engines []string, path, method string, params []interface{}, result interface{}) (string, error) {
+	if len(engines) == 0 {
+		return "", errors.New("no block engine configured")
+	}
+
+	body, err := json.Marshal(jitoRequest{JsonRPC: "2.0", ID: 1, Method: method, Params: params})
+	if err != nil {
+		return "", err
+	}
+
+	var errs []error
+	for _, engine := range engines {
+		err = c.post(ctx, engine+path, body, result)
+		if err == nil {
+			return engine, nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", engine, err))
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return "", errors.Join(errs...)
+}
+
+func (c *JitoClient) post(ctx context.Context, url string, body []byte, result interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(c.auth) > 0 {
+		req.Header.Set("x-jito-auth", c.auth)
+	}
+
+	ret, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer ret.Body.Close()
+
+	data, err := io.ReadAll(ret.Body)
+	if err != nil {
+		return err
+	}
+	if ret.StatusCode != http.StatusOK {
+		return fmt.Errorf("block engine %s: %s", ret.Status, string(data))
+	}
+
+	var resp jitoResponse
+	err = json.Unmarshal(data, &resp)
+	if err != nil {
+		return err
+	}
+	if resp.Error != nil {
+		return fmt.Errorf("block engine error %d: %s", resp.Error.Code, resp.Error.Message)
+	}
+	return json.Unmarshal(resp.Result, result)
+}
@@ -0,0 +1,220 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/system"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/meme-bots/go-web3/types"
+)
+
+type engineCall struct {
+	Path   string
+	Method string
+	Params []json.RawMessage
+}
+
+// newStubEngine stands in for a block engine that accepts bundles and
+// reports them Pending for pendingPolls polls before they land.
+func newStubEngine(tb testing.TB, pendingPolls int) (*httptest.Server, func() []engineCall) {
+	var mu sync.Mutex
+	var calls []engineCall
+	polls := 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		call := engineCall{Path: r.URL.Path}
+		if err := json.Unmarshal(body, &call); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		calls = append(calls, call)
+		mu.Unlock()
+
+		var result string
+		switch call.Method {
+		case "sendBundle":
+			result = `"b1"`
+		case "getInflightBundleStatuses":
+			mu.Lock()
+			polls++
+			status := BundleStatusPending
+			if polls > pendingPolls {
+				status = BundleStatusLanded
+			}
+			mu.Unlock()
+			result = `{"context":{"slot":100},"value":[{"bundle_id":"b1","status":"` + status + `","landed_slot":null}]}`
+		case "getBundleStatuses":
+			result = `{"context":{"slot":100},"value":[{"bundle_id":"b1","transactions":[],"slot":99,"confirmation_status":"confirmed","err":{"Ok":null}}]}`
+		default:
+			http.Error(w, "unknown method", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":` + result + `}`))
+	}))
+	tb.Cleanup(server.Close)
+
+	return server, func() []engineCall {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]engineCall(nil), calls...)
+	}
+}
+
+func newDownEngine(tb testing.TB) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "rate limited", http.StatusTooManyRequests)
+	}))
+	tb.Cleanup(server.Close)
+	return server
+}
+
+func newTransferTx(tb testing.TB, recipient solana.PublicKey) *solana.Transaction {
+	key := solana.NewWallet().PrivateKey
+	tx, err := solana.NewTransaction(
+		[]solana.Instruction{system.NewTransferInstruction(1000, key.PublicKey(), recipient).Build()},
+		solana.Hash{1},
+		solana.TransactionPayer(key.PublicKey()),
+	)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	_, err = tx.Sign(func(solana.PublicKey) *solana.PrivateKey { return &key })
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return tx
+}
+
+func TestJitoClient_SendBundleFallsOverAndTracks(t *testing.T) {
+	down := newDownEngine(t)
+	engine, calls := newStubEngine(t, 2)
+	client := NewJitoClient([]string{down.URL, engine.URL + "/"}, "")
+
+	txs := []*solana.Transaction{
+		newTransferTx(t, solana.NewWallet().PublicKey()),
+		newTransferTx(t, JitoTipPaymentAccounts[0]),
+	}
+	bundleID, err := client.SendBundle(context.Background(), txs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bundleID != "b1" {
+		t.Fatalf("bundle id = %s, want b1", bundleID)
+	}
+	if id, ok := client.BundleOf(txs[0].Signatures[0]); !ok || id != bundleID {
+		t.Errorf("BundleOf = %s, %v", id, ok)
+	}
+
+	sent := calls()
+	if len(sent) != 1 || sent[0].Path != "/api/v1/bundles" {
+		t.Fatalf("unexpected engine calls: %+v", sent)
+	}
+	var encoded []string
+	if err := json.Unmarshal(sent[0].Params[0], &encoded); err != nil {
+		t.Fatal(err)
+	}
+	if len(encoded) != len(txs) {
+		t.Fatalf("sent %d transactions, want %d", len(encoded), len(txs))
+	}
+
+	status, err := client.WaitForBundle(context.Background(), bundleID, 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Slot != 99 || !status.Succeeded() {
+		t.Errorf("unexpected status: %+v", status)
+	}
+	// statuses go straight to the engine that took the bundle
+	if n := len(calls()); n != 1+3+1 {
+		t.Errorf("got %d engine calls, want 5", n)
+	}
+	if _, ok := client.BundleOf(txs[0].Signatures[0]); ok {
+		t.Error("landed bundle still tracked")
+	}
+}
+
+func TestJitoClient_ForgetsOldBundles(t *testing.T) {
+	engine, _ := newStubEngine(t, 0)
+	client := NewJitoClient([]string{engine.URL}, "")
+
+	old := newTransferTx(t, JitoTipPaymentAccounts[0])
+	if _, err := client.SendBundle(context.Background(), []*solana.Transaction{old}); err != nil {
+		t.Fatal(err)
+	}
+	client.accepted["b1"].sentAt = time.Now().Add(-BundleTTL - time.Minute)
+
+	// the engine hands out b1 again, for the new transaction only
+	recent := newTransferTx(t, JitoTipPaymentAccounts[0])
+	if _, err := client.SendBundle(context.Background(), []*solana.Transaction{recent}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := client.BundleOf(old.Signatures[0]); ok {
+		t.Error("expired bundle still tracked")
+	}
+	if _, ok := client.BundleOf(recent.Signatures[0]); !ok {
+		t.Error("recent bundle not tracked")
+	}
+}
+
+func TestJitoClient_RequiresTipLast(t *testing.T) {
+	engine, calls := newStubEngine(t, 0)
+	client := NewJitoClient([]string{engine.URL}, "")
+
+	_, err := client.SendBundle(context.Background(), []*solana.Transaction{
+		newTransferTx(t, JitoTipPaymentAccounts[0]),
+		newTransferTx(t, solana.NewWallet().PublicKey()),
+	})
+	if !errors.Is(err, ErrBundleTip) {
+		t.Fatalf("err = %v, want ErrBundleTip", err)
+	}
+	if len(calls()) != 0 {
+		t.Error("bundle without a trailing tip was sent")
+	}
+}
+
+func TestJitoClient_WaitForFailedBundle(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":{"context":{"slot":1},"value":[{"bundle_id":"b1","status":"Failed","landed_slot":null}]}}`))
+	}))
+	defer server.Close()
+
+	_, err := NewJitoClient([]string{server.URL}, "").WaitForBundle(context.Background(), "b1", 5*time.Second)
+	if !errors.Is(err, types.ErrTxNotLand) {
+		t.Fatalf("err = %v, want ErrTxNotLand", err)
+	}
+}
+
+func TestSendBundled_FallsBackToRPC(t *testing.T) {
+	tx := newTransferTx(t, JitoTipPaymentAccounts[0])
+
+	var method string
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var call engineCall
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &call)
+		method = call.Method
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":"` + tx.Signatures[0].String() + `"}`))
+	}))
+	defer node.Close()
+
+	jito := NewJitoClient([]string{newDownEngine(t).URL}, "")
+	signature, err := SendBundled(context.Background(), jito, rpc.New(node.URL), tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if method != "sendTransaction" || !signature.Equals(tx.Signatures[0]) {
+		t.Errorf("method = %s, signature = %s", method, signature)
+	}
+}
@@ -0,0 +1,244 @@
+package common
+
+import (
+	"context"
+	"encoding/binary"
+
+	"github.com/gagliardetto/solana-go"
+	addresslookuptable "github.com/gagliardetto/solana-go/programs/address-lookup-table"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/samber/lo"
+)
+
+const (
+	lookupInstructionCreate uint32 = 0
+	lookupInstructionExtend uint32 = 2
+
+	// MaxLookupExtend is how many addresses fit in one extend instruction
+	// together with a create in a legacy transaction.
+	MaxLookupExtend = 20
+)
+
+var (
+	AddressLookupTableProgramID = solana.MPK("AddressLookupTab1e1111111111111111111111111")
+)
+
+// FindLookupTable derives the address of the table authority creates at
+// recentSlot.
+func FindLookupTable(authority solana.PublicKey, recentSlot uint64) (solana.PublicKey, uint8) {
+	slot := make([]byte, 8)
+	binary.LittleEndian.PutUint64(slot, recentSlot)
+	table, bump, _ := solana.FindProgramAddress([][]byte{authority.Bytes(), slot}, AddressLookupTableProgramID)
+	return table, bump
+}
+
+func NewCreateLookupTableInstruction(authority, payer solana.PublicKey, recentSlot uint64) (solana.Instruction, solana.PublicKey) {
+	table, bump := FindLookupTable(authority, recentSlot)
+	data := binary.LittleEndian.AppendUint32(nil, lookupInstructionCreate)
+	data = binary.LittleEndian.AppendUint64(data, recentSlot)
+	data = append(data, bump)
+
+	return solana.NewInstruction(
+		AddressLookupTableProgramID,
+		solana.AccountMetaSlice{
+			solana.Meta(table).WRITE(),
+			solana.Meta(authority).SIGNER(),
+			solana.Meta(payer).WRITE().SIGNER(),
+			solana.Meta(solana.SystemProgramID),
+		},
+		data,
+	), table
+}
+
+func NewExtendLookupTableInstruction(table, authority, payer solana.PublicKey, addresses []solana.PublicKey) solana.Instruction {
+	data := binary.LittleEndian.AppendUint32(nil, lookupInstructionExtend)
+	data = binary.LittleEndian.AppendUint64(data, uint64(len(addresses)))
+	for _, address := range addresses {
+		data = append(data, address.Bytes()...)
+	}
+
+	return solana.NewInstruction(
+		AddressLookupTableProgramID,
+		solana.AccountMetaSlice{
+			solana.Meta(table).WRITE(),
+			solana.Meta(authority).SIGNER(),
+			solana.Meta(payer).WRITE().SIGNER(),
+			solana.Meta(solana.SystemProgramID),
+		},
+		data,
+	)
+}
+
+// CreateLookupTable creates a lookup table owned by privKey holding
+// addresses. Addresses beyond MaxLookupExtend are added by follow-up
+// transactions. The table is usable one slot after the last one lands.
+func CreateLookupTable(
+	ctx context.Context,
+	url string,
+	addresses []solana.PublicKey,
+	privKey solana.PrivateKey,
+) (solana.PublicKey, []solana.Signature, error) {
+	client := rpc.New(url)
+	owner := privKey.PublicKey()
+
+	slot, err := client.GetSlot(ctx, rpc.CommitmentFinalized)
+	if err != nil {
+		return solana.PublicKey{}, nil, err
+	}
+
+	createInst, table := NewCreateLookupTableInstruction(owner, owner, slot)
+	chunks := lo.Chunk(addresses, MaxLookupExtend)
+
+	instructions := []solana.Instruction{createInst}
+	if len(chunks) > 0 {
+		instructions = append(instructions, NewExtendLookupTableInstruction(table, owner, owner, chunks[0]))
+		chunks = chunks[1:]
+	}
+	signature, err := sendLookupTransaction(ctx, client, instructions, privKey)
+	if err != nil {
+		return solana.PublicKey{}, nil, err
+	}
+
+	signatures := []solana.Signature{signature}
+	for _, chunk := range chunks {
+		signature, err = sendLookupTransaction(ctx, client, []solana.Instruction{NewExtendLookupTableInstruction(table, owner, owner, chunk)}, privKey)
+		if err != nil {
+			return table, signatures, err
+		}
+		signatures = append(signatures, signature)
+	}
+	return table, signatures, nil
+}
+
+// ExtendLookupTable adds the addresses table does not hold yet.
+func ExtendLookupTable(
+	ctx context.Context,
+	url string,
+	table solana.PublicKey,
+	addresses []solana.PublicKey,
+	privKey solana.PrivateKey,
+) ([]solana.Signature, error) {
+	client := rpc.New(url)
+	owner := privKey.PublicKey()
+
+	state, err := addresslookuptable.GetAddressLookupTableStateWithOpts(ctx, client, table, &rpc.GetAccountInfoOpts{Commitment: rpc.CommitmentConfirmed})
+	if err != nil {
+		return nil, err
+	}
+	missing := lo.Uniq(lo.Filter(addresses, func(address solana.PublicKey, _ int) bool {
+		return !state.Addresses.Contains(address)
+	}))
+
+	var signatures []solana.Signature
+	for _, chunk := range lo.Chunk(missing, MaxLookupExtend) {
+		signature, err := sendLookupTransaction(ctx, client, []solana.Instruction{NewExtendLookupTableInstruction(table, owner, owner, chunk)}, privKey)
+		if err != nil {
+			return signatures, err
+		}
+		signatures = append(signatures, signature)
+	}
+	return signatures, nil
+}
+
+func sendLookupTransaction(ctx context.Context, client *rpc.Client, instructions []solana.Instruction, privKey solana.PrivateKey) (solana.Signature, error) {
+	recentBlock, err := client.GetLatestBlockhash(ctx, rpc.CommitmentFinalized)
+	if err != nil {
+		return solana.Signature{}, err
+	}
+
+	tx, err := solana.NewTransaction(instructions, recentBlock.Value.Blockhash, solana.TransactionPayer(privKey.PublicKey()))
+	if err != nil {
+		return solana.Signature{}, err
+	}
+
+	_, err = tx.Sign(func(key solana.PublicKey) *solana.PrivateKey {
+		return &privKey
+	})
+	if err != nil {
+		return solana.Signature{}, err
+	}
+
+	return client.SendTransaction(ctx, tx)
+}
+
+// GetLookupTables loads the active tables among keys.
+func GetLookupTables(ctx context.Context, url string, keys []solana.PublicKey) (map[solana.PublicKey]solana.PublicKeySlice, error) {
+	tables := make(map[solana.PublicKey]solana.PublicKeySlice)
+	if len(keys) == 0 {
+		return tables, nil
+	}
+
+	accounts, err := rpc.New(url).GetMultipleAccountsWithOpts(ctx, keys, &rpc.GetMultipleAccountsOpts{Commitment: rpc.CommitmentConfirmed})
+	if err != nil {
+		return nil, err
+	}
+	for i, account := range accounts.Value {
+		if account == nil {
+			continue
+		}
+		state, err := addresslookuptable.DecodeAddressLookupTableState(account.Data.GetBinary())
+		if err != nil {
+			return nil, err
+		}
+		if state.IsActive() {
+			tables[keys[i]] = state.Addresses
+		}
+	}
+	return tables, nil
+}
+
+// NewTransaction builds a v0 transaction when tables are given and a legacy
+// one otherwise.
+func NewTransaction(
+	instructions []solana.Instruction,
+	recentBlockHash solana.Hash,
+	payer solana.PublicKey,
+	tables map[solana.PublicKey]solana.PublicKeySlice,
+) (*solana.Transaction, error) {
+	opts := []solana.TransactionOption{solana.TransactionPayer(payer)}
+	if len(tables) > 0 {
+		opts = append(opts, solana.TransactionAddressTables(tables))
+	}
+	return solana.NewTransaction(instructions, recentBlockHash, opts...)
+}
+
+// ResolveLoadedAddresses makes the addresses a v0 transaction loaded from
+// lookup tables, as reported in its meta, addressable by account index.
+func ResolveLoadedAddresses(tx *solana.Transaction, loaded rpc.LoadedAddresses) error {
+	lookups := tx.Message.GetAddressTableLookups()
+	if len(lookups) == 0 {
+		return nil
+	}
+
+	tables := make(map[solana.PublicKey]solana.PublicKeySlice)
+	writable, readonly := loaded.Writable, loaded.ReadOnly
+	fill := func(table solana.PublicKeySlice, indexes []uint8, addresses solana.PublicKeySlice) (solana.PublicKeySlice, solana.PublicKeySlice) {
+		for _, idx := range indexes {
+			if len(addresses) == 0 {
+				break
+			}
+			if int(idx) >= len(table) {
+				table = append(table, make(solana.PublicKeySlice, int(idx)+1-len(table))...)
+			}
+			table[idx] = addresses[0]
+			addresses = addresses[1:]
+		}
+		return table, addresses
+	}
+	for _, lookup := range lookups {
+		table := tables[lookup.AccountKey]
+		table, writable = fill(table, lookup.WritableIndexes, writable)
+		tables[lookup.AccountKey] = table
+	}
+	for _, lookup := range lookups {
+		table := tables[lookup.AccountKey]
+		table, readonly = fill(table, lookup.ReadonlyIndexes, readonly)
+		tables[lookup.AccountKey] = table
+	}
+
+	err := tx.Message.SetAddressTables(tables)
+	if err != nil {
+		return err
+	}
+	return tx.Message.ResolveLookups()
+}
@@ -2,6 +2,8 @@ package common
 
 import (
 	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/meme-bots/go-web3/utils"
 	"github.com/near/borsh-go"
 )
 
@@ -26,6 +28,22 @@ func MetadataDeserialize(data []byte) (Metadata, error) {
 	return metadata, err
 }
 
+// TokenName reads name and symbol from the mint's metadata extension when
+// it points at the mint, and from the Metaplex metadata account otherwise.
+func TokenName(mint *Mint, address solana.PublicKey, metadata *rpc.Account) (string, string, error) {
+	if mint.MetadataOnMint(address) {
+		return utils.TrimSpace(mint.Metadata.Name), utils.TrimSpace(mint.Metadata.Symbol), nil
+	}
+	if metadata == nil {
+		return "", "", nil
+	}
+	meta, err := MetadataDeserialize(metadata.Data.GetBinary())
+	if err != nil {
+		return "", "", err
+	}
+	return utils.TrimSpace(meta.Data.Name), utils.TrimSpace(meta.Data.Symbol), nil
+}
+
 type Metadata struct {
 	Key                 uint8
 	UpdateAuthority     solana.PublicKey
@@ -0,0 +1,163 @@
+package common
+
+import (
+	"context"
+	"encoding/binary"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/system"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/meme-bots/go-web3/types"
+)
+
+const (
+	NonceAccountSize = 80
+
+	nonceStateInitialized   uint32 = 1
+	nonceInstructionAdvance uint32 = 4
+)
+
+// DurableNonce stands in for a recent blockhash, letting a transaction be
+// signed now and sent any time until the nonce is advanced.
+type DurableNonce struct {
+	Account              solana.PublicKey
+	Authority            solana.PublicKey
+	Nonce                solana.Hash
+	LamportsPerSignature uint64
+}
+
+// Apply makes instructions spend the nonce and returns the blockhash to
+// build with. A nil nonce leaves both as they are.
+func (n *DurableNonce) Apply(instructions []solana.Instruction, recentBlockHash solana.Hash) ([]solana.Instruction, solana.Hash) {
+	if n == nil {
+		return instructions, recentBlockHash
+	}
+	advance := system.NewAdvanceNonceAccountInstruction(n.Account, solana.SysVarRecentBlockHashesPubkey, n.Authority).Build()
+	return append([]solana.Instruction{advance}, instructions...), n.Nonce
+}
+
+// GetDurableNonce reads the nonce account.
+func GetDurableNonce(ctx context.Context, client *rpc.Client, account solana.PublicKey) (*DurableNonce, error) {
+	info, err := client.GetAccountInfoWithOpts(ctx, account, &rpc.GetAccountInfoOpts{Commitment: rpc.CommitmentConfirmed})
+	if err != nil {
+		return nil, err
+	}
+	if !info.Value.Owner.Equals(solana.SystemProgramID) || len(info.Value.Data.GetBinary()) != NonceAccountSize {
+		return nil, types.ErrNotFound
+	}
+
+	var state system.NonceAccount
+	err = state.UnmarshalWithDecoder(bin.NewBinDecoder(info.Value.Data.GetBinary()))
+	if err != nil {
+		return nil, err
+	}
+	if state.State != nonceStateInitialized {
+		return nil, types.ErrAccountNotInitialized
+	}
+	return &DurableNonce{
+		Account:              account,
+		Authority:            state.AuthorizedPubkey,
+		Nonce:                solana.Hash(state.Nonce),
+		LamportsPerSignature: state.FeeCalculator.LamportsPerSignature,
+	}, nil
+}
+
+// CreateNonceAccount creates a nonce account authorized to privKey and
+// holding its rent exemption plus lamports.
+func CreateNonceAccount(ctx context.Context, url string, lamports uint64, privKey solana.PrivateKey) (solana.PublicKey, solana.Signature, error) {
+	client := rpc.New(url)
+	owner := privKey.PublicKey()
+
+	nonceKey, err := solana.NewRandomPrivateKey()
+	if err != nil {
+		return solana.PublicKey{}, solana.Signature{}, err
+	}
+	nonce := nonceKey.PublicKey()
+
+	rent, err := client.GetMinimumBalanceForRentExemption(ctx, NonceAccountSize, rpc.CommitmentConfirmed)
+	if err != nil {
+		return solana.PublicKey{}, solana.Signature{}, err
+	}
+
+	instructions := []solana.Instruction{
+		system.NewCreateAccountInstruction(rent+lamports, NonceAccountSize, solana.SystemProgramID, owner, nonce).Build(),
+		system.NewInitializeNonceAccountInstruction(owner, nonce, solana.SysVarRecentBlockHashesPubkey, solana.SysVarRentPubkey).Build(),
+	}
+	signature, err := sendNonceTransaction(ctx, client, instructions, privKey, nonceKey)
+	if err != nil {
+		return solana.PublicKey{}, solana.Signature{}, err
+	}
+	return nonce, signature, nil
+}
+
+// AdvanceNonceAccount moves the nonce on, voiding everything signed with
+// its current value.
+func AdvanceNonceAccount(ctx context.Context, url string, account solana.PublicKey, privKey solana.PrivateKey) (solana.Signature, error) {
+	instructions := []solana.Instruction{
+		system.NewAdvanceNonceAccountInstruction(account, solana.SysVarRecentBlockHashesPubkey, privKey.PublicKey()).Build(),
+	}
+	return sendNonceTransaction(ctx, rpc.New(url), instructions, privKey)
+}
+
+// CloseNonceAccount withdraws all of the account's lamports to its
+// authority, which deletes it.
+func CloseNonceAccount(ctx context.Context, url string, account solana.PublicKey, privKey solana.PrivateKey) (solana.Signature, error) {
+	client := rpc.New(url)
+	balance, err := client.GetBalance(ctx, account, rpc.CommitmentConfirmed)
+	if err != nil {
+		return solana.Signature{}, err
+	}
+
+	owner := privKey.PublicKey()
+	instructions := []solana.Instruction{
+		system.NewWithdrawNonceAccountInstruction(balance.Value, account, owner, solana.SysVarRecentBlockHashesPubkey, solana.SysVarRentPubkey, owner).Build(),
+	}
+	return sendNonceTransaction(ctx, client, instructions, privKey)
+}
+
+func sendNonceTransaction(ctx context.Context, client *rpc.Client, instructions []solana.Instruction, signers ...solana.PrivateKey) (solana.Signature, error) {
+	recentBlock, err := client.GetLatestBlockhash(ctx, rpc.CommitmentFinalized)
+	if err != nil {
+		return solana.Signature{}, err
+	}
+
+	tx, err := solana.NewTransaction(instructions, recentBlock.Value.Blockhash, solana.TransactionPayer(signers[0].PublicKey()))
+	if err != nil {
+		return solana.Signature{}, err
+	}
+
+	_, err = tx.Sign(func(key solana.PublicKey) *solana.PrivateKey {
+		for i := range signers {
+			if signers[i].PublicKey().Equals(key) {
+				return &signers[i]
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return solana.Signature{}, err
+	}
+
+	return client.SendTransaction(ctx, tx)
+}
+
+// nonceAccountOf returns the nonce account tx spends, if it is built on a
+// durable nonce rather than a recent blockhash.
+func nonceAccountOf(tx *solana.Transaction) (solana.PublicKey, bool) {
+	if len(tx.Message.Instructions) == 0 {
+		return solana.PublicKey{}, false
+	}
+	first := tx.Message.Instructions[0]
+	program, err := tx.Message.Program(first.ProgramIDIndex)
+	if err != nil || !program.Equals(solana.SystemProgramID) {
+		return solana.PublicKey{}, false
+	}
+	if len(first.Data) < 4 || binary.LittleEndian.Uint32(first.Data) != nonceInstructionAdvance || len(first.Accounts) == 0 {
+		return solana.PublicKey{}, false
+	}
+	if int(first.Accounts[0]) >= len(tx.Message.AccountKeys) {
+		return solana.PublicKey{}, false
+	}
+	return tx.Message.AccountKeys[first.Accounts[0]], true
+}
@@ -0,0 +1,65 @@
+package common
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	computebudget "github.com/gagliardetto/solana-go/programs/compute-budget"
+	"github.com/gagliardetto/solana-go/programs/system"
+)
+
+func TestDurableNonce_Apply(t *testing.T) {
+	payer := solana.NewWallet().PublicKey()
+	nonce := &DurableNonce{
+		Account:   solana.NewWallet().PublicKey(),
+		Authority: payer,
+		Nonce:     solana.Hash{7},
+	}
+	instructions := []solana.Instruction{
+		computebudget.NewSetComputeUnitLimitInstruction(1000).Build(),
+		system.NewTransferInstruction(1, payer, solana.NewWallet().PublicKey()).Build(),
+	}
+
+	built, blockHash := nonce.Apply(instructions, solana.Hash{1})
+	if blockHash != nonce.Nonce || len(built) != len(instructions)+1 {
+		t.Fatalf("blockhash = %s, %d instructions", blockHash, len(built))
+	}
+	tx, err := NewTransaction(built, blockHash, payer, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if account, ok := nonceAccountOf(tx); !ok || !account.Equals(nonce.Account) {
+		t.Errorf("nonceAccountOf = %s, %v", account, ok)
+	}
+
+	built, blockHash = (*DurableNonce)(nil).Apply(instructions, solana.Hash{1})
+	tx, err = NewTransaction(built, blockHash, payer, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := nonceAccountOf(tx); ok || blockHash != (solana.Hash{1}) {
+		t.Error("nil nonce changed the transaction")
+	}
+}
+
+func TestBuildSwap_OnDurableNonce(t *testing.T) {
+	key := solana.NewWallet().PrivateKey
+	nonce := &DurableNonce{Account: solana.NewWallet().PublicKey(), Authority: key.PublicKey(), Nonce: solana.Hash{7}}
+	instructions := []solana.Instruction{system.NewTransferInstruction(1, key.PublicKey(), solana.NewWallet().PublicKey()).Build()}
+
+	client := newSimulatingNode(t, `{"err":null,"logs":[],"unitsConsumed":1000}`)
+	tx, err := BuildSwap(context.Background(), client, instructions, FixedPriorityFee(1000), key, solana.Hash{}, nonce, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tx.Message.RecentBlockhash != nonce.Nonce {
+		t.Errorf("built on %s", tx.Message.RecentBlockhash)
+	}
+	if account, ok := nonceAccountOf(tx); !ok || !account.Equals(nonce.Account) {
+		t.Error("nonce not advanced first")
+	}
+	if err = tx.VerifySignatures(); err != nil {
+		t.Errorf("signatures: %v", err)
+	}
+}
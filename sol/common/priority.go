@@ -0,0 +1,85 @@
+package common
+
+import (
+	"context"
+	"math/big"
+	"slices"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/meme-bots/go-web3/types"
+	"github.com/samber/lo"
+)
+
+// EstimatePriorityFee returns the compute unit price, in micro-lamports,
+// that percentile of the recent blocks required to write accounts. The node
+// reports one fee per block, the least a landed transaction touching the
+// accounts paid.
+func EstimatePriorityFee(ctx context.Context, client *rpc.Client, accounts []solana.PublicKey, percentile int) (uint64, error) {
+	fees, err := client.GetRecentPrioritizationFees(ctx, lo.Uniq(accounts))
+	if err != nil {
+		return 0, err
+	}
+	if len(fees) == 0 {
+		return 0, types.ErrNotFound
+	}
+
+	prices := lo.Map(fees, func(fee rpc.PriorizationFeeResult, _ int) uint64 { return fee.PrioritizationFee })
+	slices.Sort(prices)
+	return Percentile(prices, percentile), nil
+}
+
+// Percentile picks the nearest-rank percentile of sorted, which must not be
+// empty.
+func Percentile(sorted []uint64, percentile int) uint64 {
+	percentile = max(1, min(percentile, 100))
+	rank := (percentile*len(sorted) + 99) / 100
+	return sorted[rank-1]
+}
+
+// PriorityFeeLamports is what a transaction with a limit of units pays at
+// price micro-lamports per compute unit.
+func PriorityFeeLamports(price uint64, units uint32) uint64 {
+	fee := new(big.Int).Mul(new(big.Int).SetUint64(price), big.NewInt(int64(units)))
+	return fee.Div(fee, big.NewInt(1000000)).Uint64()
+}
+
+// PriorityFee is what a transaction bids for being scheduled. A Price, in
+// micro-lamports per compute unit, is paid on every unit of the limit, at
+// most MaxLamports in total when that is set. Without a Price, Lamports are
+// spread over the limit.
+type PriorityFee struct {
+	Price       uint64
+	MaxLamports uint64
+	Lamports    uint64
+}
+
+// FixedPriorityFee pays lamports whatever limit the transaction gets.
+func FixedPriorityFee(lamports uint64) PriorityFee {
+	return PriorityFee{Lamports: lamports}
+}
+
+func (f PriorityFee) IsZero() bool {
+	return f.Price == 0 && f.Lamports == 0
+}
+
+// UnitPrice is the compute unit price a transaction limited to units pays.
+func (f PriorityFee) UnitPrice(units uint32) uint64 {
+	lamports := f.Lamports
+	if f.Price > 0 {
+		if f.MaxLamports == 0 || PriorityFeeLamports(f.Price, units) <= f.MaxLamports {
+			return f.Price
+		}
+		lamports = f.MaxLamports
+	}
+	if units == 0 {
+		return 0
+	}
+	price := new(big.Int).Mul(big.NewInt(1000000), new(big.Int).SetUint64(lamports))
+	return price.Div(price, big.NewInt(int64(units))).Uint64()
+}
+
+// Fee is the lamports a transaction limited to units pays.
+func (f PriorityFee) Fee(units uint32) uint64 {
+	return PriorityFeeLamports(f.UnitPrice(units), units)
+}
@@ -3,6 +3,10 @@ package common
 import (
 	"math/big"
 
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/token"
+	"github.com/gagliardetto/solana-go/rpc"
 	"github.com/shopspring/decimal"
 )
 
@@ -21,7 +25,8 @@ type (
 		Burnt                 bool
 		MintAuthorityDisabled bool
 		TokenReserve          *big.Int
-		SolReserve            *big.Int
+		SolReserve            *big.Int // lamports, what QuoteReserve is worth where the quote is not SOL
+		QuoteReserve          *big.Int // in quote units, only set where the quote is not SOL
 		Name                  string
 		Symbol                string
 		Decimals              uint8
@@ -31,6 +36,14 @@ type (
 		PoolAddress           string
 		MarketId              string
 		MarketProgramId       string
+		HopPool               string
+		PoolFeeBps            uint16 // swap fee of the pool, where known
+		TokenProgram          string
+		TransferFeeBps        uint16
+		TransferHook          bool
+		PermanentDelegate     bool
+		DefaultFrozen         bool
+		NonTransferable       bool
 	}
 
 	Balance struct {
@@ -38,3 +51,46 @@ type (
 		TokenBalance  *big.Int
 	}
 )
+
+// SetMint records the token program of mint and flags the Token-2022
+// extensions that can stop or tax a holder.
+func (r *GetSolPoolResponse) SetMint(mint *Mint) {
+	r.TokenProgram = mint.Program.String()
+	r.TransferFeeBps = mint.FeeBasisPoints()
+	r.TransferHook = mint.TransferHook != nil
+	r.PermanentDelegate = mint.PermanentDelegate != nil
+	r.DefaultFrozen = mint.DefaultFrozen
+	r.NonTransferable = mint.NonTransferable
+}
+
+// TokenAccounts returns the ATAs of owner under the legacy and the
+// Token-2022 program, so they can be fetched before the mint is known.
+func TokenAccounts(owner, mint solana.PublicKey) []solana.PublicKey {
+	return []solana.PublicKey{
+		FindAssociatedTokenAddress(owner, mint, solana.TokenProgramID),
+		FindAssociatedTokenAddress(owner, mint, solana.Token2022ProgramID),
+	}
+}
+
+// TokenAccountOf picks the account of the mint's program out of those
+// fetched for TokenAccounts.
+func TokenAccountOf(mint *rpc.Account, accounts []*rpc.Account) *rpc.Account {
+	if mint != nil && mint.Owner.Equals(solana.Token2022ProgramID) {
+		return accounts[1]
+	}
+	return accounts[0]
+}
+
+// TokenBalance reads the amount held in a token account, zero if it does
+// not exist.
+func TokenBalance(account *rpc.Account) (*big.Int, error) {
+	if account == nil {
+		return big.NewInt(0), nil
+	}
+	var tokenAccount token.Account
+	err := tokenAccount.UnmarshalWithDecoder(bin.NewBinDecoder(account.Data.GetBinary()))
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetUint64(tokenAccount.Amount), nil
+}
@@ -0,0 +1,148 @@
+package common
+
+import (
+	"context"
+	"math/rand"
+
+	"github.com/gagliardetto/solana-go"
+	computebudget "github.com/gagliardetto/solana-go/programs/compute-budget"
+	"github.com/gagliardetto/solana-go/programs/system"
+	"github.com/gagliardetto/solana-go/programs/token"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// CreateAndInitWsolTokenAccount creates a throwaway WSOL account holding
+// amount, to be closed again after the swap.
+func CreateAndInitWsolTokenAccount(wallet solana.PublicKey, amount uint64) ([]solana.Instruction, solana.PublicKey) {
+	randomKey, _ := solana.NewRandomPrivateKey()
+	seed := randomKey.PublicKey().String()[0:32]
+	wsolTokenAccount, _ := solana.CreateWithSeed(wallet, seed, solana.TokenProgramID)
+	createWithSeedInst := system.NewCreateAccountWithSeedInstruction(
+		wallet,
+		seed,
+		RentATA+amount,
+		165,
+		solana.TokenProgramID,
+		wallet,
+		wsolTokenAccount,
+		wallet,
+	).Build()
+
+	initializeAccountInst := token.NewInitializeAccountInstruction(
+		wsolTokenAccount,
+		solana.SolMint,
+		wallet,
+		solana.SysVarRentPubkey,
+	).Build()
+	return []solana.Instruction{createWithSeedInst, initializeAccountInst}, wsolTokenAccount
+}
+
+// FeeAndTipInstructions pay the bot fee to feeRecipient and the jito tip to
+// one of the tip accounts after a swap. Either is left out when zero.
+func FeeAndTipInstructions(payer, feeRecipient solana.PublicKey, fee, jitoTip uint64) []solana.Instruction {
+	var instructions []solana.Instruction
+	if fee != 0 {
+		instructions = append(instructions, system.NewTransferInstruction(fee, payer, feeRecipient).Build())
+	}
+	if jitoTip != 0 {
+		tipAccount := JitoTipPaymentAccounts[rand.Intn(len(JitoTipPaymentAccounts))]
+		instructions = append(instructions, system.NewTransferInstruction(jitoTip, payer, tipAccount).Build())
+	}
+	return instructions
+}
+
+// BuildSwap prices and signs the instructions of a swap without sending
+// them. With a durable nonce the transaction can be sent until the nonce is
+// advanced.
+func BuildSwap(
+	ctx context.Context,
+	cli *rpc.Client,
+	instructions []solana.Instruction,
+	priorityFee PriorityFee,
+	privKey solana.PrivateKey,
+	recentBlockHash solana.Hash,
+	nonce *DurableNonce,
+	lookupTables map[solana.PublicKey]solana.PublicKeySlice,
+) (*solana.Transaction, error) {
+	if recentBlockHash.IsZero() && nonce == nil {
+		recentBlock, err := cli.GetLatestBlockhash(ctx, rpc.CommitmentFinalized)
+		if err != nil {
+			return nil, err
+		}
+		recentBlockHash = recentBlock.Value.Blockhash
+	}
+
+	instructions, err := WithComputeBudget(ctx, cli, instructions, privKey.PublicKey(), lookupTables, priorityFee, SwapComputeUnits)
+	if err != nil {
+		return nil, err
+	}
+
+	instructions, recentBlockHash = nonce.Apply(instructions, recentBlockHash)
+
+	tx, err := NewTransaction(instructions, recentBlockHash, privKey.PublicKey(), lookupTables)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Sign(func(key solana.PublicKey) *solana.PrivateKey {
+		return &privKey
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// SwapFits reports whether the instructions of a swap, once BuildSwap has
+// priced them and applied nonce, fit in a transaction signed by payer.
+func SwapFits(
+	instructions []solana.Instruction,
+	payer solana.PublicKey,
+	nonce *DurableNonce,
+	lookupTables map[solana.PublicKey]solana.PublicKeySlice,
+) bool {
+	// the budget takes as many bytes whatever its values
+	instructions, recentBlockHash := nonce.Apply(append([]solana.Instruction{
+		computebudget.NewSetComputeUnitLimitInstruction(0).Build(),
+		computebudget.NewSetComputeUnitPriceInstruction(0).Build(),
+	}, instructions...), solana.Hash{})
+	tx, err := NewTransaction(instructions, recentBlockHash, payer, lookupTables)
+	if err != nil {
+		return false
+	}
+	message, err := tx.Message.MarshalBinary()
+	if err != nil {
+		return false
+	}
+	// the signatures are counted in one byte below 128
+	return 1+int(tx.Message.Header.NumRequiredSignatures)*solana.SignatureLength+len(message) <= MaxTransactionSize
+}
+
+// SendSwap prices, signs and sends the instructions of a swap, as a bundle
+// through jito when there is a jito tip. Transactions sent otherwise are
+// kept in outbox to be rebroadcast.
+func SendSwap(
+	ctx context.Context,
+	url string,
+	jito *JitoClient,
+	outbox *Outbox,
+	instructions []solana.Instruction,
+	priorityFee PriorityFee,
+	jitoTip uint64,
+	privKey solana.PrivateKey,
+	recentBlockHash solana.Hash,
+	nonce *DurableNonce,
+	lookupTables map[solana.PublicKey]solana.PublicKeySlice,
+) (solana.Signature, error) {
+	cli := rpc.New(url)
+	tx, err := BuildSwap(ctx, cli, instructions, priorityFee, privKey, recentBlockHash, nonce, lookupTables)
+	if err != nil {
+		return solana.Signature{}, err
+	}
+
+	if jitoTip != 0 {
+		return SendBundled(ctx, jito, cli, tx)
+	}
+
+	return SendTransaction(ctx, cli, outbox, tx, rpc.TransactionOpts{SkipPreflight: true})
+}
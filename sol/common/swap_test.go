@@ -0,0 +1,32 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/system"
+)
+
+func TestSwapFits(t *testing.T) {
+	payer := solana.NewWallet().PublicKey()
+	transfers := func(n int) []solana.Instruction {
+		instructions := make([]solana.Instruction, n)
+		for i := range instructions {
+			instructions[i] = system.NewTransferInstruction(1, payer, solana.NewWallet().PublicKey()).Build()
+		}
+		return instructions
+	}
+	nonce := &DurableNonce{Account: solana.NewWallet().PublicKey(), Authority: payer}
+
+	// a transfer to a new account takes 49 bytes, 20 of them come to 1198
+	// with the signature and the budget
+	if !SwapFits(transfers(20), payer, nil, nil) {
+		t.Error("20 transfers do not fit")
+	}
+	if SwapFits(transfers(21), payer, nil, nil) {
+		t.Error("21 transfers fit")
+	}
+	if SwapFits(transfers(20), payer, nonce, nil) {
+		t.Error("the nonce is not counted")
+	}
+}
@@ -0,0 +1,268 @@
+package common
+
+import (
+	"context"
+	"encoding/binary"
+	"math/bits"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/token"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/meme-bots/go-web3/types"
+)
+
+const (
+	// extensions follow the 165 bytes of a token account, mints are padded
+	// to the same length, then comes one byte of account type
+	tokenAccountSize   = 165
+	extensionsOffset   = tokenAccountSize + 1
+	accountTypeMint    = 1
+	accountStateFrozen = 2
+
+	ExtensionTransferFeeConfig   uint16 = 1
+	ExtensionDefaultAccountState uint16 = 6
+	ExtensionNonTransferable     uint16 = 9
+	ExtensionPermanentDelegate   uint16 = 12
+	ExtensionTransferHook        uint16 = 14
+	ExtensionMetadataPointer     uint16 = 18
+	ExtensionTokenMetadata       uint16 = 19
+)
+
+type (
+	// Mint is a mint of either token program with the Token-2022 extensions
+	// that matter for trading.
+	Mint struct {
+		token.Mint
+		Program           solana.PublicKey
+		Epoch             uint64 // fees are quoted as of this epoch
+		TransferFee       *TransferFeeConfig
+		DefaultFrozen     bool
+		NonTransferable   bool
+		PermanentDelegate *solana.PublicKey
+		TransferHook      *solana.PublicKey // program invoked on every transfer
+		MetadataPointer   *solana.PublicKey
+		Metadata          *TokenMetadata
+	}
+
+	TransferFee struct {
+		Epoch       uint64
+		MaximumFee  uint64
+		BasisPoints uint16
+	}
+
+	TransferFeeConfig struct {
+		ConfigAuthority   solana.PublicKey
+		WithdrawAuthority solana.PublicKey
+		WithheldAmount    uint64
+		Older             TransferFee
+		Newer             TransferFee
+	}
+
+	TokenMetadata struct {
+		UpdateAuthority solana.PublicKey
+		Mint            solana.PublicKey
+		Name            string
+		Symbol          string
+		Uri             string
+	}
+)
+
+// TokenProgramOf returns the token program that owns a mint or token
+// account.
+func TokenProgramOf(account *rpc.Account) (solana.PublicKey, error) {
+	if account == nil {
+		return solana.PublicKey{}, types.ErrNotFound
+	}
+	if !account.Owner.Equals(solana.TokenProgramID) && !account.Owner.Equals(solana.Token2022ProgramID) {
+		return solana.PublicKey{}, types.ErrInvalidPool
+	}
+	return account.Owner, nil
+}
+
+// FindAssociatedTokenAddress derives the ATA of wallet under tokenProgram.
+func FindAssociatedTokenAddress(wallet, mint, tokenProgram solana.PublicKey) solana.PublicKey {
+	ata, _, _ := solana.FindProgramAddress(
+		[][]byte{wallet.Bytes(), tokenProgram.Bytes(), mint.Bytes()},
+		solana.SPLAssociatedTokenAccountProgramID,
+	)
+	return ata
+}
+
+// GetMint loads a mint, along with the epoch if it charges transfer fees.
+func GetMint(ctx context.Context, client *rpc.Client, address solana.PublicKey) (*Mint, error) {
+	account, err := client.GetAccountInfoWithOpts(ctx, address, &rpc.GetAccountInfoOpts{Commitment: rpc.CommitmentConfirmed})
+	if err != nil {
+		return nil, err
+	}
+	mint, err := DecodeMint(account.Value)
+	if err != nil {
+		return nil, err
+	}
+	return mint, mint.SetEpoch(ctx, client)
+}
+
+// SetEpoch fetches the current epoch for mints with a transfer fee.
+func (m *Mint) SetEpoch(ctx context.Context, client *rpc.Client) error {
+	if m.TransferFee == nil {
+		return nil
+	}
+	epoch, err := client.GetEpochInfo(ctx, rpc.CommitmentConfirmed)
+	if err != nil {
+		return err
+	}
+	m.Epoch = epoch.Epoch
+	return nil
+}
+
+// DecodeMint reads a mint account of either token program.
+func DecodeMint(account *rpc.Account) (*Mint, error) {
+	program, err := TokenProgramOf(account)
+	if err != nil {
+		return nil, err
+	}
+
+	data := account.Data.GetBinary()
+	mint := &Mint{Program: program}
+	err = mint.Mint.UnmarshalWithDecoder(bin.NewBinDecoder(data))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) <= extensionsOffset || data[tokenAccountSize] != accountTypeMint {
+		return mint, nil
+	}
+
+	for offset := extensionsOffset; offset+4 <= len(data); {
+		kind := binary.LittleEndian.Uint16(data[offset:])
+		length := int(binary.LittleEndian.Uint16(data[offset+2:]))
+		offset += 4
+		if kind == 0 || offset+length > len(data) {
+			break
+		}
+		err = mint.decodeExtension(kind, data[offset:offset+length])
+		if err != nil {
+			return nil, err
+		}
+		offset += length
+	}
+	return mint, nil
+}
+
+func (m *Mint) decodeExtension(kind uint16, value []byte) error {
+	switch kind {
+	case ExtensionTransferFeeConfig:
+		var config TransferFeeConfig
+		err := bin.NewBinDecoder(value).Decode(&config)
+		if err != nil {
+			return err
+		}
+		m.TransferFee = &config
+	case ExtensionDefaultAccountState:
+		m.DefaultFrozen = len(value) > 0 && value[0] == accountStateFrozen
+	case ExtensionNonTransferable:
+		m.NonTransferable = true
+	case ExtensionPermanentDelegate:
+		m.PermanentDelegate = optionalKey(value, 0)
+	case ExtensionTransferHook:
+		m.TransferHook = optionalKey(value, 32)
+	case ExtensionMetadataPointer:
+		m.MetadataPointer = optionalKey(value, 32)
+	case ExtensionTokenMetadata:
+		var metadata TokenMetadata
+		decoder := bin.NewBorshDecoder(value)
+		err := decoder.Decode(&metadata)
+		if err != nil {
+			return err
+		}
+		m.Metadata = &metadata
+	}
+	return nil
+}
+
+// optionalKey reads an OptionalNonZeroPubkey, which is all zeroes when unset.
+func optionalKey(value []byte, offset int) *solana.PublicKey {
+	if len(value) < offset+32 {
+		return nil
+	}
+	key := solana.PublicKeyFromBytes(value[offset : offset+32])
+	if key.IsZero() {
+		return nil
+	}
+	return &key
+}
+
+// Fee returns the transfer fee withheld from amount in epoch.
+func (c *TransferFeeConfig) Fee(amount, epoch uint64) uint64 {
+	fee := c.Older
+	if epoch >= c.Newer.Epoch {
+		fee = c.Newer
+	}
+	if fee.BasisPoints == 0 {
+		return 0
+	}
+	// amount*bps rounded up needs 128 bits; a quotient past 64 bits is
+	// capped anyway
+	hi, lo := bits.Mul64(amount, uint64(fee.BasisPoints))
+	lo, carry := bits.Add64(lo, 9999, 0)
+	hi += carry
+	if hi >= 10000 {
+		return fee.MaximumFee
+	}
+	withheld, _ := bits.Div64(hi, lo, 10000)
+	return min(withheld, fee.MaximumFee)
+}
+
+// AmountAfterFee is what the recipient of a transfer of amount gets.
+func (m *Mint) AmountAfterFee(amount uint64) uint64 {
+	if m.TransferFee == nil {
+		return amount
+	}
+	return amount - m.TransferFee.Fee(amount, m.Epoch)
+}
+
+// FeeBasisPoints is the transfer fee rate in effect.
+func (m *Mint) FeeBasisPoints() uint16 {
+	if m.TransferFee == nil {
+		return 0
+	}
+	if m.Epoch >= m.TransferFee.Newer.Epoch {
+		return m.TransferFee.Newer.BasisPoints
+	}
+	return m.TransferFee.Older.BasisPoints
+}
+
+// MetadataOnMint reports whether the name and symbol live in the mint
+// itself rather than in a Metaplex metadata account.
+func (m *Mint) MetadataOnMint(address solana.PublicKey) bool {
+	return m.Metadata != nil && (m.MetadataPointer == nil || m.MetadataPointer.Equals(address))
+}
+
+// WithProgram retargets a token instruction built for the legacy program to
+// tokenProgram. Token-2022 keeps the legacy instruction layouts.
+func WithProgram(instruction solana.Instruction, tokenProgram solana.PublicKey) solana.Instruction {
+	if tokenProgram.Equals(solana.TokenProgramID) {
+		return instruction
+	}
+	data, err := instruction.Data()
+	if err != nil {
+		panic(err)
+	}
+	return solana.NewInstruction(tokenProgram, instruction.Accounts(), data)
+}
+
+// NewCreateIdempotentAtaInstruction creates the ATA of wallet under
+// tokenProgram unless it exists.
+func NewCreateIdempotentAtaInstruction(payer, wallet, mint, tokenProgram solana.PublicKey) solana.Instruction {
+	return solana.NewInstruction(
+		solana.SPLAssociatedTokenAccountProgramID,
+		solana.AccountMetaSlice{
+			solana.Meta(payer).WRITE().SIGNER(),
+			solana.Meta(FindAssociatedTokenAddress(wallet, mint, tokenProgram)).WRITE(),
+			solana.Meta(wallet),
+			solana.Meta(mint),
+			solana.Meta(solana.SystemProgramID),
+			solana.Meta(tokenProgram),
+		},
+		[]byte{1},
+	)
+}
@@ -0,0 +1,101 @@
+package common
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+func appendExtension(data []byte, kind uint16, value []byte) []byte {
+	data = binary.LittleEndian.AppendUint16(data, kind)
+	data = binary.LittleEndian.AppendUint16(data, uint16(len(value)))
+	return append(data, value...)
+}
+
+func TestDecodeMint_Token2022(t *testing.T) {
+	address := solana.NewWallet().PublicKey()
+	delegate := solana.NewWallet().PublicKey()
+
+	data := make([]byte, tokenAccountSize+1)
+	data[44] = 6 // decimals
+	data[45] = 1 // initialized
+	data[tokenAccountSize] = accountTypeMint
+
+	fee, err := bin.MarshalBin(&TransferFeeConfig{
+		Older: TransferFee{Epoch: 0, MaximumFee: 1000, BasisPoints: 100},
+		Newer: TransferFee{Epoch: 10, MaximumFee: 50, BasisPoints: 250},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	metadata, err := bin.MarshalBorsh(&TokenMetadata{Mint: address, Name: "Coin ", Symbol: "CN"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data = appendExtension(data, ExtensionTransferFeeConfig, fee)
+	data = appendExtension(data, ExtensionPermanentDelegate, delegate.Bytes())
+	data = appendExtension(data, ExtensionMetadataPointer, append(make([]byte, 32), address.Bytes()...))
+	data = appendExtension(data, ExtensionTokenMetadata, metadata)
+
+	mint, err := DecodeMint(&rpc.Account{
+		Owner: solana.Token2022ProgramID,
+		Data:  rpc.DataBytesOrJSONFromBytes(data),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mint.Decimals != 6 || mint.PermanentDelegate == nil || !mint.PermanentDelegate.Equals(delegate) || mint.TransferHook != nil {
+		t.Errorf("unexpected mint: %+v", mint)
+	}
+	if !mint.MetadataOnMint(address) || mint.Metadata.Symbol != "CN" {
+		t.Errorf("metadata = %+v", mint.Metadata)
+	}
+
+	if got := mint.AmountAfterFee(10000); got != 9900 {
+		t.Errorf("AmountAfterFee in epoch 0 = %d, want 9900", got)
+	}
+	mint.Epoch = 10
+	if got := mint.AmountAfterFee(10000); got != 9950 {
+		t.Errorf("AmountAfterFee in epoch 10 = %d, want 9950 (capped)", got)
+	}
+	if bps := mint.FeeBasisPoints(); bps != 250 {
+		t.Errorf("FeeBasisPoints = %d, want 250", bps)
+	}
+}
+
+func TestTransferFeeConfig_Fee(t *testing.T) {
+	config := &TransferFeeConfig{Newer: TransferFee{MaximumFee: math.MaxUint64, BasisPoints: 250}}
+
+	if fee := config.Fee(10001, 0); fee != 251 {
+		t.Errorf("fee rounds up: %d", fee)
+	}
+	// amount*bps is past 64 bits
+	if fee := config.Fee(math.MaxUint64/100, 0); fee != 4611686018427388 {
+		t.Errorf("large amount: fee %d", fee)
+	}
+	config.Newer.BasisPoints = math.MaxUint16
+	if fee := config.Fee(math.MaxUint64, 0); fee != math.MaxUint64 {
+		t.Errorf("fee past 64 bits: %d", fee)
+	}
+	config.Newer.MaximumFee = 5000
+	if fee := config.Fee(math.MaxUint64/100, 0); fee != 5000 {
+		t.Errorf("capped: %d", fee)
+	}
+}
+
+func TestFindAssociatedTokenAddress(t *testing.T) {
+	wallet := solana.NewWallet().PublicKey()
+	mint := solana.NewWallet().PublicKey()
+
+	legacy, _, _ := solana.FindAssociatedTokenAddress(wallet, mint)
+	if got := FindAssociatedTokenAddress(wallet, mint, solana.TokenProgramID); !got.Equals(legacy) {
+		t.Errorf("legacy ATA = %s, want %s", got, legacy)
+	}
+	if got := FindAssociatedTokenAddress(wallet, mint, solana.Token2022ProgramID); got.Equals(legacy) {
+		t.Error("Token-2022 ATA matches the legacy one")
+	}
+}
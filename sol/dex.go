@@ -0,0 +1,135 @@
+package sol
+
+import (
+	"context"
+	"math/big"
+	"slices"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/meme-bots/go-web3/sol/common"
+	"github.com/meme-bots/go-web3/types"
+	"github.com/meme-bots/go-web3/utils"
+	"github.com/samber/lo"
+)
+
+type (
+	// Dex is a venue tokens swap against SOL on. Venues register under
+	// their program ID and are reached from Solana only through here.
+	Dex interface {
+		// ID is the venue's types.Dex* value.
+		ID() int
+		ProgramID() solana.PublicKey
+		// FindPools looks up the venue's pools of mint, best first.
+		FindPools(ctx context.Context, url string, mint solana.PublicKey) []*types.Pool
+		GetPool(ctx context.Context, url string, pool *types.Pool, owner string, withBalance bool) (*common.GetSolPoolResponse, *common.Balance, error)
+		// LoadPool reads the state a swap of req needs, in the direction
+		// of buy.
+		LoadPool(ctx context.Context, client *rpc.Client, req *types.Transact, buy bool) (PoolState, error)
+		// ParseSwap reads the SOL and token the instruction at index of a
+		// confirmed transaction swapped for owner. It is false for
+		// instructions of the program that are not swaps.
+		ParseSwap(tx *rpc.GetTransactionResult, transaction *solana.Transaction, instruction solana.CompiledInstruction, index uint16, owner, token solana.PublicKey) (*big.Int, *big.Int, bool)
+	}
+
+	// PoolState is a pool loaded to swap one way.
+	PoolState interface {
+		// Quote is what amountIn swaps for, net of the token's transfer fee
+		// but not of the bot fee.
+		Quote(token *common.Mint, amountIn uint64) (uint64, error)
+		// Instructions are those of the swap alone, the bot fee and the
+		// jito tip are paid after it by the caller.
+		Instructions(req *SwapRequest) ([]solana.Instruction, error)
+	}
+
+	SwapRequest struct {
+		Payer     solana.PublicKey
+		TokenMint solana.PublicKey
+		Token     *common.Mint
+		Amount    uint64 // SOL to buy with, net of the bot fee, or tokens to sell
+		Slippage  uint64 // bps
+		CreateAta bool   // buying into a token account yet to be created
+		CloseAta  bool   // selling all of it
+	}
+)
+
+var (
+	// dexes are the venues in the order QueryPool prefers them.
+	dexes        []Dex
+	dexByID      = map[int]Dex{}
+	dexByProgram = map[solana.PublicKey]Dex{}
+)
+
+func init() {
+	RegisterDex(raydiumDex{})
+	RegisterDex(cpmmDex{})
+	RegisterDex(clmmDex{})
+	RegisterDex(pumpSwapDex{})
+	RegisterDex(dlmmDex{})
+	RegisterDex(dammDex{})
+	RegisterDex(orcaDex{})
+	RegisterDex(pumpFunDex{})
+}
+
+// RegisterDex adds a venue, preferred after those already registered.
+func RegisterDex(dex Dex) {
+	dexes = append(dexes, dex)
+	dexByID[dex.ID()] = dex
+	dexByProgram[dex.ProgramID()] = dex
+}
+
+// findBaseAndQuote runs a lookup of mint as base and as quote token at once.
+func findBaseAndQuote(
+	ctx context.Context,
+	url string,
+	mint solana.PublicKey,
+	find func(ctx context.Context, url string, token solana.PublicKey, isBaseToken bool) (*types.Pool, error),
+) []*types.Pool {
+	var base, quote *types.Pool
+	sub := utils.Subprocesses{}
+	sub.Go(func() {
+		base, _ = find(ctx, url, mint, true)
+	})
+	sub.Go(func() {
+		quote, _ = find(ctx, url, mint, false)
+	})
+	sub.Wait()
+	return found(base, quote)
+}
+
+// found drops the pools a lookup did not find.
+func found(pools ...*types.Pool) []*types.Pool {
+	return lo.Filter(pools, func(p *types.Pool, _ int) bool { return p != nil })
+}
+
+// findPools looks up the pools of mint on every venue at once, by venue ID.
+func (s *Solana) findPools(mint solana.PublicKey) map[int][]*types.Pool {
+	results := make([][]*types.Pool, len(dexes))
+	sub := utils.Subprocesses{}
+	for i, dex := range dexes {
+		sub.Go(func() {
+			results[i] = dex.FindPools(context.Background(), s.cfg.RPC, mint)
+		})
+	}
+	sub.Wait()
+
+	pools := make(map[int][]*types.Pool, len(dexes))
+	for i, dex := range dexes {
+		pools[dex.ID()] = results[i]
+	}
+	return pools
+}
+
+// tokenProgramIn is the program of mint as the token balances of tx report
+// it, the legacy token program if they do not.
+func tokenProgramIn(tx *rpc.GetTransactionResult, mint solana.PublicKey) solana.PublicKey {
+	if tx == nil || tx.Meta == nil {
+		return solana.TokenProgramID
+	}
+	balances := slices.Concat(tx.Meta.PreTokenBalances, tx.Meta.PostTokenBalances)
+	balance, found := lo.Find(balances, func(b rpc.TokenBalance) bool { return b.Mint.Equals(mint) && b.ProgramId != nil })
+	if !found {
+		return solana.TokenProgramID
+	}
+	return *balance.ProgramId
+}
@@ -0,0 +1,196 @@
+package sol
+
+import (
+	"context"
+	"math/big"
+	"slices"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/meme-bots/go-web3/sol/common"
+	"github.com/meme-bots/go-web3/sol/meteora"
+	"github.com/meme-bots/go-web3/types"
+)
+
+type (
+	dlmmDex struct{}
+
+	dlmmPool struct {
+		poolID    solana.PublicKey
+		pool      *meteora.DlmmPool
+		binArrays []*meteora.DlmmBinArray
+		swapForY  bool
+		buy       bool
+	}
+
+	dammDex struct{}
+
+	dammPool struct {
+		poolID                    solana.PublicKey
+		pool                      *meteora.DammPool
+		vaultA, vaultB            *meteora.DammVault
+		reserveWsol, reserveToken uint64
+		buy                       bool
+	}
+)
+
+func (dlmmDex) ID() int { return types.DexMeteoraDlmm }
+
+func (dlmmDex) ProgramID() solana.PublicKey { return meteora.DlmmProgramID }
+
+func (dlmmDex) FindPools(ctx context.Context, url string, mint solana.PublicKey) []*types.Pool {
+	return findBaseAndQuote(ctx, url, mint, meteora.GetDlmmPoolByToken)
+}
+
+func (dlmmDex) GetPool(ctx context.Context, url string, pool *types.Pool, owner string, withBalance bool) (*common.GetSolPoolResponse, *common.Balance, error) {
+	return meteora.GetDlmmPool(ctx, url, pool, owner, withBalance)
+}
+
+// LoadPool reads the pool and the bin arrays the swap runs through.
+func (dlmmDex) LoadPool(ctx context.Context, client *rpc.Client, req *types.Transact, buy bool) (PoolState, error) {
+	poolID := solana.MPK(req.PoolID)
+	pool, err := meteora.GetDlmmPoolState(ctx, client, poolID)
+	if err != nil {
+		return nil, err
+	}
+	// X goes in when buying with SOL as X or selling X
+	swapForY := buy != pool.TokenIsX()
+	binArrays, err := meteora.GetDlmmBinArrays(ctx, client, poolID, pool, swapForY, meteora.DlmmSwapBinArrays)
+	if err != nil {
+		return nil, err
+	}
+	return &dlmmPool{
+		poolID:    poolID,
+		pool:      pool,
+		binArrays: binArrays,
+		swapForY:  swapForY,
+		buy:       buy,
+	}, nil
+}
+
+func (dlmmDex) ParseSwap(tx *rpc.GetTransactionResult, transaction *solana.Transaction, instruction solana.CompiledInstruction, index uint16, _, _ solana.PublicKey) (*big.Int, *big.Int, bool) {
+	if len(instruction.Data) < 8 || !slices.Equal(instruction.Data[:8], meteora.Instruction_DlmmSwap[:]) {
+		return nil, nil, false
+	}
+	solSwapped, tokenSwapped := meteora.ParseDlmmSwapInstruction(tx, &transaction.Message, instruction, index)
+	return solSwapped, tokenSwapped, true
+}
+
+func (p *dlmmPool) Quote(token *common.Mint, amountIn uint64) (uint64, error) {
+	if !p.buy {
+		amountIn = token.AmountAfterFee(amountIn)
+	}
+	quote, err := p.pool.Quote(p.binArrays, amountIn, p.swapForY)
+	if err != nil {
+		return 0, err
+	}
+	if p.buy {
+		return token.AmountAfterFee(quote.AmountOut), nil
+	}
+	return quote.AmountOut, nil
+}
+
+func (p *dlmmPool) Instructions(req *SwapRequest) ([]solana.Instruction, error) {
+	if p.buy {
+		return meteora.DlmmBuyInstructions(
+			p.poolID,
+			req.Token,
+			req.Amount,
+			req.Slippage,
+			p.pool,
+			p.binArrays,
+			req.CreateAta,
+			req.Payer,
+		)
+	}
+	return meteora.DlmmSellInstructions(
+		p.poolID,
+		req.Token,
+		req.Amount,
+		req.Slippage,
+		p.pool,
+		p.binArrays,
+		req.CloseAta,
+		req.Payer,
+	)
+}
+
+func (dammDex) ID() int { return types.DexMeteoraDamm }
+
+func (dammDex) ProgramID() solana.PublicKey { return meteora.DammProgramID }
+
+func (dammDex) FindPools(ctx context.Context, url string, mint solana.PublicKey) []*types.Pool {
+	return findBaseAndQuote(ctx, url, mint, meteora.GetDammPoolByToken)
+}
+
+func (dammDex) GetPool(ctx context.Context, url string, pool *types.Pool, owner string, withBalance bool) (*common.GetSolPoolResponse, *common.Balance, error) {
+	return meteora.GetDammPool(ctx, url, pool, owner, withBalance)
+}
+
+// LoadPool reads the pool and its vaults, and takes the reserves from req.
+func (dammDex) LoadPool(ctx context.Context, client *rpc.Client, req *types.Transact, buy bool) (PoolState, error) {
+	poolID := solana.MPK(req.PoolID)
+	pool, vaultA, vaultB, err := meteora.GetDammPoolState(ctx, client, poolID)
+	if err != nil {
+		return nil, err
+	}
+	return &dammPool{
+		poolID:       poolID,
+		pool:         pool,
+		vaultA:       vaultA,
+		vaultB:       vaultB,
+		reserveWsol:  req.QuoteReserve.Uint64(),
+		reserveToken: req.TokenReserve.Uint64(),
+		buy:          buy,
+	}, nil
+}
+
+// ParseSwap tells a buy from a sell by whether the tokens came from the
+// owner's ata.
+func (dammDex) ParseSwap(tx *rpc.GetTransactionResult, transaction *solana.Transaction, instruction solana.CompiledInstruction, index uint16, owner, token solana.PublicKey) (*big.Int, *big.Int, bool) {
+	if len(instruction.Data) < 8 || !slices.Equal(instruction.Data[:8], meteora.Instruction_DammSwap[:]) {
+		return nil, nil, false
+	}
+	ata := common.FindAssociatedTokenAddress(owner, token, tokenProgramIn(tx, token))
+	ataIndex, _ := transaction.GetAccountIndex(ata)
+	solSwapped, tokenSwapped := meteora.ParseDammSwapInstruction(tx, &transaction.Message, instruction, index, ataIndex)
+	return solSwapped, tokenSwapped, true
+}
+
+func (p *dammPool) Quote(_ *common.Mint, amountIn uint64) (uint64, error) {
+	if p.buy {
+		return p.pool.Quote(amountIn, p.reserveWsol, p.reserveToken), nil
+	}
+	return p.pool.Quote(amountIn, p.reserveToken, p.reserveWsol), nil
+}
+
+func (p *dammPool) Instructions(req *SwapRequest) ([]solana.Instruction, error) {
+	if p.buy {
+		return meteora.DammBuyInstructions(
+			p.poolID,
+			req.Token,
+			req.Amount,
+			req.Slippage,
+			p.reserveWsol,
+			p.reserveToken,
+			p.pool,
+			p.vaultA,
+			p.vaultB,
+			req.CreateAta,
+			req.Payer,
+		)
+	}
+	return meteora.DammSellInstructions(
+		p.poolID,
+		req.Token,
+		req.Amount,
+		req.Slippage,
+		p.reserveWsol,
+		p.reserveToken,
+		p.pool,
+		p.vaultA,
+		p.vaultB,
+		req.CloseAta,
+		req.Payer,
+	)
+}
@@ -0,0 +1,94 @@
+package sol
+
+import (
+	"context"
+	"math/big"
+	"slices"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/meme-bots/go-web3/sol/common"
+	"github.com/meme-bots/go-web3/sol/orca"
+	"github.com/meme-bots/go-web3/types"
+)
+
+type (
+	// orcaDex is Orca Whirlpools. USDC pairs carry the SOL/USDC pool they
+	// route through as their market.
+	orcaDex struct{}
+
+	orcaPool struct {
+		route orca.Route
+		buy   bool
+	}
+)
+
+func (orcaDex) ID() int { return types.DexOrca }
+
+func (orcaDex) ProgramID() solana.PublicKey { return orca.ProgramID }
+
+func (orcaDex) FindPools(ctx context.Context, url string, mint solana.PublicKey) []*types.Pool {
+	pool, _ := orca.GetWhirlpoolByToken(ctx, url, mint)
+	return found(pool)
+}
+
+func (orcaDex) GetPool(ctx context.Context, url string, pool *types.Pool, owner string, withBalance bool) (*common.GetSolPoolResponse, *common.Balance, error) {
+	return orca.GetWhirlpool(ctx, url, pool, owner, withBalance)
+}
+
+// LoadPool reads the pools of the route and their tick arrays.
+func (orcaDex) LoadPool(ctx context.Context, client *rpc.Client, req *types.Transact, buy bool) (PoolState, error) {
+	var hopID solana.PublicKey
+	if req.HopPool != "" {
+		hopID = solana.MPK(req.HopPool)
+	}
+	route, err := orca.GetRoute(ctx, client, solana.MPK(req.PoolID), hopID, buy)
+	if err != nil {
+		return nil, err
+	}
+	return &orcaPool{route: route, buy: buy}, nil
+}
+
+func (orcaDex) ParseSwap(tx *rpc.GetTransactionResult, transaction *solana.Transaction, instruction solana.CompiledInstruction, index uint16, _, _ solana.PublicKey) (*big.Int, *big.Int, bool) {
+	if len(instruction.Data) < 8 {
+		return nil, nil, false
+	}
+	if slices.Equal(instruction.Data[:8], orca.Instruction_SwapV2[:]) {
+		solSwapped, tokenSwapped := orca.ParseSwapV2Instruction(tx, &transaction.Message, instruction, index)
+		return solSwapped, tokenSwapped, true
+	}
+	if slices.Equal(instruction.Data[:8], orca.Instruction_TwoHopSwapV2[:]) {
+		solSwapped, tokenSwapped := orca.ParseTwoHopSwapV2Instruction(tx, &transaction.Message, instruction, index)
+		return solSwapped, tokenSwapped, true
+	}
+	return nil, nil, false
+}
+
+func (p *orcaPool) Quote(token *common.Mint, amountIn uint64) (uint64, error) {
+	if p.buy {
+		amountOut, err := p.route.Quote(amountIn)
+		return token.AmountAfterFee(amountOut), err
+	}
+	return p.route.Quote(token.AmountAfterFee(amountIn))
+}
+
+func (p *orcaPool) Instructions(req *SwapRequest) ([]solana.Instruction, error) {
+	if p.buy {
+		return orca.BuyInstructions(
+			req.Token,
+			req.Amount,
+			req.Slippage,
+			p.route,
+			req.CreateAta,
+			req.Payer,
+		)
+	}
+	return orca.SellInstructions(
+		req.Token,
+		req.Amount,
+		req.Slippage,
+		p.route,
+		req.CloseAta,
+		req.Payer,
+	)
+}
@@ -0,0 +1,196 @@
+package sol
+
+import (
+	"context"
+	"math/big"
+	"slices"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/meme-bots/go-web3/sol/common"
+	"github.com/meme-bots/go-web3/sol/pumpfun"
+	"github.com/meme-bots/go-web3/sol/pumpswap"
+	"github.com/meme-bots/go-web3/types"
+	"github.com/near/borsh-go"
+	"github.com/samber/lo"
+)
+
+type (
+	// pumpFunDex is the pump.fun bonding curve. A pool found here may have
+	// completed, and Solana then looks for where it migrated to.
+	pumpFunDex struct{}
+
+	pumpFunPool struct {
+		bondingCurve pumpfun.BondingCurve
+		buy          bool
+	}
+
+	pumpSwapDex struct{}
+
+	pumpSwapPool struct {
+		poolID                    solana.PublicKey
+		pool                      *pumpswap.Pool
+		config                    *pumpswap.GlobalConfig
+		reserveWsol, reserveToken uint64
+		buy                       bool
+	}
+)
+
+func (pumpFunDex) ID() int { return types.DexPumpFun }
+
+func (pumpFunDex) ProgramID() solana.PublicKey { return pumpfun.ProgramID }
+
+func (pumpFunDex) FindPools(ctx context.Context, url string, mint solana.PublicKey) []*types.Pool {
+	pool, _ := pumpfun.GetPumpFunPoolByToken(ctx, url, mint)
+	return found(pool)
+}
+
+func (pumpFunDex) GetPool(ctx context.Context, url string, pool *types.Pool, owner string, withBalance bool) (*common.GetSolPoolResponse, *common.Balance, error) {
+	return pumpfun.GetPumpFunPool(ctx, url, &common.GetSolPoolRequest{Token: pool.BaseMint, Owner: owner, WithBalance: withBalance})
+}
+
+// LoadPool reads the bonding curve. It fails with types.ErrPoolCompleted
+// once the curve completed.
+func (pumpFunDex) LoadPool(ctx context.Context, client *rpc.Client, req *types.Transact, buy bool) (PoolState, error) {
+	tokenMint := solana.MPK(lo.If(buy, req.TokenOut).Else(req.TokenIn))
+	account, err := client.GetAccountInfoWithOpts(ctx, pumpfun.FindBondingCurve(tokenMint), &rpc.GetAccountInfoOpts{Commitment: rpc.CommitmentConfirmed})
+	if err != nil {
+		return nil, err
+	}
+	var bondingCurve pumpfun.BondingCurve
+	err = borsh.Deserialize(&bondingCurve, account.Value.Data.GetBinary())
+	if err != nil {
+		return nil, err
+	}
+	if bondingCurve.Complete {
+		return nil, types.ErrPoolCompleted
+	}
+	return &pumpFunPool{bondingCurve: bondingCurve, buy: buy}, nil
+}
+
+func (pumpFunDex) ParseSwap(tx *rpc.GetTransactionResult, _ *solana.Transaction, instruction solana.CompiledInstruction, index uint16, _, _ solana.PublicKey) (*big.Int, *big.Int, bool) {
+	if len(instruction.Data) < 8 {
+		return nil, nil, false
+	}
+	if slices.Equal(instruction.Data[:8], pumpfun.Instruction_Buy[:]) {
+		solSwapped, tokenSwapped := pumpfun.ParseBuyInstruction(tx, index)
+		return solSwapped, tokenSwapped, true
+	}
+	if slices.Equal(instruction.Data[:8], pumpfun.Instruction_Sell[:]) {
+		solSwapped, tokenSwapped := pumpfun.ParseSellInstruction(tx, index)
+		return solSwapped, tokenSwapped, true
+	}
+	return nil, nil, false
+}
+
+func (p *pumpFunPool) Quote(token *common.Mint, amountIn uint64) (uint64, error) {
+	if p.buy {
+		return token.AmountAfterFee(pumpfun.BuyQuote(p.bondingCurve, amountIn)), nil
+	}
+	return pumpfun.SellQuote(p.bondingCurve, token.AmountAfterFee(amountIn)), nil
+}
+
+func (p *pumpFunPool) Instructions(req *SwapRequest) ([]solana.Instruction, error) {
+	if p.buy {
+		return pumpfun.BuyInstructions(
+			req.TokenMint,
+			req.Token,
+			req.Amount,
+			req.Slippage,
+			p.bondingCurve,
+			req.CreateAta,
+			req.Payer,
+		)
+	}
+	return pumpfun.SellInstructions(
+		req.TokenMint,
+		req.Token,
+		req.Amount,
+		req.Slippage,
+		p.bondingCurve,
+		req.CloseAta,
+		req.Payer,
+	)
+}
+
+func (pumpSwapDex) ID() int { return types.DexPumpSwap }
+
+func (pumpSwapDex) ProgramID() solana.PublicKey { return pumpswap.ProgramID }
+
+func (pumpSwapDex) FindPools(ctx context.Context, url string, mint solana.PublicKey) []*types.Pool {
+	pool, _ := pumpswap.GetPumpSwapPoolByToken(ctx, url, mint)
+	return found(pool)
+}
+
+func (pumpSwapDex) GetPool(ctx context.Context, url string, pool *types.Pool, owner string, withBalance bool) (*common.GetSolPoolResponse, *common.Balance, error) {
+	return pumpswap.GetPumpSwapPool(ctx, url, pool, owner, withBalance)
+}
+
+// LoadPool reads the pool and the global config, and takes the reserves
+// from req.
+func (pumpSwapDex) LoadPool(ctx context.Context, client *rpc.Client, req *types.Transact, buy bool) (PoolState, error) {
+	poolID := solana.MPK(req.PoolID)
+	pool, config, err := pumpswap.GetPoolState(ctx, client, poolID)
+	if err != nil {
+		return nil, err
+	}
+	return &pumpSwapPool{
+		poolID:       poolID,
+		pool:         pool,
+		config:       config,
+		reserveWsol:  req.QuoteReserve.Uint64(),
+		reserveToken: req.TokenReserve.Uint64(),
+		buy:          buy,
+	}, nil
+}
+
+func (pumpSwapDex) ParseSwap(tx *rpc.GetTransactionResult, transaction *solana.Transaction, instruction solana.CompiledInstruction, index uint16, _, _ solana.PublicKey) (*big.Int, *big.Int, bool) {
+	if len(instruction.Data) < 8 {
+		return nil, nil, false
+	}
+	if slices.Equal(instruction.Data[:8], pumpswap.Instruction_Buy[:]) {
+		solSwapped, tokenSwapped := pumpswap.ParseBuyInstruction(tx, &transaction.Message, index)
+		return solSwapped, tokenSwapped, true
+	}
+	if slices.Equal(instruction.Data[:8], pumpswap.Instruction_Sell[:]) {
+		solSwapped, tokenSwapped := pumpswap.ParseSellInstruction(tx, &transaction.Message, index)
+		return solSwapped, tokenSwapped, true
+	}
+	return nil, nil, false
+}
+
+func (p *pumpSwapPool) Quote(token *common.Mint, amountIn uint64) (uint64, error) {
+	if p.buy {
+		return token.AmountAfterFee(p.config.BuyQuote(p.pool, amountIn, p.reserveToken, p.reserveWsol)), nil
+	}
+	return p.config.SellQuote(p.pool, token.AmountAfterFee(amountIn), p.reserveToken, p.reserveWsol), nil
+}
+
+func (p *pumpSwapPool) Instructions(req *SwapRequest) ([]solana.Instruction, error) {
+	if p.buy {
+		return pumpswap.BuyInstructions(
+			p.poolID,
+			req.Token,
+			req.Amount,
+			req.Slippage,
+			p.reserveWsol,
+			p.reserveToken,
+			p.pool,
+			p.config,
+			req.CreateAta,
+			req.Payer,
+		)
+	}
+	return pumpswap.SellInstructions(
+		p.poolID,
+		req.Token,
+		req.Amount,
+		req.Slippage,
+		p.reserveWsol,
+		p.reserveToken,
+		p.pool,
+		p.config,
+		req.CloseAta,
+		req.Payer,
+	)
+}
@@ -0,0 +1,284 @@
+package sol
+
+import (
+	"context"
+	"math/big"
+	"slices"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/meme-bots/go-web3/sol/common"
+	"github.com/meme-bots/go-web3/sol/raydium"
+	"github.com/meme-bots/go-web3/types"
+	"github.com/near/borsh-go"
+)
+
+type (
+	// raydiumDex is Raydium AMM v4, which only pools legacy SPL tokens.
+	raydiumDex struct{}
+
+	raydiumPool struct {
+		marketID, marketProgramID solana.PublicKey
+		market                    raydium.Market
+		reserveWsol, reserveToken uint64
+		buy                       bool
+	}
+
+	cpmmDex struct{}
+
+	cpmmPool struct {
+		poolID                    solana.PublicKey
+		pool                      *raydium.CpmmPool
+		config                    *raydium.CpmmConfig
+		reserveWsol, reserveToken uint64
+		buy                       bool
+	}
+
+	clmmDex struct{}
+
+	clmmPool struct {
+		poolID     solana.PublicKey
+		pool       *raydium.ClmmPool
+		config     *raydium.ClmmConfig
+		tickArrays []*raydium.ClmmTickArray
+		zeroForOne bool
+		buy        bool
+	}
+)
+
+func (raydiumDex) ID() int { return types.DexRaydium }
+
+func (raydiumDex) ProgramID() solana.PublicKey { return raydium.ProgramID }
+
+func (raydiumDex) FindPools(ctx context.Context, url string, mint solana.PublicKey) []*types.Pool {
+	return findBaseAndQuote(ctx, url, mint, raydium.GetRaydiumPoolByToken)
+}
+
+func (raydiumDex) GetPool(ctx context.Context, url string, pool *types.Pool, owner string, withBalance bool) (*common.GetSolPoolResponse, *common.Balance, error) {
+	return raydium.GeRaydiumPoolP2(ctx, url, pool, owner, withBalance)
+}
+
+// LoadPool reads the market, and takes the reserves from req.
+func (raydiumDex) LoadPool(ctx context.Context, client *rpc.Client, req *types.Transact, buy bool) (PoolState, error) {
+	marketID := solana.MPK(req.MarketId)
+	account, err := client.GetAccountInfoWithOpts(ctx, marketID, &rpc.GetAccountInfoOpts{Commitment: rpc.CommitmentConfirmed})
+	if err != nil {
+		return nil, err
+	}
+	var market raydium.Market
+	err = borsh.Deserialize(&market, account.Value.Data.GetBinary())
+	if err != nil {
+		return nil, err
+	}
+	return &raydiumPool{
+		marketID:        marketID,
+		marketProgramID: solana.MPK(req.MarketProgramId),
+		market:          market,
+		reserveWsol:     req.QuoteReserve.Uint64(),
+		reserveToken:    req.TokenReserve.Uint64(),
+		buy:             buy,
+	}, nil
+}
+
+func (raydiumDex) ParseSwap(tx *rpc.GetTransactionResult, transaction *solana.Transaction, instruction solana.CompiledInstruction, index uint16, owner, token solana.PublicKey) (*big.Int, *big.Int, bool) {
+	if len(instruction.Data) == 0 || instruction.Data[0] != uint8(raydium.InstructionSwap) {
+		return nil, nil, false
+	}
+	ata := common.FindAssociatedTokenAddress(owner, token, tokenProgramIn(tx, token))
+	ataIndex, _ := transaction.GetAccountIndex(ata)
+	solSwapped, tokenSwapped := raydium.ParseSwapInstruction(tx, index, ataIndex)
+	return solSwapped, tokenSwapped, true
+}
+
+func (p *raydiumPool) Quote(_ *common.Mint, amountIn uint64) (uint64, error) {
+	if p.buy {
+		return raydium.Quote(amountIn, p.reserveWsol, p.reserveToken), nil
+	}
+	return raydium.Quote(amountIn, p.reserveToken, p.reserveWsol), nil
+}
+
+func (p *raydiumPool) Instructions(req *SwapRequest) ([]solana.Instruction, error) {
+	if p.buy {
+		return raydium.BuyInstructions(
+			p.marketID,
+			p.marketProgramID,
+			req.Amount,
+			req.Slippage,
+			p.reserveWsol,
+			p.reserveToken,
+			p.market,
+			req.CreateAta,
+			req.Payer,
+		)
+	}
+	return raydium.SellInstructions(
+		p.marketID,
+		p.marketProgramID,
+		req.Amount,
+		req.Slippage,
+		p.reserveWsol,
+		p.reserveToken,
+		p.market,
+		req.CloseAta,
+		req.Payer,
+	)
+}
+
+func (cpmmDex) ID() int { return types.DexRaydiumCpmm }
+
+func (cpmmDex) ProgramID() solana.PublicKey { return raydium.CpmmProgramID }
+
+func (cpmmDex) FindPools(ctx context.Context, url string, mint solana.PublicKey) []*types.Pool {
+	return findBaseAndQuote(ctx, url, mint, raydium.GetCpmmPoolByToken)
+}
+
+func (cpmmDex) GetPool(ctx context.Context, url string, pool *types.Pool, owner string, withBalance bool) (*common.GetSolPoolResponse, *common.Balance, error) {
+	return raydium.GetCpmmPool(ctx, url, pool, owner, withBalance)
+}
+
+// LoadPool reads the pool and its config, and takes the reserves from req.
+func (cpmmDex) LoadPool(ctx context.Context, client *rpc.Client, req *types.Transact, buy bool) (PoolState, error) {
+	poolID := solana.MPK(req.PoolID)
+	pool, config, err := raydium.GetCpmmPoolState(ctx, client, poolID)
+	if err != nil {
+		return nil, err
+	}
+	return &cpmmPool{
+		poolID:       poolID,
+		pool:         pool,
+		config:       config,
+		reserveWsol:  req.QuoteReserve.Uint64(),
+		reserveToken: req.TokenReserve.Uint64(),
+		buy:          buy,
+	}, nil
+}
+
+func (cpmmDex) ParseSwap(tx *rpc.GetTransactionResult, transaction *solana.Transaction, instruction solana.CompiledInstruction, index uint16, _, _ solana.PublicKey) (*big.Int, *big.Int, bool) {
+	if len(instruction.Data) < 8 ||
+		!slices.Equal(instruction.Data[:8], raydium.Instruction_CpmmSwapBaseInput[:]) && !slices.Equal(instruction.Data[:8], raydium.Instruction_CpmmSwapBaseOutput[:]) {
+		return nil, nil, false
+	}
+	solSwapped, tokenSwapped := raydium.ParseCpmmSwapInstruction(tx, &transaction.Message, instruction, index)
+	return solSwapped, tokenSwapped, true
+}
+
+func (p *cpmmPool) Quote(token *common.Mint, amountIn uint64) (uint64, error) {
+	if p.buy {
+		return token.AmountAfterFee(p.config.Quote(amountIn, p.reserveWsol, p.reserveToken)), nil
+	}
+	return p.config.Quote(token.AmountAfterFee(amountIn), p.reserveToken, p.reserveWsol), nil
+}
+
+func (p *cpmmPool) Instructions(req *SwapRequest) ([]solana.Instruction, error) {
+	if p.buy {
+		return raydium.CpmmBuyInstructions(
+			p.poolID,
+			req.Token,
+			req.Amount,
+			req.Slippage,
+			p.reserveWsol,
+			p.reserveToken,
+			p.pool,
+			p.config,
+			req.CreateAta,
+			req.Payer,
+		)
+	}
+	return raydium.CpmmSellInstructions(
+		p.poolID,
+		req.Token,
+		req.Amount,
+		req.Slippage,
+		p.reserveWsol,
+		p.reserveToken,
+		p.pool,
+		p.config,
+		req.CloseAta,
+		req.Payer,
+	)
+}
+
+func (clmmDex) ID() int { return types.DexRaydiumClmm }
+
+func (clmmDex) ProgramID() solana.PublicKey { return raydium.ClmmProgramID }
+
+func (clmmDex) FindPools(ctx context.Context, url string, mint solana.PublicKey) []*types.Pool {
+	return findBaseAndQuote(ctx, url, mint, raydium.GetClmmPoolByToken)
+}
+
+func (clmmDex) GetPool(ctx context.Context, url string, pool *types.Pool, owner string, withBalance bool) (*common.GetSolPoolResponse, *common.Balance, error) {
+	return raydium.GetClmmPool(ctx, url, pool, owner, withBalance)
+}
+
+// LoadPool reads the pool, its config and the tick arrays the swap runs
+// through.
+func (clmmDex) LoadPool(ctx context.Context, client *rpc.Client, req *types.Transact, buy bool) (PoolState, error) {
+	poolID := solana.MPK(req.PoolID)
+	pool, config, err := raydium.GetClmmPoolState(ctx, client, poolID)
+	if err != nil {
+		return nil, err
+	}
+	// token 0 goes in when buying with SOL as token 0 or selling token 0
+	zeroForOne := buy != pool.TokenIsToken0()
+	tickArrays, err := raydium.GetClmmTickArrays(ctx, client, poolID, pool, zeroForOne, raydium.ClmmSwapTickArrays)
+	if err != nil {
+		return nil, err
+	}
+	return &clmmPool{
+		poolID:     poolID,
+		pool:       pool,
+		config:     config,
+		tickArrays: tickArrays,
+		zeroForOne: zeroForOne,
+		buy:        buy,
+	}, nil
+}
+
+func (clmmDex) ParseSwap(tx *rpc.GetTransactionResult, transaction *solana.Transaction, instruction solana.CompiledInstruction, index uint16, _, _ solana.PublicKey) (*big.Int, *big.Int, bool) {
+	if len(instruction.Data) < 8 || !slices.Equal(instruction.Data[:8], raydium.Instruction_ClmmSwapV2[:]) {
+		return nil, nil, false
+	}
+	solSwapped, tokenSwapped := raydium.ParseClmmSwapInstruction(tx, &transaction.Message, instruction, index)
+	return solSwapped, tokenSwapped, true
+}
+
+func (p *clmmPool) Quote(token *common.Mint, amountIn uint64) (uint64, error) {
+	if !p.buy {
+		amountIn = token.AmountAfterFee(amountIn)
+	}
+	quote, err := p.pool.Quote(p.config, p.tickArrays, amountIn, p.zeroForOne, true)
+	if err != nil {
+		return 0, err
+	}
+	if p.buy {
+		return token.AmountAfterFee(quote.AmountOut), nil
+	}
+	return quote.AmountOut, nil
+}
+
+func (p *clmmPool) Instructions(req *SwapRequest) ([]solana.Instruction, error) {
+	if p.buy {
+		return raydium.ClmmBuyInstructions(
+			p.poolID,
+			req.Token,
+			req.Amount,
+			req.Slippage,
+			p.pool,
+			p.config,
+			p.tickArrays,
+			req.CreateAta,
+			req.Payer,
+		)
+	}
+	return raydium.ClmmSellInstructions(
+		p.poolID,
+		req.Token,
+		req.Amount,
+		req.Slippage,
+		p.pool,
+		p.config,
+		p.tickArrays,
+		req.CloseAta,
+		req.Payer,
+	)
+}
@@ -0,0 +1,69 @@
+package sol
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/meme-bots/go-web3/sol/common"
+	"github.com/meme-bots/go-web3/sol/raydium"
+	"github.com/meme-bots/go-web3/types"
+)
+
+func TestDexRegistry(t *testing.T) {
+	ids := []int{
+		types.DexRaydium,
+		types.DexPumpFun,
+		types.DexRaydiumCpmm,
+		types.DexRaydiumClmm,
+		types.DexPumpSwap,
+		types.DexMeteoraDlmm,
+		types.DexMeteoraDamm,
+		types.DexOrca,
+	}
+	if len(dexes) != len(ids) || len(dexByProgram) != len(ids) {
+		t.Fatalf("%d venues, %d programs registered", len(dexes), len(dexByProgram))
+	}
+	for _, id := range ids {
+		dex, ok := dexByID[id]
+		if !ok {
+			t.Errorf("dex %d is not registered", id)
+			continue
+		}
+		if dexByProgram[dex.ProgramID()] != dex {
+			t.Errorf("dex %d is not found by its program %s", id, dex.ProgramID())
+		}
+		// an instruction too short to name a swap is none
+		if _, _, ok = dex.ParseSwap(nil, nil, solana.CompiledInstruction{}, 0, solana.PublicKey{}, solana.PublicKey{}); ok {
+			t.Errorf("dex %d parsed an empty instruction as a swap", id)
+		}
+	}
+}
+
+func TestRaydiumDex_ParseSwap_Token2022(t *testing.T) {
+	owner := solana.NewWallet().PublicKey()
+	mint := solana.NewWallet().PublicKey()
+	ata := common.FindAssociatedTokenAddress(owner, mint, solana.Token2022ProgramID)
+	transaction := &solana.Transaction{Message: solana.Message{AccountKeys: solana.PublicKeySlice{
+		owner, ata, solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey(), raydium.ProgramID,
+	}}}
+
+	transfer := func(from, to uint16, amount uint64) solana.CompiledInstruction {
+		return solana.CompiledInstruction{Accounts: []uint16{from, to}, Data: binary.LittleEndian.AppendUint64([]byte{3}, amount)}
+	}
+	// a sell: the owner's tokens into the pool, then the pool's WSOL out
+	tx := &rpc.GetTransactionResult{Meta: &rpc.TransactionMeta{
+		InnerInstructions: []rpc.InnerInstruction{{
+			Index:        0,
+			Instructions: []solana.CompiledInstruction{transfer(1, 2, 500), transfer(3, 0, 700)},
+		}},
+		PostTokenBalances: []rpc.TokenBalance{{AccountIndex: 1, Mint: mint, ProgramId: &solana.Token2022ProgramID}},
+	}}
+
+	instruction := solana.CompiledInstruction{ProgramIDIndex: 4, Data: []byte{raydium.InstructionSwap}}
+	sol, tokens, ok := raydiumDex{}.ParseSwap(tx, transaction, instruction, 0, owner, mint)
+	if !ok || sol.Int64() != 700 || tokens.Int64() != -500 {
+		t.Errorf("ParseSwap = %s, %s, %v", sol, tokens, ok)
+	}
+}
@@ -0,0 +1,75 @@
+package sol
+
+import (
+	"github.com/gagliardetto/solana-go"
+	"github.com/meme-bots/go-web3/sol/common"
+	"github.com/meme-bots/go-web3/sol/pumpfun"
+	"github.com/meme-bots/go-web3/sol/raydium"
+	"github.com/samber/lo"
+)
+
+// LookupAddresses are the accounts every swap references, worth keeping in
+// a lookup table. Programs are left out since invoked programs must be
+// static keys, and so are the Jito tip accounts, which block engines only
+// see when they are.
+func LookupAddresses() []solana.PublicKey {
+	return []solana.PublicKey{
+		solana.SolMint,
+		solana.SysVarRentPubkey,
+		pumpfun.GlobalPubKey,
+		pumpfun.GlobalFeeRecipient,
+		pumpfun.MINT_AUTHORITY,
+		pumpfun.EventAuthority,
+		raydium.AuthorityV4,
+	}
+}
+
+// CreateLookupTable creates a lookup table with addresses, or with
+// LookupAddresses if none are given. Add the returned address to
+// Config.LookupTables to have swaps use it. Transfers stay legacy, as
+// their recipients are never in a table.
+func (s *Solana) CreateLookupTable(addresses []string, privateKey string) (string, error) {
+	keys := lo.Map(addresses, func(a string, _ int) solana.PublicKey { return solana.MPK(a) })
+	if len(keys) == 0 {
+		keys = LookupAddresses()
+	}
+
+	table, _, err := common.CreateLookupTable(s.ctx, s.cfg.RPC, keys, solana.MustPrivateKeyFromBase58(privateKey))
+	if err != nil {
+		return "", err
+	}
+	s.resetLookupTables()
+	return table.String(), nil
+}
+
+// ExtendLookupTable adds addresses, e.g. the accounts of a pool traded
+// often, to one of our lookup tables.
+func (s *Solana) ExtendLookupTable(table string, addresses []string, privateKey string) error {
+	keys := lo.Map(addresses, func(a string, _ int) solana.PublicKey { return solana.MPK(a) })
+	_, err := common.ExtendLookupTable(s.ctx, s.cfg.RPC, solana.MPK(table), keys, solana.MustPrivateKeyFromBase58(privateKey))
+	s.resetLookupTables()
+	return err
+}
+
+// lookupTables returns the configured tables, loading them on first use. A
+// failed load leaves transactions legacy rather than failing them.
+func (s *Solana) lookupTables() map[solana.PublicKey]solana.PublicKeySlice {
+	s.tablesMu.Lock()
+	defer s.tablesMu.Unlock()
+
+	if s.tables == nil && len(s.cfg.LookupTables) > 0 {
+		keys := lo.Map(s.cfg.LookupTables, func(a string, _ int) solana.PublicKey { return solana.MPK(a) })
+		tables, err := common.GetLookupTables(s.ctx, s.cfg.RPC, keys)
+		if err != nil {
+			return nil
+		}
+		s.tables = tables
+	}
+	return s.tables
+}
+
+func (s *Solana) resetLookupTables() {
+	s.tablesMu.Lock()
+	defer s.tablesMu.Unlock()
+	s.tables = nil
+}
@@ -0,0 +1,456 @@
+package meteora
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"time"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/token"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/meme-bots/go-web3/sol/common"
+	"github.com/meme-bots/go-web3/types"
+	"github.com/meme-bots/go-web3/utils"
+	"github.com/near/borsh-go"
+	"github.com/samber/lo"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	DammCurveConstantProduct = 0
+
+	// locked profit unlocks linearly at degradation / 1e12 per second
+	vaultLockedProfitDenominator = 1000000000000
+
+	dammTokenAMintOffset = 40
+	dammTokenBMintOffset = 72
+)
+
+var (
+	DammProgramID  = solana.MPK("Eo7WjKq67rjJQSZxS6z3YkapzY3eMj6Xy8X5EQVn5UaB")
+	VaultProgramID = solana.MPK("24Uqj9JCLxUeoC3hGfh5W3s9FM9uCHDS2SG3LYwBpyTi")
+
+	DammPoolDiscriminator = [8]byte{241, 154, 109, 4, 17, 177, 109, 188}
+	VaultDiscriminator    = [8]byte{211, 8, 232, 43, 2, 152, 117, 119}
+
+	Instruction_DammSwap = [8]byte{248, 198, 158, 145, 225, 117, 135, 200}
+
+	ErrNotDammPool = errors.New("not a meteora dynamic amm pool")
+)
+
+type (
+	DammPoolFees struct {
+		TradeFeeNumerator           uint64
+		TradeFeeDenominator         uint64
+		ProtocolTradeFeeNumerator   uint64
+		ProtocolTradeFeeDenominator uint64
+	}
+
+	// DammPool is a pool of the Meteora dynamic AMM. It holds no tokens of
+	// its own but LP tokens of two vaults that lend the tokens out.
+	DammPool struct {
+		Discriminator     [8]byte
+		LpMint            solana.PublicKey
+		TokenAMint        solana.PublicKey
+		TokenBMint        solana.PublicKey
+		AVault            solana.PublicKey
+		BVault            solana.PublicKey
+		AVaultLp          solana.PublicKey
+		BVaultLp          solana.PublicKey
+		AVaultLpBump      uint8
+		Enabled           bool
+		ProtocolTokenAFee solana.PublicKey
+		ProtocolTokenBFee solana.PublicKey
+		FeeLastUpdatedAt  uint64
+		Padding0          [24]uint8
+		Fees              DammPoolFees
+		PoolType          uint8
+		Stake             solana.PublicKey
+		TotalLockedLp     uint64
+		ActivationPoint   uint64
+		WhitelistedVault  solana.PublicKey
+		PoolCreator       solana.PublicKey
+		ActivationType    uint8
+		PartnerFee        uint64
+		PartnerAuthority  solana.PublicKey
+		PendingPartnerA   uint64
+		PendingPartnerB   uint64
+		Padding           [342]uint8
+		CurveType         uint8
+	}
+
+	VaultLockedProfitTracker struct {
+		LastUpdatedLockedProfit uint64
+		LastReport              uint64
+		LockedProfitDegradation uint64
+	}
+
+	DammVault struct {
+		Discriminator       [8]byte
+		Enabled             uint8
+		Bumps               [2]uint8
+		TotalAmount         uint64
+		TokenVault          solana.PublicKey
+		FeeVault            solana.PublicKey
+		TokenMint           solana.PublicKey
+		LpMint              solana.PublicKey
+		Strategies          [30]solana.PublicKey
+		Base                solana.PublicKey
+		Admin               solana.PublicKey
+		Operator            solana.PublicKey
+		LockedProfitTracker VaultLockedProfitTracker
+	}
+)
+
+func DecodeDammPool(data []byte) (*DammPool, error) {
+	var pool DammPool
+	err := bin.NewBorshDecoder(data).Decode(&pool)
+	if err != nil {
+		return nil, err
+	}
+	if pool.Discriminator != DammPoolDiscriminator {
+		return nil, ErrNotDammPool
+	}
+	return &pool, nil
+}
+
+func DecodeDammVault(data []byte) (*DammVault, error) {
+	var vault DammVault
+	err := borsh.Deserialize(&vault, data)
+	if err != nil {
+		return nil, err
+	}
+	if vault.Discriminator != VaultDiscriminator {
+		return nil, ErrNotDammPool
+	}
+	return &vault, nil
+}
+
+// UnlockedAmount is what the vault holds less the profit still locked
+// at now, in unix seconds.
+func (v *DammVault) UnlockedAmount(now int64) uint64 {
+	tracker := v.LockedProfitTracker
+	elapsed := uint64(max(now-int64(tracker.LastReport), 0))
+	ratio := new(big.Int).Mul(new(big.Int).SetUint64(elapsed), new(big.Int).SetUint64(tracker.LockedProfitDegradation))
+	if ratio.Cmp(big.NewInt(vaultLockedProfitDenominator)) >= 0 {
+		return v.TotalAmount
+	}
+	locked := new(big.Int).Sub(big.NewInt(vaultLockedProfitDenominator), ratio)
+	locked.Mul(locked, new(big.Int).SetUint64(tracker.LastUpdatedLockedProfit))
+	locked.Quo(locked, big.NewInt(vaultLockedProfitDenominator))
+	return v.TotalAmount - min(v.TotalAmount, locked.Uint64())
+}
+
+// VaultShare is the pool's share of the vault's unlocked amount for the
+// vault LP it holds.
+func VaultShare(vault *DammVault, poolLp, lpSupply uint64, now int64) uint64 {
+	if lpSupply == 0 {
+		return 0
+	}
+	share := new(big.Int).SetUint64(vault.UnlockedAmount(now))
+	share.Mul(share, new(big.Int).SetUint64(poolLp))
+	return share.Quo(share, new(big.Int).SetUint64(lpSupply)).Uint64()
+}
+
+// TokenIsA reports whether the non-SOL side of the pool is token A.
+func (p *DammPool) TokenIsA() bool {
+	return !p.TokenAMint.Equals(solana.SolMint)
+}
+
+// Mints returns the token and SOL mints of the pool, in that order.
+func (p *DammPool) Mints() (solana.PublicKey, solana.PublicKey) {
+	if p.TokenIsA() {
+		return p.TokenAMint, p.TokenBMint
+	}
+	return p.TokenBMint, p.TokenAMint
+}
+
+// Quote returns the output of selling amountIn into the pool after the
+// trade fee, which includes the protocol's share.
+func (p *DammPool) Quote(amountIn, reserveIn, reserveOut uint64) uint64 {
+	if p.Fees.TradeFeeDenominator == 0 {
+		return utils.CalculateOutput(amountIn, reserveIn, reserveOut)
+	}
+	fee := new(big.Int).Mul(new(big.Int).SetUint64(amountIn), new(big.Int).SetUint64(p.Fees.TradeFeeNumerator))
+	fee.Quo(fee, new(big.Int).SetUint64(p.Fees.TradeFeeDenominator))
+	return utils.CalculateOutput(amountIn-fee.Uint64(), reserveIn, reserveOut)
+}
+
+// FeeBps is the trade fee in basis points.
+func (p *DammPool) FeeBps() uint16 {
+	if p.Fees.TradeFeeDenominator == 0 {
+		return 0
+	}
+	return uint16(p.Fees.TradeFeeNumerator * 10000 / p.Fees.TradeFeeDenominator)
+}
+
+// GetDammPoolState reads a pool and its two vaults.
+func GetDammPoolState(ctx context.Context, client *rpc.Client, poolID solana.PublicKey) (*DammPool, *DammVault, *DammVault, error) {
+	info, err := client.GetAccountInfoWithOpts(ctx, poolID, &rpc.GetAccountInfoOpts{Commitment: rpc.CommitmentConfirmed})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	pool, err := DecodeDammPool(info.Value.Data.GetBinary())
+	if err != nil {
+		return nil, nil, nil, types.ErrInvalidPool
+	}
+
+	accounts, err := client.GetMultipleAccountsWithOpts(
+		ctx,
+		[]solana.PublicKey{pool.AVault, pool.BVault},
+		&rpc.GetMultipleAccountsOpts{Commitment: rpc.CommitmentConfirmed},
+	)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	var vaults []*DammVault
+	for _, account := range accounts.Value {
+		if account == nil {
+			return nil, nil, nil, types.ErrInvalidPool
+		}
+		vault, err := DecodeDammVault(account.Data.GetBinary())
+		if err != nil {
+			return nil, nil, nil, types.ErrInvalidPool
+		}
+		vaults = append(vaults, vault)
+	}
+	return pool, vaults[0], vaults[1], nil
+}
+
+// dammReserves works out the reserves of A and B from the accounts of the
+// pool's vault LP balances and the vault LP mints, in that order.
+func dammReserves(vaultA, vaultB *DammVault, accounts []*rpc.Account) (uint64, uint64, error) {
+	var amounts [4]uint64
+	for i, account := range accounts[:4] {
+		if account == nil {
+			return 0, 0, types.ErrInvalidPool
+		}
+		if i < 2 {
+			var lp token.Account
+			err := lp.UnmarshalWithDecoder(bin.NewBinDecoder(account.Data.GetBinary()))
+			if err != nil {
+				return 0, 0, err
+			}
+			amounts[i] = lp.Amount
+		} else {
+			var mint token.Mint
+			err := mint.UnmarshalWithDecoder(bin.NewBinDecoder(account.Data.GetBinary()))
+			if err != nil {
+				return 0, 0, err
+			}
+			amounts[i] = mint.Supply
+		}
+	}
+	now := time.Now().Unix()
+	return VaultShare(vaultA, amounts[0], amounts[2], now), VaultShare(vaultB, amounts[1], amounts[3], now), nil
+}
+
+func GetDammPoolByToken(ctx context.Context, url string, token solana.PublicKey, isBaseToken bool) (*types.Pool, error) {
+	client := rpc.New(url)
+	tokenA := lo.If(isBaseToken, token).Else(solana.SolMint)
+	tokenB := lo.If(isBaseToken, solana.SolMint).Else(token)
+
+	result, err := client.GetProgramAccountsWithOpts(ctx, DammProgramID, &rpc.GetProgramAccountsOpts{
+		Commitment: rpc.CommitmentConfirmed,
+		Encoding:   solana.EncodingBase64,
+		Filters: []rpc.RPCFilter{
+			{Memcmp: &rpc.RPCFilterMemcmp{Offset: 0, Bytes: DammPoolDiscriminator[:]}},
+			{Memcmp: &rpc.RPCFilterMemcmp{Offset: dammTokenAMintOffset, Bytes: tokenA.Bytes()}},
+			{Memcmp: &rpc.RPCFilterMemcmp{Offset: dammTokenBMintOffset, Bytes: tokenB.Bytes()}},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, account := range result {
+		pool, err := DecodeDammPool(account.Account.Data.GetBinary())
+		if err != nil || !pool.Enabled || pool.CurveType != DammCurveConstantProduct {
+			continue
+		}
+		return &types.Pool{
+			AmmPublicKey: account.Pubkey.String(),
+			BaseMint:     pool.TokenAMint.String(),
+			BaseVault:    pool.AVault.String(),
+			QuoteMint:    pool.TokenBMint.String(),
+			QuoteVault:   pool.BVault.String(),
+			LpMint:       pool.LpMint.String(),
+			OpenTime:     int64(pool.ActivationPoint),
+			Dex:          types.DexMeteoraDamm,
+			Marked:       true,
+		}, nil
+	}
+	return nil, nil
+}
+
+// GetDammPool reads price, reserves and fee of a dynamic AMM pool for
+// GetPool.
+func GetDammPool(ctx context.Context, url string, p *types.Pool, owner string, withBalance bool) (*common.GetSolPoolResponse, *common.Balance, error) {
+	poolID, err := solana.PublicKeyFromBase58(p.AmmPublicKey)
+	if err != nil {
+		return nil, nil, types.ErrInvalidPool
+	}
+
+	client := rpc.New(url)
+	pool, vaultA, vaultB, err := GetDammPoolState(ctx, client, poolID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !pool.TokenAMint.Equals(solana.SolMint) && !pool.TokenBMint.Equals(solana.SolMint) {
+		return nil, nil, types.ErrInvalidPool
+	}
+
+	mint, _ := pool.Mints()
+	metaAddress, _, _ := solana.FindTokenMetadataAddress(mint)
+	publicKeys := []solana.PublicKey{
+		pool.AVaultLp,
+		pool.BVaultLp,
+		vaultA.LpMint,
+		vaultB.LpMint,
+		mint,
+		pool.LpMint,
+		metaAddress,
+	}
+
+	if withBalance {
+		owner_ := solana.MPK(owner)
+		publicKeys = append(publicKeys, owner_)
+		publicKeys = append(publicKeys, common.TokenAccounts(owner_, mint)...)
+	}
+
+	accounts, err := client.GetMultipleAccountsWithOpts(
+		ctx,
+		publicKeys,
+		&rpc.GetMultipleAccountsOpts{Commitment: rpc.CommitmentProcessed},
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	balance := &common.Balance{NativeBalance: big.NewInt(0), TokenBalance: big.NewInt(0)}
+	if withBalance {
+		if accounts.Value[7] != nil {
+			balance.NativeBalance = new(big.Int).SetUint64(accounts.Value[7].Lamports)
+		}
+
+		balance.TokenBalance, err = common.TokenBalance(common.TokenAccountOf(accounts.Value[4], accounts.Value[8:]))
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if !pool.Enabled || pool.CurveType != DammCurveConstantProduct {
+		return nil, balance, types.ErrInvalidPool
+	}
+
+	reserveA, reserveB, err := dammReserves(vaultA, vaultB, accounts.Value)
+	if err != nil {
+		return nil, balance, err
+	}
+	tokenReserve, solReserve := lo.If(pool.TokenIsA(), reserveA).Else(reserveB), lo.If(pool.TokenIsA(), reserveB).Else(reserveA)
+	if tokenReserve == 0 || solReserve == 0 {
+		return nil, balance, types.ErrPoolCompleted
+	}
+
+	tokenMint, err := common.DecodeMint(accounts.Value[4])
+	if err != nil {
+		return nil, balance, err
+	}
+	err = tokenMint.SetEpoch(ctx, client)
+	if err != nil {
+		return nil, balance, err
+	}
+	var lpMint token.Mint
+	err = lpMint.UnmarshalWithDecoder(bin.NewBinDecoder(accounts.Value[5].Data.GetBinary()))
+	if err != nil {
+		return nil, balance, err
+	}
+
+	name, symbol, err := common.TokenName(tokenMint, mint, accounts.Value[6])
+	if err != nil {
+		return nil, balance, err
+	}
+
+	priceInSol := decimal.NewFromBigInt(new(big.Int).SetUint64(solReserve), -9).
+		Div(decimal.NewFromBigInt(new(big.Int).SetUint64(tokenReserve), -int32(tokenMint.Decimals)))
+	totalSupply := decimal.NewFromUint64(tokenMint.Supply).Div(decimal.New(1, int32(tokenMint.Decimals)))
+
+	ret := &common.GetSolPoolResponse{
+		PriceInSol:            priceInSol,
+		TotalSupply:           totalSupply,
+		FreezeDisabled:        tokenMint.FreezeAuthority == nil,
+		Burnt:                 lpMint.Supply <= pool.TotalLockedLp, // locked for good rather than burnt
+		MintAuthorityDisabled: tokenMint.MintAuthority == nil,
+		TokenReserve:          new(big.Int).SetUint64(tokenReserve),
+		SolReserve:            new(big.Int).SetUint64(solReserve),
+		Name:                  name,
+		Symbol:                symbol,
+		Decimals:              tokenMint.Decimals,
+		QuoteDecimals:         9,
+		TokenAddress:          mint.String(),
+		QuoteAddress:          solana.SolMint.String(),
+		PoolAddress:           p.AmmPublicKey,
+		PoolFeeBps:            pool.FeeBps(),
+	}
+	ret.SetMint(tokenMint)
+	return ret, balance, nil
+}
+
+type DammSwapParam struct {
+	PoolID solana.PublicKey
+	Pool   *DammPool
+	VaultA *DammVault
+	VaultB *DammVault
+	Payer  solana.PublicKey
+
+	AmountIn     uint64
+	MinAmountOut uint64
+
+	InputMint     solana.PublicKey
+	SourceAccount solana.PublicKey
+	DestAccount   solana.PublicKey
+}
+
+// NewDammSwapInstruction swaps exactly AmountIn of InputMint for at least
+// MinAmountOut. The protocol fee is paid in the input token.
+func NewDammSwapInstruction(param DammSwapParam) solana.Instruction {
+	data, err := borsh.Serialize(struct {
+		Discriminator [8]byte
+		AmountIn      uint64
+		MinAmountOut  uint64
+	}{
+		Discriminator: Instruction_DammSwap,
+		AmountIn:      param.AmountIn,
+		MinAmountOut:  param.MinAmountOut,
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	pool := param.Pool
+	protocolFee := lo.If(param.InputMint.Equals(pool.TokenAMint), pool.ProtocolTokenAFee).Else(pool.ProtocolTokenBFee)
+
+	return &solana.GenericInstruction{
+		ProgID: DammProgramID,
+		AccountValues: solana.AccountMetaSlice{
+			{PublicKey: param.PoolID, IsSigner: false, IsWritable: true},
+			{PublicKey: param.SourceAccount, IsSigner: false, IsWritable: true},
+			{PublicKey: param.DestAccount, IsSigner: false, IsWritable: true},
+			{PublicKey: pool.AVault, IsSigner: false, IsWritable: true},
+			{PublicKey: pool.BVault, IsSigner: false, IsWritable: true},
+			{PublicKey: param.VaultA.TokenVault, IsSigner: false, IsWritable: true},
+			{PublicKey: param.VaultB.TokenVault, IsSigner: false, IsWritable: true},
+			{PublicKey: param.VaultA.LpMint, IsSigner: false, IsWritable: true},
+			{PublicKey: param.VaultB.LpMint, IsSigner: false, IsWritable: true},
+			{PublicKey: pool.AVaultLp, IsSigner: false, IsWritable: true},
+			{PublicKey: pool.BVaultLp, IsSigner: false, IsWritable: true},
+			{PublicKey: protocolFee, IsSigner: false, IsWritable: true},
+			{PublicKey: param.Payer, IsSigner: true, IsWritable: false},
+			{PublicKey: VaultProgramID, IsSigner: false, IsWritable: false},
+			{PublicKey: solana.TokenProgramID, IsSigner: false, IsWritable: false},
+		},
+		DataBytes: data,
+	}
+}
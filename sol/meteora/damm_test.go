@@ -0,0 +1,43 @@
+package meteora
+
+import (
+	"testing"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+)
+
+func TestDecodeDammPool_Offsets(t *testing.T) {
+	tokenA := solana.NewWallet().PublicKey()
+	data, err := bin.MarshalBorsh(&DammPool{Discriminator: DammPoolDiscriminator, TokenAMint: tokenA, TokenBMint: solana.SolMint, Enabled: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// GetProgramAccounts filters match the mints at these offsets
+	if got := solana.PublicKeyFromBytes(data[dammTokenAMintOffset : dammTokenAMintOffset+32]); !got.Equals(tokenA) {
+		t.Errorf("token A at %d = %s", dammTokenAMintOffset, got)
+	}
+	if got := solana.PublicKeyFromBytes(data[dammTokenBMintOffset : dammTokenBMintOffset+32]); !got.Equals(solana.SolMint) {
+		t.Errorf("token B at %d = %s", dammTokenBMintOffset, got)
+	}
+}
+
+func TestDammVault_UnlockedAmount(t *testing.T) {
+	vault := &DammVault{
+		TotalAmount: 1000,
+		LockedProfitTracker: VaultLockedProfitTracker{
+			LastUpdatedLockedProfit: 100,
+			LastReport:              1000,
+			LockedProfitDegradation: vaultLockedProfitDenominator / 100, // unlocks over 100s
+		},
+	}
+	for now, want := range map[int64]uint64{1000: 900, 1050: 950, 1100: 1000, 2000: 1000} {
+		if got := vault.UnlockedAmount(now); got != want {
+			t.Errorf("UnlockedAmount(%d) = %d, want %d", now, got, want)
+		}
+	}
+	// the pool owns a quarter of the vault LP
+	if got := VaultShare(vault, 25, 100, 1050); got != 237 {
+		t.Errorf("VaultShare = %d, want 237", got)
+	}
+}
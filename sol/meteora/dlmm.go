@@ -0,0 +1,508 @@
+package meteora
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"math/big"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/token"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/meme-bots/go-web3/sol/common"
+	"github.com/meme-bots/go-web3/types"
+	"github.com/near/borsh-go"
+	"github.com/samber/lo"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	DlmmPoolSize = 904
+
+	DlmmBinsPerArray = 70
+
+	// the pool's bitmap tracks 512 bin arrays either side of bin 0
+	dlmmBitmapOffset = 512
+
+	DlmmStatusEnabled = 0
+
+	dlmmTokenXMintOffset = 88
+	dlmmTokenYMintOffset = 120
+)
+
+var (
+	DlmmProgramID      = solana.MPK("LBUZKhRxPF3XUpBCjp4YzTKgLccjZhTSDM9YuVaPwxo")
+	DlmmEventAuthority = solana.MPK("D1ZN9Wj1fRSUQfCjhvnu1hqDMT7hzjzBBpi12nVniYD6")
+
+	DlmmPoolDiscriminator     = [8]byte{33, 11, 49, 98, 181, 101, 177, 13}
+	DlmmBinArrayDiscriminator = [8]byte{92, 142, 92, 220, 5, 148, 70, 181}
+
+	Instruction_DlmmSwap = [8]byte{248, 198, 158, 145, 225, 117, 135, 200}
+
+	ErrNotDlmmPool = errors.New("not a meteora dlmm pool")
+)
+
+type (
+	DlmmStaticParameters struct {
+		BaseFactor               uint16
+		FilterPeriod             uint16
+		DecayPeriod              uint16
+		ReductionFactor          uint16
+		VariableFeeControl       uint32
+		MaxVolatilityAccumulator uint32
+		MinBinID                 int32
+		MaxBinID                 int32
+		ProtocolShare            uint16
+		BaseFeePowerFactor       uint8
+		Padding                  [5]uint8
+	}
+
+	DlmmVariableParameters struct {
+		VolatilityAccumulator uint32
+		VolatilityReference   uint32
+		IndexReference        int32
+		Padding               [4]uint8
+		LastUpdateTimestamp   int64
+		Padding1              [8]uint8
+	}
+
+	// DlmmPool is the LbPair account of the Meteora DLMM program. Liquidity
+	// sits in bins of fixed price, the active bin holds both tokens.
+	DlmmPool struct {
+		Discriminator           [8]byte
+		Parameters              DlmmStaticParameters
+		VParameters             DlmmVariableParameters
+		BumpSeed                [1]uint8
+		BinStepSeed             [2]uint8
+		PairType                uint8
+		ActiveID                int32
+		BinStep                 uint16
+		Status                  uint8
+		RequireBaseFactorSeed   uint8
+		BaseFactorSeed          [2]uint8
+		ActivationType          uint8
+		CreatorPoolOnOffControl uint8
+		TokenXMint              solana.PublicKey
+		TokenYMint              solana.PublicKey
+		ReserveX                solana.PublicKey
+		ReserveY                solana.PublicKey
+		ProtocolFeeX            uint64
+		ProtocolFeeY            uint64
+		Padding1                [32]uint8
+		RewardInfos             [288]uint8
+		Oracle                  solana.PublicKey
+		BinArrayBitmap          [16]uint64
+		LastUpdatedAt           int64
+		Padding2                [32]uint8
+		PreActivationSwap       solana.PublicKey
+		BaseKey                 solana.PublicKey
+		ActivationPoint         uint64
+		PreActivationDuration   uint64
+		Padding3                [8]uint8
+		Padding4                uint64
+		Creator                 solana.PublicKey
+		TokenMintXProgramFlag   uint8
+		TokenMintYProgramFlag   uint8
+		Reserved                [22]uint8
+	}
+
+	DlmmBin struct {
+		AmountX                  uint64
+		AmountY                  uint64
+		Price                    bin.Uint128
+		LiquiditySupply          bin.Uint128
+		RewardPerTokenStored     [2]bin.Uint128
+		FeeAmountXPerTokenStored bin.Uint128
+		FeeAmountYPerTokenStored bin.Uint128
+		AmountXIn                bin.Uint128
+		AmountYIn                bin.Uint128
+	}
+
+	DlmmBinArray struct {
+		Discriminator [8]byte
+		Index         int64
+		Version       uint8
+		Padding       [7]uint8
+		LbPair        solana.PublicKey
+		Bins          [DlmmBinsPerArray]DlmmBin
+	}
+)
+
+func DecodeDlmmPool(data []byte) (*DlmmPool, error) {
+	var pool DlmmPool
+	err := bin.NewBorshDecoder(data).Decode(&pool)
+	if err != nil {
+		return nil, err
+	}
+	if pool.Discriminator != DlmmPoolDiscriminator {
+		return nil, ErrNotDlmmPool
+	}
+	return &pool, nil
+}
+
+func DecodeDlmmBinArray(data []byte) (*DlmmBinArray, error) {
+	var array DlmmBinArray
+	err := bin.NewBorshDecoder(data).Decode(&array)
+	if err != nil {
+		return nil, err
+	}
+	if array.Discriminator != DlmmBinArrayDiscriminator {
+		return nil, ErrNotDlmmPool
+	}
+	return &array, nil
+}
+
+// TokenIsX reports whether the non-SOL side of the pool is token X.
+func (p *DlmmPool) TokenIsX() bool {
+	return !p.TokenXMint.Equals(solana.SolMint)
+}
+
+// Mints returns the token and SOL mints of the pool, in that order.
+func (p *DlmmPool) Mints() (solana.PublicKey, solana.PublicKey) {
+	if p.TokenIsX() {
+		return p.TokenXMint, p.TokenYMint
+	}
+	return p.TokenYMint, p.TokenXMint
+}
+
+// TokenPrograms returns the token programs of X and Y.
+func (p *DlmmPool) TokenPrograms() (solana.PublicKey, solana.PublicKey) {
+	program := func(flag uint8) solana.PublicKey {
+		return lo.If(flag == 1, solana.Token2022ProgramID).Else(solana.TokenProgramID)
+	}
+	return program(p.TokenMintXProgramFlag), program(p.TokenMintYProgramFlag)
+}
+
+// PriceInSol is the price of the active bin in SOL per token, in whole
+// units.
+func (p *DlmmPool) PriceInSol(tokenDecimals uint8) decimal.Decimal {
+	price := decimal.NewFromBigInt(DlmmPriceAtBin(p.ActiveID, p.BinStep), 0).Div(decimal.NewFromBigInt(q64, 0))
+	if p.TokenIsX() {
+		return price.Shift(int32(tokenDecimals) - 9)
+	}
+	return decimal.New(1, 0).Div(price).Shift(int32(tokenDecimals) - 9)
+}
+
+// FeeBps is the base fee in basis points, the variable fee comes on top
+// while the price moves.
+func (p *DlmmPool) FeeBps() uint16 {
+	return uint16(p.baseFeeRate() * 10000 / DlmmFeePrecision)
+}
+
+// DlmmBinArrayIndex returns the bin array holding binID.
+func DlmmBinArrayIndex(binID int32) int64 {
+	index := int64(binID) / DlmmBinsPerArray
+	if binID < 0 && int64(binID)%DlmmBinsPerArray != 0 {
+		index--
+	}
+	return index
+}
+
+func FindDlmmBinArray(poolID solana.PublicKey, index int64) solana.PublicKey {
+	address, _, _ := solana.FindProgramAddress(
+		[][]byte{[]byte("bin_array"), poolID.Bytes(), binary.LittleEndian.AppendUint64(nil, uint64(index))},
+		DlmmProgramID,
+	)
+	return address
+}
+
+func FindDlmmBitmapExtension(poolID solana.PublicKey) solana.PublicKey {
+	address, _, _ := solana.FindProgramAddress(
+		[][]byte{[]byte("bitmap"), poolID.Bytes()},
+		DlmmProgramID,
+	)
+	return address
+}
+
+// BitmapExtension is the extension account a swap passes when the bin
+// arrays it crosses lie beyond the pool's own bitmap, else the program ID
+// standing in for none.
+func (p *DlmmPool) BitmapExtension(poolID solana.PublicKey, binArrays []*DlmmBinArray) solana.PublicKey {
+	for _, array := range binArrays {
+		if array.Index < -dlmmBitmapOffset || array.Index >= dlmmBitmapOffset {
+			return FindDlmmBitmapExtension(poolID)
+		}
+	}
+	return DlmmProgramID
+}
+
+// GetDlmmPoolState reads a pool.
+func GetDlmmPoolState(ctx context.Context, client *rpc.Client, poolID solana.PublicKey) (*DlmmPool, error) {
+	info, err := client.GetAccountInfoWithOpts(ctx, poolID, &rpc.GetAccountInfoOpts{Commitment: rpc.CommitmentConfirmed})
+	if err != nil {
+		return nil, err
+	}
+	pool, err := DecodeDlmmPool(info.Value.Data.GetBinary())
+	if err != nil {
+		return nil, types.ErrInvalidPool
+	}
+	return pool, nil
+}
+
+// GetDlmmBinArrays reads up to n initialized bin arrays from the active
+// one in the direction of the swap, in the order the swap crosses them.
+func GetDlmmBinArrays(ctx context.Context, client *rpc.Client, poolID solana.PublicKey, pool *DlmmPool, swapForY bool, n int) ([]*DlmmBinArray, error) {
+	start := DlmmBinArrayIndex(pool.ActiveID)
+	step := lo.If(swapForY, int64(-1)).Else(1)
+	var addresses []solana.PublicKey
+	for i := 0; i < n; i++ {
+		addresses = append(addresses, FindDlmmBinArray(poolID, start+int64(i)*step))
+	}
+
+	accounts, err := client.GetMultipleAccountsWithOpts(ctx, addresses, &rpc.GetMultipleAccountsOpts{Commitment: rpc.CommitmentConfirmed})
+	if err != nil {
+		return nil, err
+	}
+	var binArrays []*DlmmBinArray
+	for _, account := range accounts.Value {
+		if account == nil {
+			continue
+		}
+		array, err := DecodeDlmmBinArray(account.Data.GetBinary())
+		if err != nil {
+			return nil, err
+		}
+		binArrays = append(binArrays, array)
+	}
+	if len(binArrays) == 0 {
+		return nil, ErrDlmmBinArrays
+	}
+	return binArrays, nil
+}
+
+func GetDlmmPoolByToken(ctx context.Context, url string, token solana.PublicKey, isBaseToken bool) (*types.Pool, error) {
+	client := rpc.New(url)
+	tokenX := lo.If(isBaseToken, token).Else(solana.SolMint)
+	tokenY := lo.If(isBaseToken, solana.SolMint).Else(token)
+
+	result, err := client.GetProgramAccountsWithOpts(ctx, DlmmProgramID, &rpc.GetProgramAccountsOpts{
+		Commitment: rpc.CommitmentConfirmed,
+		Encoding:   solana.EncodingBase64,
+		Filters: []rpc.RPCFilter{
+			{DataSize: DlmmPoolSize},
+			{Memcmp: &rpc.RPCFilterMemcmp{Offset: dlmmTokenXMintOffset, Bytes: tokenX.Bytes()}},
+			{Memcmp: &rpc.RPCFilterMemcmp{Offset: dlmmTokenYMintOffset, Bytes: tokenY.Bytes()}},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// a pair has a pool per bin step, take the one holding the most SOL
+	var pools []*DlmmPool
+	var poolIDs, solReserves []solana.PublicKey
+	for _, account := range result {
+		pool, err := DecodeDlmmPool(account.Account.Data.GetBinary())
+		if err != nil || pool.Status != DlmmStatusEnabled {
+			continue
+		}
+		pools = append(pools, pool)
+		poolIDs = append(poolIDs, account.Pubkey)
+		solReserves = append(solReserves, lo.If(isBaseToken, pool.ReserveY).Else(pool.ReserveX))
+	}
+	if len(pools) == 0 {
+		return nil, nil
+	}
+
+	best := 0
+	if len(pools) > 1 {
+		accounts, err := client.GetMultipleAccountsWithOpts(ctx, solReserves, &rpc.GetMultipleAccountsOpts{Commitment: rpc.CommitmentConfirmed})
+		if err != nil {
+			return nil, err
+		}
+		var bestReserve *big.Int
+		for i, account := range accounts.Value {
+			reserve, err := common.TokenBalance(account)
+			if err != nil {
+				continue
+			}
+			if bestReserve == nil || reserve.Cmp(bestReserve) > 0 {
+				best, bestReserve = i, reserve
+			}
+		}
+	}
+
+	pool := pools[best]
+	return &types.Pool{
+		AmmPublicKey: poolIDs[best].String(),
+		BaseMint:     pool.TokenXMint.String(),
+		BaseVault:    pool.ReserveX.String(),
+		QuoteMint:    pool.TokenYMint.String(),
+		QuoteVault:   pool.ReserveY.String(),
+		Status:       int(pool.Status),
+		Dex:          types.DexMeteoraDlmm,
+		Marked:       true,
+	}, nil
+}
+
+// GetDlmmPool reads price, reserves and fee of a DLMM pool for GetPool.
+// The price is the active bin's; the reserves are whole reserve balances.
+func GetDlmmPool(ctx context.Context, url string, p *types.Pool, owner string, withBalance bool) (*common.GetSolPoolResponse, *common.Balance, error) {
+	poolID, err := solana.PublicKeyFromBase58(p.AmmPublicKey)
+	if err != nil {
+		return nil, nil, types.ErrInvalidPool
+	}
+
+	client := rpc.New(url)
+	pool, err := GetDlmmPoolState(ctx, client, poolID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !pool.TokenXMint.Equals(solana.SolMint) && !pool.TokenYMint.Equals(solana.SolMint) {
+		return nil, nil, types.ErrInvalidPool
+	}
+
+	mint, _ := pool.Mints()
+	metaAddress, _, _ := solana.FindTokenMetadataAddress(mint)
+	publicKeys := []solana.PublicKey{
+		pool.ReserveX,
+		pool.ReserveY,
+		mint,
+		metaAddress,
+	}
+
+	if withBalance {
+		owner_ := solana.MPK(owner)
+		publicKeys = append(publicKeys, owner_)
+		publicKeys = append(publicKeys, common.TokenAccounts(owner_, mint)...)
+	}
+
+	accounts, err := client.GetMultipleAccountsWithOpts(
+		ctx,
+		publicKeys,
+		&rpc.GetMultipleAccountsOpts{Commitment: rpc.CommitmentProcessed},
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	balance := &common.Balance{NativeBalance: big.NewInt(0), TokenBalance: big.NewInt(0)}
+	if withBalance {
+		if accounts.Value[4] != nil {
+			balance.NativeBalance = new(big.Int).SetUint64(accounts.Value[4].Lamports)
+		}
+
+		balance.TokenBalance, err = common.TokenBalance(common.TokenAccountOf(accounts.Value[2], accounts.Value[5:]))
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if pool.Status != DlmmStatusEnabled {
+		return nil, balance, types.ErrInvalidPool
+	}
+
+	var reserveX, reserveY token.Account
+	for i, reserve := range []*token.Account{&reserveX, &reserveY} {
+		if accounts.Value[i] == nil {
+			return nil, balance, types.ErrInvalidPool
+		}
+		err = reserve.UnmarshalWithDecoder(bin.NewBinDecoder(accounts.Value[i].Data.GetBinary()))
+		if err != nil {
+			return nil, balance, err
+		}
+	}
+	amountX := reserveX.Amount - min(reserveX.Amount, pool.ProtocolFeeX)
+	amountY := reserveY.Amount - min(reserveY.Amount, pool.ProtocolFeeY)
+	tokenReserve, solReserve := lo.If(pool.TokenIsX(), amountX).Else(amountY), lo.If(pool.TokenIsX(), amountY).Else(amountX)
+	if tokenReserve == 0 || solReserve == 0 {
+		return nil, balance, types.ErrPoolCompleted
+	}
+
+	tokenMint, err := common.DecodeMint(accounts.Value[2])
+	if err != nil {
+		return nil, balance, err
+	}
+	err = tokenMint.SetEpoch(ctx, client)
+	if err != nil {
+		return nil, balance, err
+	}
+
+	name, symbol, err := common.TokenName(tokenMint, mint, accounts.Value[3])
+	if err != nil {
+		return nil, balance, err
+	}
+
+	totalSupply := decimal.NewFromUint64(tokenMint.Supply).Div(decimal.New(1, int32(tokenMint.Decimals)))
+
+	ret := &common.GetSolPoolResponse{
+		PriceInSol:            pool.PriceInSol(tokenMint.Decimals),
+		TotalSupply:           totalSupply,
+		FreezeDisabled:        tokenMint.FreezeAuthority == nil,
+		Burnt:                 false, // positions are not tokens and cannot be burnt
+		MintAuthorityDisabled: tokenMint.MintAuthority == nil,
+		TokenReserve:          new(big.Int).SetUint64(tokenReserve),
+		SolReserve:            new(big.Int).SetUint64(solReserve),
+		Name:                  name,
+		Symbol:                symbol,
+		Decimals:              tokenMint.Decimals,
+		QuoteDecimals:         9,
+		TokenAddress:          mint.String(),
+		QuoteAddress:          solana.SolMint.String(),
+		PoolAddress:           p.AmmPublicKey,
+		PoolFeeBps:            pool.FeeBps(),
+	}
+	ret.SetMint(tokenMint)
+	return ret, balance, nil
+}
+
+type DlmmSwapParam struct {
+	PoolID    solana.PublicKey
+	Pool      *DlmmPool
+	BinArrays []*DlmmBinArray
+	Payer     solana.PublicKey
+
+	AmountIn     uint64
+	MinAmountOut uint64
+
+	SourceAccount solana.PublicKey
+	DestAccount   solana.PublicKey
+}
+
+// NewDlmmSwapInstruction swaps exactly AmountIn for at least MinAmountOut,
+// crossing the bins of BinArrays. Which way it swaps follows from the
+// accounts the tokens come from and go to.
+func NewDlmmSwapInstruction(param DlmmSwapParam) solana.Instruction {
+	data, err := borsh.Serialize(struct {
+		Discriminator [8]byte
+		AmountIn      uint64
+		MinAmountOut  uint64
+	}{
+		Discriminator: Instruction_DlmmSwap,
+		AmountIn:      param.AmountIn,
+		MinAmountOut:  param.MinAmountOut,
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	pool := param.Pool
+	programX, programY := pool.TokenPrograms()
+	accounts := solana.AccountMetaSlice{
+		{PublicKey: param.PoolID, IsSigner: false, IsWritable: true},
+		{PublicKey: pool.BitmapExtension(param.PoolID, param.BinArrays), IsSigner: false, IsWritable: false},
+		{PublicKey: pool.ReserveX, IsSigner: false, IsWritable: true},
+		{PublicKey: pool.ReserveY, IsSigner: false, IsWritable: true},
+		{PublicKey: param.SourceAccount, IsSigner: false, IsWritable: true},
+		{PublicKey: param.DestAccount, IsSigner: false, IsWritable: true},
+		{PublicKey: pool.TokenXMint, IsSigner: false, IsWritable: false},
+		{PublicKey: pool.TokenYMint, IsSigner: false, IsWritable: false},
+		{PublicKey: pool.Oracle, IsSigner: false, IsWritable: true},
+		{PublicKey: DlmmProgramID, IsSigner: false, IsWritable: false}, // no host fee
+		{PublicKey: param.Payer, IsSigner: true, IsWritable: false},
+		{PublicKey: programX, IsSigner: false, IsWritable: false},
+		{PublicKey: programY, IsSigner: false, IsWritable: false},
+		{PublicKey: DlmmEventAuthority, IsSigner: false, IsWritable: false},
+		{PublicKey: DlmmProgramID, IsSigner: false, IsWritable: false},
+	}
+	for _, array := range param.BinArrays {
+		accounts = append(accounts, &solana.AccountMeta{PublicKey: FindDlmmBinArray(param.PoolID, array.Index), IsSigner: false, IsWritable: true})
+	}
+
+	return &solana.GenericInstruction{
+		ProgID:        DlmmProgramID,
+		AccountValues: accounts,
+		DataBytes:     data,
+	}
+}
@@ -0,0 +1,141 @@
+package meteora
+
+import (
+	"errors"
+	"math"
+	"math/big"
+)
+
+const (
+	// fee rates are in billionths
+	DlmmFeePrecision = 1000000000
+	DlmmMaxFeeRate   = 100000000
+)
+
+var (
+	ErrDlmmBinArrays = errors.New("swap runs past the loaded bin arrays")
+
+	q64 = new(big.Int).Lsh(big.NewInt(1), 64)
+)
+
+// DlmmQuote is the result of walking a swap through the pool's bins.
+type DlmmQuote struct {
+	AmountIn  uint64 // including the fee
+	AmountOut uint64
+	Fee       uint64
+	EndBinID  int32
+}
+
+// DlmmPriceAtBin returns (1 + binStep / 10000)^binID, the price of X in Y
+// in their smallest units, as a Q64.64 number. It is computed in floating
+// point, which is close enough to quote with.
+func DlmmPriceAtBin(binID int32, binStep uint16) *big.Int {
+	price := new(big.Float).SetFloat64(math.Pow(1+float64(binStep)/10000, float64(binID)))
+	price.Mul(price, new(big.Float).SetInt(q64))
+	ret, _ := price.Int(nil)
+	return ret
+}
+
+func (p *DlmmPool) baseFeeRate() uint64 {
+	return uint64(p.Parameters.BaseFactor) * uint64(p.BinStep) * 10 * uint64(math.Pow10(int(p.Parameters.BaseFeePowerFactor)))
+}
+
+// variableFeeRate grows with the square of the recent volatility.
+func (p *DlmmPool) variableFeeRate() uint64 {
+	if p.Parameters.VariableFeeControl == 0 {
+		return 0
+	}
+	vfa := new(big.Int).SetUint64(uint64(p.VParameters.VolatilityAccumulator) * uint64(p.BinStep))
+	fee := new(big.Int).Mul(vfa, vfa)
+	fee.Mul(fee, big.NewInt(int64(p.Parameters.VariableFeeControl)))
+	fee.Add(fee, big.NewInt(99999999999))
+	return fee.Quo(fee, big.NewInt(100000000000)).Uint64()
+}
+
+// FeeRate is the total fee rate at the current volatility.
+func (p *DlmmPool) FeeRate() uint64 {
+	return min(p.baseFeeRate()+p.variableFeeRate(), DlmmMaxFeeRate)
+}
+
+// Quote walks a swap of amountIn, fee included, through the bins of
+// binArrays, which must cover the bins the price moves through. swapForY
+// sells X for Y. The fee rate is held at the current one while the real
+// swap raises it as it crosses bins, so the quote is slightly generous.
+func (p *DlmmPool) Quote(binArrays []*DlmmBinArray, amountIn uint64, swapForY bool) (*DlmmQuote, error) {
+	bins := make(map[int32]*DlmmBin)
+	for _, array := range binArrays {
+		for i := range array.Bins {
+			bins[int32(array.Index*DlmmBinsPerArray)+int32(i)] = &array.Bins[i]
+		}
+	}
+
+	feeRate := new(big.Int).SetUint64(p.FeeRate())
+	feeComplement := new(big.Int).Sub(big.NewInt(DlmmFeePrecision), feeRate)
+	remaining := new(big.Int).SetUint64(amountIn)
+	amountOut, fee := new(big.Int), new(big.Int)
+	activeID := p.ActiveID
+
+	for remaining.Sign() > 0 {
+		if activeID < p.Parameters.MinBinID || activeID > p.Parameters.MaxBinID {
+			return nil, ErrDlmmBinArrays
+		}
+		current, ok := bins[activeID]
+		if !ok {
+			return nil, ErrDlmmBinArrays
+		}
+		price := current.Price.BigInt()
+		if price.Sign() == 0 {
+			price = DlmmPriceAtBin(activeID, p.BinStep)
+		}
+
+		var reserveOut, maxIn *big.Int
+		if swapForY {
+			reserveOut = new(big.Int).SetUint64(current.AmountY)
+			maxIn = div(new(big.Int).Lsh(reserveOut, 64), price, true)
+		} else {
+			reserveOut = new(big.Int).SetUint64(current.AmountX)
+			maxIn = div(new(big.Int).Mul(reserveOut, price), q64, true)
+		}
+		maxFee := div(new(big.Int).Mul(maxIn, feeRate), feeComplement, true)
+
+		if maxIn.Sign() > 0 {
+			if maxTotal := new(big.Int).Add(maxIn, maxFee); remaining.Cmp(maxTotal) >= 0 {
+				amountOut.Add(amountOut, reserveOut)
+				fee.Add(fee, maxFee)
+				remaining.Sub(remaining, maxTotal)
+			} else {
+				stepFee := div(new(big.Int).Mul(remaining, feeRate), big.NewInt(DlmmFeePrecision), true)
+				in := new(big.Int).Sub(remaining, stepFee)
+				if swapForY {
+					amountOut.Add(amountOut, div(in.Mul(in, price), q64, false))
+				} else {
+					amountOut.Add(amountOut, div(in.Lsh(in, 64), price, false))
+				}
+				fee.Add(fee, stepFee)
+				remaining.SetUint64(0)
+				break
+			}
+		}
+
+		if swapForY {
+			activeID--
+		} else {
+			activeID++
+		}
+	}
+
+	return &DlmmQuote{
+		AmountIn:  amountIn,
+		AmountOut: amountOut.Uint64(),
+		Fee:       fee.Uint64(),
+		EndBinID:  activeID,
+	}, nil
+}
+
+func div(a, b *big.Int, roundUp bool) *big.Int {
+	q, r := new(big.Int).QuoRem(a, b, new(big.Int))
+	if roundUp && r.Sign() != 0 {
+		q.Add(q, big.NewInt(1))
+	}
+	return q
+}
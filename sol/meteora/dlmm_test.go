@@ -0,0 +1,78 @@
+package meteora
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+func TestDecodeDlmmPool_Offsets(t *testing.T) {
+	tokenX := solana.NewWallet().PublicKey()
+	oracle := solana.NewWallet().PublicKey()
+
+	data := make([]byte, DlmmPoolSize)
+	copy(data, DlmmPoolDiscriminator[:])
+	activeID := int32(-1234)
+	binary.LittleEndian.PutUint32(data[76:], uint32(activeID))
+	binary.LittleEndian.PutUint16(data[80:], 25) // bin step
+	copy(data[dlmmTokenXMintOffset:], tokenX.Bytes())
+	copy(data[dlmmTokenYMintOffset:], solana.SolMint.Bytes())
+	copy(data[552:], oracle.Bytes())
+	data[DlmmPoolSize-23] = 1 // token Y program flag
+
+	pool, err := DecodeDlmmPool(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pool.ActiveID != -1234 || pool.BinStep != 25 || !pool.Oracle.Equals(oracle) {
+		t.Errorf("active id %d, bin step %d, oracle %s", pool.ActiveID, pool.BinStep, pool.Oracle)
+	}
+	if mint, sol := pool.Mints(); !mint.Equals(tokenX) || !sol.Equals(solana.SolMint) {
+		t.Errorf("Mints = %s, %s", mint, sol)
+	}
+	if _, programY := pool.TokenPrograms(); !programY.Equals(solana.Token2022ProgramID) {
+		t.Errorf("token Y program = %s", programY)
+	}
+}
+
+func TestDlmmBinArrayIndex(t *testing.T) {
+	for binID, want := range map[int32]int64{0: 0, 69: 0, 70: 1, -1: -1, -70: -1, -71: -2} {
+		if got := DlmmBinArrayIndex(binID); got != want {
+			t.Errorf("DlmmBinArrayIndex(%d) = %d, want %d", binID, got, want)
+		}
+	}
+}
+
+func TestDlmmPool_Quote(t *testing.T) {
+	// bin step 100 and base factor 10000 make a 1% base fee
+	pool := &DlmmPool{ActiveID: 0, BinStep: 100}
+	pool.Parameters.BaseFactor = 10000
+	pool.Parameters.MinBinID, pool.Parameters.MaxBinID = -100, 100
+	array := &DlmmBinArray{Index: -1}
+	array.Bins[69] = DlmmBin{AmountY: 1000} // bin -1
+	current := &DlmmBinArray{Index: 0}
+	current.Bins[0] = DlmmBin{AmountX: 500, AmountY: 1000} // bin 0, price 1
+	arrays := []*DlmmBinArray{current, array}
+
+	quote, err := pool.Quote(arrays, 1010, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if quote.AmountOut != 999 || quote.EndBinID != 0 {
+		t.Errorf("inside the active bin: %+v", quote)
+	}
+
+	// the active bin takes 1000 + 11 fee, the rest crosses into bin -1
+	quote, err = pool.Quote(arrays, 1500, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if quote.AmountOut <= 1000 || quote.AmountOut >= 1500 || quote.EndBinID != -1 {
+		t.Errorf("crossing a bin: %+v", quote)
+	}
+
+	if _, err = pool.Quote(arrays, 5000, true); err != ErrDlmmBinArrays {
+		t.Errorf("past the loaded bins: err = %v", err)
+	}
+}
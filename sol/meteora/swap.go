@@ -0,0 +1,276 @@
+package meteora
+
+import (
+	"math/big"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/token"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/meme-bots/go-web3/sol/common"
+)
+
+// DlmmSwapBinArrays is how many bin arrays a swap loads, which bounds how
+// far it can move the price.
+const DlmmSwapBinArrays = 3
+
+func DlmmBuyInstructions(
+	poolID solana.PublicKey,
+	tokenMint *common.Mint,
+	solAmount, slippage uint64,
+	pool *DlmmPool,
+	binArrays []*DlmmBinArray,
+	createAta bool,
+	payer solana.PublicKey,
+) ([]solana.Instruction, error) {
+	var instructions []solana.Instruction
+
+	//quote across bins, the pool checks the amount out net of the mint's transfer fee
+	quote, err := pool.Quote(binArrays, solAmount, !pool.TokenIsX())
+	if err != nil {
+		return nil, err
+	}
+	minAmountOut := tokenMint.AmountAfterFee(quote.AmountOut)
+	minAmountOut -= minAmountOut * slippage / 10000
+
+	//create and init tmp wsol token account
+	createAndInitInsts, wsolAta := common.CreateAndInitWsolTokenAccount(payer, solAmount)
+	instructions = append(instructions, createAndInitInsts...)
+	//create ata
+	mint, _ := pool.Mints()
+	if createAta {
+		instructions = append(instructions, common.NewCreateIdempotentAtaInstruction(payer, payer, mint, tokenMint.Program))
+	}
+	//swap
+	swapInst := NewDlmmSwapInstruction(DlmmSwapParam{
+		PoolID:        poolID,
+		Pool:          pool,
+		BinArrays:     binArrays,
+		Payer:         payer,
+		AmountIn:      solAmount,
+		MinAmountOut:  minAmountOut,
+		SourceAccount: wsolAta,
+		DestAccount:   common.FindAssociatedTokenAddress(payer, mint, tokenMint.Program),
+	})
+	instructions = append(instructions, swapInst)
+	instructions = appendSwapTail(instructions, payer, wsolAta, nil, nil)
+
+	return instructions, nil
+}
+
+func DlmmSellInstructions(
+	poolID solana.PublicKey,
+	tokenMint *common.Mint,
+	tokenAmount, slippage uint64,
+	pool *DlmmPool,
+	binArrays []*DlmmBinArray,
+	isSellAll bool,
+	payer solana.PublicKey,
+) ([]solana.Instruction, error) {
+	var instructions []solana.Instruction
+
+	//quote across bins, the reserve receives what is left after the mint's transfer fee
+	quote, err := pool.Quote(binArrays, tokenMint.AmountAfterFee(tokenAmount), pool.TokenIsX())
+	if err != nil {
+		return nil, err
+	}
+	minAmountOut := quote.AmountOut
+	minAmountOut -= minAmountOut * slippage / 10000
+
+	//create and init tmp wsol token account
+	createAndInitInsts, wsolAta := common.CreateAndInitWsolTokenAccount(payer, 0)
+	instructions = append(instructions, createAndInitInsts...)
+
+	//swap
+	mint, _ := pool.Mints()
+	ata := common.FindAssociatedTokenAddress(payer, mint, tokenMint.Program)
+	swapInst := NewDlmmSwapInstruction(DlmmSwapParam{
+		PoolID:        poolID,
+		Pool:          pool,
+		BinArrays:     binArrays,
+		Payer:         payer,
+		AmountIn:      tokenAmount,
+		MinAmountOut:  minAmountOut,
+		SourceAccount: ata,
+		DestAccount:   wsolAta,
+	})
+	instructions = append(instructions, swapInst)
+	if isSellAll {
+		instructions = appendSwapTail(instructions, payer, wsolAta, &ata, tokenMint)
+	} else {
+		instructions = appendSwapTail(instructions, payer, wsolAta, nil, nil)
+	}
+
+	return instructions, nil
+}
+
+func DammBuyInstructions(
+	poolID solana.PublicKey,
+	tokenMint *common.Mint,
+	solAmount, slippage, reserveWsol, reserveToken uint64,
+	pool *DammPool,
+	vaultA, vaultB *DammVault,
+	createAta bool,
+	payer solana.PublicKey,
+) ([]solana.Instruction, error) {
+	var instructions []solana.Instruction
+
+	//create and init tmp wsol token account
+	createAndInitInsts, wsolAta := common.CreateAndInitWsolTokenAccount(payer, solAmount)
+	instructions = append(instructions, createAndInitInsts...)
+	//create ata
+	mint, _ := pool.Mints()
+	if createAta {
+		instructions = append(instructions, common.NewCreateIdempotentAtaInstruction(payer, payer, mint, tokenMint.Program))
+	}
+	//swap
+	minAmountOut := pool.Quote(solAmount, reserveWsol, reserveToken)
+	minAmountOut -= minAmountOut * slippage / 10000
+
+	swapInst := NewDammSwapInstruction(DammSwapParam{
+		PoolID:        poolID,
+		Pool:          pool,
+		VaultA:        vaultA,
+		VaultB:        vaultB,
+		Payer:         payer,
+		AmountIn:      solAmount,
+		MinAmountOut:  minAmountOut,
+		InputMint:     solana.SolMint,
+		SourceAccount: wsolAta,
+		DestAccount:   common.FindAssociatedTokenAddress(payer, mint, tokenMint.Program),
+	})
+	instructions = append(instructions, swapInst)
+	instructions = appendSwapTail(instructions, payer, wsolAta, nil, nil)
+
+	return instructions, nil
+}
+
+func DammSellInstructions(
+	poolID solana.PublicKey,
+	tokenMint *common.Mint,
+	tokenAmount, slippage, reserveWsol, reserveToken uint64,
+	pool *DammPool,
+	vaultA, vaultB *DammVault,
+	isSellAll bool,
+	payer solana.PublicKey,
+) ([]solana.Instruction, error) {
+	var instructions []solana.Instruction
+
+	//create and init tmp wsol token account
+	createAndInitInsts, wsolAta := common.CreateAndInitWsolTokenAccount(payer, 0)
+	instructions = append(instructions, createAndInitInsts...)
+
+	//swap
+	mint, _ := pool.Mints()
+	ata := common.FindAssociatedTokenAddress(payer, mint, tokenMint.Program)
+	minAmountOut := pool.Quote(tokenAmount, reserveToken, reserveWsol)
+	minAmountOut -= minAmountOut * slippage / 10000
+
+	swapInst := NewDammSwapInstruction(DammSwapParam{
+		PoolID:        poolID,
+		Pool:          pool,
+		VaultA:        vaultA,
+		VaultB:        vaultB,
+		Payer:         payer,
+		AmountIn:      tokenAmount,
+		MinAmountOut:  minAmountOut,
+		InputMint:     mint,
+		SourceAccount: ata,
+		DestAccount:   wsolAta,
+	})
+	instructions = append(instructions, swapInst)
+	if isSellAll {
+		instructions = appendSwapTail(instructions, payer, wsolAta, &ata, tokenMint)
+	} else {
+		instructions = appendSwapTail(instructions, payer, wsolAta, nil, nil)
+	}
+
+	return instructions, nil
+}
+
+// appendSwapTail closes the tmp wsol account, and the token account when
+// closeAta is set.
+func appendSwapTail(
+	instructions []solana.Instruction,
+	owner, wsolAta solana.PublicKey,
+	closeAta *solana.PublicKey,
+	tokenMint *common.Mint,
+) []solana.Instruction {
+	//close wsol token account
+	closeAccountInst := token.NewCloseAccountInstruction(
+		wsolAta,
+		owner,
+		owner,
+		[]solana.PublicKey{owner},
+	).Build()
+	instructions = append(instructions, closeAccountInst)
+
+	//close ata
+	if closeAta != nil {
+		closeAccountInst := common.WithProgram(token.NewCloseAccountInstruction(
+			*closeAta,
+			owner,
+			owner,
+			[]solana.PublicKey{owner},
+		).Build(), tokenMint.Program)
+		instructions = append(instructions, closeAccountInst)
+	}
+
+	return instructions
+}
+
+// ParseDlmmSwapInstruction reads the SOL and token amounts a DLMM swap
+// moved for its signer from the transfers into and out of its accounts.
+func ParseDlmmSwapInstruction(tx *rpc.GetTransactionResult, message *solana.Message, instruction solana.CompiledInstruction, instructionIndex uint16) (*big.Int, *big.Int) {
+	return parseSwapTransfers(tx, message, instruction, instructionIndex, 4, 5, nil)
+}
+
+// ParseDammSwapInstruction reads the SOL and token amounts a dynamic AMM
+// swap moved for its signer. Its transfers carry no mint, so the direction
+// follows from whether the tokens came from the signer's ata at ataIndex.
+func ParseDammSwapInstruction(tx *rpc.GetTransactionResult, message *solana.Message, instruction solana.CompiledInstruction, instructionIndex, ataIndex uint16) (*big.Int, *big.Int) {
+	return parseSwapTransfers(tx, message, instruction, instructionIndex, 1, 2, &ataIndex)
+}
+
+// parseSwapTransfers sums what left the account at sourceIndex and reached
+// the account at destIndex of the instruction. A sell spends from the ata,
+// known either as ataIndex or by the mint of the transfer_checked out of it.
+func parseSwapTransfers(tx *rpc.GetTransactionResult, message *solana.Message, instruction solana.CompiledInstruction, instructionIndex uint16, sourceIndex, destIndex int, ataIndex *uint16) (*big.Int, *big.Int) {
+	solSwaped := big.NewInt(0)
+	tokenSwaped := big.NewInt(0)
+	if len(instruction.Accounts) <= max(sourceIndex, destIndex) {
+		return solSwaped, tokenSwaped
+	}
+	source, err := message.Account(instruction.Accounts[sourceIndex])
+	if err != nil {
+		return solSwaped, tokenSwaped
+	}
+	dest, err := message.Account(instruction.Accounts[destIndex])
+	if err != nil {
+		return solSwaped, tokenSwaped
+	}
+
+	var amountIn, amountOut uint64
+	var inputMint solana.PublicKey
+	for _, transfer := range common.InnerTokenTransfers(tx, message, instructionIndex) {
+		if transfer.Source.Equals(source) {
+			amountIn += transfer.Amount
+			inputMint = transfer.Mint
+		}
+		if transfer.Destination.Equals(dest) {
+			amountOut += transfer.Amount
+		}
+	}
+
+	sell := !inputMint.IsZero() && !inputMint.Equals(solana.SolMint)
+	if ataIndex != nil {
+		sell = instruction.Accounts[sourceIndex] == *ataIndex
+	}
+	if sell {
+		solSwaped = new(big.Int).SetUint64(amountOut)
+		tokenSwaped = new(big.Int).Neg(new(big.Int).SetUint64(amountIn))
+	} else {
+		solSwaped = new(big.Int).Neg(new(big.Int).SetUint64(amountIn))
+		tokenSwaped = new(big.Int).SetUint64(amountOut)
+	}
+	return solSwaped, tokenSwaped
+}
@@ -0,0 +1,92 @@
+package sol
+
+import (
+	"github.com/gagliardetto/solana-go"
+	"github.com/meme-bots/go-web3/sol/pumpfun"
+	"github.com/meme-bots/go-web3/sol/pumpswap"
+	"github.com/meme-bots/go-web3/types"
+	"github.com/samber/lo"
+)
+
+// migrationDexes are the venues completed pump.fun curves move to, in order
+// of preference.
+var migrationDexes = []int{types.DexPumpSwap, types.DexRaydium, types.DexRaydiumCpmm}
+
+// migratedPool picks the pool a completed pump.fun curve of mint moved to
+// from candidates, in order of preference. Only the canonical PumpSwap pool
+// or a pool the migration itself touched is taken, not any pool someone
+// opened for the token.
+func (s *Solana) migratedPool(mint solana.PublicKey, candidates ...*types.Pool) (*types.Pool, error) {
+	candidates = lo.Filter(candidates, func(p *types.Pool, _ int) bool { return p != nil })
+	if len(candidates) == 0 {
+		// the curve completed but the pool is yet to be created
+		return nil, types.ErrPoolCompleted
+	}
+
+	migration, err := s.migration(mint)
+	if err != nil {
+		return nil, err
+	}
+	canonical := pumpswap.FindCanonicalPool(mint)
+	pool, found := lo.Find(candidates, func(p *types.Pool) bool {
+		address, err := solana.PublicKeyFromBase58(p.AmmPublicKey)
+		return err == nil && (address.Equals(canonical) || migration.Accounts.Contains(address))
+	})
+	if !found {
+		return nil, types.ErrPoolCompleted
+	}
+	pool.Migrated = true
+	pool.MigratedAt = migration.At
+	return pool, nil
+}
+
+// migration returns the migration of mint's completed curve, looked up once
+// per mint since it never changes.
+func (s *Solana) migration(mint solana.PublicKey) (*pumpfun.Migration, error) {
+	s.migrationsMu.Lock()
+	migration, ok := s.migrations[mint]
+	s.migrationsMu.Unlock()
+	if ok {
+		return migration, nil
+	}
+
+	migration, err := pumpfun.GetMigration(s.ctx, s.cfg.RPC, mint)
+	if err != nil {
+		return nil, err
+	}
+
+	s.migrationsMu.Lock()
+	defer s.migrationsMu.Unlock()
+	if s.migrations == nil {
+		s.migrations = make(map[solana.PublicKey]*pumpfun.Migration)
+	}
+	s.migrations[mint] = migration
+	return migration, nil
+}
+
+// transactMigrated sends a swap meant for a completed pump.fun curve to the
+// pool the token moved to, so positions opened on the curve still sell.
+func (s *Solana) transactMigrated(req *types.Transact, feeRecipient string, feeRatio uint64, privateKey string) (*types.TransactResponse, error) {
+	token := lo.If(solana.MPK(req.TokenIn).Equals(solana.SolMint), req.TokenOut).Else(req.TokenIn)
+	pool, err := s.QueryPool(&types.QueryPoolRequest{Token: token})
+	if err != nil {
+		return nil, err
+	}
+	if pool.Dex == types.DexPumpFun {
+		return nil, types.ErrPoolCompleted
+	}
+	info, err := s.GetPool(&types.GetPoolRequest{Token: token}, pool)
+	if err != nil {
+		return nil, err
+	}
+
+	migrated := *req
+	migrated.Dex = uint32(info.DexID)
+	migrated.PoolID = info.PoolAddress
+	migrated.MarketId = info.MarketId
+	migrated.MarketProgramId = info.MarketProgramId
+	migrated.HopPool = info.HopPool
+	migrated.TokenReserve = info.TokenReserve
+	migrated.QuoteReserve = info.QuoteReserve
+	return s.Transact(&migrated, feeRecipient, feeRatio, privateKey)
+}
@@ -0,0 +1,148 @@
+package sol
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/system"
+	"github.com/meme-bots/go-web3/sol/pumpfun"
+	"github.com/meme-bots/go-web3/sol/pumpswap"
+	"github.com/meme-bots/go-web3/types"
+)
+
+// curveTx is a transaction on a bonding curve as getSignaturesForAddress
+// and getTransaction report it.
+type curveTx struct {
+	blockTime int64
+	failed    bool
+	migrate   bool
+	pool      solana.PublicKey // opened by the migration
+}
+
+// newCurveNode serves the history of a bonding curve, newest first.
+func newCurveNode(tb testing.TB, history []curveTx) *httptest.Server {
+	payer := solana.NewWallet().PrivateKey
+	txs := make(map[solana.Signature]string)
+	var signatures []map[string]interface{}
+	for i, entry := range history {
+		var instruction solana.Instruction = system.NewTransferInstruction(uint64(i+1), payer.PublicKey(), solana.NewWallet().PublicKey()).Build()
+		if entry.migrate {
+			accounts := solana.AccountMetaSlice{solana.Meta(payer.PublicKey()).SIGNER()}
+			if !entry.pool.IsZero() {
+				accounts = append(accounts, solana.Meta(entry.pool).WRITE())
+			}
+			instruction = solana.NewInstruction(pumpfun.ProgramID, accounts, []byte{155, 234, 231, 146, 236, 158, 162, 30})
+		}
+		tx, err := solana.NewTransaction([]solana.Instruction{instruction}, solana.Hash{1}, solana.TransactionPayer(payer.PublicKey()))
+		if err != nil {
+			tb.Fatal(err)
+		}
+		if _, err = tx.Sign(func(solana.PublicKey) *solana.PrivateKey { return &payer }); err != nil {
+			tb.Fatal(err)
+		}
+		txs[tx.Signatures[0]], _ = tx.ToBase64()
+
+		var txErr interface{}
+		if entry.failed {
+			txErr = map[string]interface{}{"InstructionError": []interface{}{0, "Custom"}}
+		}
+		signatures = append(signatures, map[string]interface{}{
+			"signature": tx.Signatures[0].String(), "slot": 5, "blockTime": entry.blockTime, "err": txErr,
+		})
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var call struct {
+			Method string
+			Params []json.RawMessage
+		}
+		_ = json.NewDecoder(r.Body).Decode(&call)
+
+		var result interface{}
+		switch call.Method {
+		case "getSignaturesForAddress":
+			result = signatures
+		case "getTransaction":
+			var signature solana.Signature
+			_ = json.Unmarshal(call.Params[0], &signature)
+			result = map[string]interface{}{
+				"slot":        5,
+				"transaction": []string{txs[signature], "base64"},
+				"meta":        map[string]interface{}{"err": nil, "fee": 5000, "preBalances": []int{}, "postBalances": []int{}},
+			}
+		default:
+			http.Error(w, fmt.Sprintf("unexpected %s", call.Method), http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": 1, "result": result})
+	}))
+	tb.Cleanup(server.Close)
+	return server
+}
+
+func TestSolana_migratedPool(t *testing.T) {
+	mint := solana.NewWallet().PublicKey()
+	migrated := solana.NewWallet().PublicKey()
+	server := newCurveNode(t, []curveTx{{blockTime: 1700000000, migrate: true, pool: migrated}})
+	s := &Solana{ctx: context.Background(), cfg: &types.Config{RPC: server.URL}}
+
+	if _, err := s.migratedPool(mint, nil, nil); !errors.Is(err, types.ErrPoolCompleted) {
+		t.Errorf("no destination: err = %v, want ErrPoolCompleted", err)
+	}
+
+	// a pool someone else opened for the token is not the destination
+	other := &types.Pool{Dex: types.DexRaydiumCpmm, AmmPublicKey: solana.NewWallet().PublicKey().String()}
+	if _, err := s.migratedPool(mint, other); !errors.Is(err, types.ErrPoolCompleted) {
+		t.Errorf("unrelated pool: err = %v, want ErrPoolCompleted", err)
+	}
+
+	raydium := &types.Pool{Dex: types.DexRaydium, AmmPublicKey: migrated.String()}
+	pool, err := s.migratedPool(mint, other, raydium)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pool != raydium || !pool.Migrated || pool.MigratedAt != 1700000000 {
+		t.Errorf("migratedPool = %+v", pool)
+	}
+
+	// the migration is looked up once
+	server.Close()
+	canonical := &types.Pool{Dex: types.DexPumpSwap, AmmPublicKey: pumpswap.FindCanonicalPool(mint).String()}
+	pool, err = s.migratedPool(mint, canonical, raydium)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pool != canonical || pool.MigratedAt != 1700000000 {
+		t.Errorf("canonical: migratedPool = %+v", pool)
+	}
+}
+
+func TestGetCompletedAt(t *testing.T) {
+	mint := solana.NewWallet().PublicKey()
+	cases := []struct {
+		name    string
+		history []curveTx
+		want    int64
+	}{
+		{"failed after the migration", []curveTx{{blockTime: 300, failed: true}, {blockTime: 200, migrate: true}}, 200},
+		{"transfer after the migration", []curveTx{{blockTime: 300}, {blockTime: 200, migrate: true}, {blockTime: 100}}, 200},
+		{"migration further back", []curveTx{{blockTime: 300, failed: true}, {blockTime: 200}, {blockTime: 100}}, 200},
+	}
+	for _, c := range cases {
+		server := newCurveNode(t, c.history)
+		if at, err := pumpfun.GetCompletedAt(context.Background(), server.URL, mint); err != nil || at != c.want {
+			t.Errorf("%s: %d, %v, want %d", c.name, at, err, c.want)
+		}
+	}
+
+	server := newCurveNode(t, []curveTx{{blockTime: 300, failed: true}})
+	if _, err := pumpfun.GetCompletedAt(context.Background(), server.URL, mint); !errors.Is(err, types.ErrNotFound) {
+		t.Errorf("only failed: err = %v", err)
+	}
+}
@@ -0,0 +1,122 @@
+package sol
+
+import (
+	"errors"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/meme-bots/go-web3/sol/common"
+	"github.com/meme-bots/go-web3/types"
+	"github.com/shopspring/decimal"
+)
+
+var ErrNonceAuthority = errors.New("nonce account has another authority")
+
+// CreateNonceAccount creates a durable nonce account the wallet of
+// privateKey controls, funded with its rent exemption.
+func (s *Solana) CreateNonceAccount(privateKey string) (string, string, error) {
+	account, signature, err := common.CreateNonceAccount(s.ctx, s.cfg.RPC, 0, solana.MustPrivateKeyFromBase58(privateKey))
+	if err != nil {
+		return "", "", err
+	}
+	return account.String(), signature.String(), nil
+}
+
+// FundNonceAccount tops account up by amount SOL, e.g. after a rent change.
+func (s *Solana) FundNonceAccount(account string, amount decimal.Decimal, privateKey string) (string, error) {
+	return s.Withdraw(account, amount, privateKey)
+}
+
+func (s *Solana) AdvanceNonceAccount(account string, privateKey string) (string, error) {
+	signature, err := common.AdvanceNonceAccount(s.ctx, s.cfg.RPC, solana.MPK(account), solana.MustPrivateKeyFromBase58(privateKey))
+	if err != nil {
+		return "", err
+	}
+	return signature.String(), nil
+}
+
+func (s *Solana) GetNonceAccount(account string) (*common.DurableNonce, error) {
+	return common.GetDurableNonce(s.ctx, rpc.New(s.cfg.RPC), solana.MPK(account))
+}
+
+// CloseNonceAccount returns the account's lamports to its authority.
+func (s *Solana) CloseNonceAccount(account string, privateKey string) (string, error) {
+	signature, err := common.CloseNonceAccount(s.ctx, s.cfg.RPC, solana.MPK(account), solana.MustPrivateKeyFromBase58(privateKey))
+	if err != nil {
+		return "", err
+	}
+	return signature.String(), nil
+}
+
+// durableNonce loads account for authority to build with, or returns nil
+// when no account is given.
+func (s *Solana) durableNonce(account string, authority solana.PublicKey) (*common.DurableNonce, error) {
+	if len(account) == 0 {
+		return nil, nil
+	}
+	nonce, err := s.GetNonceAccount(account)
+	if err != nil {
+		return nil, err
+	}
+	if !nonce.Authority.Equals(authority) {
+		return nil, ErrNonceAuthority
+	}
+	return nonce, nil
+}
+
+// SignTransact builds and signs the swap of req without sending it. Its
+// TxHash is the signature the transaction will have once sent, SignedTx
+// the transaction itself; build on a durable nonce to send it later than a
+// blockhash allows.
+func (s *Solana) SignTransact(req *types.Transact, feeRecipient string, feeRatio uint64, privateKey string) (*types.TransactResponse, error) {
+	signOnly := *req
+	signOnly.SignOnly = true
+	return s.Transact(&signOnly, feeRecipient, feeRatio, privateKey)
+}
+
+// SendSignedTransaction sends a transaction SignTransact built, as a bundle
+// if it pays a Jito tip.
+func (s *Solana) SendSignedTransaction(signedTx string) (string, error) {
+	tx, err := solana.TransactionFromBase64(signedTx)
+	if err != nil {
+		return "", err
+	}
+
+	client := rpc.New(s.cfg.RPC)
+	var signature solana.Signature
+	if common.PaysTip(tx) {
+		signature, err = common.SendBundled(s.ctx, s.jito, client, tx)
+	} else {
+		signature, err = common.SendTransaction(s.ctx, client, s.outbox, tx, rpc.TransactionOpts{SkipPreflight: true})
+	}
+	if err != nil {
+		return "", err
+	}
+	return signature.String(), nil
+}
+
+// submitSwap sends the swap, or for a SignOnly request returns it signed
+// and base64 encoded.
+func (s *Solana) submitSwap(
+	req *types.Transact,
+	instructions []solana.Instruction,
+	priorityFee common.PriorityFee,
+	pk solana.PrivateKey,
+	recentBlockHash solana.Hash,
+	nonce *common.DurableNonce,
+) (solana.Signature, string, error) {
+	if !req.SignOnly {
+		signature, err := common.SendSwap(s.ctx, s.cfg.RPC, s.jito, s.outbox, instructions, priorityFee, req.Tip.Uint64(), pk, recentBlockHash, nonce, s.lookupTables())
+		return signature, "", err
+	}
+
+	tx, err := common.BuildSwap(s.ctx, rpc.New(s.cfg.RPC), instructions, priorityFee, pk, recentBlockHash, nonce, s.lookupTables())
+	if err != nil {
+		return solana.Signature{}, "", err
+	}
+	signedTx, err := tx.ToBase64()
+	if err != nil {
+		return solana.Signature{}, "", err
+	}
+	return tx.Signatures[0], signedTx, nil
+}
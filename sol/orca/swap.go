@@ -0,0 +1,453 @@
+package orca
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/token"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/meme-bots/go-web3/sol/common"
+	"github.com/meme-bots/go-web3/types"
+	"github.com/near/borsh-go"
+	"github.com/samber/lo"
+)
+
+type (
+	// Leg is a swap through one whirlpool.
+	Leg struct {
+		PoolID     solana.PublicKey
+		Pool       *Whirlpool
+		AToB       bool
+		TickArrays []*TickArray
+	}
+
+	// Route is the legs between SOL and a token, in the order the swap takes
+	// them: the token's pool alone, or with the SOL/USDC pool for USDC
+	// pairs.
+	Route []*Leg
+)
+
+func (l *Leg) inputMint() solana.PublicKey {
+	return lo.If(l.AToB, l.Pool.TokenMintA).Else(l.Pool.TokenMintB)
+}
+
+func (l *Leg) outputMint() solana.PublicKey {
+	return lo.If(l.AToB, l.Pool.TokenMintB).Else(l.Pool.TokenMintA)
+}
+
+// GetRoute loads the pools and tick arrays a buy or sell through poolID
+// takes. hopID is the SOL/USDC pool a USDC pair goes through; it is looked
+// up if zero.
+func GetRoute(ctx context.Context, client *rpc.Client, poolID, hopID solana.PublicKey, buy bool) (Route, error) {
+	pool, err := GetWhirlpoolState(ctx, client, poolID)
+	if err != nil {
+		return nil, err
+	}
+	mint, quoteMint := pool.Mints()
+	if !quoteMint.Equals(solana.SolMint) && !quoteMint.Equals(UsdcMint) {
+		return nil, types.ErrInvalidPool
+	}
+
+	// the quote goes in when buying, so A goes in when buying with A as quote
+	route := Route{{PoolID: poolID, Pool: pool, AToB: buy != pool.TokenIsA()}}
+	if quoteMint.Equals(UsdcMint) {
+		var hop *Whirlpool
+		if hopID.IsZero() {
+			hopID, hop, err = FindSolUsdcWhirlpool(ctx, client)
+		} else {
+			hop, err = GetWhirlpoolState(ctx, client, hopID)
+		}
+		if err != nil {
+			return nil, err
+		}
+		// SOL goes in when buying
+		leg := &Leg{PoolID: hopID, Pool: hop, AToB: buy == hop.TokenMintA.Equals(solana.SolMint)}
+		route = lo.If(buy, Route{leg, route[0]}).Else(Route{route[0], leg})
+	}
+
+	for _, leg := range route {
+		leg.TickArrays, err = GetTickArrays(ctx, client, leg.PoolID, leg.Pool, leg.AToB)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !route[0].inputMint().Equals(lo.If(buy, solana.SolMint).Else(mint)) || !route[len(route)-1].outputMint().Equals(lo.If(buy, mint).Else(solana.SolMint)) {
+		return nil, types.ErrInvalidPool
+	}
+	return route, nil
+}
+
+// Token is the non-SOL end of the route.
+func (r Route) Token() solana.PublicKey {
+	if r[0].inputMint().Equals(solana.SolMint) {
+		return r[len(r)-1].outputMint()
+	}
+	return r[0].inputMint()
+}
+
+// Quote chains the quotes of the legs, each taking what the one before put
+// out.
+func (r Route) Quote(amountIn uint64) (uint64, error) {
+	amount := amountIn
+	for _, leg := range r {
+		quote, err := leg.Pool.Quote(leg.TickArrays, amount, leg.AToB)
+		if err != nil {
+			return 0, err
+		}
+		amount = quote.AmountOut
+	}
+	return amount, nil
+}
+
+// instruction builds the swap of the route, exactly amount in for at least
+// threshold out, with the token on tokenProgram.
+func (r Route) instruction(payer, source, dest, tokenProgram solana.PublicKey, amount, threshold uint64) solana.Instruction {
+	programOf := func(mint solana.PublicKey) solana.PublicKey {
+		return lo.If(mint.Equals(r.Token()), tokenProgram).Else(solana.TokenProgramID)
+	}
+
+	if len(r) == 1 {
+		leg := r[0]
+		ownerA, ownerB := lo.If(leg.AToB, source).Else(dest), lo.If(leg.AToB, dest).Else(source)
+		return NewSwapV2Instruction(SwapV2Param{
+			Leg:           leg,
+			Payer:         payer,
+			Amount:        amount,
+			Threshold:     threshold,
+			TokenProgramA: programOf(leg.Pool.TokenMintA),
+			TokenProgramB: programOf(leg.Pool.TokenMintB),
+			OwnerAccountA: ownerA,
+			OwnerAccountB: ownerB,
+		})
+	}
+
+	return NewTwoHopSwapV2Instruction(TwoHopSwapV2Param{
+		One:                 r[0],
+		Two:                 r[1],
+		Payer:               payer,
+		Amount:              amount,
+		Threshold:           threshold,
+		ProgramInput:        programOf(r[0].inputMint()),
+		ProgramIntermediate: programOf(r[0].outputMint()),
+		ProgramOutput:       programOf(r[1].outputMint()),
+		SourceAccount:       source,
+		DestAccount:         dest,
+	})
+}
+
+type SwapV2Param struct {
+	Leg   *Leg
+	Payer solana.PublicKey
+
+	// exactly Amount in for at least Threshold out
+	Amount    uint64
+	Threshold uint64
+
+	TokenProgramA solana.PublicKey
+	TokenProgramB solana.PublicKey
+	OwnerAccountA solana.PublicKey
+	OwnerAccountB solana.PublicKey
+}
+
+func sqrtPriceLimit(aToB bool) (uint64, uint64) {
+	limit := lo.If(aToB, MinSqrtPrice).Else(MaxSqrtPrice)
+	return new(big.Int).And(limit, new(big.Int).SetUint64(^uint64(0))).Uint64(), new(big.Int).Rsh(limit, 64).Uint64()
+}
+
+// NewSwapV2Instruction builds swap_v2, which takes Token-2022 mints. The
+// price limit is the furthest allowed; Threshold guards the amount out.
+func NewSwapV2Instruction(param SwapV2Param) solana.Instruction {
+	leg := param.Leg
+	limitLo, limitHi := sqrtPriceLimit(leg.AToB)
+	data, err := borsh.Serialize(struct {
+		Discriminator          [8]byte
+		Amount                 uint64
+		OtherAmountThreshold   uint64
+		SqrtPriceLimitLo       uint64
+		SqrtPriceLimitHi       uint64
+		AmountSpecifiedIsInput bool
+		AToB                   bool
+		RemainingAccountsInfo  uint8 // None
+	}{
+		Discriminator:          Instruction_SwapV2,
+		Amount:                 param.Amount,
+		OtherAmountThreshold:   param.Threshold,
+		SqrtPriceLimitLo:       limitLo,
+		SqrtPriceLimitHi:       limitHi,
+		AmountSpecifiedIsInput: true,
+		AToB:                   leg.AToB,
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	pool := leg.Pool
+	accounts := solana.AccountMetaSlice{
+		{PublicKey: param.TokenProgramA, IsSigner: false, IsWritable: false},
+		{PublicKey: param.TokenProgramB, IsSigner: false, IsWritable: false},
+		{PublicKey: MemoProgramID, IsSigner: false, IsWritable: false},
+		{PublicKey: param.Payer, IsSigner: true, IsWritable: false},
+		{PublicKey: leg.PoolID, IsSigner: false, IsWritable: true},
+		{PublicKey: pool.TokenMintA, IsSigner: false, IsWritable: false},
+		{PublicKey: pool.TokenMintB, IsSigner: false, IsWritable: false},
+		{PublicKey: param.OwnerAccountA, IsSigner: false, IsWritable: true},
+		{PublicKey: pool.TokenVaultA, IsSigner: false, IsWritable: true},
+		{PublicKey: param.OwnerAccountB, IsSigner: false, IsWritable: true},
+		{PublicKey: pool.TokenVaultB, IsSigner: false, IsWritable: true},
+	}
+	accounts = append(accounts, tickArrayAccounts(leg)...)
+	accounts = append(accounts, &solana.AccountMeta{PublicKey: FindOracle(leg.PoolID), IsWritable: true})
+
+	return &solana.GenericInstruction{
+		ProgID:        ProgramID,
+		AccountValues: accounts,
+		DataBytes:     data,
+	}
+}
+
+type TwoHopSwapV2Param struct {
+	One   *Leg
+	Two   *Leg
+	Payer solana.PublicKey
+
+	// exactly Amount in for at least Threshold out
+	Amount    uint64
+	Threshold uint64
+
+	ProgramInput        solana.PublicKey
+	ProgramIntermediate solana.PublicKey
+	ProgramOutput       solana.PublicKey
+	SourceAccount       solana.PublicKey
+	DestAccount         solana.PublicKey
+}
+
+// NewTwoHopSwapV2Instruction builds two_hop_swap_v2, which swaps through
+// two pools sharing a mint. The intermediate tokens move from vault to
+// vault and never reach the payer.
+func NewTwoHopSwapV2Instruction(param TwoHopSwapV2Param) solana.Instruction {
+	one, two := param.One, param.Two
+	limitOneLo, limitOneHi := sqrtPriceLimit(one.AToB)
+	limitTwoLo, limitTwoHi := sqrtPriceLimit(two.AToB)
+	data, err := borsh.Serialize(struct {
+		Discriminator          [8]byte
+		Amount                 uint64
+		OtherAmountThreshold   uint64
+		AmountSpecifiedIsInput bool
+		AToBOne                bool
+		AToBTwo                bool
+		SqrtPriceLimitOneLo    uint64
+		SqrtPriceLimitOneHi    uint64
+		SqrtPriceLimitTwoLo    uint64
+		SqrtPriceLimitTwoHi    uint64
+		RemainingAccountsInfo  uint8 // None
+	}{
+		Discriminator:          Instruction_TwoHopSwapV2,
+		Amount:                 param.Amount,
+		OtherAmountThreshold:   param.Threshold,
+		AmountSpecifiedIsInput: true,
+		AToBOne:                one.AToB,
+		AToBTwo:                two.AToB,
+		SqrtPriceLimitOneLo:    limitOneLo,
+		SqrtPriceLimitOneHi:    limitOneHi,
+		SqrtPriceLimitTwoLo:    limitTwoLo,
+		SqrtPriceLimitTwoHi:    limitTwoHi,
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	vaultOneInput, vaultOneIntermediate := one.Pool.TokenVaultA, one.Pool.TokenVaultB
+	if !one.AToB {
+		vaultOneInput, vaultOneIntermediate = vaultOneIntermediate, vaultOneInput
+	}
+	vaultTwoIntermediate, vaultTwoOutput := two.Pool.TokenVaultA, two.Pool.TokenVaultB
+	if !two.AToB {
+		vaultTwoIntermediate, vaultTwoOutput = vaultTwoOutput, vaultTwoIntermediate
+	}
+
+	accounts := solana.AccountMetaSlice{
+		{PublicKey: one.PoolID, IsSigner: false, IsWritable: true},
+		{PublicKey: two.PoolID, IsSigner: false, IsWritable: true},
+		{PublicKey: one.inputMint(), IsSigner: false, IsWritable: false},
+		{PublicKey: one.outputMint(), IsSigner: false, IsWritable: false},
+		{PublicKey: two.outputMint(), IsSigner: false, IsWritable: false},
+		{PublicKey: param.ProgramInput, IsSigner: false, IsWritable: false},
+		{PublicKey: param.ProgramIntermediate, IsSigner: false, IsWritable: false},
+		{PublicKey: param.ProgramOutput, IsSigner: false, IsWritable: false},
+		{PublicKey: param.SourceAccount, IsSigner: false, IsWritable: true},
+		{PublicKey: vaultOneInput, IsSigner: false, IsWritable: true},
+		{PublicKey: vaultOneIntermediate, IsSigner: false, IsWritable: true},
+		{PublicKey: vaultTwoIntermediate, IsSigner: false, IsWritable: true},
+		{PublicKey: vaultTwoOutput, IsSigner: false, IsWritable: true},
+		{PublicKey: param.DestAccount, IsSigner: false, IsWritable: true},
+		{PublicKey: param.Payer, IsSigner: true, IsWritable: false},
+	}
+	accounts = append(accounts, tickArrayAccounts(one)...)
+	accounts = append(accounts, tickArrayAccounts(two)...)
+	accounts = append(accounts,
+		&solana.AccountMeta{PublicKey: FindOracle(one.PoolID), IsWritable: true},
+		&solana.AccountMeta{PublicKey: FindOracle(two.PoolID), IsWritable: true},
+		&solana.AccountMeta{PublicKey: MemoProgramID},
+	)
+
+	return &solana.GenericInstruction{
+		ProgID:        ProgramID,
+		AccountValues: accounts,
+		DataBytes:     data,
+	}
+}
+
+// tickArrayAccounts lists the three tick arrays of leg, repeating the last
+// one near the end of the tick range.
+func tickArrayAccounts(leg *Leg) solana.AccountMetaSlice {
+	starts := leg.Pool.TickArrayStarts(leg.AToB)
+	accounts := make(solana.AccountMetaSlice, 0, SwapTickArrays)
+	for i := 0; i < SwapTickArrays; i++ {
+		start := starts[min(i, len(starts)-1)]
+		accounts = append(accounts, &solana.AccountMeta{PublicKey: FindTickArray(leg.PoolID, start), IsWritable: true})
+	}
+	return accounts
+}
+
+func BuyInstructions(
+	tokenMint *common.Mint,
+	solAmount, slippage uint64,
+	route Route,
+	createAta bool,
+	payer solana.PublicKey,
+) ([]solana.Instruction, error) {
+	var instructions []solana.Instruction
+
+	//quote across ticks and legs, the pool checks the amount out net of the mint's transfer fee
+	amountOut, err := route.Quote(solAmount)
+	if err != nil {
+		return nil, err
+	}
+	minAmountOut := tokenMint.AmountAfterFee(amountOut)
+	minAmountOut -= minAmountOut * slippage / 10000
+
+	//create and init tmp wsol token account
+	createAndInitInsts, wsolAta := common.CreateAndInitWsolTokenAccount(payer, solAmount)
+	instructions = append(instructions, createAndInitInsts...)
+	//create ata
+	mint := route.Token()
+	if createAta {
+		instructions = append(instructions, common.NewCreateIdempotentAtaInstruction(payer, payer, mint, tokenMint.Program))
+	}
+	//swap
+	ata := common.FindAssociatedTokenAddress(payer, mint, tokenMint.Program)
+	swapInst := route.instruction(payer, wsolAta, ata, tokenMint.Program, solAmount, minAmountOut)
+	instructions = append(instructions, swapInst)
+	//close wsol token account
+	closeAccountInst := token.NewCloseAccountInstruction(
+		wsolAta,
+		payer,
+		payer,
+		[]solana.PublicKey{payer},
+	).Build()
+	instructions = append(instructions, closeAccountInst)
+
+	return instructions, nil
+}
+
+func SellInstructions(
+	tokenMint *common.Mint,
+	tokenAmount, slippage uint64,
+	route Route,
+	isSellAll bool,
+	payer solana.PublicKey,
+) ([]solana.Instruction, error) {
+	var instructions []solana.Instruction
+
+	//quote across ticks and legs, the vault receives what is left after the mint's transfer fee
+	minAmountOut, err := route.Quote(tokenMint.AmountAfterFee(tokenAmount))
+	if err != nil {
+		return nil, err
+	}
+	minAmountOut -= minAmountOut * slippage / 10000
+
+	//create and init tmp wsol token account
+	createAndInitInsts, wsolAta := common.CreateAndInitWsolTokenAccount(payer, 0)
+	instructions = append(instructions, createAndInitInsts...)
+
+	//swap
+	ata := common.FindAssociatedTokenAddress(payer, route.Token(), tokenMint.Program)
+	swapInst := route.instruction(payer, ata, wsolAta, tokenMint.Program, tokenAmount, minAmountOut)
+	instructions = append(instructions, swapInst)
+
+	//close wsol token account
+	closeAccountInst := token.NewCloseAccountInstruction(
+		wsolAta,
+		payer,
+		payer,
+		[]solana.PublicKey{payer},
+	).Build()
+	instructions = append(instructions, closeAccountInst)
+
+	//close ata
+	if isSellAll {
+		closeAccountInst := common.WithProgram(token.NewCloseAccountInstruction(
+			ata,
+			payer,
+			payer,
+			[]solana.PublicKey{payer},
+		).Build(), tokenMint.Program)
+		instructions = append(instructions, closeAccountInst)
+	}
+
+	return instructions, nil
+}
+
+// ParseSwapV2Instruction reads the SOL and token amounts a swap_v2 moved
+// for its signer, whose accounts for A and B are at 7 and 9.
+func ParseSwapV2Instruction(tx *rpc.GetTransactionResult, message *solana.Message, instruction solana.CompiledInstruction, instructionIndex uint16) (*big.Int, *big.Int) {
+	return parseSwapTransfers(tx, message, instruction, instructionIndex, 7, 9)
+}
+
+// ParseTwoHopSwapV2Instruction reads the SOL and token amounts a
+// two_hop_swap_v2 moved for its signer, from its account at 8 to the one
+// at 13.
+func ParseTwoHopSwapV2Instruction(tx *rpc.GetTransactionResult, message *solana.Message, instruction solana.CompiledInstruction, instructionIndex uint16) (*big.Int, *big.Int) {
+	return parseSwapTransfers(tx, message, instruction, instructionIndex, 8, 13)
+}
+
+// parseSwapTransfers sums the transfers out of and into the signer's token
+// accounts at ownerIndexes. Whirlpool v2 swaps use transfer_checked, so the
+// input mint tells buys from sells.
+func parseSwapTransfers(tx *rpc.GetTransactionResult, message *solana.Message, instruction solana.CompiledInstruction, instructionIndex uint16, ownerIndexes ...int) (*big.Int, *big.Int) {
+	solSwaped := big.NewInt(0)
+	tokenSwaped := big.NewInt(0)
+	var owners []solana.PublicKey
+	for _, index := range ownerIndexes {
+		if len(instruction.Accounts) <= index {
+			return solSwaped, tokenSwaped
+		}
+		owner, err := message.Account(instruction.Accounts[index])
+		if err != nil {
+			return solSwaped, tokenSwaped
+		}
+		owners = append(owners, owner)
+	}
+
+	var amountIn, amountOut uint64
+	var inputMint solana.PublicKey
+	for _, transfer := range common.InnerTokenTransfers(tx, message, instructionIndex) {
+		if lo.Contains(owners, transfer.Source) {
+			amountIn += transfer.Amount
+			inputMint = transfer.Mint
+		}
+		if lo.Contains(owners, transfer.Destination) {
+			amountOut += transfer.Amount
+		}
+	}
+
+	if inputMint.Equals(solana.SolMint) {
+		solSwaped = new(big.Int).Neg(new(big.Int).SetUint64(amountIn))
+		tokenSwaped = new(big.Int).SetUint64(amountOut)
+	} else if !inputMint.IsZero() {
+		solSwaped = new(big.Int).SetUint64(amountOut)
+		tokenSwaped = new(big.Int).Neg(new(big.Int).SetUint64(amountIn))
+	}
+	return solSwaped, tokenSwaped
+}
@@ -0,0 +1,572 @@
+package orca
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/big"
+	"strconv"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/token"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/meme-bots/go-web3/sol/common"
+	"github.com/meme-bots/go-web3/types"
+	"github.com/samber/lo"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	WhirlpoolSize = 653
+
+	// fee rates are in millionths
+	FeeDenominator = 1000000
+
+	TickArraySize = 88
+
+	// how many tick arrays a swap takes, which bounds how far it can move
+	// the price
+	SwapTickArrays = 3
+
+	whirlpoolTokenMintAOffset = 101
+	whirlpoolTokenMintBOffset = 181
+
+	UsdcDecimals = 6
+)
+
+var (
+	ProgramID     = solana.MPK("whirLbMiicVdio4qvUfM5KAg6Ct8VwpYzGff3uctyCc")
+	MemoProgramID = solana.MPK("MemoSq4gqABAXKb96qnH8TysNcWxMyWCqXgDLGmfcHr")
+	UsdcMint      = solana.MPK("EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v")
+
+	WhirlpoolDiscriminator        = [8]byte{63, 149, 209, 12, 225, 128, 99, 9}
+	TickArrayDiscriminator        = [8]byte{69, 97, 189, 190, 110, 7, 66, 187}
+	DynamicTickArrayDiscriminator = [8]byte{17, 216, 246, 142, 225, 199, 218, 56}
+
+	Instruction_SwapV2       = [8]byte{43, 4, 237, 11, 26, 201, 30, 98}
+	Instruction_TwoHopSwapV2 = [8]byte{186, 143, 209, 29, 254, 2, 194, 117}
+
+	ErrNotWhirlpool = errors.New("not an orca whirlpool")
+)
+
+type (
+	WhirlpoolRewardInfo struct {
+		Mint                  solana.PublicKey
+		Vault                 solana.PublicKey
+		Authority             solana.PublicKey
+		EmissionsPerSecondX64 bin.Uint128
+		GrowthGlobalX64       bin.Uint128
+	}
+
+	// Whirlpool is a concentrated liquidity pool of Orca. Its mints are
+	// ordered by address, so SOL or USDC can be either side.
+	Whirlpool struct {
+		Discriminator              [8]byte
+		WhirlpoolsConfig           solana.PublicKey
+		WhirlpoolBump              uint8
+		TickSpacing                uint16
+		FeeTierIndexSeed           [2]uint8
+		FeeRate                    uint16
+		ProtocolFeeRate            uint16
+		Liquidity                  bin.Uint128
+		SqrtPrice                  bin.Uint128
+		TickCurrentIndex           int32
+		ProtocolFeeOwedA           uint64
+		ProtocolFeeOwedB           uint64
+		TokenMintA                 solana.PublicKey
+		TokenVaultA                solana.PublicKey
+		FeeGrowthGlobalA           bin.Uint128
+		TokenMintB                 solana.PublicKey
+		TokenVaultB                solana.PublicKey
+		FeeGrowthGlobalB           bin.Uint128
+		RewardLastUpdatedTimestamp uint64
+		RewardInfos                [3]WhirlpoolRewardInfo
+	}
+
+	Tick struct {
+		Initialized          bool
+		LiquidityNet         bin.Int128
+		LiquidityGross       bin.Uint128
+		FeeGrowthOutsideA    bin.Uint128
+		FeeGrowthOutsideB    bin.Uint128
+		RewardGrowthsOutside [3]bin.Uint128
+	}
+
+	// TickArray holds the ticks from StartTickIndex on, one per tick
+	// spacing. Dynamic tick arrays decode to it as well.
+	TickArray struct {
+		Discriminator  [8]byte
+		StartTickIndex int32
+		Ticks          [TickArraySize]Tick
+		Whirlpool      solana.PublicKey
+	}
+
+	// tickData is a tick of a dynamic tick array, which stores only the
+	// initialized ones in full.
+	tickData struct {
+		LiquidityNet         bin.Int128
+		LiquidityGross       bin.Uint128
+		FeeGrowthOutsideA    bin.Uint128
+		FeeGrowthOutsideB    bin.Uint128
+		RewardGrowthsOutside [3]bin.Uint128
+	}
+)
+
+func DecodeWhirlpool(data []byte) (*Whirlpool, error) {
+	var pool Whirlpool
+	err := bin.NewBorshDecoder(data).Decode(&pool)
+	if err != nil {
+		return nil, err
+	}
+	if pool.Discriminator != WhirlpoolDiscriminator {
+		return nil, ErrNotWhirlpool
+	}
+	return &pool, nil
+}
+
+func DecodeTickArray(data []byte) (*TickArray, error) {
+	var array TickArray
+	decoder := bin.NewBorshDecoder(data)
+	if len(data) >= 8 && [8]byte(data[:8]) == DynamicTickArrayDiscriminator {
+		var bitmap bin.Uint128
+		err := decoder.Decode(&array.Discriminator)
+		if err == nil {
+			err = decoder.Decode(&array.StartTickIndex)
+		}
+		if err == nil {
+			err = decoder.Decode(&array.Whirlpool)
+		}
+		if err == nil {
+			err = decoder.Decode(&bitmap)
+		}
+		for i := 0; err == nil && i < TickArraySize; i++ {
+			var initialized bool
+			err = decoder.Decode(&initialized)
+			if err != nil || !initialized {
+				continue
+			}
+			var tick tickData
+			err = decoder.Decode(&tick)
+			array.Ticks[i] = Tick{
+				Initialized:          true,
+				LiquidityNet:         tick.LiquidityNet,
+				LiquidityGross:       tick.LiquidityGross,
+				FeeGrowthOutsideA:    tick.FeeGrowthOutsideA,
+				FeeGrowthOutsideB:    tick.FeeGrowthOutsideB,
+				RewardGrowthsOutside: tick.RewardGrowthsOutside,
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &array, nil
+	}
+
+	err := decoder.Decode(&array)
+	if err != nil {
+		return nil, err
+	}
+	if array.Discriminator != TickArrayDiscriminator {
+		return nil, ErrNotWhirlpool
+	}
+	return &array, nil
+}
+
+// QuoteMint is the SOL or USDC side of the pool, SOL if it has both.
+func (p *Whirlpool) QuoteMint() solana.PublicKey {
+	if p.TokenMintA.Equals(solana.SolMint) || p.TokenMintB.Equals(solana.SolMint) {
+		return solana.SolMint
+	}
+	return UsdcMint
+}
+
+// TokenIsA reports whether the non-quote side of the pool is token A.
+func (p *Whirlpool) TokenIsA() bool {
+	return !p.TokenMintA.Equals(p.QuoteMint())
+}
+
+// Mints returns the token and quote mints of the pool, in that order.
+func (p *Whirlpool) Mints() (solana.PublicKey, solana.PublicKey) {
+	if p.TokenIsA() {
+		return p.TokenMintA, p.TokenMintB
+	}
+	return p.TokenMintB, p.TokenMintA
+}
+
+// Price is the spot price of mint, one of the pool's, in the other one, in
+// whole units of both.
+func (p *Whirlpool) Price(mint solana.PublicKey, decimals, otherDecimals uint8) decimal.Decimal {
+	if mint.Equals(p.TokenMintA) {
+		return SqrtPriceToPrice(p.SqrtPrice.BigInt(), decimals, otherDecimals)
+	}
+	price := SqrtPriceToPrice(p.SqrtPrice.BigInt(), otherDecimals, decimals)
+	if price.IsZero() {
+		return price
+	}
+	return decimal.NewFromInt(1).Div(price)
+}
+
+// FeeBps is the swap fee in basis points.
+func (p *Whirlpool) FeeBps() uint16 {
+	return uint16(uint64(p.FeeRate) * 10000 / FeeDenominator)
+}
+
+func (p *Whirlpool) tickCount() int32 {
+	return int32(p.TickSpacing) * TickArraySize
+}
+
+// TickArrayStartIndex returns the start of the tick array holding tick.
+func (p *Whirlpool) TickArrayStartIndex(tick int32) int32 {
+	count := p.tickCount()
+	start := tick / count
+	if tick < 0 && tick%count != 0 {
+		start--
+	}
+	return start * count
+}
+
+// TickArrayStarts returns the start indexes of the tick arrays a swap
+// passes, beginning with the one holding the current tick. They need not
+// be initialized.
+func (p *Whirlpool) TickArrayStarts(aToB bool) []int32 {
+	count := p.tickCount()
+	step := lo.If(aToB, -count).Else(count)
+	start := p.TickArrayStartIndex(p.TickCurrentIndex)
+
+	starts := make([]int32, 0, SwapTickArrays)
+	for i := 0; i < SwapTickArrays; i++ {
+		if start < p.TickArrayStartIndex(MinTick) || start > p.TickArrayStartIndex(MaxTick) {
+			break
+		}
+		starts = append(starts, start)
+		start += step
+	}
+	return starts
+}
+
+func FindTickArray(poolID solana.PublicKey, startIndex int32) solana.PublicKey {
+	address, _, _ := solana.FindProgramAddress(
+		[][]byte{[]byte("tick_array"), poolID.Bytes(), []byte(strconv.Itoa(int(startIndex)))},
+		ProgramID,
+	)
+	return address
+}
+
+func FindOracle(poolID solana.PublicKey) solana.PublicKey {
+	address, _, _ := solana.FindProgramAddress(
+		[][]byte{[]byte("oracle"), poolID.Bytes()},
+		ProgramID,
+	)
+	return address
+}
+
+func GetWhirlpoolState(ctx context.Context, client *rpc.Client, poolID solana.PublicKey) (*Whirlpool, error) {
+	info, err := client.GetAccountInfoWithOpts(ctx, poolID, &rpc.GetAccountInfoOpts{Commitment: rpc.CommitmentConfirmed})
+	if err != nil {
+		return nil, err
+	}
+	pool, err := DecodeWhirlpool(info.Value.Data.GetBinary())
+	if err != nil {
+		return nil, types.ErrInvalidPool
+	}
+	return pool, nil
+}
+
+// GetTickArrays loads the tick arrays a swap in the given direction passes.
+// Those not yet initialized hold no liquidity and are left out.
+func GetTickArrays(ctx context.Context, client *rpc.Client, poolID solana.PublicKey, pool *Whirlpool, aToB bool) ([]*TickArray, error) {
+	addresses := lo.Map(pool.TickArrayStarts(aToB), func(start int32, _ int) solana.PublicKey {
+		return FindTickArray(poolID, start)
+	})
+	accounts, err := client.GetMultipleAccountsWithOpts(ctx, addresses, &rpc.GetMultipleAccountsOpts{Commitment: rpc.CommitmentConfirmed})
+	if err != nil {
+		return nil, err
+	}
+
+	var arrays []*TickArray
+	for _, account := range accounts.Value {
+		if account == nil {
+			continue
+		}
+		array, err := DecodeTickArray(account.Data.GetBinary())
+		if err != nil {
+			return nil, err
+		}
+		arrays = append(arrays, array)
+	}
+	return arrays, nil
+}
+
+type whirlpoolAccount struct {
+	ID   solana.PublicKey
+	Pool *Whirlpool
+}
+
+// findWhirlpools lists the pools of a mint pair with liquidity in range.
+func findWhirlpools(ctx context.Context, client *rpc.Client, mint, otherMint solana.PublicKey) ([]whirlpoolAccount, error) {
+	mintA, mintB := mint, otherMint
+	if bytes.Compare(mintA.Bytes(), mintB.Bytes()) > 0 {
+		mintA, mintB = mintB, mintA
+	}
+
+	result, err := client.GetProgramAccountsWithOpts(ctx, ProgramID, &rpc.GetProgramAccountsOpts{
+		Commitment: rpc.CommitmentConfirmed,
+		Encoding:   solana.EncodingBase64,
+		Filters: []rpc.RPCFilter{
+			{DataSize: WhirlpoolSize},
+			{Memcmp: &rpc.RPCFilterMemcmp{Offset: whirlpoolTokenMintAOffset, Bytes: mintA.Bytes()}},
+			{Memcmp: &rpc.RPCFilterMemcmp{Offset: whirlpoolTokenMintBOffset, Bytes: mintB.Bytes()}},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var pools []whirlpoolAccount
+	for _, account := range result {
+		pool, err := DecodeWhirlpool(account.Account.Data.GetBinary())
+		if err != nil || pool.Liquidity.BigInt().Sign() == 0 {
+			continue
+		}
+		pools = append(pools, whirlpoolAccount{ID: account.Pubkey, Pool: pool})
+	}
+	return pools, nil
+}
+
+// FindSolUsdcWhirlpool finds the SOL/USDC pool with the most liquidity in
+// range, which USDC pairs route through.
+func FindSolUsdcWhirlpool(ctx context.Context, client *rpc.Client) (solana.PublicKey, *Whirlpool, error) {
+	pools, err := findWhirlpools(ctx, client, solana.SolMint, UsdcMint)
+	if err != nil {
+		return solana.PublicKey{}, nil, err
+	}
+	if len(pools) == 0 {
+		return solana.PublicKey{}, nil, types.ErrInvalidPool
+	}
+	best := lo.MaxBy(pools, func(a, b whirlpoolAccount) bool {
+		return a.Pool.Liquidity.BigInt().Cmp(b.Pool.Liquidity.BigInt()) > 0
+	})
+	return best.ID, best.Pool, nil
+}
+
+// GetWhirlpoolByToken finds the SOL or USDC pool of mint whose quote vault
+// is worth the most SOL. A USDC pool carries the SOL/USDC pool it routes
+// through as its market.
+func GetWhirlpoolByToken(ctx context.Context, url string, mint solana.PublicKey) (*types.Pool, error) {
+	client := rpc.New(url)
+	solPools, err := findWhirlpools(ctx, client, mint, solana.SolMint)
+	if err != nil {
+		return nil, err
+	}
+	usdcPools, err := findWhirlpools(ctx, client, mint, UsdcMint)
+	if err != nil {
+		return nil, err
+	}
+
+	var hopID solana.PublicKey
+	var solPrice decimal.Decimal
+	if len(usdcPools) > 0 {
+		var hop *Whirlpool
+		hopID, hop, err = FindSolUsdcWhirlpool(ctx, client)
+		if err != nil {
+			return nil, err
+		}
+		solPrice = hop.Price(solana.SolMint, 9, UsdcDecimals)
+		if solPrice.IsZero() {
+			usdcPools = nil
+		}
+	}
+
+	candidates := append(solPools, usdcPools...)
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+	vaults := lo.Map(candidates, func(c whirlpoolAccount, _ int) solana.PublicKey {
+		return lo.If(c.Pool.TokenIsA(), c.Pool.TokenVaultB).Else(c.Pool.TokenVaultA)
+	})
+	accounts, err := client.GetMultipleAccountsWithOpts(ctx, vaults, &rpc.GetMultipleAccountsOpts{Commitment: rpc.CommitmentConfirmed})
+	if err != nil {
+		return nil, err
+	}
+
+	var best *whirlpoolAccount
+	bestDepth := decimal.Zero
+	for i := range candidates {
+		if accounts.Value[i] == nil {
+			continue
+		}
+		var vault token.Account
+		err = vault.UnmarshalWithDecoder(bin.NewBinDecoder(accounts.Value[i].Data.GetBinary()))
+		if err != nil {
+			continue
+		}
+		// in SOL
+		depth := decimal.NewFromUint64(vault.Amount).Shift(-9)
+		if candidates[i].Pool.QuoteMint().Equals(UsdcMint) {
+			depth = decimal.NewFromUint64(vault.Amount).Shift(-UsdcDecimals).Div(solPrice)
+		}
+		if depth.GreaterThan(bestDepth) {
+			best, bestDepth = &candidates[i], depth
+		}
+	}
+	if best == nil {
+		return nil, nil
+	}
+
+	pool := &types.Pool{
+		AmmPublicKey: best.ID.String(),
+		BaseMint:     best.Pool.TokenMintA.String(),
+		BaseVault:    best.Pool.TokenVaultA.String(),
+		QuoteMint:    best.Pool.TokenMintB.String(),
+		QuoteVault:   best.Pool.TokenVaultB.String(),
+		Dex:          types.DexOrca,
+		Marked:       true,
+	}
+	if best.Pool.QuoteMint().Equals(UsdcMint) {
+		pool.HopPool = hopID.String()
+	}
+	return pool, nil
+}
+
+// GetWhirlpool reads price, vault balances and fee of a whirlpool for
+// GetPool. The reserves are whole vault balances, not the liquidity in
+// range; for a USDC pool the quote reserve is in USDC and its price and
+// SOL reserve go through the SOL/USDC pool.
+func GetWhirlpool(ctx context.Context, url string, p *types.Pool, owner string, withBalance bool) (*common.GetSolPoolResponse, *common.Balance, error) {
+	poolID, err := solana.PublicKeyFromBase58(p.AmmPublicKey)
+	if err != nil {
+		return nil, nil, types.ErrInvalidPool
+	}
+
+	client := rpc.New(url)
+	pool, err := GetWhirlpoolState(ctx, client, poolID)
+	if err != nil {
+		return nil, nil, err
+	}
+	mint, quoteMint := pool.Mints()
+	if !quoteMint.Equals(solana.SolMint) && !quoteMint.Equals(UsdcMint) {
+		return nil, nil, types.ErrInvalidPool
+	}
+
+	var hopID solana.PublicKey
+	var hop *Whirlpool
+	if quoteMint.Equals(UsdcMint) {
+		if p.HopPool != "" {
+			hopID = solana.MPK(p.HopPool)
+			hop, err = GetWhirlpoolState(ctx, client, hopID)
+		} else {
+			hopID, hop, err = FindSolUsdcWhirlpool(ctx, client)
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	metaAddress, _, _ := solana.FindTokenMetadataAddress(mint)
+	publicKeys := []solana.PublicKey{
+		pool.TokenVaultA,
+		pool.TokenVaultB,
+		mint,
+		metaAddress,
+	}
+
+	if withBalance {
+		owner_ := solana.MPK(owner)
+		publicKeys = append(publicKeys, owner_)
+		publicKeys = append(publicKeys, common.TokenAccounts(owner_, mint)...)
+	}
+
+	accounts, err := client.GetMultipleAccountsWithOpts(
+		ctx,
+		publicKeys,
+		&rpc.GetMultipleAccountsOpts{Commitment: rpc.CommitmentProcessed},
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	balance := &common.Balance{NativeBalance: big.NewInt(0), TokenBalance: big.NewInt(0)}
+	if withBalance {
+		if accounts.Value[4] != nil {
+			balance.NativeBalance = new(big.Int).SetUint64(accounts.Value[4].Lamports)
+		}
+
+		balance.TokenBalance, err = common.TokenBalance(common.TokenAccountOf(accounts.Value[2], accounts.Value[5:]))
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var vaultA, vaultB token.Account
+	for i, vault := range []*token.Account{&vaultA, &vaultB} {
+		if accounts.Value[i] == nil {
+			return nil, balance, types.ErrInvalidPool
+		}
+		err = vault.UnmarshalWithDecoder(bin.NewBinDecoder(accounts.Value[i].Data.GetBinary()))
+		if err != nil {
+			return nil, balance, err
+		}
+	}
+
+	tokenMint, err := common.DecodeMint(accounts.Value[2])
+	if err != nil {
+		return nil, balance, err
+	}
+	err = tokenMint.SetEpoch(ctx, client)
+	if err != nil {
+		return nil, balance, err
+	}
+
+	name, symbol, err := common.TokenName(tokenMint, mint, accounts.Value[3])
+	if err != nil {
+		return nil, balance, err
+	}
+
+	tokenReserve, quoteReserve := vaultA.Amount-min(vaultA.Amount, pool.ProtocolFeeOwedA), vaultB.Amount-min(vaultB.Amount, pool.ProtocolFeeOwedB)
+	if !pool.TokenIsA() {
+		tokenReserve, quoteReserve = quoteReserve, tokenReserve
+	}
+	if pool.Liquidity.BigInt().Sign() == 0 || tokenReserve == 0 || quoteReserve == 0 {
+		return nil, balance, types.ErrPoolCompleted
+	}
+
+	var quoteDecimals uint8 = 9
+	priceInSol := pool.Price(mint, tokenMint.Decimals, quoteDecimals)
+	solReserve := new(big.Int).SetUint64(quoteReserve)
+	if hop != nil {
+		quoteDecimals = UsdcDecimals
+		solPrice := hop.Price(solana.SolMint, 9, UsdcDecimals)
+		if solPrice.IsZero() {
+			return nil, balance, types.ErrInvalidPool
+		}
+		priceInSol = pool.Price(mint, tokenMint.Decimals, quoteDecimals).Div(solPrice)
+		solReserve = decimal.NewFromUint64(quoteReserve).Shift(9 - UsdcDecimals).Div(solPrice).BigInt()
+	}
+
+	totalSupply := decimal.NewFromUint64(tokenMint.Supply).Div(decimal.New(1, int32(tokenMint.Decimals)))
+
+	ret := &common.GetSolPoolResponse{
+		PriceInSol:            priceInSol,
+		TotalSupply:           totalSupply,
+		FreezeDisabled:        tokenMint.FreezeAuthority == nil,
+		Burnt:                 false, // positions are not tokens and cannot be burnt
+		MintAuthorityDisabled: tokenMint.MintAuthority == nil,
+		TokenReserve:          new(big.Int).SetUint64(tokenReserve),
+		SolReserve:            solReserve,
+		Name:                  name,
+		Symbol:                symbol,
+		Decimals:              tokenMint.Decimals,
+		QuoteDecimals:         quoteDecimals,
+		TokenAddress:          mint.String(),
+		QuoteAddress:          quoteMint.String(),
+		PoolAddress:           p.AmmPublicKey,
+		PoolFeeBps:            pool.FeeBps(),
+	}
+	if hop != nil {
+		ret.QuoteReserve = new(big.Int).SetUint64(quoteReserve)
+		ret.HopPool = hopID.String()
+	}
+	ret.SetMint(tokenMint)
+	return ret, balance, nil
+}
@@ -0,0 +1,229 @@
+package orca
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"sort"
+
+	"github.com/samber/lo"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	MinTick = -443636
+	MaxTick = 443636
+)
+
+var (
+	ErrTickArrays = errors.New("swap runs past the tick arrays it can take")
+
+	// the sqrt prices at MinTick and MaxTick, the limits a swap may not pass
+	MinSqrtPrice, _ = new(big.Int).SetString("4295048016", 10)
+	MaxSqrtPrice, _ = new(big.Int).SetString("79226673515401279992447579055", 10)
+
+	q64 = new(big.Int).Lsh(big.NewInt(1), 64)
+)
+
+// Quote is the result of walking a swap through a pool's ticks.
+type Quote struct {
+	AmountIn  uint64 // including the fee
+	AmountOut uint64
+	Fee       uint64
+	SqrtPrice *big.Int // price after the swap
+}
+
+// SqrtPriceAtTick returns sqrt(1.0001^tick) as a Q64.64 number. It is
+// computed in floating point, which is close enough to quote with.
+func SqrtPriceAtTick(tick int32) *big.Int {
+	sqrtPrice := new(big.Float).SetFloat64(math.Pow(1.0001, float64(tick)/2))
+	sqrtPrice.Mul(sqrtPrice, new(big.Float).SetInt(q64))
+	ret, _ := sqrtPrice.Int(nil)
+	return ret
+}
+
+// TickAtSqrtPrice returns the greatest tick whose sqrt price is at most
+// sqrtPrice.
+func TickAtSqrtPrice(sqrtPrice *big.Int) int32 {
+	ratio, _ := new(big.Float).Quo(new(big.Float).SetInt(sqrtPrice), new(big.Float).SetInt(q64)).Float64()
+	tick := int32(math.Floor(2 * math.Log(ratio) / math.Log(1.0001)))
+	for tick > MinTick && SqrtPriceAtTick(tick).Cmp(sqrtPrice) > 0 {
+		tick--
+	}
+	for tick < MaxTick && SqrtPriceAtTick(tick+1).Cmp(sqrtPrice) <= 0 {
+		tick++
+	}
+	return tick
+}
+
+// SqrtPriceToPrice converts a Q64.64 sqrt price to the price of token A in
+// token B, both in whole units.
+func SqrtPriceToPrice(sqrtPrice *big.Int, decimalsA, decimalsB uint8) decimal.Decimal {
+	ratio := decimal.NewFromBigInt(sqrtPrice, 0).Div(decimal.NewFromBigInt(q64, 0))
+	return ratio.Mul(ratio).Shift(int32(decimalsA) - int32(decimalsB))
+}
+
+// amountADelta is the token A between two sqrt prices at liquidity:
+// L * (upper - lower) / (upper * lower), scaled back from Q64.64.
+func amountADelta(sqrtA, sqrtB, liquidity *big.Int, roundUp bool) *big.Int {
+	lower, upper := sqrtA, sqrtB
+	if lower.Cmp(upper) > 0 {
+		lower, upper = upper, lower
+	}
+	numerator := new(big.Int).Lsh(liquidity, 64)
+	numerator.Mul(numerator, new(big.Int).Sub(upper, lower))
+	denominator := new(big.Int).Mul(upper, lower)
+	return div(numerator, denominator, roundUp)
+}
+
+// amountBDelta is the token B between two sqrt prices at liquidity:
+// L * (upper - lower), scaled back from Q64.64.
+func amountBDelta(sqrtA, sqrtB, liquidity *big.Int, roundUp bool) *big.Int {
+	diff := new(big.Int).Sub(sqrtA, sqrtB)
+	diff.Abs(diff)
+	return div(diff.Mul(diff, liquidity), q64, roundUp)
+}
+
+func div(a, b *big.Int, roundUp bool) *big.Int {
+	q, r := new(big.Int).QuoRem(a, b, new(big.Int))
+	if roundUp && r.Sign() != 0 {
+		q.Add(q, big.NewInt(1))
+	}
+	return q
+}
+
+// nextSqrtPrice moves the price by amount of token A or B going in.
+func nextSqrtPrice(sqrtPrice, liquidity, amount *big.Int, aToB bool) *big.Int {
+	if aToB {
+		// L * P / (L + amount * P)
+		numerator := new(big.Int).Lsh(liquidity, 64)
+		denominator := new(big.Int).Add(numerator, new(big.Int).Mul(amount, sqrtPrice))
+		return div(numerator.Mul(numerator, sqrtPrice), denominator, true)
+	}
+	// P + amount / L
+	delta := div(new(big.Int).Lsh(amount, 64), liquidity, false)
+	return delta.Add(sqrtPrice, delta)
+}
+
+type initializedTick struct {
+	Index        int32
+	LiquidityNet *big.Int
+}
+
+// Quote walks a swap of amountIn, fee included, through the ticks of
+// tickArrays, which are the initialized ones among those the swap takes.
+// aToB sells token A for token B. A swap that would run past the last of
+// the arrays it takes fails.
+func (p *Whirlpool) Quote(tickArrays []*TickArray, amountIn uint64, aToB bool) (*Quote, error) {
+	var ticks []initializedTick
+	for _, array := range tickArrays {
+		for i, tick := range array.Ticks {
+			if tick.Initialized {
+				index := array.StartTickIndex + int32(i)*int32(p.TickSpacing)
+				ticks = append(ticks, initializedTick{Index: index, LiquidityNet: tick.LiquidityNet.BigInt()})
+			}
+		}
+	}
+	sort.Slice(ticks, func(i, j int) bool { return ticks[i].Index < ticks[j].Index })
+
+	// the furthest the price may go within the arrays the swap takes
+	starts := p.TickArrayStarts(aToB)
+	if len(starts) == 0 {
+		return nil, ErrTickArrays
+	}
+	bound := starts[len(starts)-1]
+	if !aToB {
+		bound += p.tickCount() - int32(p.TickSpacing)
+	}
+	bound = max(min(bound, MaxTick), MinTick)
+
+	feeRate := big.NewInt(int64(p.FeeRate))
+	feeComplement := new(big.Int).Sub(big.NewInt(FeeDenominator), feeRate)
+	sqrtPrice := p.SqrtPrice.BigInt()
+	liquidity := p.Liquidity.BigInt()
+	tickCurrent := p.TickCurrentIndex
+	remaining := new(big.Int).SetUint64(amountIn)
+	amountOut, fee := new(big.Int), new(big.Int)
+
+	for remaining.Sign() > 0 {
+		next, crossing := nextInitializedTick(ticks, tickCurrent, aToB)
+		if crossing && (aToB && next.Index < bound || !aToB && next.Index > bound) {
+			crossing = false
+		}
+		targetTick := lo.If(crossing, next.Index).Else(bound)
+		sqrtTarget := SqrtPriceAtTick(targetTick)
+		if !crossing && sqrtPrice.Cmp(sqrtTarget) == 0 {
+			return nil, ErrTickArrays
+		}
+
+		var stepIn, stepFee, sqrtNext *big.Int
+		lessFee := div(new(big.Int).Mul(remaining, feeComplement), big.NewInt(FeeDenominator), false)
+		stepIn = stepAmountIn(sqrtPrice, sqrtTarget, liquidity, aToB)
+		if lessFee.Cmp(stepIn) >= 0 {
+			sqrtNext = sqrtTarget
+			stepFee = div(new(big.Int).Mul(stepIn, feeRate), feeComplement, true)
+		} else {
+			sqrtNext = nextSqrtPrice(sqrtPrice, liquidity, lessFee, aToB)
+			stepIn = stepAmountIn(sqrtPrice, sqrtNext, liquidity, aToB)
+			stepFee = new(big.Int).Sub(remaining, stepIn)
+		}
+		amountOut.Add(amountOut, stepAmountOut(sqrtPrice, sqrtNext, liquidity, aToB))
+		fee.Add(fee, stepFee)
+		remaining.Sub(remaining, new(big.Int).Add(stepIn, stepFee))
+		sqrtPrice = sqrtNext
+
+		if sqrtNext.Cmp(sqrtTarget) != 0 {
+			tickCurrent = TickAtSqrtPrice(sqrtNext)
+		} else if crossing {
+			if aToB {
+				liquidity.Sub(liquidity, next.LiquidityNet)
+				tickCurrent = next.Index - 1
+			} else {
+				liquidity.Add(liquidity, next.LiquidityNet)
+				tickCurrent = next.Index
+			}
+		} else {
+			tickCurrent = lo.If(aToB, targetTick-1).Else(targetTick)
+		}
+	}
+
+	return &Quote{
+		AmountIn:  amountIn,
+		AmountOut: amountOut.Uint64(),
+		Fee:       fee.Uint64(),
+		SqrtPrice: sqrtPrice,
+	}, nil
+}
+
+func stepAmountIn(sqrtPrice, sqrtTarget, liquidity *big.Int, aToB bool) *big.Int {
+	if aToB {
+		return amountADelta(sqrtTarget, sqrtPrice, liquidity, true)
+	}
+	return amountBDelta(sqrtPrice, sqrtTarget, liquidity, true)
+}
+
+func stepAmountOut(sqrtPrice, sqrtTarget, liquidity *big.Int, aToB bool) *big.Int {
+	if aToB {
+		return amountBDelta(sqrtTarget, sqrtPrice, liquidity, false)
+	}
+	return amountADelta(sqrtPrice, sqrtTarget, liquidity, false)
+}
+
+// nextInitializedTick finds the next tick the price reaches: at or below
+// the current tick going down, above it going up.
+func nextInitializedTick(ticks []initializedTick, current int32, aToB bool) (initializedTick, bool) {
+	if aToB {
+		for i := len(ticks) - 1; i >= 0; i-- {
+			if ticks[i].Index <= current {
+				return ticks[i], true
+			}
+		}
+		return initializedTick{}, false
+	}
+	for _, tick := range ticks {
+		if tick.Index > current {
+			return tick, true
+		}
+	}
+	return initializedTick{}, false
+}
@@ -0,0 +1,115 @@
+package orca
+
+import (
+	"encoding/binary"
+	"math/big"
+	"testing"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+)
+
+func TestDecodeWhirlpool_Offsets(t *testing.T) {
+	token := solana.NewWallet().PublicKey()
+
+	data := make([]byte, WhirlpoolSize)
+	copy(data, WhirlpoolDiscriminator[:])
+	binary.LittleEndian.PutUint16(data[41:], 64)   // tick spacing
+	binary.LittleEndian.PutUint16(data[45:], 3000) // fee rate
+	tick := int32(-1234)
+	binary.LittleEndian.PutUint32(data[81:], uint32(tick))
+	copy(data[whirlpoolTokenMintAOffset:], solana.SolMint.Bytes())
+	copy(data[whirlpoolTokenMintBOffset:], token.Bytes())
+
+	pool, err := DecodeWhirlpool(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pool.TickSpacing != 64 || pool.FeeBps() != 30 || pool.TickCurrentIndex != -1234 {
+		t.Errorf("tick spacing %d, fee %d bps, tick %d", pool.TickSpacing, pool.FeeBps(), pool.TickCurrentIndex)
+	}
+	if mint, quote := pool.Mints(); !mint.Equals(token) || !quote.Equals(solana.SolMint) || pool.TokenIsA() {
+		t.Errorf("Mints = %s, %s", mint, quote)
+	}
+}
+
+func TestWhirlpool_TickArrayStarts(t *testing.T) {
+	pool := &Whirlpool{TickSpacing: 64, TickCurrentIndex: -1}
+	// 88 ticks of 64 make arrays of 5632
+	if got := pool.TickArrayStarts(true); got[0] != -5632 || got[1] != -11264 || got[2] != -16896 {
+		t.Errorf("a to b: %v", got)
+	}
+	if got := pool.TickArrayStarts(false); got[0] != -5632 || got[1] != 0 || got[2] != 5632 {
+		t.Errorf("b to a: %v", got)
+	}
+}
+
+func TestDecodeTickArray_Dynamic(t *testing.T) {
+	whirlpool := solana.NewWallet().PublicKey()
+	data := append([]byte{}, DynamicTickArrayDiscriminator[:]...)
+	data = binary.LittleEndian.AppendUint32(data, uint32(5632))
+	data = append(data, whirlpool.Bytes()...)
+	data = append(data, make([]byte, 16)...) // bitmap
+	for i := 0; i < TickArraySize; i++ {
+		if i != 3 {
+			data = append(data, 0)
+			continue
+		}
+		data = append(data, 1)
+		data = binary.LittleEndian.AppendUint64(data, 77) // liquidity net, low half
+		data = append(data, make([]byte, 8+16*6)...)
+	}
+
+	array, err := DecodeTickArray(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if array.StartTickIndex != 5632 || !array.Whirlpool.Equals(whirlpool) {
+		t.Errorf("start %d, whirlpool %s", array.StartTickIndex, array.Whirlpool)
+	}
+	if !array.Ticks[3].Initialized || array.Ticks[3].LiquidityNet.BigInt().Int64() != 77 || array.Ticks[2].Initialized {
+		t.Errorf("ticks %+v", array.Ticks[2:4])
+	}
+}
+
+func TestWhirlpool_Quote(t *testing.T) {
+	liquidity := uint64(1000000000)
+	pool := &Whirlpool{
+		TickSpacing: 1,
+		FeeRate:     3000,
+		Liquidity:   bin.Uint128{Lo: liquidity},
+		SqrtPrice:   bin.Uint128{Lo: 0, Hi: 1}, // price 1
+	}
+
+	quote, err := pool.Quote(nil, 1000, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 997 in after the fee, at price 1 with deep liquidity
+	if quote.AmountOut != 996 || quote.Fee != 3 {
+		t.Errorf("no ticks: %+v", quote)
+	}
+
+	// half the liquidity leaves at tick -10
+	array := &TickArray{StartTickIndex: -88}
+	array.Ticks[78] = Tick{Initialized: true, LiquidityNet: bin.Int128{Lo: liquidity / 2}}
+	deep, err := pool.Quote(nil, 1000000, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	crossed, err := pool.Quote([]*TickArray{array}, 1000000, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if crossed.AmountOut >= deep.AmountOut || crossed.SqrtPrice.Cmp(SqrtPriceAtTick(-10)) >= 0 {
+		t.Errorf("crossing a tick: %d out, deep %d out", crossed.AmountOut, deep.AmountOut)
+	}
+
+	// three arrays of 88 ticks reach down to tick -176
+	if _, err = pool.Quote(nil, 100000000, true); err != ErrTickArrays {
+		t.Errorf("past the tick arrays: err = %v", err)
+	}
+	if new(big.Int).Lsh(big.NewInt(1), 64).Cmp(pool.SqrtPrice.BigInt()) != 0 {
+		t.Error("quote moved the pool's price")
+	}
+}
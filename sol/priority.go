@@ -0,0 +1,79 @@
+package sol
+
+import (
+	"math/big"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/meme-bots/go-web3/sol/common"
+	"github.com/meme-bots/go-web3/sol/pumpfun"
+	"github.com/meme-bots/go-web3/types"
+)
+
+// priorityFee resolves what a transaction pays at level for writing
+// accounts. Estimates are bid as a compute unit price, which the transaction
+// pays on whatever limit it ends up with; under PriorityLevelAuto the total
+// is capped by gas or MaxPriorityFee. Estimates that cannot be had fall back
+// to gas lamports, as if the level were manual.
+func (s *Solana) priorityFee(level int, gas *big.Int, accounts []solana.PublicKey) common.PriorityFee {
+	var manual uint64
+	if gas != nil {
+		manual = gas.Uint64()
+	}
+	if level == types.PriorityLevelManual {
+		return common.FixedPriorityFee(manual)
+	}
+
+	percentile := level
+	if level == types.PriorityLevelAuto {
+		percentile = types.PriorityLevelHigh
+	}
+	price, err := common.EstimatePriorityFee(s.ctx, rpc.New(s.cfg.RPC), accounts, percentile)
+	if err != nil {
+		return common.FixedPriorityFee(manual)
+	}
+
+	fee := common.PriorityFee{Price: price}
+	if level == types.PriorityLevelAuto {
+		fee.MaxLamports = s.cfg.MaxPriorityFee
+		if manual > 0 {
+			fee.MaxLamports = manual
+		}
+	}
+	return fee
+}
+
+// transactAccounts are the contended accounts a swap writes, as far as they
+// follow from the request and the token program of its mint.
+func transactAccounts(req *types.Transact, tokenProgram solana.PublicKey) []solana.PublicKey {
+	if req.BestExecution {
+		// the venues are only known once routed
+		return nil
+	}
+	if req.Dex == types.DexRaydium {
+		accounts := []solana.PublicKey{solana.MPK(req.MarketId)}
+		if req.PoolID != "" {
+			accounts = append(accounts, solana.MPK(req.PoolID))
+		}
+		return accounts
+	}
+	if req.Dex == types.DexRaydiumCpmm || req.Dex == types.DexRaydiumClmm || req.Dex == types.DexPumpSwap ||
+		req.Dex == types.DexMeteoraDlmm || req.Dex == types.DexMeteoraDamm {
+		return []solana.PublicKey{solana.MPK(req.PoolID)}
+	}
+	if req.Dex == types.DexOrca {
+		accounts := []solana.PublicKey{solana.MPK(req.PoolID)}
+		if req.HopPool != "" {
+			accounts = append(accounts, solana.MPK(req.HopPool))
+		}
+		return accounts
+	}
+
+	tokenMint := solana.MPK(req.TokenIn)
+	if tokenMint.Equals(solana.SolMint) {
+		tokenMint = solana.MPK(req.TokenOut)
+	}
+	bondingCurve := pumpfun.FindBondingCurve(tokenMint)
+	bondingCurveAta := common.FindAssociatedTokenAddress(bondingCurve, tokenMint, tokenProgram)
+	return []solana.PublicKey{bondingCurve, bondingCurveAta, pumpfun.GlobalFeeRecipient}
+}
@@ -3,13 +3,10 @@ package pumpfun
 import (
 	"context"
 	"math/big"
-	"math/rand"
 
 	bin "github.com/gagliardetto/binary"
 	"github.com/gagliardetto/solana-go"
 	associatedtokenaccount "github.com/gagliardetto/solana-go/programs/associated-token-account"
-	computebudget "github.com/gagliardetto/solana-go/programs/compute-budget"
-	"github.com/gagliardetto/solana-go/programs/system"
 	"github.com/gagliardetto/solana-go/programs/token"
 	"github.com/gagliardetto/solana-go/rpc"
 	"github.com/meme-bots/go-web3/sol/common"
@@ -23,15 +20,27 @@ import (
 var (
 	GlobalPubKey                  = solana.MPK("4wTV1YmiEkRvAtNtsSGPtUrqRYQMe5SKy2uB4Jjaxnjf")
 	GlobalFeeRecipient            = solana.MPK("CebN5WGQ4jvEPvsVU4EoHEpgzq1VV7AbicfhtW4xC9iM")
-	eventAuthority                = solana.MPK("Ce6TQqeHC9p8KetsN6JsjHK7UTZk7nasjjnr7XxXp9F1")
+	EventAuthority                = solana.MPK("Ce6TQqeHC9p8KetsN6JsjHK7UTZk7nasjjnr7XxXp9F1")
 	MPL_TOKEN_METADATA_PROGRAM_ID = solana.MPK("metaqbxxUerdq28cj1RbAWkYQm3ybzjb6a8bt518x1s")
 	MINT_AUTHORITY                = solana.MPK("TSLvdd1pWpHVjahSpsvCXUbgwsL3JAcvokwaKt1eokM")
+
+	// instructionMigrate moves a completed curve into PumpSwap, where
+	// Withdraw used to hand it out for Raydium.
+	instructionMigrate = bin.TypeID([8]byte{155, 234, 231, 146, 236, 158, 162, 30})
 )
 
 const (
 	TotalSupplyWithDecimals = 1000000000000000
 	TotalSupply             = 1000000000
 
+	// FeeBps is the curve's trade fee, paid on top of what a buy costs and
+	// out of what a sell returns.
+	FeeBps = 100
+
+	// completedAtLookback is how many of a curve's last transactions are
+	// searched for its migration.
+	completedAtLookback = 20
+
 	// MINT_AUTHORITY_SEED = "mint-authority"
 	// BONDING_CURVE_SEED  = "bonding-curve"
 )
@@ -62,8 +71,8 @@ func GetPumpFunPool(ctx context.Context, url string, req *common.GetSolPoolReque
 
 	if req.WithBalance {
 		owner := solana.MPK(req.Owner)
-		ata, _, _ := solana.FindAssociatedTokenAddress(owner, mint)
-		publicKeys = append(publicKeys, owner, ata)
+		publicKeys = append(publicKeys, owner)
+		publicKeys = append(publicKeys, common.TokenAccounts(owner, mint)...)
 	}
 
 	accounts, err := client.GetMultipleAccountsWithOpts(
@@ -81,13 +90,9 @@ func GetPumpFunPool(ctx context.Context, url string, req *common.GetSolPoolReque
 			balance.NativeBalance = new(big.Int).SetUint64(accounts.Value[3].Lamports)
 		}
 
-		if accounts.Value[4] != nil {
-			var tokenAccount token.Account
-			err = tokenAccount.UnmarshalWithDecoder(bin.NewBinDecoder(accounts.Value[4].Data.GetBinary()))
-			if err != nil {
-				return nil, nil, err
-			}
-			balance.TokenBalance = new(big.Int).SetUint64(tokenAccount.Amount)
+		balance.TokenBalance, err = common.TokenBalance(common.TokenAccountOf(accounts.Value[1], accounts.Value[4:]))
+		if err != nil {
+			return nil, nil, err
 		}
 	}
 
@@ -105,13 +110,16 @@ func GetPumpFunPool(ctx context.Context, url string, req *common.GetSolPoolReque
 		return nil, balance, types.ErrPoolCompleted
 	}
 
-	var tokenMintAccount token.Mint
-	err = tokenMintAccount.UnmarshalWithDecoder(bin.NewBinDecoder(accounts.Value[1].Data.GetBinary()))
+	tokenMint, err := common.DecodeMint(accounts.Value[1])
+	if err != nil {
+		return nil, balance, err
+	}
+	err = tokenMint.SetEpoch(ctx, client)
 	if err != nil {
 		return nil, balance, err
 	}
 
-	meta, err := common.MetadataDeserialize(accounts.Value[2].Data.GetBinary())
+	name, symbol, err := common.TokenName(tokenMint, mint, accounts.Value[2])
 	if err != nil {
 		return nil, balance, err
 	}
@@ -120,7 +128,7 @@ func GetPumpFunPool(ctx context.Context, url string, req *common.GetSolPoolReque
 	tokenAmount := decimal.NewFromUint64(bondingCurve.VirtualTokenReserves).Div(decimal.NewFromInt(1e6))
 	priceInSol := solAmount.Div(tokenAmount)
 
-	return &common.GetSolPoolResponse{
+	ret := &common.GetSolPoolResponse{
 		PriceInSol:            priceInSol,
 		TotalSupply:           decimal.NewFromUint64(TotalSupply),
 		FreezeDisabled:        true,
@@ -128,31 +136,44 @@ func GetPumpFunPool(ctx context.Context, url string, req *common.GetSolPoolReque
 		MintAuthorityDisabled: true,
 		TokenReserve:          new(big.Int).SetUint64(bondingCurve.VirtualTokenReserves),
 		SolReserve:            new(big.Int).SetUint64(bondingCurve.VirtualSolReserves),
-		Name:                  utils.TrimSpace(meta.Data.Name),
-		Symbol:                utils.TrimSpace(meta.Data.Symbol),
-		Decimals:              tokenMintAccount.Decimals,
+		Name:                  name,
+		Symbol:                symbol,
+		Decimals:              tokenMint.Decimals,
 		QuoteDecimals:         9,
 		TokenAddress:          req.Token,
 		QuoteAddress:          solana.SolMint.String(),
 		PoolAddress:           bondingCurvePubKey.String(),
-	}, balance, nil
+	}
+	ret.SetMint(tokenMint)
+	return ret, balance, nil
 }
 
 func GetPumpFunPoolByToken(ctx context.Context, url string, token solana.PublicKey) (*types.Pool, error) {
 	client := rpc.New(url)
 
 	bondingCurvePubKey := FindBondingCurve(token)
-	baseVault, _, _ := solana.FindAssociatedTokenAddress(bondingCurvePubKey, token)
-	accountInfo, err := client.GetAccountInfoWithOpts(ctx, bondingCurvePubKey, &rpc.GetAccountInfoOpts{Commitment: rpc.CommitmentConfirmed})
+	accounts, err := client.GetMultipleAccountsWithOpts(
+		ctx,
+		[]solana.PublicKey{bondingCurvePubKey, token},
+		&rpc.GetMultipleAccountsOpts{Commitment: rpc.CommitmentConfirmed},
+	)
 	if err != nil {
 		return nil, err
 	}
+	if accounts.Value[0] == nil {
+		return nil, types.ErrInvalidPool
+	}
 
 	bondingCurve := BondingCurve{}
-	err = borsh.Deserialize(&bondingCurve, accountInfo.GetBinary())
+	err = borsh.Deserialize(&bondingCurve, accounts.Value[0].Data.GetBinary())
 	if err != nil {
 		return nil, err
 	}
+	tokenProgram, err := common.TokenProgramOf(accounts.Value[1])
+	if err != nil {
+		return nil, err
+	}
+	baseVault := common.FindAssociatedTokenAddress(bondingCurvePubKey, token, tokenProgram)
 
 	return &types.Pool{
 		AmmPublicKey: bondingCurvePubKey.String(),
@@ -179,38 +200,26 @@ func FindBondingCurve(mint solana.PublicKey) solana.PublicKey {
 	return bondingCurve
 }
 
-func SendBuy(
-	ctx context.Context,
-	url string,
-	mint, botFeeRecipient solana.PublicKey,
-	solAmount, slippage, gasFee, feeRatio, jitoTip uint64,
+func BuyInstructions(
+	mint solana.PublicKey,
+	tokenMint *common.Mint,
+	solAmount, slippage uint64,
 	bondingCurve BondingCurve,
 	createAta bool,
-	privKey solana.PrivateKey,
-	recentBlockHash solana.Hash,
-) (solana.Signature, error) {
+	payer solana.PublicKey,
+) ([]solana.Instruction, error) {
 	var instructions []solana.Instruction
 
-	//set gas limit and gas price
-	gasLimit := 140000
-	gasTmp := new(big.Int).Mul(big.NewInt(1000000), new(big.Int).SetUint64(gasFee))
-	gasPrice := new(big.Int).Div(gasTmp, big.NewInt(int64(gasLimit)))
-	setCULimitInst := computebudget.NewSetComputeUnitLimitInstruction(uint32(gasLimit)).Build()
-	setCUPriceInst := computebudget.NewSetComputeUnitPriceInstruction(gasPrice.Uint64()).Build()
-	instructions = append(instructions, setCULimitInst, setCUPriceInst)
-
 	bondingCurvePubKey := FindBondingCurve(mint)
-	bondingCurveAta, _, _ := solana.FindAssociatedTokenAddress(bondingCurvePubKey, mint)
-	ata, _, _ := solana.FindAssociatedTokenAddress(privKey.PublicKey(), mint)
+	bondingCurveAta := common.FindAssociatedTokenAddress(bondingCurvePubKey, mint, tokenMint.Program)
+	ata := common.FindAssociatedTokenAddress(payer, mint, tokenMint.Program)
 
 	//create ata
 	if createAta {
-		createAtaInst := associatedtokenaccount.NewCreateInstruction(privKey.PublicKey(), privKey.PublicKey(), mint).Build()
+		createAtaInst := common.NewCreateIdempotentAtaInstruction(payer, payer, mint, tokenMint.Program)
 		instructions = append(instructions, createAtaInst)
 	}
 
-	fee := solAmount * feeRatio / 10000
-	solAmount -= fee
 	tokenAmount := utils.CalculateOutput(solAmount, bondingCurve.VirtualSolReserves, bondingCurve.VirtualTokenReserves)
 	solAmount += solAmount * slippage / 10000
 
@@ -224,81 +233,33 @@ func SendBuy(
 		bondingCurvePubKey,
 		bondingCurveAta,
 		ata,
-		privKey.PublicKey(),
+		payer,
 		solana.SystemProgramID,
-		solana.TokenProgramID,
+		tokenMint.Program,
 		solana.SysVarRentPubkey,
-		eventAuthority,
+		EventAuthority,
 		ProgramID,
 	).Build()
 	instructions = append(instructions, swapInst)
 
-	//transfer fee
-	if fee != 0 {
-		feeTransferInst := system.NewTransferInstruction(fee, privKey.PublicKey(), botFeeRecipient).Build()
-		instructions = append(instructions, feeTransferInst)
-	}
-
-	//jito tip
-	if jitoTip != 0 {
-		idx := rand.Intn(len(common.JitoTipPaymentAccounts))
-		jitoTipTransferInst := system.NewTransferInstruction(jitoTip, privKey.PublicKey(), common.JitoTipPaymentAccounts[idx]).Build()
-		instructions = append(instructions, jitoTipTransferInst)
-	}
-
-	cli := rpc.New(url)
-	if recentBlockHash.IsZero() {
-		recentBlock, err := cli.GetLatestBlockhash(ctx, rpc.CommitmentFinalized)
-		if err != nil {
-			return solana.Signature{}, err
-		}
-		recentBlockHash = recentBlock.Value.Blockhash
-	}
-
-	tx, err := solana.NewTransaction(instructions, recentBlockHash, solana.TransactionPayer(privKey.PublicKey()))
-	if err != nil {
-		return solana.Signature{}, err
-	}
-
-	_, err = tx.Sign(func(key solana.PublicKey) *solana.PrivateKey {
-		return &privKey
-	})
-	if err != nil {
-		return solana.Signature{}, err
-	}
-
-	if jitoTip != 0 {
-		return rpc.New(common.JitoRpc).SendTransaction(ctx, tx)
-	}
-
-	return cli.SendTransactionWithOpts(ctx, tx, rpc.TransactionOpts{SkipPreflight: true})
+	return instructions, nil
 }
 
-func SendSell(
-	ctx context.Context,
-	url string,
-	mint, botFeeRecipient solana.PublicKey,
-	tokenAmount, slippage, gasFee, feeRatio, jitoTip uint64,
+func SellInstructions(
+	mint solana.PublicKey,
+	tokenMint *common.Mint,
+	tokenAmount, slippage uint64,
 	bondingCurve BondingCurve,
 	isSellAll bool,
-	privKey solana.PrivateKey,
-	recentBlockHash solana.Hash,
-) (solana.Signature, error) {
+	payer solana.PublicKey,
+) ([]solana.Instruction, error) {
 	var instructions []solana.Instruction
 
-	//set gas limit and gas price
-	gasLimit := 140000
-	gasTmp := new(big.Int).Mul(big.NewInt(1000000), new(big.Int).SetUint64(gasFee))
-	gasPrice := new(big.Int).Div(gasTmp, big.NewInt(int64(gasLimit)))
-	setCULimitInst := computebudget.NewSetComputeUnitLimitInstruction(uint32(gasLimit)).Build()
-	setCUPriceInst := computebudget.NewSetComputeUnitPriceInstruction(gasPrice.Uint64()).Build()
-	instructions = append(instructions, setCULimitInst, setCUPriceInst)
-
 	bondingCurvePubKey := FindBondingCurve(mint)
-	bondingCurveAta, _, _ := solana.FindAssociatedTokenAddress(bondingCurvePubKey, mint)
-	ata, _, _ := solana.FindAssociatedTokenAddress(privKey.PublicKey(), mint)
-	minSolOutput := utils.CalculateOutput(tokenAmount, bondingCurve.VirtualTokenReserves, bondingCurve.VirtualSolReserves)
-	fee := minSolOutput * feeRatio / 10000
+	bondingCurveAta := common.FindAssociatedTokenAddress(bondingCurvePubKey, mint, tokenMint.Program)
+	ata := common.FindAssociatedTokenAddress(payer, mint, tokenMint.Program)
+	// the curve receives what is left after the mint's transfer fee
+	minSolOutput := utils.CalculateOutput(tokenMint.AmountAfterFee(tokenAmount), bondingCurve.VirtualTokenReserves, bondingCurve.VirtualSolReserves)
 	minSolOutput -= minSolOutput * slippage / 10000
 	//swap
 	swapInst := NewSellInstruction(
@@ -310,64 +271,93 @@ func SendSell(
 		bondingCurvePubKey,
 		bondingCurveAta,
 		ata,
-		privKey.PublicKey(),
+		payer,
 		solana.SystemProgramID,
 		solana.SPLAssociatedTokenAccountProgramID,
-		solana.TokenProgramID,
-		eventAuthority,
+		tokenMint.Program,
+		EventAuthority,
 		ProgramID,
 	).Build()
 	instructions = append(instructions, swapInst)
 
 	//close ata
 	if isSellAll {
-		closeAccountInst := token.NewCloseAccountInstruction(
+		closeAccountInst := common.WithProgram(token.NewCloseAccountInstruction(
 			ata,
-			privKey.PublicKey(),
-			privKey.PublicKey(),
-			[]solana.PublicKey{privKey.PublicKey()},
-		).Build()
+			payer,
+			payer,
+			[]solana.PublicKey{payer},
+		).Build(), tokenMint.Program)
 		instructions = append(instructions, closeAccountInst)
 	}
 
-	if fee != 0 {
-		feeTransferInst := system.NewTransferInstruction(fee, privKey.PublicKey(), botFeeRecipient).Build()
-		instructions = append(instructions, feeTransferInst)
-	}
+	return instructions, nil
+}
 
-	//jito tip
-	if jitoTip != 0 {
-		idx := rand.Intn(len(common.JitoTipPaymentAccounts))
-		jitoTipTransferInst := system.NewTransferInstruction(jitoTip, privKey.PublicKey(), common.JitoTipPaymentAccounts[idx]).Build()
-		instructions = append(instructions, jitoTipTransferInst)
-	}
+// BuyQuote is what solAmount, the fee included, buys on bondingCurve.
+func BuyQuote(bondingCurve BondingCurve, solAmount uint64) uint64 {
+	solCost := solAmount/(10000+FeeBps)*10000 + solAmount%(10000+FeeBps)*10000/(10000+FeeBps)
+	return utils.CalculateOutput(solCost, bondingCurve.VirtualSolReserves, bondingCurve.VirtualTokenReserves)
+}
 
-	cli := rpc.New(url)
-	if recentBlockHash.IsZero() {
-		recentBlock, err := cli.GetLatestBlockhash(ctx, rpc.CommitmentFinalized)
-		if err != nil {
-			return solana.Signature{}, err
-		}
-		recentBlockHash = recentBlock.Value.Blockhash
-	}
+// SellQuote is what tokenAmount sells for on bondingCurve, net of the fee.
+func SellQuote(bondingCurve BondingCurve, tokenAmount uint64) uint64 {
+	solOutput := utils.CalculateOutput(tokenAmount, bondingCurve.VirtualTokenReserves, bondingCurve.VirtualSolReserves)
+	return solOutput - solOutput*FeeBps/10000
+}
 
-	tx, err := solana.NewTransaction(instructions, recentBlockHash, solana.TransactionPayer(privKey.PublicKey()))
+// SendBuy buys with solAmount, less the bot fee of feeRatio, and sends the
+// fee and the jito tip along.
+//
+// Deprecated: Use sol.Solana.Transact.
+func SendBuy(
+	ctx context.Context,
+	url string,
+	mint, botFeeRecipient solana.PublicKey,
+	solAmount, slippage, gasFee, feeRatio, jitoTip uint64,
+	bondingCurve BondingCurve,
+	createAta bool,
+	privKey solana.PrivateKey,
+	recentBlockHash solana.Hash,
+) (solana.Signature, error) {
+	tokenMint, err := common.GetMint(ctx, rpc.New(url), mint)
 	if err != nil {
 		return solana.Signature{}, err
 	}
-
-	_, err = tx.Sign(func(key solana.PublicKey) *solana.PrivateKey {
-		return &privKey
-	})
+	fee := solAmount * feeRatio / 10000
+	instructions, err := BuyInstructions(mint, tokenMint, solAmount-fee, slippage, bondingCurve, createAta, privKey.PublicKey())
 	if err != nil {
 		return solana.Signature{}, err
 	}
+	instructions = append(instructions, common.FeeAndTipInstructions(privKey.PublicKey(), botFeeRecipient, fee, jitoTip)...)
+	return common.SendSwap(ctx, url, common.NewJitoClient(common.JitoBlockEngines, ""), nil, instructions, common.FixedPriorityFee(gasFee), jitoTip, privKey, recentBlockHash, nil, nil)
+}
 
-	if jitoTip != 0 {
-		return rpc.New(common.JitoRpc).SendTransaction(ctx, tx)
+// SendSell sells tokenAmount and sends along the bot fee of feeRatio on the
+// quoted SOL and the jito tip.
+//
+// Deprecated: Use sol.Solana.Transact.
+func SendSell(
+	ctx context.Context,
+	url string,
+	mint, botFeeRecipient solana.PublicKey,
+	tokenAmount, slippage, gasFee, feeRatio, jitoTip uint64,
+	bondingCurve BondingCurve,
+	isSellAll bool,
+	privKey solana.PrivateKey,
+	recentBlockHash solana.Hash,
+) (solana.Signature, error) {
+	tokenMint, err := common.GetMint(ctx, rpc.New(url), mint)
+	if err != nil {
+		return solana.Signature{}, err
 	}
-
-	return cli.SendTransactionWithOpts(ctx, tx, rpc.TransactionOpts{SkipPreflight: true})
+	fee := utils.CalculateOutput(tokenMint.AmountAfterFee(tokenAmount), bondingCurve.VirtualTokenReserves, bondingCurve.VirtualSolReserves) * feeRatio / 10000
+	instructions, err := SellInstructions(mint, tokenMint, tokenAmount, slippage, bondingCurve, isSellAll, privKey.PublicKey())
+	if err != nil {
+		return solana.Signature{}, err
+	}
+	instructions = append(instructions, common.FeeAndTipInstructions(privKey.PublicKey(), botFeeRecipient, fee, jitoTip)...)
+	return common.SendSwap(ctx, url, common.NewJitoClient(common.JitoBlockEngines, ""), nil, instructions, common.FixedPriorityFee(gasFee), jitoTip, privKey, recentBlockHash, nil, nil)
 }
 
 func GetInitialBuyPrice(global *Global, solAmount uint64) uint64 {
@@ -390,23 +380,19 @@ func GetInitialBuyPrice(global *Global, solAmount uint64) uint64 {
 func CreateAndBuy(
 	ctx context.Context,
 	rpcUrl, name, symbol, uri string,
+	jito *common.JitoClient,
+	outbox *common.Outbox,
 	botFeeRecipient solana.PublicKey,
-	solAmount, slippage, priorityFee, feeRatio, jitoTip uint64,
+	solAmount, slippage uint64,
+	priorityFee common.PriorityFee,
+	feeRatio, jitoTip uint64,
 	global *Global,
 	privKey solana.PrivateKey,
 	recentBlockHash solana.Hash,
+	lookupTables map[solana.PublicKey]solana.PublicKeySlice,
 ) (solana.Signature, solana.PublicKey, error) {
 	var instructions []solana.Instruction
 
-	if priorityFee > 0 {
-		gasLimit := lo.If(solAmount > 0, 240000).Else(200000)
-		gasTmp := new(big.Int).Mul(big.NewInt(1000000), new(big.Int).SetUint64(priorityFee))
-		gasPrice := new(big.Int).Div(gasTmp, big.NewInt(int64(gasLimit)))
-		setCULimitInst := computebudget.NewSetComputeUnitLimitInstruction(uint32(gasLimit)).Build()
-		setCUPriceInst := computebudget.NewSetComputeUnitPriceInstruction(gasPrice.Uint64()).Build()
-		instructions = append(instructions, setCULimitInst, setCUPriceInst)
-	}
-
 	owner := privKey.PublicKey()
 	mintPrivateKey, err := solana.NewRandomPrivateKey()
 	if err != nil {
@@ -437,7 +423,7 @@ func CreateAndBuy(
 		solana.TokenProgramID,
 		solana.SPLAssociatedTokenAccountProgramID,
 		solana.SysVarRentPubkey,
-		eventAuthority,
+		EventAuthority,
 		ProgramID,
 	).Build()
 
@@ -463,22 +449,12 @@ func CreateAndBuy(
 			solana.SystemProgramID,
 			solana.TokenProgramID,
 			solana.SysVarRentPubkey,
-			eventAuthority,
+			EventAuthority,
 			ProgramID,
 		).Build()
 		instructions = append(instructions, swapInst)
 	}
-
-	if fee != 0 {
-		feeTransferInst := system.NewTransferInstruction(fee, owner, botFeeRecipient).Build()
-		instructions = append(instructions, feeTransferInst)
-	}
-
-	if jitoTip != 0 {
-		idx := rand.Intn(len(common.JitoTipPaymentAccounts))
-		jitoTipTransferInst := system.NewTransferInstruction(jitoTip, owner, common.JitoTipPaymentAccounts[idx]).Build()
-		instructions = append(instructions, jitoTipTransferInst)
-	}
+	instructions = append(instructions, common.FeeAndTipInstructions(owner, botFeeRecipient, fee, jitoTip)...)
 
 	cli := rpc.New(rpcUrl)
 	if recentBlockHash.IsZero() {
@@ -489,7 +465,14 @@ func CreateAndBuy(
 		recentBlockHash = recentBlock.Value.Blockhash
 	}
 
-	tx, err := solana.NewTransaction(instructions, recentBlockHash, solana.TransactionPayer(owner))
+	if !priorityFee.IsZero() {
+		instructions, err = common.WithComputeBudget(ctx, cli, instructions, owner, lookupTables, priorityFee, lo.If(solAmount > 0, common.LaunchAndBuyComputeUnits).Else(common.LaunchComputeUnits))
+		if err != nil {
+			return solana.Signature{}, solana.PublicKey{}, err
+		}
+	}
+
+	tx, err := common.NewTransaction(instructions, recentBlockHash, owner, lookupTables)
 	if err != nil {
 		return solana.Signature{}, solana.PublicKey{}, err
 	}
@@ -508,9 +491,9 @@ func CreateAndBuy(
 
 	var signature solana.Signature
 	if jitoTip != 0 {
-		signature, err = rpc.New(common.JitoRpc).SendTransaction(ctx, tx)
+		signature, err = common.SendBundled(ctx, jito, cli, tx)
 	} else {
-		signature, err = cli.SendTransactionWithOpts(ctx, tx, rpc.TransactionOpts{SkipPreflight: false})
+		signature, err = common.SendTransaction(ctx, cli, outbox, tx, rpc.TransactionOpts{SkipPreflight: false})
 	}
 
 	if err != nil {
@@ -518,3 +501,98 @@ func CreateAndBuy(
 	}
 	return signature, mint, nil
 }
+
+// Migration is when the liquidity of a completed curve was withdrawn, and
+// the accounts of the transaction that did it, the pool it moved to among
+// them. Accounts is empty if the migration is further back than searched.
+type Migration struct {
+	At       int64
+	Accounts solana.PublicKeySlice
+}
+
+// GetMigration finds the migration of mint's completed curve. The curve's
+// recent successful transactions are searched for the migrate or withdraw
+// instruction; if it is further back, the last successful one stands in for
+// its time.
+func GetMigration(ctx context.Context, url string, mint solana.PublicKey) (*Migration, error) {
+	client := rpc.New(url)
+	limit := completedAtLookback
+	signatures, err := client.GetSignaturesForAddressWithOpts(ctx, FindBondingCurve(mint), &rpc.GetSignaturesForAddressOpts{
+		Limit:      &limit,
+		Commitment: rpc.CommitmentConfirmed,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var last *rpc.TransactionSignature
+	for _, signature := range signatures {
+		if signature.Err != nil || signature.BlockTime == nil {
+			continue
+		}
+		if last == nil {
+			last = signature
+		}
+
+		tx, err := client.GetTransaction(ctx, signature.Signature, &rpc.GetTransactionOpts{
+			Commitment:                     rpc.CommitmentConfirmed,
+			MaxSupportedTransactionVersion: &rpc.MaxSupportedTransactionVersion0,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if accounts, ok := migrates(tx); ok {
+			return &Migration{At: int64(*signature.BlockTime), Accounts: accounts}, nil
+		}
+	}
+	if last == nil {
+		return nil, types.ErrNotFound
+	}
+	return &Migration{At: int64(*last.BlockTime)}, nil
+}
+
+// GetCompletedAt returns when the liquidity of mint's completed curve was
+// withdrawn for the migration, as GetMigration finds it.
+func GetCompletedAt(ctx context.Context, url string, mint solana.PublicKey) (int64, error) {
+	migration, err := GetMigration(ctx, url, mint)
+	if err != nil {
+		return 0, err
+	}
+	return migration.At, nil
+}
+
+// migrates reports whether tx calls the program to move a curve's
+// liquidity out, directly or from another program, and returns the accounts
+// of tx if so.
+func migrates(tx *rpc.GetTransactionResult) (solana.PublicKeySlice, bool) {
+	transaction, err := tx.Transaction.GetTransaction()
+	if err != nil || tx.Meta == nil || common.ResolveLoadedAddresses(transaction, tx.Meta.LoadedAddresses) != nil {
+		return nil, false
+	}
+	accounts, err := transaction.Message.GetAllKeys()
+	if err != nil {
+		return nil, false
+	}
+
+	isMigration := func(programIndex uint16, data solana.Base58) bool {
+		program, err := transaction.Message.Account(programIndex)
+		if err != nil || !program.Equals(ProgramID) || len(data) < 8 {
+			return false
+		}
+		id := bin.TypeIDFromBytes(data[:8])
+		return id == Instruction_Withdraw || id == instructionMigrate
+	}
+	for _, instruction := range transaction.Message.Instructions {
+		if isMigration(instruction.ProgramIDIndex, instruction.Data) {
+			return accounts, true
+		}
+	}
+	for _, inner := range tx.Meta.InnerInstructions {
+		for _, instruction := range inner.Instructions {
+			if isMigration(instruction.ProgramIDIndex, instruction.Data) {
+				return accounts, true
+			}
+		}
+	}
+	return nil, false
+}
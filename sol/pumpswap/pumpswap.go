@@ -0,0 +1,383 @@
+package pumpswap
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"math/big"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/token"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/meme-bots/go-web3/sol/common"
+	"github.com/meme-bots/go-web3/sol/pumpfun"
+	"github.com/meme-bots/go-web3/types"
+	"github.com/near/borsh-go"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	FeeDenominator = 10000
+
+	DisableCreatePool = 1 << 0
+	DisableDeposit    = 1 << 1
+	DisableWithdraw   = 1 << 2
+	DisableBuy        = 1 << 3
+	DisableSell       = 1 << 4
+
+	// index of the pool pump.fun migrates a completed curve into
+	CanonicalPoolIndex = 0
+)
+
+var (
+	ProgramID      = solana.MPK("pAMMBay6oceH9fJKBRHGP5D4bD4sWpmSwMn52FMfXEA")
+	GlobalConfigID = solana.MPK("ADyA8hdefvWN2dbGGWFotbzWxrAvLW83WG6QCVXvJKqw")
+	EventAuthority = solana.MPK("GS4CU59F31iL7aR2Q8zVS8DRrcRnXX1yjQ66TqNVQnaR")
+
+	PoolDiscriminator         = [8]byte{241, 154, 109, 4, 17, 177, 109, 188}
+	GlobalConfigDiscriminator = [8]byte{149, 8, 156, 202, 160, 252, 176, 217}
+
+	Instruction_Buy  = [8]byte{102, 6, 61, 18, 1, 218, 235, 234}
+	Instruction_Sell = [8]byte{51, 230, 133, 164, 1, 127, 131, 173}
+
+	// anchor prefixes events emitted through a self CPI with this tag
+	EventInstructionTag    = [8]byte{228, 69, 165, 46, 81, 203, 154, 29}
+	BuyEventDiscriminator  = [8]byte{103, 244, 82, 31, 44, 245, 119, 119}
+	SellEventDiscriminator = [8]byte{62, 47, 55, 10, 165, 3, 220, 42}
+
+	ErrNotPumpSwapPool = errors.New("not a pumpswap pool")
+)
+
+type (
+	// Pool is a PumpSwap constant product pool. Pools migrated from
+	// pump.fun hold the token as base and WSOL as quote.
+	Pool struct {
+		Discriminator         [8]byte
+		PoolBump              uint8
+		Index                 uint16
+		Creator               solana.PublicKey
+		BaseMint              solana.PublicKey
+		QuoteMint             solana.PublicKey
+		LpMint                solana.PublicKey
+		PoolBaseTokenAccount  solana.PublicKey
+		PoolQuoteTokenAccount solana.PublicKey
+		LpSupply              uint64
+		// receives the creator fee, none when zero
+		CoinCreator solana.PublicKey
+	}
+
+	GlobalConfig struct {
+		Discriminator                [8]byte
+		Admin                        solana.PublicKey
+		LpFeeBasisPoints             uint64
+		ProtocolFeeBasisPoints       uint64
+		DisableFlags                 uint8
+		ProtocolFeeRecipients        [8]solana.PublicKey
+		CoinCreatorFeeBasisPoints    uint64
+		AdminSetCoinCreatorAuthority solana.PublicKey
+	}
+)
+
+func DecodePool(data []byte) (*Pool, error) {
+	var pool Pool
+	err := borsh.Deserialize(&pool, data)
+	if err != nil {
+		return nil, err
+	}
+	if pool.Discriminator != PoolDiscriminator {
+		return nil, ErrNotPumpSwapPool
+	}
+	return &pool, nil
+}
+
+func DecodeGlobalConfig(data []byte) (*GlobalConfig, error) {
+	var config GlobalConfig
+	err := borsh.Deserialize(&config, data)
+	if err != nil {
+		return nil, err
+	}
+	if config.Discriminator != GlobalConfigDiscriminator {
+		return nil, ErrNotPumpSwapPool
+	}
+	return &config, nil
+}
+
+// FindPoolAuthority is the pump.fun account that creates the pool a
+// completed curve migrates into.
+func FindPoolAuthority(mint solana.PublicKey) solana.PublicKey {
+	authority, _, _ := solana.FindProgramAddress(
+		[][]byte{
+			[]byte("pool-authority"),
+			mint.Bytes(),
+		},
+		pumpfun.ProgramID,
+	)
+	return authority
+}
+
+func FindPool(index uint16, creator, baseMint, quoteMint solana.PublicKey) solana.PublicKey {
+	pool, _, _ := solana.FindProgramAddress(
+		[][]byte{
+			[]byte("pool"),
+			binary.LittleEndian.AppendUint16(nil, index),
+			creator.Bytes(),
+			baseMint.Bytes(),
+			quoteMint.Bytes(),
+		},
+		ProgramID,
+	)
+	return pool
+}
+
+// FindCanonicalPool is the pool pump.fun migrates mint into.
+func FindCanonicalPool(mint solana.PublicKey) solana.PublicKey {
+	return FindPool(CanonicalPoolIndex, FindPoolAuthority(mint), mint, solana.SolMint)
+}
+
+func FindCoinCreatorVaultAuthority(coinCreator solana.PublicKey) solana.PublicKey {
+	authority, _, _ := solana.FindProgramAddress(
+		[][]byte{
+			[]byte("creator_vault"),
+			coinCreator.Bytes(),
+		},
+		ProgramID,
+	)
+	return authority
+}
+
+func (p *Pool) HasCoinCreator() bool {
+	return !p.CoinCreator.IsZero()
+}
+
+// FeeBps is the fee a trade in pool pays: lp and protocol fee, and the
+// creator fee when the pool has a coin creator.
+func (c *GlobalConfig) FeeBps(pool *Pool) uint64 {
+	fee := c.LpFeeBasisPoints + c.ProtocolFeeBasisPoints
+	if pool.HasCoinCreator() {
+		fee += c.CoinCreatorFeeBasisPoints
+	}
+	return fee
+}
+
+// BuyQuote returns the base received for quoteAmount in, fees included.
+func (c *GlobalConfig) BuyQuote(pool *Pool, quoteAmount, baseReserve, quoteReserve uint64) uint64 {
+	effective := new(big.Int).SetUint64(quoteAmount)
+	effective.Mul(effective, big.NewInt(FeeDenominator))
+	effective.Quo(effective, new(big.Int).SetUint64(FeeDenominator+c.FeeBps(pool)))
+	return output(effective, quoteReserve, baseReserve)
+}
+
+// SellQuote returns the quote received for baseAmount in, after each fee
+// is taken off the output rounded up.
+func (c *GlobalConfig) SellQuote(pool *Pool, baseAmount, baseReserve, quoteReserve uint64) uint64 {
+	out := output(new(big.Int).SetUint64(baseAmount), baseReserve, quoteReserve)
+	fees := []uint64{c.LpFeeBasisPoints, c.ProtocolFeeBasisPoints}
+	if pool.HasCoinCreator() {
+		fees = append(fees, c.CoinCreatorFeeBasisPoints)
+	}
+	var total uint64
+	for _, bps := range fees {
+		total += (out*bps + FeeDenominator - 1) / FeeDenominator
+	}
+	return out - min(out, total)
+}
+
+// output of amountIn against a constant product pool, in big ints as the
+// reserves of 1e15 supply tokens overflow when multiplied.
+func output(amountIn *big.Int, reserveIn, reserveOut uint64) uint64 {
+	numerator := new(big.Int).Mul(amountIn, new(big.Int).SetUint64(reserveOut))
+	denominator := new(big.Int).Add(amountIn, new(big.Int).SetUint64(reserveIn))
+	if denominator.Sign() == 0 {
+		return 0
+	}
+	return numerator.Quo(numerator, denominator).Uint64()
+}
+
+// ProtocolFeeRecipient picks one of the configured fee recipients, they
+// are interchangeable and spreading them avoids write contention.
+func (c *GlobalConfig) ProtocolFeeRecipient(idx int) solana.PublicKey {
+	var recipients []solana.PublicKey
+	for _, recipient := range c.ProtocolFeeRecipients {
+		if !recipient.IsZero() {
+			recipients = append(recipients, recipient)
+		}
+	}
+	if len(recipients) == 0 {
+		return solana.PublicKey{}
+	}
+	return recipients[idx%len(recipients)]
+}
+
+// GetPoolState reads a pool and the global config.
+func GetPoolState(ctx context.Context, client *rpc.Client, poolID solana.PublicKey) (*Pool, *GlobalConfig, error) {
+	accounts, err := client.GetMultipleAccountsWithOpts(
+		ctx,
+		[]solana.PublicKey{poolID, GlobalConfigID},
+		&rpc.GetMultipleAccountsOpts{Commitment: rpc.CommitmentConfirmed},
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	if accounts.Value[0] == nil || accounts.Value[1] == nil {
+		return nil, nil, types.ErrInvalidPool
+	}
+	pool, err := DecodePool(accounts.Value[0].Data.GetBinary())
+	if err != nil {
+		return nil, nil, types.ErrInvalidPool
+	}
+	config, err := DecodeGlobalConfig(accounts.Value[1].Data.GetBinary())
+	if err != nil {
+		return nil, nil, types.ErrInvalidPool
+	}
+	return pool, config, nil
+}
+
+// GetPumpSwapPoolByToken finds the pool pump.fun migrated token into.
+func GetPumpSwapPoolByToken(ctx context.Context, url string, token solana.PublicKey) (*types.Pool, error) {
+	client := rpc.New(url)
+
+	poolID := FindCanonicalPool(token)
+	accounts, err := client.GetMultipleAccountsWithOpts(
+		ctx,
+		[]solana.PublicKey{poolID, token},
+		&rpc.GetMultipleAccountsOpts{Commitment: rpc.CommitmentConfirmed},
+	)
+	if err != nil {
+		return nil, err
+	}
+	if accounts.Value[0] == nil {
+		return nil, nil
+	}
+	pool, err := DecodePool(accounts.Value[0].Data.GetBinary())
+	if err != nil {
+		return nil, types.ErrInvalidPool
+	}
+	mint, err := common.DecodeMint(accounts.Value[1])
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.Pool{
+		AmmPublicKey: poolID.String(),
+		BaseMint:     pool.BaseMint.String(),
+		BaseVault:    pool.PoolBaseTokenAccount.String(),
+		BaseDecimal:  mint.Decimals,
+		QuoteMint:    pool.QuoteMint.String(),
+		QuoteVault:   pool.PoolQuoteTokenAccount.String(),
+		QuoteDecimal: 9,
+		LpMint:       pool.LpMint.String(),
+		Dex:          types.DexPumpSwap,
+		Marked:       true,
+	}, nil
+}
+
+// GetPumpSwapPool reads price, reserves and fee of a PumpSwap pool for
+// GetPool.
+func GetPumpSwapPool(ctx context.Context, url string, p *types.Pool, owner string, withBalance bool) (*common.GetSolPoolResponse, *common.Balance, error) {
+	poolID, err := solana.PublicKeyFromBase58(p.AmmPublicKey)
+	if err != nil {
+		return nil, nil, types.ErrInvalidPool
+	}
+
+	client := rpc.New(url)
+	pool, config, err := GetPoolState(ctx, client, poolID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !pool.QuoteMint.Equals(solana.SolMint) {
+		return nil, nil, types.ErrInvalidPool
+	}
+
+	mint := pool.BaseMint
+	metaAddress, _, _ := solana.FindTokenMetadataAddress(mint)
+	publicKeys := []solana.PublicKey{
+		pool.PoolBaseTokenAccount,
+		pool.PoolQuoteTokenAccount,
+		mint,
+		metaAddress,
+	}
+
+	if withBalance {
+		owner_ := solana.MPK(owner)
+		publicKeys = append(publicKeys, owner_)
+		publicKeys = append(publicKeys, common.TokenAccounts(owner_, mint)...)
+	}
+
+	accounts, err := client.GetMultipleAccountsWithOpts(
+		ctx,
+		publicKeys,
+		&rpc.GetMultipleAccountsOpts{Commitment: rpc.CommitmentProcessed},
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	balance := &common.Balance{NativeBalance: big.NewInt(0), TokenBalance: big.NewInt(0)}
+	if withBalance {
+		if accounts.Value[4] != nil {
+			balance.NativeBalance = new(big.Int).SetUint64(accounts.Value[4].Lamports)
+		}
+
+		balance.TokenBalance, err = common.TokenBalance(common.TokenAccountOf(accounts.Value[2], accounts.Value[5:]))
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if config.DisableFlags&(DisableBuy|DisableSell) != 0 {
+		return nil, balance, types.ErrInvalidPool
+	}
+
+	var baseVault, quoteVault token.Account
+	for i, vault := range []*token.Account{&baseVault, &quoteVault} {
+		if accounts.Value[i] == nil {
+			return nil, balance, types.ErrInvalidPool
+		}
+		err = vault.UnmarshalWithDecoder(bin.NewBinDecoder(accounts.Value[i].Data.GetBinary()))
+		if err != nil {
+			return nil, balance, err
+		}
+	}
+	if baseVault.Amount == 0 || quoteVault.Amount == 0 {
+		return nil, balance, types.ErrPoolCompleted
+	}
+
+	tokenMint, err := common.DecodeMint(accounts.Value[2])
+	if err != nil {
+		return nil, balance, err
+	}
+	err = tokenMint.SetEpoch(ctx, client)
+	if err != nil {
+		return nil, balance, err
+	}
+
+	name, symbol, err := common.TokenName(tokenMint, mint, accounts.Value[3])
+	if err != nil {
+		return nil, balance, err
+	}
+
+	priceInSol := decimal.NewFromBigInt(new(big.Int).SetUint64(quoteVault.Amount), -9).
+		Div(decimal.NewFromBigInt(new(big.Int).SetUint64(baseVault.Amount), -int32(tokenMint.Decimals)))
+	totalSupply := decimal.NewFromUint64(tokenMint.Supply).Div(decimal.New(1, int32(tokenMint.Decimals)))
+
+	ret := &common.GetSolPoolResponse{
+		PriceInSol:            priceInSol,
+		TotalSupply:           totalSupply,
+		FreezeDisabled:        tokenMint.FreezeAuthority == nil,
+		Burnt:                 true, // migration burns the lp tokens
+		MintAuthorityDisabled: tokenMint.MintAuthority == nil,
+		TokenReserve:          new(big.Int).SetUint64(baseVault.Amount),
+		SolReserve:            new(big.Int).SetUint64(quoteVault.Amount),
+		Name:                  name,
+		Symbol:                symbol,
+		Decimals:              tokenMint.Decimals,
+		QuoteDecimals:         9,
+		TokenAddress:          mint.String(),
+		QuoteAddress:          solana.SolMint.String(),
+		PoolAddress:           p.AmmPublicKey,
+		PoolFeeBps:            uint16(config.FeeBps(pool)),
+	}
+	ret.SetMint(tokenMint)
+	return ret, balance, nil
+}
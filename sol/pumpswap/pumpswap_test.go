@@ -0,0 +1,77 @@
+package pumpswap
+
+import (
+	"testing"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/near/borsh-go"
+)
+
+func TestProgramAddresses(t *testing.T) {
+	config, _, _ := solana.FindProgramAddress([][]byte{[]byte("global_config")}, ProgramID)
+	if !config.Equals(GlobalConfigID) {
+		t.Errorf("global config = %s, want %s", config, GlobalConfigID)
+	}
+	authority, _, _ := solana.FindProgramAddress([][]byte{[]byte("__event_authority")}, ProgramID)
+	if !authority.Equals(EventAuthority) {
+		t.Errorf("event authority = %s, want %s", authority, EventAuthority)
+	}
+}
+
+func TestDecodePool_Offsets(t *testing.T) {
+	mint := solana.NewWallet().PublicKey()
+	data, err := borsh.Serialize(Pool{Discriminator: PoolDiscriminator, BaseMint: mint, QuoteMint: solana.SolMint})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := solana.PublicKeyFromBytes(data[43:75]); !got.Equals(mint) {
+		t.Errorf("base mint at 43 = %s", got)
+	}
+	if got := solana.PublicKeyFromBytes(data[75:107]); !got.Equals(solana.SolMint) {
+		t.Errorf("quote mint at 75 = %s", got)
+	}
+	pool, err := DecodePool(append(data, make([]byte, 64)...))
+	if err != nil || !pool.BaseMint.Equals(mint) {
+		t.Errorf("DecodePool = %+v, %v", pool, err)
+	}
+}
+
+func TestQuotes(t *testing.T) {
+	config := &GlobalConfig{LpFeeBasisPoints: 20, ProtocolFeeBasisPoints: 5, CoinCreatorFeeBasisPoints: 5}
+	pool := &Pool{}
+
+	// 1 SOL into 100 SOL / 1e15 tokens, 0.25% fees without a coin creator
+	if got := config.BuyQuote(pool, 1e9, 1e15, 100e9); got != 9876543205818 {
+		t.Errorf("BuyQuote = %d", got)
+	}
+	if got := config.SellQuote(pool, 1e13, 1e15, 100e9); got != 987623760 {
+		t.Errorf("SellQuote = %d", got)
+	}
+	pool.CoinCreator = solana.NewWallet().PublicKey()
+	if got := config.FeeBps(pool); got != 30 {
+		t.Errorf("FeeBps with a coin creator = %d, want 30", got)
+	}
+}
+
+func TestParseSellInstruction(t *testing.T) {
+	event, err := bin.MarshalBorsh(&SellEvent{BaseAmountIn: 500, UserQuoteAmountOut: 700})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := append(append(EventInstructionTag[:], SellEventDiscriminator[:]...), event...)
+
+	message := &solana.Message{AccountKeys: solana.PublicKeySlice{solana.NewWallet().PublicKey(), ProgramID}}
+	tx := &rpc.GetTransactionResult{Meta: &rpc.TransactionMeta{
+		InnerInstructions: []rpc.InnerInstruction{{
+			Index:        2,
+			Instructions: []solana.CompiledInstruction{{ProgramIDIndex: 1, Data: data}},
+		}},
+	}}
+
+	sol, tokens := ParseSellInstruction(tx, message, 2)
+	if sol.Int64() != 700 || tokens.Int64() != -500 {
+		t.Errorf("ParseSellInstruction = %s, %s", sol, tokens)
+	}
+}
@@ -0,0 +1,284 @@
+package pumpswap
+
+import (
+	"math/big"
+	"math/rand"
+	"slices"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/token"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/meme-bots/go-web3/sol/common"
+	"github.com/near/borsh-go"
+)
+
+type (
+	BuyEvent struct {
+		Timestamp                        int64
+		BaseAmountOut                    uint64
+		MaxQuoteAmountIn                 uint64
+		UserBaseTokenReserves            uint64
+		UserQuoteTokenReserves           uint64
+		PoolBaseTokenReserves            uint64
+		PoolQuoteTokenReserves           uint64
+		QuoteAmountIn                    uint64
+		LpFeeBasisPoints                 uint64
+		LpFee                            uint64
+		ProtocolFeeBasisPoints           uint64
+		ProtocolFee                      uint64
+		QuoteAmountInWithLpFee           uint64
+		UserQuoteAmountIn                uint64 // all the user paid, fees included
+		Pool                             solana.PublicKey
+		User                             solana.PublicKey
+		UserBaseTokenAccount             solana.PublicKey
+		UserQuoteTokenAccount            solana.PublicKey
+		ProtocolFeeRecipient             solana.PublicKey
+		ProtocolFeeRecipientTokenAccount solana.PublicKey
+	}
+
+	SellEvent struct {
+		Timestamp                        int64
+		BaseAmountIn                     uint64
+		MinQuoteAmountOut                uint64
+		UserBaseTokenReserves            uint64
+		UserQuoteTokenReserves           uint64
+		PoolBaseTokenReserves            uint64
+		PoolQuoteTokenReserves           uint64
+		QuoteAmountOut                   uint64
+		LpFeeBasisPoints                 uint64
+		LpFee                            uint64
+		ProtocolFeeBasisPoints           uint64
+		ProtocolFee                      uint64
+		QuoteAmountOutWithoutLpFee       uint64
+		UserQuoteAmountOut               uint64 // all the user received, fees taken
+		Pool                             solana.PublicKey
+		User                             solana.PublicKey
+		UserBaseTokenAccount             solana.PublicKey
+		UserQuoteTokenAccount            solana.PublicKey
+		ProtocolFeeRecipient             solana.PublicKey
+		ProtocolFeeRecipientTokenAccount solana.PublicKey
+	}
+
+	SwapParam struct {
+		PoolID solana.PublicKey
+		Pool   *Pool
+		Config *GlobalConfig
+		User   solana.PublicKey
+
+		// buy: BaseAmount out and the most QuoteAmount to pay, sell:
+		// BaseAmount in and the least QuoteAmount to receive
+		BaseAmount  uint64
+		QuoteAmount uint64
+
+		BaseTokenProgram      solana.PublicKey
+		UserBaseTokenAccount  solana.PublicKey
+		UserQuoteTokenAccount solana.PublicKey
+	}
+)
+
+func NewBuyInstruction(param SwapParam) solana.Instruction {
+	return newSwapInstruction(Instruction_Buy, param)
+}
+
+func NewSellInstruction(param SwapParam) solana.Instruction {
+	return newSwapInstruction(Instruction_Sell, param)
+}
+
+// buy and sell share their accounts, the protocol fee recipient is picked
+// at random and the creator vault is only charged when the pool has one
+func newSwapInstruction(discriminator [8]byte, param SwapParam) solana.Instruction {
+	data, err := borsh.Serialize(struct {
+		Discriminator [8]byte
+		BaseAmount    uint64
+		QuoteAmount   uint64
+	}{
+		Discriminator: discriminator,
+		BaseAmount:    param.BaseAmount,
+		QuoteAmount:   param.QuoteAmount,
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	pool := param.Pool
+	protocolFeeRecipient := param.Config.ProtocolFeeRecipient(rand.Int())
+	protocolFeeRecipientAta := common.FindAssociatedTokenAddress(protocolFeeRecipient, pool.QuoteMint, solana.TokenProgramID)
+	coinCreatorVaultAuthority := FindCoinCreatorVaultAuthority(pool.CoinCreator)
+	coinCreatorVaultAta := common.FindAssociatedTokenAddress(coinCreatorVaultAuthority, pool.QuoteMint, solana.TokenProgramID)
+
+	return &solana.GenericInstruction{
+		ProgID: ProgramID,
+		AccountValues: solana.AccountMetaSlice{
+			{PublicKey: param.PoolID, IsSigner: false, IsWritable: true},
+			{PublicKey: param.User, IsSigner: true, IsWritable: true},
+			{PublicKey: GlobalConfigID, IsSigner: false, IsWritable: false},
+			{PublicKey: pool.BaseMint, IsSigner: false, IsWritable: false},
+			{PublicKey: pool.QuoteMint, IsSigner: false, IsWritable: false},
+			{PublicKey: param.UserBaseTokenAccount, IsSigner: false, IsWritable: true},
+			{PublicKey: param.UserQuoteTokenAccount, IsSigner: false, IsWritable: true},
+			{PublicKey: pool.PoolBaseTokenAccount, IsSigner: false, IsWritable: true},
+			{PublicKey: pool.PoolQuoteTokenAccount, IsSigner: false, IsWritable: true},
+			{PublicKey: protocolFeeRecipient, IsSigner: false, IsWritable: false},
+			{PublicKey: protocolFeeRecipientAta, IsSigner: false, IsWritable: true},
+			{PublicKey: param.BaseTokenProgram, IsSigner: false, IsWritable: false},
+			{PublicKey: solana.TokenProgramID, IsSigner: false, IsWritable: false},
+			{PublicKey: solana.SystemProgramID, IsSigner: false, IsWritable: false},
+			{PublicKey: solana.SPLAssociatedTokenAccountProgramID, IsSigner: false, IsWritable: false},
+			{PublicKey: EventAuthority, IsSigner: false, IsWritable: false},
+			{PublicKey: ProgramID, IsSigner: false, IsWritable: false},
+			{PublicKey: coinCreatorVaultAta, IsSigner: false, IsWritable: true},
+			{PublicKey: coinCreatorVaultAuthority, IsSigner: false, IsWritable: false},
+		},
+		DataBytes: data,
+	}
+}
+
+func BuyInstructions(
+	poolID solana.PublicKey,
+	tokenMint *common.Mint,
+	solAmount, slippage, reserveWsol, reserveToken uint64,
+	pool *Pool,
+	config *GlobalConfig,
+	createAta bool,
+	payer solana.PublicKey,
+) ([]solana.Instruction, error) {
+	var instructions []solana.Instruction
+
+	//the pool takes its fees on top of the quote, so the wsol account holds
+	//the most the swap may pay
+	tokenAmount := config.BuyQuote(pool, solAmount, reserveToken, reserveWsol)
+	maxSolAmount := solAmount + solAmount*slippage/10000
+
+	//create and init tmp wsol token account
+	createAndInitInsts, wsolAta := common.CreateAndInitWsolTokenAccount(payer, maxSolAmount)
+	instructions = append(instructions, createAndInitInsts...)
+	//create ata
+	if createAta {
+		instructions = append(instructions, common.NewCreateIdempotentAtaInstruction(payer, payer, pool.BaseMint, tokenMint.Program))
+	}
+	//swap
+	swapInst := NewBuyInstruction(SwapParam{
+		PoolID:                poolID,
+		Pool:                  pool,
+		Config:                config,
+		User:                  payer,
+		BaseAmount:            tokenAmount,
+		QuoteAmount:           maxSolAmount,
+		BaseTokenProgram:      tokenMint.Program,
+		UserBaseTokenAccount:  common.FindAssociatedTokenAddress(payer, pool.BaseMint, tokenMint.Program),
+		UserQuoteTokenAccount: wsolAta,
+	})
+	instructions = append(instructions, swapInst)
+	//close wsol token account
+	closeAccountInst := token.NewCloseAccountInstruction(
+		wsolAta,
+		payer,
+		payer,
+		[]solana.PublicKey{payer},
+	).Build()
+	instructions = append(instructions, closeAccountInst)
+
+	return instructions, nil
+}
+
+func SellInstructions(
+	poolID solana.PublicKey,
+	tokenMint *common.Mint,
+	tokenAmount, slippage, reserveWsol, reserveToken uint64,
+	pool *Pool,
+	config *GlobalConfig,
+	isSellAll bool,
+	payer solana.PublicKey,
+) ([]solana.Instruction, error) {
+	var instructions []solana.Instruction
+
+	//create and init tmp wsol token account
+	createAndInitInsts, wsolAta := common.CreateAndInitWsolTokenAccount(payer, 0)
+	instructions = append(instructions, createAndInitInsts...)
+
+	//swap, the vault receives what is left after the mint's transfer fee
+	ata := common.FindAssociatedTokenAddress(payer, pool.BaseMint, tokenMint.Program)
+	minAmountOut := config.SellQuote(pool, tokenMint.AmountAfterFee(tokenAmount), reserveToken, reserveWsol)
+	minAmountOut -= minAmountOut * slippage / 10000
+
+	swapInst := NewSellInstruction(SwapParam{
+		PoolID:                poolID,
+		Pool:                  pool,
+		Config:                config,
+		User:                  payer,
+		BaseAmount:            tokenAmount,
+		QuoteAmount:           minAmountOut,
+		BaseTokenProgram:      tokenMint.Program,
+		UserBaseTokenAccount:  ata,
+		UserQuoteTokenAccount: wsolAta,
+	})
+	instructions = append(instructions, swapInst)
+
+	//close wsol token account
+	closeAccountInst := token.NewCloseAccountInstruction(
+		wsolAta,
+		payer,
+		payer,
+		[]solana.PublicKey{payer},
+	).Build()
+	instructions = append(instructions, closeAccountInst)
+
+	//close ata
+	if isSellAll {
+		closeAccountInst := common.WithProgram(token.NewCloseAccountInstruction(
+			ata,
+			payer,
+			payer,
+			[]solana.PublicKey{payer},
+		).Build(), tokenMint.Program)
+		instructions = append(instructions, closeAccountInst)
+	}
+
+	return instructions, nil
+}
+
+// ParseBuyInstruction reads the SOL paid and tokens received from the
+// BuyEvent the swap emitted.
+func ParseBuyInstruction(tx *rpc.GetTransactionResult, message *solana.Message, instructionIndex uint16) (solSwaped, tokenSwaped *big.Int) {
+	var event BuyEvent
+	if !parseEvent(tx, message, instructionIndex, BuyEventDiscriminator, &event) {
+		return big.NewInt(0), big.NewInt(0)
+	}
+	solSwaped = new(big.Int).Neg(new(big.Int).SetUint64(event.UserQuoteAmountIn))
+	tokenSwaped = new(big.Int).SetUint64(event.BaseAmountOut)
+	return
+}
+
+// ParseSellInstruction reads the tokens paid and SOL received from the
+// SellEvent the swap emitted.
+func ParseSellInstruction(tx *rpc.GetTransactionResult, message *solana.Message, instructionIndex uint16) (solSwaped, tokenSwaped *big.Int) {
+	var event SellEvent
+	if !parseEvent(tx, message, instructionIndex, SellEventDiscriminator, &event) {
+		return big.NewInt(0), big.NewInt(0)
+	}
+	solSwaped = new(big.Int).SetUint64(event.UserQuoteAmountOut)
+	tokenSwaped = new(big.Int).Neg(new(big.Int).SetUint64(event.BaseAmountIn))
+	return
+}
+
+// parseEvent decodes the event the instruction at instructionIndex emitted
+// through its self CPI: the event tag, the event discriminator, the event.
+func parseEvent(tx *rpc.GetTransactionResult, message *solana.Message, instructionIndex uint16, discriminator [8]byte, event interface{}) bool {
+	for _, innerInstruction := range tx.Meta.InnerInstructions {
+		if innerInstruction.Index != instructionIndex {
+			continue
+		}
+		for _, inner := range innerInstruction.Instructions {
+			program, err := message.Account(inner.ProgramIDIndex)
+			if err != nil || !program.Equals(ProgramID) || len(inner.Data) < 16 {
+				continue
+			}
+			if !slices.Equal(inner.Data[:8], EventInstructionTag[:]) || !slices.Equal(inner.Data[8:16], discriminator[:]) {
+				continue
+			}
+			return bin.NewBorshDecoder(inner.Data[16:]).Decode(event) == nil
+		}
+	}
+	return false
+}
@@ -0,0 +1,522 @@
+package raydium
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"math/big"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/token"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/meme-bots/go-web3/sol/common"
+	"github.com/meme-bots/go-web3/types"
+	"github.com/near/borsh-go"
+	"github.com/samber/lo"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	ClmmPoolSize = 1544
+
+	// fee rates are in millionths
+	ClmmFeeDenominator = 1000000
+
+	ClmmTickArraySize = 60
+
+	// the pool's bitmap tracks 512 tick arrays either side of tick 0
+	clmmBitmapOffset = 512
+
+	ClmmStatusSwapDisabled = 1 << 4
+
+	clmmToken0MintOffset = 73
+	clmmToken1MintOffset = 105
+)
+
+var (
+	ClmmProgramID = solana.MPK("CAMMCzo5YL8w4VFF8KVHrK22GGUsp5VTaW7grrKgrWqK")
+	MemoProgramID = solana.MPK("MemoSq4gqABAXKb96qnH8TysNcWxMyWCqXgDLGmfcHr")
+
+	ClmmPoolDiscriminator      = [8]byte{247, 237, 227, 245, 215, 195, 222, 70}
+	ClmmConfigDiscriminator    = [8]byte{218, 244, 33, 104, 203, 203, 43, 111}
+	ClmmTickArrayDiscriminator = [8]byte{192, 155, 85, 205, 49, 249, 129, 42}
+
+	Instruction_ClmmSwapV2 = [8]byte{43, 4, 237, 11, 26, 201, 30, 98}
+
+	ErrNotClmmPool = errors.New("not a raydium clmm pool")
+)
+
+type (
+	ClmmRewardInfo struct {
+		RewardState           uint8
+		OpenTime              uint64
+		EndTime               uint64
+		LastUpdateTime        uint64
+		EmissionsPerSecondX64 bin.Uint128
+		RewardTotalEmissioned uint64
+		RewardClaimed         uint64
+		TokenMint             solana.PublicKey
+		TokenVault            solana.PublicKey
+		Authority             solana.PublicKey
+		RewardGrowthGlobalX64 bin.Uint128
+	}
+
+	// ClmmPool is the pool state of the Raydium concentrated liquidity
+	// program, up to the tick array bitmap.
+	ClmmPool struct {
+		Discriminator       [8]byte
+		Bump                uint8
+		AmmConfig           solana.PublicKey
+		Owner               solana.PublicKey
+		Token0Mint          solana.PublicKey
+		Token1Mint          solana.PublicKey
+		Token0Vault         solana.PublicKey
+		Token1Vault         solana.PublicKey
+		ObservationKey      solana.PublicKey
+		Mint0Decimals       uint8
+		Mint1Decimals       uint8
+		TickSpacing         uint16
+		Liquidity           bin.Uint128
+		SqrtPriceX64        bin.Uint128
+		TickCurrent         int32
+		Padding3            uint16
+		Padding4            uint16
+		FeeGrowthGlobal0X64 bin.Uint128
+		FeeGrowthGlobal1X64 bin.Uint128
+		ProtocolFeesToken0  uint64
+		ProtocolFeesToken1  uint64
+		SwapInAmountToken0  bin.Uint128
+		SwapOutAmountToken1 bin.Uint128
+		SwapInAmountToken1  bin.Uint128
+		SwapOutAmountToken0 bin.Uint128
+		Status              uint8
+		Padding             [7]uint8
+		RewardInfos         [3]ClmmRewardInfo
+		TickArrayBitmap     [16]uint64
+	}
+
+	ClmmConfig struct {
+		Discriminator   [8]byte
+		Bump            uint8
+		Index           uint16
+		Owner           solana.PublicKey
+		ProtocolFeeRate uint32
+		TradeFeeRate    uint32
+		TickSpacing     uint16
+		FundFeeRate     uint32
+	}
+
+	ClmmTick struct {
+		Tick                    int32
+		LiquidityNet            bin.Int128
+		LiquidityGross          bin.Uint128
+		FeeGrowthOutside0X64    bin.Uint128
+		FeeGrowthOutside1X64    bin.Uint128
+		RewardGrowthsOutsideX64 [3]bin.Uint128
+		Padding                 [13]uint32
+	}
+
+	ClmmTickArray struct {
+		Discriminator  [8]byte
+		PoolID         solana.PublicKey
+		StartTickIndex int32
+		Ticks          [ClmmTickArraySize]ClmmTick
+	}
+)
+
+func DecodeClmmPool(data []byte) (*ClmmPool, error) {
+	var pool ClmmPool
+	err := bin.NewBorshDecoder(data).Decode(&pool)
+	if err != nil {
+		return nil, err
+	}
+	if pool.Discriminator != ClmmPoolDiscriminator {
+		return nil, ErrNotClmmPool
+	}
+	return &pool, nil
+}
+
+func DecodeClmmConfig(data []byte) (*ClmmConfig, error) {
+	var config ClmmConfig
+	err := borsh.Deserialize(&config, data)
+	if err != nil {
+		return nil, err
+	}
+	if config.Discriminator != ClmmConfigDiscriminator {
+		return nil, ErrNotClmmPool
+	}
+	return &config, nil
+}
+
+func DecodeClmmTickArray(data []byte) (*ClmmTickArray, error) {
+	var array ClmmTickArray
+	err := bin.NewBorshDecoder(data).Decode(&array)
+	if err != nil {
+		return nil, err
+	}
+	if array.Discriminator != ClmmTickArrayDiscriminator {
+		return nil, ErrNotClmmPool
+	}
+	return &array, nil
+}
+
+// TokenIsToken0 reports whether the non-SOL side of the pool is token 0.
+func (p *ClmmPool) TokenIsToken0() bool {
+	return !p.Token0Mint.Equals(solana.SolMint)
+}
+
+// Mints returns the token and SOL mints of the pool, in that order.
+func (p *ClmmPool) Mints() (solana.PublicKey, solana.PublicKey) {
+	if p.TokenIsToken0() {
+		return p.Token0Mint, p.Token1Mint
+	}
+	return p.Token1Mint, p.Token0Mint
+}
+
+// PriceInSol is the spot price of the pool's token.
+func (p *ClmmPool) PriceInSol() decimal.Decimal {
+	price := SqrtPriceToPrice(p.SqrtPriceX64.BigInt(), p.Mint0Decimals, p.Mint1Decimals)
+	if p.TokenIsToken0() || price.IsZero() {
+		return price
+	}
+	return decimal.NewFromInt(1).Div(price)
+}
+
+func (p *ClmmPool) tickCount() int32 {
+	return int32(p.TickSpacing) * ClmmTickArraySize
+}
+
+// TickArrayStartIndex returns the start of the tick array holding tick.
+func (p *ClmmPool) TickArrayStartIndex(tick int32) int32 {
+	count := p.tickCount()
+	start := tick / count
+	if tick < 0 && tick%count != 0 {
+		start--
+	}
+	return start * count
+}
+
+// TickArrayStarts returns the start indexes of up to n initialized tick
+// arrays a swap meets, beginning with the one holding the current tick.
+// Arrays beyond the pool's own bitmap are not found.
+func (p *ClmmPool) TickArrayStarts(zeroForOne bool, n int) []int32 {
+	count := p.tickCount()
+	step := lo.If(zeroForOne, -1).Else(1)
+
+	var starts []int32
+	for bit := int(p.TickArrayStartIndex(p.TickCurrent)/count) + clmmBitmapOffset; bit >= 0 && bit < 1024 && len(starts) < n; bit += step {
+		if p.TickArrayBitmap[bit/64]&(1<<(bit%64)) != 0 {
+			starts = append(starts, int32(bit-clmmBitmapOffset)*count)
+		}
+	}
+	return starts
+}
+
+func FindClmmTickArray(poolID solana.PublicKey, startIndex int32) solana.PublicKey {
+	index := make([]byte, 4)
+	binary.BigEndian.PutUint32(index, uint32(startIndex))
+	address, _, _ := solana.FindProgramAddress(
+		[][]byte{[]byte("tick_array"), poolID.Bytes(), index},
+		ClmmProgramID,
+	)
+	return address
+}
+
+func FindClmmTickArrayBitmapExtension(poolID solana.PublicKey) solana.PublicKey {
+	address, _, _ := solana.FindProgramAddress(
+		[][]byte{[]byte("pool_tick_array_bitmap_extension"), poolID.Bytes()},
+		ClmmProgramID,
+	)
+	return address
+}
+
+// FeeBps is the trade fee in basis points.
+func (c *ClmmConfig) FeeBps() uint16 {
+	return uint16(uint64(c.TradeFeeRate) * 10000 / ClmmFeeDenominator)
+}
+
+// GetClmmPoolState reads a pool and its fee config.
+func GetClmmPoolState(ctx context.Context, client *rpc.Client, poolID solana.PublicKey) (*ClmmPool, *ClmmConfig, error) {
+	info, err := client.GetAccountInfoWithOpts(ctx, poolID, &rpc.GetAccountInfoOpts{Commitment: rpc.CommitmentConfirmed})
+	if err != nil {
+		return nil, nil, err
+	}
+	pool, err := DecodeClmmPool(info.Value.Data.GetBinary())
+	if err != nil {
+		return nil, nil, types.ErrInvalidPool
+	}
+
+	info, err = client.GetAccountInfoWithOpts(ctx, pool.AmmConfig, &rpc.GetAccountInfoOpts{Commitment: rpc.CommitmentConfirmed})
+	if err != nil {
+		return nil, nil, err
+	}
+	config, err := DecodeClmmConfig(info.Value.Data.GetBinary())
+	if err != nil {
+		return nil, nil, types.ErrInvalidPool
+	}
+	return pool, config, nil
+}
+
+// GetClmmTickArrays loads the initialized tick arrays, up to n, that a swap
+// in the given direction crosses first.
+func GetClmmTickArrays(ctx context.Context, client *rpc.Client, poolID solana.PublicKey, pool *ClmmPool, zeroForOne bool, n int) ([]*ClmmTickArray, error) {
+	starts := pool.TickArrayStarts(zeroForOne, n)
+	if len(starts) == 0 {
+		return nil, ErrClmmTickArrays
+	}
+	addresses := lo.Map(starts, func(start int32, _ int) solana.PublicKey {
+		return FindClmmTickArray(poolID, start)
+	})
+	accounts, err := client.GetMultipleAccountsWithOpts(ctx, addresses, &rpc.GetMultipleAccountsOpts{Commitment: rpc.CommitmentConfirmed})
+	if err != nil {
+		return nil, err
+	}
+
+	var arrays []*ClmmTickArray
+	for _, account := range accounts.Value {
+		if account == nil {
+			continue
+		}
+		array, err := DecodeClmmTickArray(account.Data.GetBinary())
+		if err != nil {
+			return nil, err
+		}
+		arrays = append(arrays, array)
+	}
+	return arrays, nil
+}
+
+// GetClmmPoolByToken finds the SOL pool of token with the most liquidity
+// in range.
+func GetClmmPoolByToken(ctx context.Context, url string, token solana.PublicKey, isBaseToken bool) (*types.Pool, error) {
+	client := rpc.New(url)
+	token0 := lo.If(isBaseToken, token).Else(solana.SolMint)
+	token1 := lo.If(isBaseToken, solana.SolMint).Else(token)
+
+	result, err := client.GetProgramAccountsWithOpts(ctx, ClmmProgramID, &rpc.GetProgramAccountsOpts{
+		Commitment: rpc.CommitmentConfirmed,
+		Encoding:   solana.EncodingBase64,
+		Filters: []rpc.RPCFilter{
+			{DataSize: ClmmPoolSize},
+			{Memcmp: &rpc.RPCFilterMemcmp{Offset: clmmToken0MintOffset, Bytes: token0.Bytes()}},
+			{Memcmp: &rpc.RPCFilterMemcmp{Offset: clmmToken1MintOffset, Bytes: token1.Bytes()}},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var best *ClmmPool
+	var bestID solana.PublicKey
+	for _, account := range result {
+		pool, err := DecodeClmmPool(account.Account.Data.GetBinary())
+		if err != nil || pool.Status&ClmmStatusSwapDisabled != 0 {
+			continue
+		}
+		if best == nil || pool.Liquidity.BigInt().Cmp(best.Liquidity.BigInt()) > 0 {
+			best, bestID = pool, account.Pubkey
+		}
+	}
+	if best == nil {
+		return nil, nil
+	}
+
+	return &types.Pool{
+		AmmPublicKey: bestID.String(),
+		BaseMint:     best.Token0Mint.String(),
+		BaseVault:    best.Token0Vault.String(),
+		BaseDecimal:  best.Mint0Decimals,
+		QuoteMint:    best.Token1Mint.String(),
+		QuoteVault:   best.Token1Vault.String(),
+		QuoteDecimal: best.Mint1Decimals,
+		Status:       int(best.Status),
+		Dex:          types.DexRaydiumClmm,
+		Marked:       true,
+	}, nil
+}
+
+// GetClmmPool reads price, vault balances and fee of a CLMM pool for
+// GetPool. The price is the pool's spot price; the reserves are whole vault
+// balances, not the liquidity in range.
+func GetClmmPool(ctx context.Context, url string, p *types.Pool, owner string, withBalance bool) (*common.GetSolPoolResponse, *common.Balance, error) {
+	poolID, err := solana.PublicKeyFromBase58(p.AmmPublicKey)
+	if err != nil {
+		return nil, nil, types.ErrInvalidPool
+	}
+
+	client := rpc.New(url)
+	pool, config, err := GetClmmPoolState(ctx, client, poolID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !pool.Token0Mint.Equals(solana.SolMint) && !pool.Token1Mint.Equals(solana.SolMint) {
+		return nil, nil, types.ErrInvalidPool
+	}
+
+	mint, _ := pool.Mints()
+	metaAddress, _, _ := solana.FindTokenMetadataAddress(mint)
+	publicKeys := []solana.PublicKey{
+		pool.Token0Vault,
+		pool.Token1Vault,
+		mint,
+		metaAddress,
+	}
+
+	if withBalance {
+		owner_ := solana.MPK(owner)
+		publicKeys = append(publicKeys, owner_)
+		publicKeys = append(publicKeys, common.TokenAccounts(owner_, mint)...)
+	}
+
+	accounts, err := client.GetMultipleAccountsWithOpts(
+		ctx,
+		publicKeys,
+		&rpc.GetMultipleAccountsOpts{Commitment: rpc.CommitmentProcessed},
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	balance := &common.Balance{NativeBalance: big.NewInt(0), TokenBalance: big.NewInt(0)}
+	if withBalance {
+		if accounts.Value[4] != nil {
+			balance.NativeBalance = new(big.Int).SetUint64(accounts.Value[4].Lamports)
+		}
+
+		balance.TokenBalance, err = common.TokenBalance(common.TokenAccountOf(accounts.Value[2], accounts.Value[5:]))
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if pool.Status&ClmmStatusSwapDisabled != 0 {
+		return nil, balance, types.ErrInvalidPool
+	}
+
+	var vault0, vault1 token.Account
+	for i, vault := range []*token.Account{&vault0, &vault1} {
+		if accounts.Value[i] == nil {
+			return nil, balance, types.ErrInvalidPool
+		}
+		err = vault.UnmarshalWithDecoder(bin.NewBinDecoder(accounts.Value[i].Data.GetBinary()))
+		if err != nil {
+			return nil, balance, err
+		}
+	}
+
+	tokenMint, err := common.DecodeMint(accounts.Value[2])
+	if err != nil {
+		return nil, balance, err
+	}
+	err = tokenMint.SetEpoch(ctx, client)
+	if err != nil {
+		return nil, balance, err
+	}
+
+	name, symbol, err := common.TokenName(tokenMint, mint, accounts.Value[3])
+	if err != nil {
+		return nil, balance, err
+	}
+
+	tokenReserve, solReserve := vault0.Amount-min(vault0.Amount, pool.ProtocolFeesToken0), vault1.Amount-min(vault1.Amount, pool.ProtocolFeesToken1)
+	if !pool.TokenIsToken0() {
+		tokenReserve, solReserve = solReserve, tokenReserve
+	}
+	if pool.Liquidity.BigInt().Sign() == 0 || tokenReserve == 0 || solReserve == 0 {
+		return nil, balance, types.ErrPoolCompleted
+	}
+
+	totalSupply := decimal.NewFromUint64(tokenMint.Supply).Div(decimal.New(1, int32(tokenMint.Decimals)))
+
+	ret := &common.GetSolPoolResponse{
+		PriceInSol:            pool.PriceInSol(),
+		TotalSupply:           totalSupply,
+		FreezeDisabled:        tokenMint.FreezeAuthority == nil,
+		MintAuthorityDisabled: tokenMint.MintAuthority == nil,
+		TokenReserve:          new(big.Int).SetUint64(tokenReserve),
+		SolReserve:            new(big.Int).SetUint64(solReserve),
+		Name:                  name,
+		Symbol:                symbol,
+		Decimals:              tokenMint.Decimals,
+		QuoteDecimals:         9,
+		TokenAddress:          mint.String(),
+		QuoteAddress:          solana.SolMint.String(),
+		PoolAddress:           p.AmmPublicKey,
+		PoolFeeBps:            config.FeeBps(),
+	}
+	ret.SetMint(tokenMint)
+	return ret, balance, nil
+}
+
+type ClmmSwapParam struct {
+	PoolID solana.PublicKey
+	Pool   *ClmmPool
+	Payer  solana.PublicKey
+
+	// exact in: Amount in and the least out as Threshold, exact out:
+	// Amount out and the most in as Threshold
+	Amount      uint64
+	Threshold   uint64
+	IsBaseInput bool
+
+	InputMint     solana.PublicKey
+	SourceAccount solana.PublicKey
+	DestAccount   solana.PublicKey
+	TickArrays    []int32 // start indexes, in the order the swap crosses them
+}
+
+// NewClmmSwapV2Instruction builds swap_v2, which takes Token-2022 mints.
+// There is no price limit; Threshold guards the amounts instead.
+func NewClmmSwapV2Instruction(param ClmmSwapParam) solana.Instruction {
+	data, err := borsh.Serialize(struct {
+		Discriminator        [8]byte
+		Amount               uint64
+		OtherAmountThreshold uint64
+		SqrtPriceLimitX64Lo  uint64
+		SqrtPriceLimitX64Hi  uint64
+		IsBaseInput          bool
+	}{
+		Discriminator:        Instruction_ClmmSwapV2,
+		Amount:               param.Amount,
+		OtherAmountThreshold: param.Threshold,
+		IsBaseInput:          param.IsBaseInput,
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	pool := param.Pool
+	inputVault, outputVault := pool.Token0Vault, pool.Token1Vault
+	inputMint, outputMint := pool.Token0Mint, pool.Token1Mint
+	if param.InputMint.Equals(pool.Token1Mint) {
+		inputVault, outputVault = outputVault, inputVault
+		inputMint, outputMint = outputMint, inputMint
+	}
+
+	accounts := solana.AccountMetaSlice{
+		{PublicKey: param.Payer, IsSigner: true, IsWritable: false},
+		{PublicKey: pool.AmmConfig, IsSigner: false, IsWritable: false},
+		{PublicKey: param.PoolID, IsSigner: false, IsWritable: true},
+		{PublicKey: param.SourceAccount, IsSigner: false, IsWritable: true},
+		{PublicKey: param.DestAccount, IsSigner: false, IsWritable: true},
+		{PublicKey: inputVault, IsSigner: false, IsWritable: true},
+		{PublicKey: outputVault, IsSigner: false, IsWritable: true},
+		{PublicKey: pool.ObservationKey, IsSigner: false, IsWritable: true},
+		{PublicKey: solana.TokenProgramID, IsSigner: false, IsWritable: false},
+		{PublicKey: solana.Token2022ProgramID, IsSigner: false, IsWritable: false},
+		{PublicKey: MemoProgramID, IsSigner: false, IsWritable: false},
+		{PublicKey: inputMint, IsSigner: false, IsWritable: false},
+		{PublicKey: outputMint, IsSigner: false, IsWritable: false},
+		{PublicKey: FindClmmTickArrayBitmapExtension(param.PoolID), IsSigner: false, IsWritable: true},
+	}
+	for _, start := range param.TickArrays {
+		accounts = append(accounts, &solana.AccountMeta{PublicKey: FindClmmTickArray(param.PoolID, start), IsWritable: true})
+	}
+
+	return &solana.GenericInstruction{
+		ProgID:        ClmmProgramID,
+		AccountValues: accounts,
+		DataBytes:     data,
+	}
+}
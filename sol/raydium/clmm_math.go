@@ -0,0 +1,229 @@
+package raydium
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"sort"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	ClmmMinTick = -443636
+	ClmmMaxTick = 443636
+)
+
+var (
+	ErrClmmTickArrays = errors.New("swap runs past the loaded tick arrays")
+
+	q64 = new(big.Int).Lsh(big.NewInt(1), 64)
+)
+
+// ClmmQuote is the result of walking a swap through the pool's ticks.
+type ClmmQuote struct {
+	AmountIn     uint64 // including the trade fee
+	AmountOut    uint64
+	Fee          uint64
+	SqrtPriceX64 *big.Int // price after the swap
+}
+
+// SqrtPriceAtTick returns sqrt(1.0001^tick) as a Q64.64 number. It is
+// computed in floating point, which is close enough to quote with.
+func SqrtPriceAtTick(tick int32) *big.Int {
+	sqrtPrice := new(big.Float).SetFloat64(math.Pow(1.0001, float64(tick)/2))
+	sqrtPrice.Mul(sqrtPrice, new(big.Float).SetInt(q64))
+	ret, _ := sqrtPrice.Int(nil)
+	return ret
+}
+
+// TickAtSqrtPrice returns the greatest tick whose sqrt price is at most
+// sqrtPriceX64.
+func TickAtSqrtPrice(sqrtPriceX64 *big.Int) int32 {
+	ratio, _ := new(big.Float).Quo(new(big.Float).SetInt(sqrtPriceX64), new(big.Float).SetInt(q64)).Float64()
+	tick := int32(math.Floor(2 * math.Log(ratio) / math.Log(1.0001)))
+	for tick > ClmmMinTick && SqrtPriceAtTick(tick).Cmp(sqrtPriceX64) > 0 {
+		tick--
+	}
+	for tick < ClmmMaxTick && SqrtPriceAtTick(tick+1).Cmp(sqrtPriceX64) <= 0 {
+		tick++
+	}
+	return tick
+}
+
+// SqrtPriceToPrice converts a Q64.64 sqrt price to the price of token 0 in
+// token 1, both in whole units.
+func SqrtPriceToPrice(sqrtPriceX64 *big.Int, decimals0, decimals1 uint8) decimal.Decimal {
+	sqrtPrice := decimal.NewFromBigInt(sqrtPriceX64, 0).Div(decimal.NewFromBigInt(q64, 0))
+	return sqrtPrice.Mul(sqrtPrice).Shift(int32(decimals0) - int32(decimals1))
+}
+
+// amount0Delta is the token 0 between two sqrt prices at liquidity:
+// L * (upper - lower) / (upper * lower), scaled back from Q64.64.
+func amount0Delta(sqrtA, sqrtB, liquidity *big.Int, roundUp bool) *big.Int {
+	lower, upper := sqrtA, sqrtB
+	if lower.Cmp(upper) > 0 {
+		lower, upper = upper, lower
+	}
+	numerator := new(big.Int).Lsh(liquidity, 64)
+	numerator.Mul(numerator, new(big.Int).Sub(upper, lower))
+	denominator := new(big.Int).Mul(upper, lower)
+	return div(numerator, denominator, roundUp)
+}
+
+// amount1Delta is the token 1 between two sqrt prices at liquidity:
+// L * (upper - lower), scaled back from Q64.64.
+func amount1Delta(sqrtA, sqrtB, liquidity *big.Int, roundUp bool) *big.Int {
+	diff := new(big.Int).Sub(sqrtA, sqrtB)
+	diff.Abs(diff)
+	return div(diff.Mul(diff, liquidity), q64, roundUp)
+}
+
+func div(a, b *big.Int, roundUp bool) *big.Int {
+	q, r := new(big.Int).QuoRem(a, b, new(big.Int))
+	if roundUp && r.Sign() != 0 {
+		q.Add(q, big.NewInt(1))
+	}
+	return q
+}
+
+// nextSqrtPrice moves the price by amount of token 0 or 1 going in
+// (exactIn) or coming out of the pool.
+func nextSqrtPrice(sqrtPrice, liquidity, amount *big.Int, zeroForOne, exactIn bool) *big.Int {
+	if zeroForOne == exactIn {
+		// token 0 moves: L * P / (L +- amount * P)
+		numerator := new(big.Int).Lsh(liquidity, 64)
+		product := new(big.Int).Mul(amount, sqrtPrice)
+		denominator := new(big.Int).Set(numerator)
+		if exactIn {
+			denominator.Add(denominator, product)
+		} else {
+			denominator.Sub(denominator, product)
+		}
+		if denominator.Sign() <= 0 {
+			return SqrtPriceAtTick(ClmmMaxTick)
+		}
+		return div(numerator.Mul(numerator, sqrtPrice), denominator, true)
+	}
+	// token 1 moves: P +- amount / L
+	delta := div(new(big.Int).Lsh(amount, 64), liquidity, !exactIn)
+	if exactIn {
+		return delta.Add(sqrtPrice, delta)
+	}
+	return delta.Sub(sqrtPrice, delta)
+}
+
+// Quote walks a swap of amount through the ticks of tickArrays, which must
+// cover the range the price moves through. zeroForOne sells token 0 for
+// token 1; exactIn takes amount as the input, else as the output wanted.
+func (p *ClmmPool) Quote(config *ClmmConfig, tickArrays []*ClmmTickArray, amount uint64, zeroForOne, exactIn bool) (*ClmmQuote, error) {
+	var ticks []ClmmTick
+	for _, array := range tickArrays {
+		for _, tick := range array.Ticks {
+			if tick.LiquidityGross.BigInt().Sign() != 0 {
+				ticks = append(ticks, tick)
+			}
+		}
+	}
+	sort.Slice(ticks, func(i, j int) bool { return ticks[i].Tick < ticks[j].Tick })
+
+	feeRate := big.NewInt(int64(config.TradeFeeRate))
+	feeComplement := new(big.Int).Sub(big.NewInt(ClmmFeeDenominator), feeRate)
+	sqrtPrice := p.SqrtPriceX64.BigInt()
+	liquidity := p.Liquidity.BigInt()
+	tickCurrent := p.TickCurrent
+	remaining := new(big.Int).SetUint64(amount)
+	amountIn, amountOut, fee := new(big.Int), new(big.Int), new(big.Int)
+
+	for remaining.Sign() > 0 {
+		next, ok := nextInitializedTick(ticks, tickCurrent, zeroForOne)
+		if !ok {
+			return nil, ErrClmmTickArrays
+		}
+		sqrtTarget := SqrtPriceAtTick(next.Tick)
+
+		var stepIn, stepOut, stepFee, sqrtNext *big.Int
+		if exactIn {
+			lessFee := div(new(big.Int).Mul(remaining, feeComplement), big.NewInt(ClmmFeeDenominator), false)
+			stepIn = stepAmountIn(sqrtPrice, sqrtTarget, liquidity, zeroForOne)
+			if lessFee.Cmp(stepIn) >= 0 {
+				sqrtNext = sqrtTarget
+				stepFee = div(new(big.Int).Mul(stepIn, feeRate), feeComplement, true)
+			} else {
+				sqrtNext = nextSqrtPrice(sqrtPrice, liquidity, lessFee, zeroForOne, true)
+				stepIn = stepAmountIn(sqrtPrice, sqrtNext, liquidity, zeroForOne)
+				stepFee = new(big.Int).Sub(remaining, stepIn)
+			}
+			stepOut = stepAmountOut(sqrtPrice, sqrtNext, liquidity, zeroForOne)
+			remaining.Sub(remaining, new(big.Int).Add(stepIn, stepFee))
+		} else {
+			stepOut = stepAmountOut(sqrtPrice, sqrtTarget, liquidity, zeroForOne)
+			if remaining.Cmp(stepOut) >= 0 {
+				sqrtNext = sqrtTarget
+			} else {
+				sqrtNext = nextSqrtPrice(sqrtPrice, liquidity, remaining, zeroForOne, false)
+				stepOut = new(big.Int).Set(remaining)
+			}
+			stepIn = stepAmountIn(sqrtPrice, sqrtNext, liquidity, zeroForOne)
+			stepFee = div(new(big.Int).Mul(stepIn, feeRate), feeComplement, true)
+			remaining.Sub(remaining, stepOut)
+		}
+		amountIn.Add(amountIn, stepIn)
+		amountOut.Add(amountOut, stepOut)
+		fee.Add(fee, stepFee)
+		sqrtPrice = sqrtNext
+
+		if sqrtNext.Cmp(sqrtTarget) == 0 {
+			net := next.LiquidityNet.BigInt()
+			if zeroForOne {
+				liquidity.Sub(liquidity, net)
+				tickCurrent = next.Tick - 1
+			} else {
+				liquidity.Add(liquidity, net)
+				tickCurrent = next.Tick
+			}
+		} else {
+			tickCurrent = TickAtSqrtPrice(sqrtNext)
+		}
+	}
+
+	return &ClmmQuote{
+		AmountIn:     new(big.Int).Add(amountIn, fee).Uint64(),
+		AmountOut:    amountOut.Uint64(),
+		Fee:          fee.Uint64(),
+		SqrtPriceX64: sqrtPrice,
+	}, nil
+}
+
+func stepAmountIn(sqrtPrice, sqrtTarget, liquidity *big.Int, zeroForOne bool) *big.Int {
+	if zeroForOne {
+		return amount0Delta(sqrtTarget, sqrtPrice, liquidity, true)
+	}
+	return amount1Delta(sqrtPrice, sqrtTarget, liquidity, true)
+}
+
+func stepAmountOut(sqrtPrice, sqrtTarget, liquidity *big.Int, zeroForOne bool) *big.Int {
+	if zeroForOne {
+		return amount1Delta(sqrtTarget, sqrtPrice, liquidity, false)
+	}
+	return amount0Delta(sqrtPrice, sqrtTarget, liquidity, false)
+}
+
+// nextInitializedTick finds the next tick the price reaches: at or below
+// the current tick going down, above it going up.
+func nextInitializedTick(ticks []ClmmTick, current int32, zeroForOne bool) (ClmmTick, bool) {
+	if zeroForOne {
+		for i := len(ticks) - 1; i >= 0; i-- {
+			if ticks[i].Tick <= current {
+				return ticks[i], true
+			}
+		}
+		return ClmmTick{}, false
+	}
+	for _, tick := range ticks {
+		if tick.Tick > current {
+			return tick, true
+		}
+	}
+	return ClmmTick{}, false
+}
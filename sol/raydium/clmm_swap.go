@@ -0,0 +1,146 @@
+package raydium
+
+import (
+	"math/big"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/token"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/meme-bots/go-web3/sol/common"
+	"github.com/samber/lo"
+)
+
+// ClmmSwapTickArrays is how many initialized tick arrays a swap loads,
+// which bounds how far it can move the price.
+const ClmmSwapTickArrays = 3
+
+func ClmmBuyInstructions(
+	poolID solana.PublicKey,
+	tokenMint *common.Mint,
+	solAmount, slippage uint64,
+	pool *ClmmPool,
+	config *ClmmConfig,
+	tickArrays []*ClmmTickArray,
+	createAta bool,
+	payer solana.PublicKey,
+) ([]solana.Instruction, error) {
+	var instructions []solana.Instruction
+
+	//quote across ticks, the pool checks the amount out net of the mint's transfer fee
+	quote, err := pool.Quote(config, tickArrays, solAmount, !pool.TokenIsToken0(), true)
+	if err != nil {
+		return nil, err
+	}
+	minAmountOut := tokenMint.AmountAfterFee(quote.AmountOut)
+	minAmountOut -= minAmountOut * slippage / 10000
+
+	//create and init tmp wsol token account
+	createAndInitInsts, wsolAta := CreateAndInitWsolTokenAccount(payer, solAmount)
+	instructions = append(instructions, createAndInitInsts...)
+	//create ata
+	mint, _ := pool.Mints()
+	if createAta {
+		instructions = append(instructions, common.NewCreateIdempotentAtaInstruction(payer, payer, mint, tokenMint.Program))
+	}
+	//swap
+	ata := common.FindAssociatedTokenAddress(payer, mint, tokenMint.Program)
+	swapInst := NewClmmSwapV2Instruction(ClmmSwapParam{
+		PoolID:        poolID,
+		Pool:          pool,
+		Payer:         payer,
+		Amount:        solAmount,
+		Threshold:     minAmountOut,
+		IsBaseInput:   true,
+		InputMint:     solana.SolMint,
+		SourceAccount: wsolAta,
+		DestAccount:   ata,
+		TickArrays:    tickArrayStarts(tickArrays),
+	})
+	instructions = append(instructions, swapInst)
+	//close wsol token account
+	closeAccountInst := token.NewCloseAccountInstruction(
+		wsolAta,
+		payer,
+		payer,
+		[]solana.PublicKey{payer},
+	).Build()
+	instructions = append(instructions, closeAccountInst)
+
+	return instructions, nil
+}
+
+func ClmmSellInstructions(
+	poolID solana.PublicKey,
+	tokenMint *common.Mint,
+	tokenAmount, slippage uint64,
+	pool *ClmmPool,
+	config *ClmmConfig,
+	tickArrays []*ClmmTickArray,
+	isSellAll bool,
+	payer solana.PublicKey,
+) ([]solana.Instruction, error) {
+	var instructions []solana.Instruction
+
+	//quote across ticks, the vault receives what is left after the mint's transfer fee
+	quote, err := pool.Quote(config, tickArrays, tokenMint.AmountAfterFee(tokenAmount), pool.TokenIsToken0(), true)
+	if err != nil {
+		return nil, err
+	}
+	minAmountOut := quote.AmountOut
+	minAmountOut -= minAmountOut * slippage / 10000
+
+	//create and init tmp wsol token account
+	createAndInitInsts, wsolAta := CreateAndInitWsolTokenAccount(payer, 0)
+	instructions = append(instructions, createAndInitInsts...)
+
+	//swap
+	mint, _ := pool.Mints()
+	ata := common.FindAssociatedTokenAddress(payer, mint, tokenMint.Program)
+	swapInst := NewClmmSwapV2Instruction(ClmmSwapParam{
+		PoolID:        poolID,
+		Pool:          pool,
+		Payer:         payer,
+		Amount:        tokenAmount,
+		Threshold:     minAmountOut,
+		IsBaseInput:   true,
+		InputMint:     mint,
+		SourceAccount: ata,
+		DestAccount:   wsolAta,
+		TickArrays:    tickArrayStarts(tickArrays),
+	})
+	instructions = append(instructions, swapInst)
+
+	//close wsol token account
+	closeAccountInst := token.NewCloseAccountInstruction(
+		wsolAta,
+		payer,
+		payer,
+		[]solana.PublicKey{payer},
+	).Build()
+	instructions = append(instructions, closeAccountInst)
+
+	//close ata
+	if isSellAll {
+		closeAccountInst := common.WithProgram(token.NewCloseAccountInstruction(
+			ata,
+			payer,
+			payer,
+			[]solana.PublicKey{payer},
+		).Build(), tokenMint.Program)
+		instructions = append(instructions, closeAccountInst)
+	}
+
+	return instructions, nil
+}
+
+func tickArrayStarts(tickArrays []*ClmmTickArray) []int32 {
+	return lo.Map(tickArrays, func(array *ClmmTickArray, _ int) int32 {
+		return array.StartTickIndex
+	})
+}
+
+// ParseClmmSwapInstruction reads the SOL and token amounts a CLMM swap_v2
+// moved for its signer.
+func ParseClmmSwapInstruction(tx *rpc.GetTransactionResult, message *solana.Message, instruction solana.CompiledInstruction, instructionIndex uint16) (*big.Int, *big.Int) {
+	return parseTransferCheckedSwap(tx, message, instruction, instructionIndex, 11)
+}
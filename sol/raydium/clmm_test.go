@@ -0,0 +1,100 @@
+package raydium
+
+import (
+	"encoding/binary"
+	"math/big"
+	"testing"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+)
+
+func TestDecodeClmmPool_Offsets(t *testing.T) {
+	token0 := solana.NewWallet().PublicKey()
+
+	data := make([]byte, ClmmPoolSize)
+	copy(data, ClmmPoolDiscriminator[:])
+	copy(data[clmmToken0MintOffset:], token0.Bytes())
+	copy(data[clmmToken1MintOffset:], solana.SolMint.Bytes())
+	binary.LittleEndian.PutUint16(data[235:], 60) // tick spacing
+	current := int32(-7200)
+	binary.LittleEndian.PutUint32(data[269:], uint32(current))
+	binary.LittleEndian.PutUint64(data[904+8*7:], 1<<63) // bitmap bit 511
+
+	pool, err := DecodeClmmPool(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pool.Token0Mint.Equals(token0) || !pool.Token1Mint.Equals(solana.SolMint) {
+		t.Errorf("mints = %s, %s", pool.Token0Mint, pool.Token1Mint)
+	}
+	if pool.TickSpacing != 60 || pool.TickCurrent != -7200 {
+		t.Errorf("tick spacing %d, current tick %d", pool.TickSpacing, pool.TickCurrent)
+	}
+	// ticks -7200..-3601 are in the array starting at -7200, bit 510
+	if start := pool.TickArrayStartIndex(pool.TickCurrent); start != -7200 {
+		t.Errorf("TickArrayStartIndex = %d, want -7200", start)
+	}
+	if starts := pool.TickArrayStarts(false, 3); len(starts) != 1 || starts[0] != -3600 {
+		t.Errorf("TickArrayStarts = %v, want [-3600]", starts)
+	}
+}
+
+func TestTickAtSqrtPrice(t *testing.T) {
+	for _, tick := range []int32{-443636, -7201, 0, 1, 120000} {
+		if got := TickAtSqrtPrice(SqrtPriceAtTick(tick)); got != tick {
+			t.Errorf("TickAtSqrtPrice(SqrtPriceAtTick(%d)) = %d", tick, got)
+		}
+	}
+}
+
+// fullRangePool holds liquidity between ticks -60 * 60 and 60 * 60, one
+// tick array either side of the price at tick 0.
+func fullRangePool(liquidity int64) (*ClmmPool, []*ClmmTickArray) {
+	pool := &ClmmPool{
+		TickSpacing:  60,
+		Liquidity:    bin.Uint128{Lo: uint64(liquidity)},
+		SqrtPriceX64: bin.Uint128{Lo: 0, Hi: 1},
+	}
+	lower := &ClmmTickArray{StartTickIndex: -3600}
+	lower.Ticks[0] = ClmmTick{Tick: -3600, LiquidityNet: bin.Int128{Lo: uint64(liquidity)}, LiquidityGross: bin.Uint128{Lo: uint64(liquidity)}}
+	upper := &ClmmTickArray{StartTickIndex: 3600}
+	net := new(big.Int).Neg(big.NewInt(liquidity))
+	upper.Ticks[0] = ClmmTick{Tick: 3600, LiquidityNet: int128(net), LiquidityGross: bin.Uint128{Lo: uint64(liquidity)}}
+	return pool, []*ClmmTickArray{lower, upper}
+}
+
+func int128(v *big.Int) bin.Int128 {
+	two128 := new(big.Int).Lsh(big.NewInt(1), 128)
+	u := new(big.Int).Mod(v, two128)
+	lo := new(big.Int).And(u, new(big.Int).SetUint64(^uint64(0)))
+	hi := new(big.Int).Rsh(u, 64)
+	return bin.Int128{Lo: lo.Uint64(), Hi: hi.Uint64()}
+}
+
+func TestClmmPool_Quote(t *testing.T) {
+	config := &ClmmConfig{TradeFeeRate: 2500}
+	pool, arrays := fullRangePool(1000000000)
+
+	// a small swap at price 1 gets about the input less fee
+	quote, err := pool.Quote(config, arrays, 1000, true, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if quote.AmountIn != 1000 || quote.AmountOut < 990 || quote.AmountOut > 997 {
+		t.Errorf("exact in quote = %+v", quote)
+	}
+
+	exactOut, err := pool.Quote(config, arrays, quote.AmountOut, true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exactOut.AmountOut != quote.AmountOut || exactOut.AmountIn > 1001 || exactOut.AmountIn < 999 {
+		t.Errorf("exact out quote = %+v", exactOut)
+	}
+
+	// more than the range holds runs past the last tick
+	if _, err = pool.Quote(config, arrays, 1000000000, false, true); err != ErrClmmTickArrays {
+		t.Errorf("err = %v, want ErrClmmTickArrays", err)
+	}
+}
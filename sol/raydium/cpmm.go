@@ -0,0 +1,405 @@
+package raydium
+
+import (
+	"context"
+	"errors"
+	"math/big"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/token"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/meme-bots/go-web3/sol/common"
+	"github.com/meme-bots/go-web3/types"
+	"github.com/meme-bots/go-web3/utils"
+	"github.com/near/borsh-go"
+	"github.com/samber/lo"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	CpmmPoolSize = 637
+
+	// fee rates are in millionths
+	CpmmFeeDenominator = 1000000
+
+	CpmmStatusDepositDisabled  = 1 << 0
+	CpmmStatusWithdrawDisabled = 1 << 1
+	CpmmStatusSwapDisabled     = 1 << 2
+
+	cpmmToken0MintOffset = 168
+	cpmmToken1MintOffset = 200
+)
+
+var (
+	CpmmProgramID = solana.MPK("CPMMoo8L3F4NbTegBCKVNunggL7H1ZpdTHKxQB5qKP1C")
+	CpmmAuthority = solana.MPK("GpMZbSM2GgvTKHJirzeGfMFoaZ8UR2X7F4v8vHTvxFbL")
+
+	CpmmPoolDiscriminator   = [8]byte{247, 237, 227, 245, 215, 195, 222, 70}
+	CpmmConfigDiscriminator = [8]byte{218, 244, 33, 104, 203, 203, 43, 111}
+
+	Instruction_CpmmSwapBaseInput  = [8]byte{143, 190, 90, 218, 196, 30, 51, 222}
+	Instruction_CpmmSwapBaseOutput = [8]byte{55, 217, 98, 86, 163, 74, 180, 173}
+
+	ErrNotCpmmPool = errors.New("not a raydium cpmm pool")
+)
+
+type (
+	// CpmmPool is the pool state of the Raydium CPMM program. Unlike AMM v4
+	// it trades without an OpenBook market and takes Token-2022 mints.
+	CpmmPool struct {
+		Discriminator  [8]byte
+		AmmConfig      solana.PublicKey
+		PoolCreator    solana.PublicKey
+		Token0Vault    solana.PublicKey
+		Token1Vault    solana.PublicKey
+		LpMint         solana.PublicKey
+		Token0Mint     solana.PublicKey
+		Token1Mint     solana.PublicKey
+		Token0Program  solana.PublicKey
+		Token1Program  solana.PublicKey
+		ObservationKey solana.PublicKey
+		AuthBump       uint8
+		Status         uint8
+		LpMintDecimals uint8
+		Mint0Decimals  uint8
+		Mint1Decimals  uint8
+		LpSupply       uint64
+		// fees owed to the protocol and fund sit in the vaults until
+		// collected and are not part of the reserves
+		ProtocolFeesToken0 uint64
+		ProtocolFeesToken1 uint64
+		FundFeesToken0     uint64
+		FundFeesToken1     uint64
+		OpenTime           uint64
+		RecentEpoch        uint64
+	}
+
+	CpmmConfig struct {
+		Discriminator     [8]byte
+		Bump              uint8
+		DisableCreatePool bool
+		Index             uint16
+		TradeFeeRate      uint64
+		ProtocolFeeRate   uint64
+		FundFeeRate       uint64
+		CreatePoolFee     uint64
+	}
+)
+
+func DecodeCpmmPool(data []byte) (*CpmmPool, error) {
+	var pool CpmmPool
+	err := borsh.Deserialize(&pool, data)
+	if err != nil {
+		return nil, err
+	}
+	if pool.Discriminator != CpmmPoolDiscriminator {
+		return nil, ErrNotCpmmPool
+	}
+	return &pool, nil
+}
+
+func DecodeCpmmConfig(data []byte) (*CpmmConfig, error) {
+	var config CpmmConfig
+	err := borsh.Deserialize(&config, data)
+	if err != nil {
+		return nil, err
+	}
+	if config.Discriminator != CpmmConfigDiscriminator {
+		return nil, ErrNotCpmmPool
+	}
+	return &config, nil
+}
+
+// TokenIsToken0 reports whether the non-SOL side of the pool is token 0.
+func (p *CpmmPool) TokenIsToken0() bool {
+	return !p.Token0Mint.Equals(solana.SolMint)
+}
+
+// Mints returns the token and SOL mints of the pool, in that order.
+func (p *CpmmPool) Mints() (solana.PublicKey, solana.PublicKey) {
+	if p.TokenIsToken0() {
+		return p.Token0Mint, p.Token1Mint
+	}
+	return p.Token1Mint, p.Token0Mint
+}
+
+// Reserves returns the tradable token and SOL reserves given the vault
+// balances.
+func (p *CpmmPool) Reserves(vault0, vault1 uint64) (uint64, uint64) {
+	reserve0 := vault0 - min(vault0, p.ProtocolFeesToken0+p.FundFeesToken0)
+	reserve1 := vault1 - min(vault1, p.ProtocolFeesToken1+p.FundFeesToken1)
+	if p.TokenIsToken0() {
+		return reserve0, reserve1
+	}
+	return reserve1, reserve0
+}
+
+// Quote returns the output of selling amountIn into the pool after the
+// trade fee. Transfer fees of Token-2022 mints are up to the caller.
+func (c *CpmmConfig) Quote(amountIn, reserveIn, reserveOut uint64) uint64 {
+	fee := new(big.Int).Mul(new(big.Int).SetUint64(amountIn), new(big.Int).SetUint64(c.TradeFeeRate))
+	fee.Add(fee, big.NewInt(CpmmFeeDenominator-1))
+	fee.Quo(fee, big.NewInt(CpmmFeeDenominator))
+	return utils.CalculateOutput(amountIn-fee.Uint64(), reserveIn, reserveOut)
+}
+
+// FeeBps is the trade fee in basis points.
+func (c *CpmmConfig) FeeBps() uint16 {
+	return uint16(c.TradeFeeRate * 10000 / CpmmFeeDenominator)
+}
+
+// GetCpmmPoolState reads a pool and its fee config.
+func GetCpmmPoolState(ctx context.Context, client *rpc.Client, poolID solana.PublicKey) (*CpmmPool, *CpmmConfig, error) {
+	info, err := client.GetAccountInfoWithOpts(ctx, poolID, &rpc.GetAccountInfoOpts{Commitment: rpc.CommitmentConfirmed})
+	if err != nil {
+		return nil, nil, err
+	}
+	pool, err := DecodeCpmmPool(info.Value.Data.GetBinary())
+	if err != nil {
+		return nil, nil, types.ErrInvalidPool
+	}
+
+	info, err = client.GetAccountInfoWithOpts(ctx, pool.AmmConfig, &rpc.GetAccountInfoOpts{Commitment: rpc.CommitmentConfirmed})
+	if err != nil {
+		return nil, nil, err
+	}
+	config, err := DecodeCpmmConfig(info.Value.Data.GetBinary())
+	if err != nil {
+		return nil, nil, types.ErrInvalidPool
+	}
+	return pool, config, nil
+}
+
+func GetCpmmPoolByToken(ctx context.Context, url string, token solana.PublicKey, isBaseToken bool) (*types.Pool, error) {
+	client := rpc.New(url)
+	token0 := lo.If(isBaseToken, token).Else(solana.SolMint)
+	token1 := lo.If(isBaseToken, solana.SolMint).Else(token)
+
+	result, err := client.GetProgramAccountsWithOpts(ctx, CpmmProgramID, &rpc.GetProgramAccountsOpts{
+		Commitment: rpc.CommitmentConfirmed,
+		Encoding:   solana.EncodingBase64,
+		Filters: []rpc.RPCFilter{
+			{DataSize: CpmmPoolSize},
+			{Memcmp: &rpc.RPCFilterMemcmp{Offset: cpmmToken0MintOffset, Bytes: token0.Bytes()}},
+			{Memcmp: &rpc.RPCFilterMemcmp{Offset: cpmmToken1MintOffset, Bytes: token1.Bytes()}},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(result) == 0 {
+		return nil, nil
+	}
+
+	pool, err := DecodeCpmmPool(result[0].Account.Data.GetBinary())
+	if err != nil {
+		return nil, types.ErrInvalidPool
+	}
+
+	return &types.Pool{
+		AmmPublicKey: result[0].Pubkey.String(),
+		BaseMint:     pool.Token0Mint.String(),
+		BaseVault:    pool.Token0Vault.String(),
+		BaseDecimal:  pool.Mint0Decimals,
+		QuoteMint:    pool.Token1Mint.String(),
+		QuoteVault:   pool.Token1Vault.String(),
+		QuoteDecimal: pool.Mint1Decimals,
+		LpMint:       pool.LpMint.String(),
+		OpenTime:     int64(pool.OpenTime),
+		Status:       int(pool.Status),
+		Dex:          types.DexRaydiumCpmm,
+		Marked:       true,
+	}, nil
+}
+
+// GetCpmmPool reads price, reserves and fee of a CPMM pool for GetPool.
+func GetCpmmPool(ctx context.Context, url string, p *types.Pool, owner string, withBalance bool) (*common.GetSolPoolResponse, *common.Balance, error) {
+	poolID, err := solana.PublicKeyFromBase58(p.AmmPublicKey)
+	if err != nil {
+		return nil, nil, types.ErrInvalidPool
+	}
+
+	client := rpc.New(url)
+	pool, config, err := GetCpmmPoolState(ctx, client, poolID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !pool.Token0Mint.Equals(solana.SolMint) && !pool.Token1Mint.Equals(solana.SolMint) {
+		return nil, nil, types.ErrInvalidPool
+	}
+
+	mint, _ := pool.Mints()
+	metaAddress, _, _ := solana.FindTokenMetadataAddress(mint)
+	publicKeys := []solana.PublicKey{
+		pool.Token0Vault,
+		pool.Token1Vault,
+		mint,
+		pool.LpMint,
+		metaAddress,
+	}
+
+	if withBalance {
+		owner_ := solana.MPK(owner)
+		publicKeys = append(publicKeys, owner_)
+		publicKeys = append(publicKeys, common.TokenAccounts(owner_, mint)...)
+	}
+
+	accounts, err := client.GetMultipleAccountsWithOpts(
+		ctx,
+		publicKeys,
+		&rpc.GetMultipleAccountsOpts{Commitment: rpc.CommitmentProcessed},
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	balance := &common.Balance{NativeBalance: big.NewInt(0), TokenBalance: big.NewInt(0)}
+	if withBalance {
+		if accounts.Value[5] != nil {
+			balance.NativeBalance = new(big.Int).SetUint64(accounts.Value[5].Lamports)
+		}
+
+		balance.TokenBalance, err = common.TokenBalance(common.TokenAccountOf(accounts.Value[2], accounts.Value[6:]))
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if pool.Status&CpmmStatusSwapDisabled != 0 {
+		return nil, balance, types.ErrInvalidPool
+	}
+
+	var vault0, vault1 token.Account
+	for i, vault := range []*token.Account{&vault0, &vault1} {
+		if accounts.Value[i] == nil {
+			return nil, balance, types.ErrInvalidPool
+		}
+		err = vault.UnmarshalWithDecoder(bin.NewBinDecoder(accounts.Value[i].Data.GetBinary()))
+		if err != nil {
+			return nil, balance, err
+		}
+	}
+
+	tokenMint, err := common.DecodeMint(accounts.Value[2])
+	if err != nil {
+		return nil, balance, err
+	}
+	err = tokenMint.SetEpoch(ctx, client)
+	if err != nil {
+		return nil, balance, err
+	}
+	var lpMint token.Mint
+	err = lpMint.UnmarshalWithDecoder(bin.NewBinDecoder(accounts.Value[3].Data.GetBinary()))
+	if err != nil {
+		return nil, balance, err
+	}
+
+	name, symbol, err := common.TokenName(tokenMint, mint, accounts.Value[4])
+	if err != nil {
+		return nil, balance, err
+	}
+
+	tokenReserve, solReserve := pool.Reserves(vault0.Amount, vault1.Amount)
+	if tokenReserve == 0 || solReserve == 0 {
+		return nil, balance, types.ErrPoolCompleted
+	}
+
+	priceInSol := decimal.NewFromBigInt(new(big.Int).SetUint64(solReserve), -9).
+		Div(decimal.NewFromBigInt(new(big.Int).SetUint64(tokenReserve), -int32(tokenMint.Decimals)))
+	totalSupply := decimal.NewFromUint64(tokenMint.Supply).Div(decimal.New(1, int32(tokenMint.Decimals)))
+
+	ret := &common.GetSolPoolResponse{
+		PriceInSol:            priceInSol,
+		TotalSupply:           totalSupply,
+		FreezeDisabled:        tokenMint.FreezeAuthority == nil,
+		Burnt:                 lpMint.Supply == 0,
+		MintAuthorityDisabled: tokenMint.MintAuthority == nil,
+		TokenReserve:          new(big.Int).SetUint64(tokenReserve),
+		SolReserve:            new(big.Int).SetUint64(solReserve),
+		Name:                  name,
+		Symbol:                symbol,
+		Decimals:              tokenMint.Decimals,
+		QuoteDecimals:         9,
+		TokenAddress:          mint.String(),
+		QuoteAddress:          solana.SolMint.String(),
+		PoolAddress:           p.AmmPublicKey,
+		PoolFeeBps:            config.FeeBps(),
+	}
+	ret.SetMint(tokenMint)
+	return ret, balance, nil
+}
+
+type CpmmSwapParam struct {
+	PoolID solana.PublicKey
+	Pool   *CpmmPool
+	Payer  solana.PublicKey
+
+	// exact in: AmountIn and the least to receive, exact out: AmountOut
+	// and the most to pay
+	AmountIn  uint64
+	AmountOut uint64
+
+	InputMint     solana.PublicKey
+	SourceAccount solana.PublicKey
+	DestAccount   solana.PublicKey
+}
+
+// NewCpmmSwapBaseInputInstruction swaps exactly AmountIn for at least
+// AmountOut.
+func NewCpmmSwapBaseInputInstruction(param CpmmSwapParam) solana.Instruction {
+	return newCpmmSwapInstruction(Instruction_CpmmSwapBaseInput, param)
+}
+
+// NewCpmmSwapBaseOutputInstruction swaps at most AmountIn for exactly
+// AmountOut.
+func NewCpmmSwapBaseOutputInstruction(param CpmmSwapParam) solana.Instruction {
+	return newCpmmSwapInstruction(Instruction_CpmmSwapBaseOutput, param)
+}
+
+// both variants take the amount in first and the amount out second
+func newCpmmSwapInstruction(discriminator [8]byte, param CpmmSwapParam) solana.Instruction {
+	data, err := borsh.Serialize(struct {
+		Discriminator [8]byte
+		AmountIn      uint64
+		AmountOut     uint64
+	}{
+		Discriminator: discriminator,
+		AmountIn:      param.AmountIn,
+		AmountOut:     param.AmountOut,
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	pool := param.Pool
+	inputVault, outputVault := pool.Token0Vault, pool.Token1Vault
+	inputProgram, outputProgram := pool.Token0Program, pool.Token1Program
+	inputMint, outputMint := pool.Token0Mint, pool.Token1Mint
+	if param.InputMint.Equals(pool.Token1Mint) {
+		inputVault, outputVault = outputVault, inputVault
+		inputProgram, outputProgram = outputProgram, inputProgram
+		inputMint, outputMint = outputMint, inputMint
+	}
+
+	return &solana.GenericInstruction{
+		ProgID: CpmmProgramID,
+		AccountValues: solana.AccountMetaSlice{
+			{PublicKey: param.Payer, IsSigner: true, IsWritable: false},
+			{PublicKey: CpmmAuthority, IsSigner: false, IsWritable: false},
+			{PublicKey: pool.AmmConfig, IsSigner: false, IsWritable: false},
+			{PublicKey: param.PoolID, IsSigner: false, IsWritable: true},
+			{PublicKey: param.SourceAccount, IsSigner: false, IsWritable: true},
+			{PublicKey: param.DestAccount, IsSigner: false, IsWritable: true},
+			{PublicKey: inputVault, IsSigner: false, IsWritable: true},
+			{PublicKey: outputVault, IsSigner: false, IsWritable: true},
+			{PublicKey: inputProgram, IsSigner: false, IsWritable: false},
+			{PublicKey: outputProgram, IsSigner: false, IsWritable: false},
+			{PublicKey: inputMint, IsSigner: false, IsWritable: false},
+			{PublicKey: outputMint, IsSigner: false, IsWritable: false},
+			{PublicKey: pool.ObservationKey, IsSigner: false, IsWritable: true},
+		},
+		DataBytes: data,
+	}
+}
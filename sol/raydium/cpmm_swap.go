@@ -0,0 +1,166 @@
+package raydium
+
+import (
+	"math/big"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/token"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/meme-bots/go-web3/sol/common"
+)
+
+func CpmmBuyInstructions(
+	poolID solana.PublicKey,
+	tokenMint *common.Mint,
+	solAmount, slippage, reserveWsol, reserveToken uint64,
+	pool *CpmmPool,
+	config *CpmmConfig,
+	createAta bool,
+	payer solana.PublicKey,
+) ([]solana.Instruction, error) {
+	var instructions []solana.Instruction
+
+	//create and init tmp wsol token account
+	createAndInitInsts, wsolAta := CreateAndInitWsolTokenAccount(payer, solAmount)
+	instructions = append(instructions, createAndInitInsts...)
+	//create ata
+	mint, _ := pool.Mints()
+	if createAta {
+		instructions = append(instructions, common.NewCreateIdempotentAtaInstruction(payer, payer, mint, tokenMint.Program))
+	}
+	//swap, the pool checks the amount out net of the mint's transfer fee
+	ata := common.FindAssociatedTokenAddress(payer, mint, tokenMint.Program)
+	minAmountOut := tokenMint.AmountAfterFee(config.Quote(solAmount, reserveWsol, reserveToken))
+	minAmountOut -= minAmountOut * slippage / 10000
+
+	swapInst := NewCpmmSwapBaseInputInstruction(CpmmSwapParam{
+		PoolID:        poolID,
+		Pool:          pool,
+		Payer:         payer,
+		AmountIn:      solAmount,
+		AmountOut:     minAmountOut,
+		InputMint:     solana.SolMint,
+		SourceAccount: wsolAta,
+		DestAccount:   ata,
+	})
+	instructions = append(instructions, swapInst)
+	//close wsol token account
+	closeAccountInst := token.NewCloseAccountInstruction(
+		wsolAta,
+		payer,
+		payer,
+		[]solana.PublicKey{payer},
+	).Build()
+	instructions = append(instructions, closeAccountInst)
+
+	return instructions, nil
+}
+
+func CpmmSellInstructions(
+	poolID solana.PublicKey,
+	tokenMint *common.Mint,
+	tokenAmount, slippage, reserveWsol, reserveToken uint64,
+	pool *CpmmPool,
+	config *CpmmConfig,
+	isSellAll bool,
+	payer solana.PublicKey,
+) ([]solana.Instruction, error) {
+	var instructions []solana.Instruction
+
+	//create and init tmp wsol token account
+	createAndInitInsts, wsolAta := CreateAndInitWsolTokenAccount(payer, 0)
+	instructions = append(instructions, createAndInitInsts...)
+
+	//swap, the vault receives what is left after the mint's transfer fee
+	mint, _ := pool.Mints()
+	ata := common.FindAssociatedTokenAddress(payer, mint, tokenMint.Program)
+	minAmountOut := config.Quote(tokenMint.AmountAfterFee(tokenAmount), reserveToken, reserveWsol)
+	minAmountOut -= minAmountOut * slippage / 10000
+
+	swapInst := NewCpmmSwapBaseInputInstruction(CpmmSwapParam{
+		PoolID:        poolID,
+		Pool:          pool,
+		Payer:         payer,
+		AmountIn:      tokenAmount,
+		AmountOut:     minAmountOut,
+		InputMint:     mint,
+		SourceAccount: ata,
+		DestAccount:   wsolAta,
+	})
+	instructions = append(instructions, swapInst)
+
+	//close wsol token account
+	closeAccountInst := token.NewCloseAccountInstruction(
+		wsolAta,
+		payer,
+		payer,
+		[]solana.PublicKey{payer},
+	).Build()
+	instructions = append(instructions, closeAccountInst)
+
+	//close ata
+	if isSellAll {
+		closeAccountInst := common.WithProgram(token.NewCloseAccountInstruction(
+			ata,
+			payer,
+			payer,
+			[]solana.PublicKey{payer},
+		).Build(), tokenMint.Program)
+		instructions = append(instructions, closeAccountInst)
+	}
+
+	return instructions, nil
+}
+
+// ParseCpmmSwapInstruction reads the SOL and token amounts a CPMM swap
+// moved for its signer.
+func ParseCpmmSwapInstruction(tx *rpc.GetTransactionResult, message *solana.Message, instruction solana.CompiledInstruction, instructionIndex uint16) (*big.Int, *big.Int) {
+	return parseTransferCheckedSwap(tx, message, instruction, instructionIndex, 10)
+}
+
+// parseTransferCheckedSwap reads a swap from the two transfer_checked calls
+// it makes, input first. The input mint is the instruction's account at
+// inputMintIndex.
+func parseTransferCheckedSwap(tx *rpc.GetTransactionResult, message *solana.Message, instruction solana.CompiledInstruction, instructionIndex uint16, inputMintIndex int) (*big.Int, *big.Int) {
+	solSwaped := big.NewInt(0)
+	tokenSwaped := big.NewInt(0)
+	if len(instruction.Accounts) <= inputMintIndex {
+		return solSwaped, tokenSwaped
+	}
+	inputMint, err := message.Account(instruction.Accounts[inputMintIndex])
+	if err != nil {
+		return solSwaped, tokenSwaped
+	}
+
+	for _, innerInstruction := range tx.Meta.InnerInstructions {
+		if innerInstruction.Index != instructionIndex {
+			continue
+		}
+		var amounts []uint64
+		for _, inner := range innerInstruction.Instructions {
+			program, err := message.Account(inner.ProgramIDIndex)
+			if err != nil || (!program.Equals(solana.TokenProgramID) && !program.Equals(solana.Token2022ProgramID)) {
+				continue
+			}
+			if len(inner.Data) < 9 || inner.Data[0] != token.Instruction_TransferChecked {
+				continue
+			}
+			var amount uint64
+			_ = bin.NewBorshDecoder(inner.Data[1:9]).Decode(&amount)
+			amounts = append(amounts, amount)
+		}
+		if len(amounts) < 2 {
+			break
+		}
+		if inputMint.Equals(solana.SolMint) {
+			solSwaped = new(big.Int).Neg(new(big.Int).SetUint64(amounts[0]))
+			tokenSwaped = new(big.Int).SetUint64(amounts[1])
+		} else {
+			solSwaped = new(big.Int).SetUint64(amounts[1])
+			tokenSwaped = new(big.Int).Neg(new(big.Int).SetUint64(amounts[0]))
+		}
+		break
+	}
+	return solSwaped, tokenSwaped
+}
@@ -0,0 +1,44 @@
+package raydium
+
+import (
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/meme-bots/go-web3/utils"
+)
+
+func TestDecodeCpmmPool_MintOffsets(t *testing.T) {
+	token0 := solana.NewWallet().PublicKey()
+	token1 := solana.SolMint
+
+	data := make([]byte, CpmmPoolSize)
+	copy(data, CpmmPoolDiscriminator[:])
+	copy(data[cpmmToken0MintOffset:], token0.Bytes())
+	copy(data[cpmmToken1MintOffset:], token1.Bytes())
+
+	pool, err := DecodeCpmmPool(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pool.Token0Mint.Equals(token0) || !pool.Token1Mint.Equals(token1) {
+		t.Errorf("mints = %s, %s", pool.Token0Mint, pool.Token1Mint)
+	}
+	if mint, _ := pool.Mints(); !mint.Equals(token0) {
+		t.Errorf("token mint = %s, want %s", mint, token0)
+	}
+}
+
+func TestCpmmConfig_Quote(t *testing.T) {
+	config := &CpmmConfig{TradeFeeRate: 2500} // 0.25%
+	if bps := config.FeeBps(); bps != 25 {
+		t.Errorf("FeeBps = %d, want 25", bps)
+	}
+	// 1000 in pays 3 in fees, 997 * 1e6 / (997 + 1e6)
+	if out := config.Quote(1000, 1000000, 1000000); out != 996 {
+		t.Errorf("Quote = %d, want 996", out)
+	}
+	// amountIn*TradeFeeRate is past 64 bits
+	if out, want := config.Quote(1e16, 1e18, 1e18), utils.CalculateOutput(1e16-25e12, 1e18, 1e18); out != want {
+		t.Errorf("large Quote = %d, want %d", out, want)
+	}
+}
@@ -10,7 +10,6 @@ import (
 	"github.com/gagliardetto/solana-go/rpc"
 	"github.com/meme-bots/go-web3/sol/common"
 	"github.com/meme-bots/go-web3/types"
-	"github.com/meme-bots/go-web3/utils"
 	"github.com/near/borsh-go"
 	"github.com/samber/lo"
 	"github.com/shopspring/decimal"
@@ -18,6 +17,9 @@ import (
 
 const (
 	InstructionSwap = 9
+
+	// SwapFeeBps is the AMM's trade fee, taken out of the amount in.
+	SwapFeeBps = 25
 )
 
 var (
@@ -159,8 +161,8 @@ func GeRaydiumPoolP2(ctx context.Context, url string, p *types.Pool, owner strin
 
 	if withBalance {
 		owner_ := solana.MPK(owner)
-		ata, _, _ := solana.FindAssociatedTokenAddress(owner_, mint)
-		publicKeys = append(publicKeys, owner_, ata)
+		publicKeys = append(publicKeys, owner_)
+		publicKeys = append(publicKeys, common.TokenAccounts(owner_, mint)...)
 	}
 
 	accounts, err := client.GetMultipleAccountsWithOpts(
@@ -178,13 +180,9 @@ func GeRaydiumPoolP2(ctx context.Context, url string, p *types.Pool, owner strin
 			balance.NativeBalance = new(big.Int).SetUint64(accounts.Value[5].Lamports)
 		}
 
-		if accounts.Value[6] != nil {
-			var tokenAccount token.Account
-			err = tokenAccount.UnmarshalWithDecoder(bin.NewBinDecoder(accounts.Value[6].Data.GetBinary()))
-			if err != nil {
-				return nil, nil, err
-			}
-			balance.TokenBalance = new(big.Int).SetUint64(tokenAccount.Amount)
+		balance.TokenBalance, err = common.TokenBalance(common.TokenAccountOf(accounts.Value[2], accounts.Value[6:]))
+		if err != nil {
+			return nil, nil, err
 		}
 	}
 
@@ -198,17 +196,21 @@ func GeRaydiumPoolP2(ctx context.Context, url string, p *types.Pool, owner strin
 		return nil, balance, err
 	}
 
-	var tokenMintAccount, lpMintAccount token.Mint
-	err = tokenMintAccount.UnmarshalWithDecoder(bin.NewBinDecoder(accounts.Value[2].Data.GetBinary()))
+	tokenMintAccount, err := common.DecodeMint(accounts.Value[2])
 	if err != nil {
 		return nil, balance, err
 	}
+	err = tokenMintAccount.SetEpoch(ctx, client)
+	if err != nil {
+		return nil, balance, err
+	}
+	var lpMintAccount token.Mint
 	err = lpMintAccount.UnmarshalWithDecoder(bin.NewBinDecoder(accounts.Value[3].Data.GetBinary()))
 	if err != nil {
 		return nil, balance, err
 	}
 
-	meta, err := common.MetadataDeserialize(accounts.Value[4].Data.GetBinary())
+	name, symbol, err := common.TokenName(tokenMintAccount, mint, accounts.Value[4])
 	if err != nil {
 		return nil, balance, err
 	}
@@ -234,7 +236,7 @@ func GeRaydiumPoolP2(ctx context.Context, url string, p *types.Pool, owner strin
 
 	totalSupply := decimal.NewFromUint64(tokenMintAccount.Supply).Div(decimal.New(1, int32(tokenMintAccount.Decimals)))
 
-	return &common.GetSolPoolResponse{
+	ret := &common.GetSolPoolResponse{
 		PriceInSol:            priceInSol,
 		TotalSupply:           totalSupply,
 		FreezeDisabled:        tokenMintAccount.FreezeAuthority == nil,
@@ -242,8 +244,8 @@ func GeRaydiumPoolP2(ctx context.Context, url string, p *types.Pool, owner strin
 		MintAuthorityDisabled: tokenMintAccount.MintAuthority == nil,
 		TokenReserve:          tokenReserve,
 		SolReserve:            solReserve,
-		Name:                  utils.TrimSpace(meta.Data.Name),
-		Symbol:                utils.TrimSpace(meta.Data.Symbol),
+		Name:                  name,
+		Symbol:                symbol,
 		Decimals:              tokenMintAccount.Decimals,
 		QuoteDecimals:         9,
 		TokenAddress:          mint.String(),
@@ -251,7 +253,9 @@ func GeRaydiumPoolP2(ctx context.Context, url string, p *types.Pool, owner strin
 		PoolAddress:           p.AmmPublicKey,
 		MarketId:              p.MarketPublicKey,
 		MarketProgramId:       p.MarketProgramID,
-	}, balance, nil
+	}
+	ret.SetMint(tokenMintAccount)
+	return ret, balance, nil
 }
 
 func GeRaydiumPool(ctx context.Context, url string, req *common.GetSolPoolRequest) (*common.GetSolPoolResponse, *common.Balance, error) {
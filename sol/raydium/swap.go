@@ -3,42 +3,26 @@ package raydium
 import (
 	"context"
 	"math/big"
-	"math/rand"
 
 	bin "github.com/gagliardetto/binary"
 	"github.com/gagliardetto/solana-go"
-	computebudget "github.com/gagliardetto/solana-go/programs/compute-budget"
-	"github.com/gagliardetto/solana-go/programs/system"
 	"github.com/gagliardetto/solana-go/programs/token"
 	"github.com/gagliardetto/solana-go/rpc"
 	"github.com/meme-bots/go-web3/sol/common"
 	"github.com/meme-bots/go-web3/utils"
 )
 
-func SendBuy(
-	ctx context.Context,
-	url string,
-	marketID, marketProgramID, botFeeRecipient solana.PublicKey,
-	solAmount, slippage, reserveWsol, reserveToken, gasFee, feeRatio, jitoTip uint64,
+func BuyInstructions(
+	marketID, marketProgramID solana.PublicKey,
+	solAmount, slippage, reserveWsol, reserveToken uint64,
 	market Market,
 	createAta bool,
-	privKey solana.PrivateKey,
-	recentBlockHash solana.Hash,
-) (solana.Signature, error) {
+	payer solana.PublicKey,
+) ([]solana.Instruction, error) {
 	var instructions []solana.Instruction
-	//set gas limit and gas price
-	gasLimit := 140000
-	gasTmp := new(big.Int).Mul(big.NewInt(1000000), new(big.Int).SetUint64(gasFee))
-	gasPrice := new(big.Int).Div(gasTmp, big.NewInt(int64(gasLimit)))
-	setCULimitInst := computebudget.NewSetComputeUnitLimitInstruction(140000).Build()
-	setCUPriceInst := computebudget.NewSetComputeUnitPriceInstruction(gasPrice.Uint64()).Build()
-	instructions = append(instructions, setCULimitInst, setCUPriceInst)
-
-	fee := solAmount * feeRatio / 10000
-	solAmount -= fee
 
 	//create and init tmp wsol token account
-	createAndInitInsts, wsolAta := CreateAndInitWsolTokenAccount(privKey.PublicKey(), solAmount)
+	createAndInitInsts, wsolAta := CreateAndInitWsolTokenAccount(payer, solAmount)
 	instructions = append(instructions, createAndInitInsts...)
 	//create ata
 	var tokenMint solana.PublicKey
@@ -48,18 +32,18 @@ func SendBuy(
 		tokenMint = market.BaseMint
 	}
 	if createAta {
-		createIdempotentInst := CreateIdempotentInstruction(tokenMint, privKey.PublicKey())
+		createIdempotentInst := CreateIdempotentInstruction(tokenMint, payer)
 		instructions = append(instructions, createIdempotentInst)
 	}
 	//swap
-	ata, _, _ := solana.FindAssociatedTokenAddress(privKey.PublicKey(), tokenMint)
+	ata, _, _ := solana.FindAssociatedTokenAddress(payer, tokenMint)
 	vaultSigner, _ := FindVaultSigner(market.VaultSignerNonce, marketID, marketProgramID)
 	minAmountOut := utils.CalculateOutput(solAmount, reserveWsol, reserveToken)
 	minAmountOut -= minAmountOut * slippage / 10000
 
 	swapParam := CreateSwapParam{
 		MarketId:      marketID,
-		Maker:         privKey.PublicKey(),
+		Maker:         payer,
 		AmountIn:      solAmount,
 		MinAmountOut:  minAmountOut,
 		Market:        market,
@@ -72,74 +56,25 @@ func SendBuy(
 	//close wsol token account
 	closeAccountInst := token.NewCloseAccountInstruction(
 		wsolAta,
-		privKey.PublicKey(),
-		privKey.PublicKey(),
-		[]solana.PublicKey{privKey.PublicKey()},
+		payer,
+		payer,
+		[]solana.PublicKey{payer},
 	).Build()
 	instructions = append(instructions, closeAccountInst)
-	//transfer fee
-	if fee != 0 {
-		feeTransferInst := system.NewTransferInstruction(fee, privKey.PublicKey(), botFeeRecipient).Build()
-		instructions = append(instructions, feeTransferInst)
-	}
-	//jito tip
-	if jitoTip != 0 {
-		idx := rand.Intn(len(common.JitoTipPaymentAccounts))
-		jitoTipTransferInst := system.NewTransferInstruction(jitoTip, privKey.PublicKey(), common.JitoTipPaymentAccounts[idx]).Build()
-		instructions = append(instructions, jitoTipTransferInst)
-	}
-	cli := rpc.New(url)
-	if recentBlockHash.IsZero() {
-		recentBlock, err := cli.GetLatestBlockhash(ctx, rpc.CommitmentFinalized)
-		if err != nil {
-			return solana.Signature{}, err
-		}
-		recentBlockHash = recentBlock.Value.Blockhash
-	}
-
-	tx, err := solana.NewTransaction(
-		instructions,
-		recentBlockHash,
-		solana.TransactionPayer(privKey.PublicKey()),
-	)
-	if err != nil {
-		return solana.Signature{}, err
-	}
-	_, err = tx.Sign(func(key solana.PublicKey) *solana.PrivateKey {
-		return &privKey
-	})
-	if err != nil {
-		return solana.Signature{}, err
-	}
-	if jitoTip != 0 {
-		return rpc.New(common.JitoRpc).SendTransaction(ctx, tx)
-	}
-
-	return cli.SendTransactionWithOpts(ctx, tx, rpc.TransactionOpts{SkipPreflight: true})
+	return instructions, nil
 }
 
-func SendSell(
-	ctx context.Context,
-	url string,
-	marketID, marketProgramID, botFeeRecipient solana.PublicKey,
-	tokenAmount, slippage, reserveWsol, reserveToken, gasFee, feeRatio, jitoTip uint64,
+func SellInstructions(
+	marketID, marketProgramID solana.PublicKey,
+	tokenAmount, slippage, reserveWsol, reserveToken uint64,
 	market Market,
 	isSellAll bool,
-	privKey solana.PrivateKey,
-	recentBlockHash solana.Hash,
-) (solana.Signature, error) {
+	payer solana.PublicKey,
+) ([]solana.Instruction, error) {
 	var instructions []solana.Instruction
 
-	//set gas limit and gas price
-	gasLimit := 140000
-	gasTmp := new(big.Int).Mul(big.NewInt(1000000), new(big.Int).SetUint64(gasFee))
-	gasPrice := new(big.Int).Div(gasTmp, big.NewInt(int64(gasLimit)))
-	setCULimitInst := computebudget.NewSetComputeUnitLimitInstruction(uint32(gasLimit)).Build()
-	setCUPriceInst := computebudget.NewSetComputeUnitPriceInstruction(gasPrice.Uint64()).Build()
-	instructions = append(instructions, setCULimitInst, setCUPriceInst)
-
 	//create and init tmp wsol token account
-	createAndInitInsts, wsolAta := CreateAndInitWsolTokenAccount(privKey.PublicKey(), 0)
+	createAndInitInsts, wsolAta := CreateAndInitWsolTokenAccount(payer, 0)
 	instructions = append(instructions, createAndInitInsts...)
 
 	var tokenMint solana.PublicKey
@@ -150,15 +85,14 @@ func SendSell(
 	}
 
 	//swap
-	ata, _, _ := solana.FindAssociatedTokenAddress(privKey.PublicKey(), tokenMint)
+	ata, _, _ := solana.FindAssociatedTokenAddress(payer, tokenMint)
 	vaultSigner, _ := FindVaultSigner(market.VaultSignerNonce, marketID, marketProgramID)
 	minAmountOut := utils.CalculateOutput(tokenAmount, reserveToken, reserveWsol)
-	fee := minAmountOut * feeRatio / 10000
 	minAmountOut -= minAmountOut * slippage / 10000
 
 	swapParam := CreateSwapParam{
 		MarketId:      marketID,
-		Maker:         privKey.PublicKey(),
+		Maker:         payer,
 		AmountIn:      tokenAmount,
 		MinAmountOut:  minAmountOut,
 		Market:        market,
@@ -172,9 +106,9 @@ func SendSell(
 	//close wsol token account
 	closeAccountInst := token.NewCloseAccountInstruction(
 		wsolAta,
-		privKey.PublicKey(),
-		privKey.PublicKey(),
-		[]solana.PublicKey{privKey.PublicKey()},
+		payer,
+		payer,
+		[]solana.PublicKey{payer},
 	).Build()
 	instructions = append(instructions, closeAccountInst)
 
@@ -182,76 +116,71 @@ func SendSell(
 	if isSellAll {
 		closeAccountInst := token.NewCloseAccountInstruction(
 			ata,
-			privKey.PublicKey(),
-			privKey.PublicKey(),
-			[]solana.PublicKey{privKey.PublicKey()},
+			payer,
+			payer,
+			[]solana.PublicKey{payer},
 		).Build()
 		instructions = append(instructions, closeAccountInst)
 	}
 
-	//transfer fee
-	if fee != 0 {
-		feeTransferInst := system.NewTransferInstruction(fee, privKey.PublicKey(), botFeeRecipient).Build()
-		instructions = append(instructions, feeTransferInst)
-	}
-
-	//jito tip
-	if jitoTip != 0 {
-		idx := rand.Intn(len(common.JitoTipPaymentAccounts))
-		jitoTipTransferInst := system.NewTransferInstruction(jitoTip, privKey.PublicKey(), common.JitoTipPaymentAccounts[idx]).Build()
-		instructions = append(instructions, jitoTipTransferInst)
-	}
+	return instructions, nil
+}
 
-	cli := rpc.New(url)
-	if recentBlockHash.IsZero() {
-		recentBlock, err := cli.GetLatestBlockhash(ctx, rpc.CommitmentFinalized)
-		if err != nil {
-			return solana.Signature{}, err
-		}
-		recentBlockHash = recentBlock.Value.Blockhash
-	}
+// Quote is what amountIn swaps for against reserveIn and reserveOut, net of
+// the trade fee the AMM rounds up.
+func Quote(amountIn, reserveIn, reserveOut uint64) uint64 {
+	fee := amountIn/10000*SwapFeeBps + (amountIn%10000*SwapFeeBps+9999)/10000
+	return utils.CalculateOutput(amountIn-fee, reserveIn, reserveOut)
+}
 
-	tx, err := solana.NewTransaction(instructions, recentBlockHash, solana.TransactionPayer(privKey.PublicKey()))
+// SendBuy buys with solAmount, less the bot fee of feeRatio, and sends the
+// fee and the jito tip along.
+//
+// Deprecated: Use sol.Solana.Transact.
+func SendBuy(
+	ctx context.Context,
+	url string,
+	marketID, marketProgramID, botFeeRecipient solana.PublicKey,
+	solAmount, slippage, reserveWsol, reserveToken, gasFee, feeRatio, jitoTip uint64,
+	market Market,
+	createAta bool,
+	privKey solana.PrivateKey,
+	recentBlockHash solana.Hash,
+) (solana.Signature, error) {
+	fee := solAmount * feeRatio / 10000
+	instructions, err := BuyInstructions(marketID, marketProgramID, solAmount-fee, slippage, reserveWsol, reserveToken, market, createAta, privKey.PublicKey())
 	if err != nil {
 		return solana.Signature{}, err
 	}
+	instructions = append(instructions, common.FeeAndTipInstructions(privKey.PublicKey(), botFeeRecipient, fee, jitoTip)...)
+	return common.SendSwap(ctx, url, common.NewJitoClient(common.JitoBlockEngines, ""), nil, instructions, common.FixedPriorityFee(gasFee), jitoTip, privKey, recentBlockHash, nil, nil)
+}
 
-	_, err = tx.Sign(func(key solana.PublicKey) *solana.PrivateKey {
-		return &privKey
-	})
+// SendSell sells tokenAmount and sends along the bot fee of feeRatio on the
+// quoted SOL and the jito tip.
+//
+// Deprecated: Use sol.Solana.Transact.
+func SendSell(
+	ctx context.Context,
+	url string,
+	marketID, marketProgramID, botFeeRecipient solana.PublicKey,
+	tokenAmount, slippage, reserveWsol, reserveToken, gasFee, feeRatio, jitoTip uint64,
+	market Market,
+	isSellAll bool,
+	privKey solana.PrivateKey,
+	recentBlockHash solana.Hash,
+) (solana.Signature, error) {
+	fee := utils.CalculateOutput(tokenAmount, reserveToken, reserveWsol) * feeRatio / 10000
+	instructions, err := SellInstructions(marketID, marketProgramID, tokenAmount, slippage, reserveWsol, reserveToken, market, isSellAll, privKey.PublicKey())
 	if err != nil {
 		return solana.Signature{}, err
 	}
-
-	if jitoTip != 0 {
-		return rpc.New(common.JitoRpc).SendTransaction(ctx, tx)
-	}
-
-	return cli.SendTransactionWithOpts(ctx, tx, rpc.TransactionOpts{SkipPreflight: true})
+	instructions = append(instructions, common.FeeAndTipInstructions(privKey.PublicKey(), botFeeRecipient, fee, jitoTip)...)
+	return common.SendSwap(ctx, url, common.NewJitoClient(common.JitoBlockEngines, ""), nil, instructions, common.FixedPriorityFee(gasFee), jitoTip, privKey, recentBlockHash, nil, nil)
 }
 
 func CreateAndInitWsolTokenAccount(wallet solana.PublicKey, amount uint64) ([]solana.Instruction, solana.PublicKey) {
-	randomKey, _ := solana.NewRandomPrivateKey()
-	seed := randomKey.PublicKey().String()[0:32]
-	wsolTokenAccount, _ := solana.CreateWithSeed(wallet, seed, solana.TokenProgramID)
-	createWithSeedInst := system.NewCreateAccountWithSeedInstruction(
-		wallet,
-		seed,
-		common.RentATA+amount,
-		165,
-		solana.TokenProgramID,
-		wallet,
-		wsolTokenAccount,
-		wallet,
-	).Build()
-
-	initializeAccountInst := token.NewInitializeAccountInstruction(
-		wsolTokenAccount,
-		solana.SolMint,
-		wallet,
-		solana.SysVarRentPubkey,
-	).Build()
-	return []solana.Instruction{createWithSeedInst, initializeAccountInst}, wsolTokenAccount
+	return common.CreateAndInitWsolTokenAccount(wallet, amount)
 }
 
 func ParseSwapInstruction(tx *rpc.GetTransactionResult, instructionIndex, ataIndex uint16) (*big.Int, *big.Int) {
@@ -0,0 +1,206 @@
+package sol
+
+import (
+	"math/big"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/meme-bots/go-web3/sol/common"
+	"github.com/meme-bots/go-web3/types"
+	"github.com/meme-bots/go-web3/utils"
+	"github.com/samber/lo"
+)
+
+// routeSplitSteps is how finely a split order is divided between its two
+// venues, in parts of the amount.
+const routeSplitSteps = 10
+
+type (
+	// routePool is a pool loaded for a routed swap.
+	routePool struct {
+		req   *types.Transact // the swap as it would trade on the pool alone
+		state PoolState
+	}
+
+	// routeLeg is the part of a routed swap one pool trades.
+	routeLeg struct {
+		pool      *routePool
+		amountIn  uint64
+		amountOut uint64
+	}
+)
+
+// transactRouted quotes the swap of req on every pool of the token and
+// sends it to the one paying the most, or, with req.SplitOrder, across the
+// two that do together when both legs fit in one transaction.
+func (s *Solana) transactRouted(req *types.Transact, feeRecipient_ string, feeRatio uint64, privateKey string) (*types.TransactResponse, error) {
+	c := rpc.New(s.cfg.RPC)
+	feeRecipient := solana.MPK(feeRecipient_)
+	pk := solana.MustPrivateKeyFromBase58(privateKey)
+
+	buy := solana.MPK(req.TokenIn).Equals(solana.SolMint)
+	tokenMint := solana.MPK(lo.If(buy, req.TokenOut).Else(req.TokenIn))
+
+	mint, tokenBalance, createAta, err := s.tokenPosition(c, pk.PublicKey(), tokenMint)
+	if err != nil {
+		return nil, err
+	}
+	pools := s.loadRoutePools(c, req, tokenMint, buy)
+	legs, single := bestRoute(pools, mint, req.InAmount.Uint64(), lo.If(buy, feeRatio).Else(0), req.SplitOrder)
+	if len(legs) == 0 {
+		return nil, types.ErrInvalidPool
+	}
+	positionClosed := !buy && tokenBalance == req.InAmount.Uint64()
+	nonce, err := s.durableNonce(req.NonceAccount, pk.PublicKey())
+	if err != nil {
+		return nil, err
+	}
+
+	// the ATA and its closing are done once, the bot fee and the tip are
+	// paid once after every leg
+	routeInstructions := func(legs []routeLeg) ([]solana.Instruction, error) {
+		var instructions []solana.Instruction
+		var fee, amountOut uint64
+		for i, leg := range legs {
+			amount := leg.amountIn
+			if buy {
+				fee += amount * feeRatio / 10000
+				amount -= amount * feeRatio / 10000
+			}
+			amountOut += leg.amountOut
+			legInstructions, err := leg.pool.state.Instructions(&SwapRequest{
+				Payer:     pk.PublicKey(),
+				TokenMint: tokenMint,
+				Token:     mint,
+				Amount:    amount,
+				Slippage:  uint64(req.SlipPage),
+				CreateAta: createAta && i == 0,
+				CloseAta:  positionClosed && i == len(legs)-1,
+			})
+			if err != nil {
+				return nil, err
+			}
+			instructions = append(instructions, legInstructions...)
+		}
+		if !buy {
+			fee = amountOut * feeRatio / 10000
+		}
+		return append(instructions, common.FeeAndTipInstructions(pk.PublicKey(), feeRecipient, fee, req.Tip.Uint64())...), nil
+	}
+	instructions, err := routeInstructions(legs)
+	if err != nil {
+		return nil, err
+	}
+	// a split too large for one transaction goes to the best venue alone
+	if len(legs) > 1 && !common.SwapFits(instructions, pk.PublicKey(), nonce, s.lookupTables()) {
+		legs = single
+		instructions, err = routeInstructions(legs)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	recentBlockHash, _ := s.watcher.GetRecentBlockHash()
+	accounts := lo.FlatMap(legs, func(leg routeLeg, _ int) []solana.PublicKey { return transactAccounts(leg.pool.req, mint.Program) })
+	priorityFee := s.priorityFee(req.PriorityLevel, req.Gas, accounts)
+
+	signature, signedTx, err := s.submitSwap(req, instructions, priorityFee, pk, recentBlockHash, nonce)
+	if err != nil {
+		return nil, swapError(err)
+	}
+	return &types.TransactResponse{
+		TxHash:              signature.String(),
+		SignedTx:            signedTx,
+		InitialTokenBalance: new(big.Int).SetUint64(tokenBalance),
+		PositionClosed:      positionClosed,
+		Venues: lo.Map(legs, func(leg routeLeg, _ int) types.Venue {
+			return types.Venue{
+				DexID:       int(leg.pool.req.Dex),
+				PoolAddress: leg.pool.req.PoolID,
+				InAmount:    new(big.Int).SetUint64(leg.amountIn),
+				QuotedOut:   new(big.Int).SetUint64(leg.amountOut),
+			}
+		}),
+	}, nil
+}
+
+// loadRoutePools loads every pool of tokenMint that can take the swap of
+// req. Pools failing to load are left out.
+func (s *Solana) loadRoutePools(c *rpc.Client, req *types.Transact, tokenMint solana.PublicKey, buy bool) []*routePool {
+	byDex := s.findPools(tokenMint)
+	candidates := lo.FlatMap(dexes, func(dex Dex, _ int) []*types.Pool { return byDex[dex.ID()] })
+
+	loaded := make([]*routePool, len(candidates))
+	sub := utils.Subprocesses{}
+	for i, pool := range candidates {
+		sub.Go(func() {
+			loaded[i], _ = s.loadRoutePool(c, req, pool, buy)
+		})
+	}
+	sub.Wait()
+	return lo.Filter(loaded, func(p *routePool, _ int) bool { return p != nil })
+}
+
+func (s *Solana) loadRoutePool(c *rpc.Client, req *types.Transact, pool *types.Pool, buy bool) (*routePool, error) {
+	dex := dexByID[pool.Dex]
+	info, _, err := dex.GetPool(s.ctx, s.cfg.RPC, pool, "", false)
+	if err != nil {
+		return nil, err
+	}
+
+	leg := *req
+	leg.BestExecution = false
+	leg.SplitOrder = false
+	leg.Dex = uint32(pool.Dex)
+	leg.PoolID = info.PoolAddress
+	leg.MarketId = info.MarketId
+	leg.MarketProgramId = info.MarketProgramId
+	leg.HopPool = info.HopPool
+	leg.TokenReserve = info.TokenReserve
+	leg.QuoteReserve = info.SolReserve
+	state, err := dex.LoadPool(s.ctx, c, &leg, buy)
+	if err != nil {
+		return nil, err
+	}
+	return &routePool{req: &leg, state: state}, nil
+}
+
+// bestRoute picks the legs swapping amount on pools for the most, net of the
+// pools' fees, price impact and the bot fee of buyFeeRatio taken before a
+// buy, and the best single leg. Without split both are the single leg. They
+// are empty if no pool quotes.
+func bestRoute(pools []*routePool, token *common.Mint, amount, buyFeeRatio uint64, split bool) ([]routeLeg, []routeLeg) {
+	quote := func(pool *routePool, amountIn uint64) (routeLeg, bool) {
+		amountOut, err := pool.state.Quote(token, amountIn-amountIn*buyFeeRatio/10000)
+		return routeLeg{pool: pool, amountIn: amountIn, amountOut: amountOut}, err == nil
+	}
+
+	var best []routeLeg
+	var bestOut uint64
+	for _, pool := range pools {
+		if leg, ok := quote(pool, amount); ok && leg.amountOut > bestOut {
+			best, bestOut = []routeLeg{leg}, leg.amountOut
+		}
+	}
+	single := best
+	if !split {
+		return best, single
+	}
+
+	for i, a := range pools {
+		for _, b := range pools[i+1:] {
+			for step := uint64(1); step < routeSplitSteps; step++ {
+				amountA := amount / routeSplitSteps * step
+				if amountA == 0 {
+					continue
+				}
+				legA, okA := quote(a, amountA)
+				legB, okB := quote(b, amount-amountA)
+				if okA && okB && legA.amountOut+legB.amountOut > bestOut {
+					best, bestOut = []routeLeg{legA, legB}, legA.amountOut+legB.amountOut
+				}
+			}
+		}
+	}
+	return best, single
+}
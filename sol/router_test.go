@@ -0,0 +1,82 @@
+package sol
+
+import (
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/meme-bots/go-web3/sol/common"
+	"github.com/meme-bots/go-web3/types"
+	"github.com/meme-bots/go-web3/utils"
+)
+
+// reservePool quotes like a constant product pool without fees.
+type reservePool struct {
+	reserveIn, reserveOut uint64
+}
+
+func (p *reservePool) Quote(_ *common.Mint, amountIn uint64) (uint64, error) {
+	return utils.CalculateOutput(amountIn, p.reserveIn, p.reserveOut), nil
+}
+
+func (p *reservePool) Instructions(*SwapRequest) ([]solana.Instruction, error) {
+	return nil, nil
+}
+
+func TestBestRoute(t *testing.T) {
+	shallow := &routePool{req: &types.Transact{Dex: types.DexPumpSwap}, state: &reservePool{1000, 1000}}
+	deep := &routePool{req: &types.Transact{Dex: types.DexRaydium}, state: &reservePool{10000, 10000}}
+	deepToo := &routePool{req: &types.Transact{Dex: types.DexMeteoraDamm}, state: &reservePool{10000, 10000}}
+
+	legs, _ := bestRoute([]*routePool{shallow, deep}, nil, 1000, 0, false)
+	if len(legs) != 1 || legs[0].pool != deep || legs[0].amountIn != 1000 {
+		t.Fatalf("single venue: %+v", legs)
+	}
+
+	// two pools of equal depth take half of a large order each
+	legs, single := bestRoute([]*routePool{deep, deepToo}, nil, 10000, 0, true)
+	if len(legs) != 2 || legs[0].amountIn != 5000 || legs[1].amountIn != 5000 {
+		t.Fatalf("split: %+v", legs)
+	}
+	if len(single) != 1 || single[0].pool != deep || single[0].amountIn != 10000 {
+		t.Fatalf("split, single venue: %+v", single)
+	}
+	if legs[0].amountOut+legs[1].amountOut <= single[0].amountOut {
+		t.Errorf("split pays %d, one venue %d", legs[0].amountOut+legs[1].amountOut, single[0].amountOut)
+	}
+
+	// the bot fee of a buy is not swapped
+	legs, _ = bestRoute([]*routePool{deep}, nil, 1000, 100, false)
+	if want := utils.CalculateOutput(990, 10000, 10000); legs[0].amountOut != want {
+		t.Errorf("buy with fee: out %d, want %d", legs[0].amountOut, want)
+	}
+
+	if legs, single = bestRoute(nil, nil, 1000, 0, true); len(legs) != 0 || len(single) != 0 {
+		t.Errorf("no pools: %+v", legs)
+	}
+}
+
+func TestBestRoute_PoolFees(t *testing.T) {
+	// the Raydium pool prices the token 0.2% better but takes 0.25% of what
+	// goes in
+	feePool := &routePool{req: &types.Transact{Dex: types.DexRaydium}, state: &raydiumPool{reserveWsol: 1000000000, reserveToken: 1003000000, buy: true}}
+	noFee := &routePool{req: &types.Transact{Dex: types.DexMeteoraDamm}, state: &reservePool{1000000000, 1001000000}}
+
+	legs, _ := bestRoute([]*routePool{feePool, noFee}, nil, 1000000, 0, false)
+	if len(legs) != 1 || legs[0].pool != noFee {
+		t.Fatalf("single venue: %+v", legs)
+	}
+	if out, _ := feePool.state.Quote(nil, 1000000); out != utils.CalculateOutput(997500, 1000000000, 1003000000) {
+		t.Errorf("raydium quote %d", out)
+	}
+
+	// a split weighs each leg net of its own pool's fee
+	legs, single := bestRoute([]*routePool{feePool, noFee}, nil, 100000000, 0, true)
+	if len(legs) != 2 || legs[0].amountOut+legs[1].amountOut <= single[0].amountOut {
+		t.Fatalf("split: %+v, single venue %+v", legs, single)
+	}
+	for _, leg := range legs {
+		if out, _ := leg.pool.state.Quote(nil, leg.amountIn); out != leg.amountOut {
+			t.Errorf("%d in on dex %d: out %d, quoted %d", leg.amountIn, leg.pool.req.Dex, leg.amountOut, out)
+		}
+	}
+}
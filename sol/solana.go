@@ -3,10 +3,12 @@ package sol
 import (
 	"context"
 	"errors"
+	"fmt"
 	"math/big"
 	"regexp"
-	"slices"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/eko/gocache/lib/v4/cache"
 	bin "github.com/gagliardetto/binary"
@@ -17,7 +19,6 @@ import (
 	"github.com/gagliardetto/solana-go/rpc/ws"
 	"github.com/meme-bots/go-web3/sol/common"
 	"github.com/meme-bots/go-web3/sol/pumpfun"
-	"github.com/meme-bots/go-web3/sol/raydium"
 	"github.com/meme-bots/go-web3/types"
 	"github.com/meme-bots/go-web3/utils"
 	"github.com/near/borsh-go"
@@ -26,11 +27,19 @@ import (
 )
 
 type Solana struct {
-	ctx     context.Context
-	cfg     *types.Config
-	watcher *Watcher
-	client  *ws.Client
-	cache   *cache.Cache[[]byte]
+	ctx      context.Context
+	cfg      *types.Config
+	watcher  *Watcher
+	client   *ws.Client
+	cache    *cache.Cache[[]byte]
+	jito     *common.JitoClient
+	outbox   *common.Outbox
+	stop     context.CancelFunc
+	tablesMu sync.Mutex
+	tables   map[solana.PublicKey]solana.PublicKeySlice
+
+	migrationsMu sync.Mutex
+	migrations   map[solana.PublicKey]*pumpfun.Migration
 }
 
 func NewSolana(
@@ -52,20 +61,36 @@ func NewSolana(
 		return nil, err
 	}
 
+	engines := lo.Ternary(len(cfg.JitoEngines) > 0, cfg.JitoEngines, common.JitoBlockEngines)
+
 	return &Solana{
 		ctx:     ctx,
 		cfg:     cfg,
 		watcher: watcher,
 		client:  client,
 		cache:   cache,
+		jito:    common.NewJitoClient(engines, cfg.JitoAuth),
+		outbox:  common.NewOutbox(rpc.New(cfg.RPC)),
 	}, nil
 }
 
+// Start starts the watcher and the rebroadcasting of sent transactions.
 func (s *Solana) Start() error {
-	return s.watcher.Start()
+	err := s.watcher.Start()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(s.ctx)
+	s.stop = cancel
+	go s.outbox.Run(ctx)
+	return nil
 }
 
 func (s *Solana) Close() error {
+	if s.stop != nil {
+		s.stop()
+	}
 	return s.watcher.Close()
 }
 
@@ -89,6 +114,10 @@ func (s *Solana) GetNativeTokenPrice() decimal.Decimal {
 	return s.watcher.GetSolPrice()
 }
 
+func (s *Solana) GetNativeTokenPriceInfo() *types.NativeTokenPrice {
+	return s.watcher.GetSolPriceInfo()
+}
+
 func (s *Solana) GetMaxMultiSendCount() int {
 	return MaxRecipientCount
 }
@@ -123,28 +152,24 @@ func (s *Solana) GetTokenBalance(req *types.GetTokenBalanceRequest) (*big.Int, e
 }
 
 func (s *Solana) QueryPool(req *types.QueryPoolRequest) (*types.Pool, error) {
-	var p1, p2, p3 *types.Pool
-	sub := utils.Subprocesses{}
 	mint := solana.MPK(req.Token)
+	pools := s.findPools(mint)
 
-	sub.Go(func() {
-		p1, _ = raydium.GetRaydiumPoolByToken(context.Background(), s.cfg.RPC, mint, true)
-	})
-	sub.Go(func() {
-		p2, _ = raydium.GetRaydiumPoolByToken(context.Background(), s.cfg.RPC, mint, false)
-	})
-	sub.Go(func() {
-		p3, _ = pumpfun.GetPumpFunPoolByToken(context.Background(), s.cfg.RPC, mint)
-	})
-
-	sub.Wait()
-
-	p := lo.If(p1 != nil, p1).ElseIf(p2 != nil, p2).ElseIf(p3 != nil, p3).Else(nil)
-	if p == nil {
-		return nil, types.ErrInvalidPool
+	curve := pools[types.DexPumpFun]
+	if len(curve) > 0 && curve[0].Status != 0 {
+		return s.migratedPool(mint, lo.FlatMap(migrationDexes, func(dex int, _ int) []*types.Pool { return pools[dex] })...)
 	}
 
-	return p, nil
+	// a curve that has yet to complete is only traded on when nothing else is
+	for _, dex := range dexes {
+		if dex.ID() != types.DexPumpFun && len(pools[dex.ID()]) > 0 {
+			return pools[dex.ID()][0], nil
+		}
+	}
+	if len(curve) > 0 {
+		return curve[0], nil
+	}
+	return nil, types.ErrInvalidPool
 }
 
 func (s *Solana) GetPool(req *types.GetPoolRequest, pool *types.Pool) (*types.GetPoolResponse, error) {
@@ -174,24 +199,41 @@ func (s *Solana) GetPool(req *types.GetPoolRequest, pool *types.Pool) (*types.Ge
 		}
 	}
 
-	if pool.Dex == 0 {
-		dexID = 0
-	} else if pool.Dex == 1 && pool.Status == 0 {
-		dexID = 1
-	} else {
+	if pool.Dex == types.DexPumpFun && pool.Status != 0 {
+		pool, err = s.QueryPool(&types.QueryPoolRequest{Token: pool.BaseMint})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	dex, ok := dexByID[pool.Dex]
+	if !ok || pool.Dex == types.DexPumpFun && pool.Status != 0 {
 		return nil, types.ErrInvalidPool
 	}
+	dexID = pool.Dex
 
 	withBalance := len(req.Owner) > 0
-	if dexID == 1 {
-		ret, balance, err = pumpfun.GetPumpFunPool(s.ctx, s.cfg.RPC, &common.GetSolPoolRequest{Token: token, Owner: req.Owner, WithBalance: withBalance})
-	} else {
-		ret, balance, err = raydium.GeRaydiumPoolP2(s.ctx, s.cfg.RPC, pool, req.Owner, withBalance)
+	ret, balance, err = dex.GetPool(s.ctx, s.cfg.RPC, pool, req.Owner, withBalance)
+	if dexID == types.DexPumpFun && errors.Is(err, types.ErrPoolCompleted) {
+		// the curve completed since pool was looked up
+		pool, err = s.QueryPool(&types.QueryPoolRequest{Token: token})
+		if err != nil {
+			return nil, err
+		}
+		if pool.Dex != types.DexPumpFun {
+			return s.GetPool(req, pool)
+		}
+		return nil, types.ErrPoolCompleted
 	}
 	if err != nil {
 		return nil, err
 	}
 
+	var migratedAt time.Time
+	if pool.Migrated {
+		migratedAt = time.Unix(pool.MigratedAt, 0)
+	}
+
 	nativeTokenPrice := s.GetNativeTokenPrice()
 	priceInUSD := ret.PriceInSol.Mul(nativeTokenPrice)
 	return &types.GetPoolResponse{
@@ -204,7 +246,7 @@ func (s *Solana) GetPool(req *types.GetPoolRequest, pool *types.Pool) (*types.Ge
 		Burnt:                 ret.Burnt,
 		MintAuthorityDisabled: ret.MintAuthorityDisabled,
 		TokenReserve:          ret.TokenReserve,
-		QuoteReserve:          ret.SolReserve,
+		QuoteReserve:          lo.Ternary(ret.QuoteReserve != nil, ret.QuoteReserve, ret.SolReserve),
 		Name:                  ret.Name,
 		Symbol:                ret.Symbol,
 		Decimals:              ret.Decimals,
@@ -215,42 +257,60 @@ func (s *Solana) GetPool(req *types.GetPoolRequest, pool *types.Pool) (*types.Ge
 		DexID:                 dexID,
 		MarketId:              ret.MarketId,
 		MarketProgramId:       ret.MarketProgramId,
+		HopPool:               ret.HopPool,
+		PoolFeeBps:            ret.PoolFeeBps,
+		TokenProgram:          ret.TokenProgram,
+		TransferFeeBps:        ret.TransferFeeBps,
+		TransferHook:          ret.TransferHook,
+		PermanentDelegate:     ret.PermanentDelegate,
+		DefaultFrozen:         ret.DefaultFrozen,
+		NonTransferable:       ret.NonTransferable,
+		Migrated:              pool.Migrated,
+		MigratedAt:            migratedAt,
 		NativeBalance:         balance.NativeBalance,
 		TokenBalance:          balance.TokenBalance,
 	}, nil
 }
 
+// WatchTransaction waits for the transaction to be processed. Transactions
+// sent from here are reported expired once their blockhash is; they are
+// rebroadcast from Start on whether watched or not. The result is a *common.Confirmation.
 func (s *Solana) WatchTransaction(req *types.WatchTransactionRequest) (interface{}, error) {
 	sig, err := solana.SignatureFromBase58(req.TxHash)
 	if err != nil {
 		return nil, err
 	}
 
-	sub, err := s.client.SignatureSubscribe(sig, rpc.CommitmentProcessed)
-	for err != nil {
-		s.WsReconnect()
-		sub, err = s.client.SignatureSubscribe(sig, rpc.CommitmentProcessed)
-	}
-	defer sub.Unsubscribe()
+	ctx, cancel := context.WithTimeout(s.ctx, req.Duration)
+	defer cancel()
 
-	result, err := sub.RecvWithTimeout(req.Duration)
+	confirmation, err := s.outbox.Confirm(ctx, sig, rpc.CommitmentProcessed)
 	if err != nil {
 		return nil, err
 	}
 
-	if result.Value.Err != nil {
-		errMap := result.Value.Err.(map[string]interface{})
-		if _, ok := errMap["InstructionError"]; ok {
-			return nil, types.ErrInstructionFailed
+	switch confirmation.Status {
+	case common.TxFailed:
+		if errMap, ok := confirmation.Err.(map[string]interface{}); ok {
+			if _, ok := errMap["InstructionError"]; ok {
+				return confirmation, types.ErrInstructionFailed
+			}
 		}
-		return nil, types.ErrTransactionFailed
+		return confirmation, types.ErrTransactionFailed
+	case common.TxExpired:
+		return confirmation, fmt.Errorf("%w: blockhash expired", types.ErrTxNotLand)
+	case common.TxPending:
+		return confirmation, types.ErrTxNotLand
 	}
-	return nil, nil
+	return confirmation, nil
 }
 
 func (s *Solana) GetTransaction(req *types.GetTransactionRequest) (*types.GetTransactionResponse, error) {
 	c := rpc.New(s.cfg.RPC)
-	tx, err := c.GetTransaction(s.ctx, solana.MustSignatureFromBase58(req.TxHash), &rpc.GetTransactionOpts{Commitment: rpc.CommitmentConfirmed})
+	tx, err := c.GetTransaction(s.ctx, solana.MustSignatureFromBase58(req.TxHash), &rpc.GetTransactionOpts{
+		Commitment:                     rpc.CommitmentConfirmed,
+		MaxSupportedTransactionVersion: &rpc.MaxSupportedTransactionVersion0,
+	})
 	if err != nil {
 		if errors.Is(err, rpc.ErrNotFound) {
 			return nil, types.ErrTxNotLand
@@ -260,7 +320,7 @@ func (s *Solana) GetTransaction(req *types.GetTransactionRequest) (*types.GetTra
 
 	if tx.Meta.Err != nil {
 		for _, logMessage := range tx.Meta.LogMessages {
-			if strings.Contains(logMessage, "TooMuchSolRequired") || strings.Contains(logMessage, "exceeds desired slippage limit") {
+			if isSlippageError(logMessage) {
 				return nil, types.ErrSlippage
 			}
 		}
@@ -271,7 +331,12 @@ func (s *Solana) GetTransaction(req *types.GetTransactionRequest) (*types.GetTra
 	if err != nil {
 		return nil, err
 	}
+	err = common.ResolveLoadedAddresses(transaction, tx.Meta.LoadedAddresses)
+	if err != nil {
+		return nil, err
+	}
 
+	// a routed trade swaps in one instruction per leg
 	solSwapped := new(big.Int)
 	tokenSwapped := new(big.Int)
 
@@ -279,15 +344,10 @@ func (s *Solana) GetTransaction(req *types.GetTransactionRequest) (*types.GetTra
 	for i, instruction := range transaction.Message.Instructions {
 		programID, _ := transaction.Message.Account(instruction.ProgramIDIndex)
 
-		if programID.Equals(raydium.ProgramID) && instruction.Data[0] == uint8(raydium.InstructionSwap) {
-			ata, _, _ := solana.FindAssociatedTokenAddress(solana.MPK(req.Owner), solana.MPK(req.Token))
-			ataIndex, _ := transaction.GetAccountIndex(ata)
-			solSwapped, tokenSwapped = raydium.ParseSwapInstruction(tx, uint16(i), ataIndex)
-		} else if programID.Equals(pumpfun.ProgramID) {
-			if slices.Equal(instruction.Data[:8], pumpfun.Instruction_Buy[:]) {
-				solSwapped, tokenSwapped = pumpfun.ParseBuyInstruction(tx, uint16(i))
-			} else if slices.Equal(instruction.Data[:8], pumpfun.Instruction_Sell[:]) {
-				solSwapped, tokenSwapped = pumpfun.ParseSellInstruction(tx, uint16(i))
+		if dex, ok := dexByProgram[programID]; ok {
+			if sol, token, ok := dex.ParseSwap(tx, transaction, instruction, uint16(i), solana.MPK(req.Owner), solana.MPK(req.Token)); ok {
+				solSwapped.Add(solSwapped, sol)
+				tokenSwapped.Add(tokenSwapped, token)
 			}
 		}
 
@@ -361,10 +421,13 @@ func (s *Solana) Withdraw(to string, amount decimal.Decimal, privateKey string)
 	signature, err := SendTransfer(
 		s.ctx,
 		s.cfg.RPC,
+		s.outbox,
 		recipient,
 		amount.Mul(decimal.New(1, 9)).BigInt().Uint64(),
+		s.priorityFee(s.cfg.PriorityLevel, nil, []solana.PublicKey{pk.PublicKey(), recipient}),
 		pk,
 		recentBlockHash,
+		nil,
 	)
 	if err != nil {
 		return "", err
@@ -396,6 +459,7 @@ func (s *Solana) Launch(req *types.LaunchRequest, feeRecipient_ string, feeRatio
 	}
 
 	recentBlockHash, _ := s.watcher.GetRecentBlockHash()
+	priorityFee := s.priorityFee(req.PriorityLevel, req.Gas, []solana.PublicKey{pumpfun.GlobalFeeRecipient})
 
 	signature, token, err := pumpfun.CreateAndBuy(
 		s.ctx,
@@ -403,15 +467,18 @@ func (s *Solana) Launch(req *types.LaunchRequest, feeRecipient_ string, feeRatio
 		req.Name,
 		req.Symbol,
 		req.Uri,
+		s.jito,
+		s.outbox,
 		feeRecipient,
 		req.BuyAmountSol.Uint64(),
 		req.SlipPage,
-		req.Gas.Uint64(),
+		priorityFee,
 		feeRatio,
 		req.Tip.Uint64(),
 		&global,
 		pk,
 		recentBlockHash,
+		s.lookupTables(),
 	)
 	if err != nil {
 		return nil, err
@@ -423,6 +490,10 @@ func (s *Solana) Launch(req *types.LaunchRequest, feeRecipient_ string, feeRatio
 }
 
 func (s *Solana) Transact(req *types.Transact, feeRecipient_ string, feeRatio uint64, privateKey string) (*types.TransactResponse, error) {
+	if req.BestExecution {
+		return s.transactRouted(req, feeRecipient_, feeRatio, privateKey)
+	}
+
 	c := rpc.New(s.cfg.RPC)
 	feeRecipient := solana.MPK(feeRecipient_)
 	pk := solana.MustPrivateKeyFromBase58(privateKey)
@@ -439,153 +510,133 @@ func (s *Solana) Transact(req *types.Transact, feeRecipient_ string, feeRatio ui
 
 	var tokenBalance uint64 = 0
 	var positionClosed bool = false
-	var accounts *rpc.GetMultipleAccountsResult
 
 	recentBlockHash, _ := s.watcher.GetRecentBlockHash()
+	nonce, err := s.durableNonce(req.NonceAccount, pk.PublicKey())
+	if err != nil {
+		return nil, err
+	}
 
-	ata, _, _ := solana.FindAssociatedTokenAddress(pk.PublicKey(), tokenMint)
-	if req.Dex == 0 { // raydium
-		accounts, err = c.GetMultipleAccountsWithOpts(
-			s.ctx,
-			[]solana.PublicKey{ata, solana.MPK(req.MarketId)},
-			&rpc.GetMultipleAccountsOpts{Commitment: rpc.CommitmentConfirmed},
-		)
-		if err != nil {
-			return nil, err
-		}
-		var market raydium.Market
-		err = borsh.Deserialize(&market, accounts.Value[1].Data.GetBinary())
-		if err != nil {
-			return nil, err
-		}
-		createAta := accounts.Value[0] == nil
-		if !createAta {
-			var tokenAccount token.Account
-			err = borsh.Deserialize(&tokenAccount, accounts.Value[0].Data.GetBinary())
-			if err != nil {
-				return nil, err
-			}
-			tokenBalance = tokenAccount.Amount
-		}
-
-		if buy {
-			signature, err = raydium.SendBuy(
-				s.ctx,
-				s.cfg.RPC,
-				solana.MPK(req.MarketId),
-				solana.MPK(req.MarketProgramId),
-				feeRecipient,
-				req.InAmount.Uint64(),
-				uint64(req.SlipPage),
-				req.QuoteReserve.Uint64(),
-				req.TokenReserve.Uint64(),
-				req.Gas.Uint64(),
-				feeRatio,
-				req.Tip.Uint64(),
-				market,
-				createAta,
-				pk,
-				recentBlockHash,
-			)
-		} else {
-			positionClosed = tokenBalance == req.InAmount.Uint64()
-			signature, err = raydium.SendSell(
-				s.ctx,
-				s.cfg.RPC,
-				solana.MPK(req.MarketId),
-				solana.MPK(req.MarketProgramId),
-				feeRecipient,
-				req.InAmount.Uint64(),
-				uint64(req.SlipPage),
-				req.QuoteReserve.Uint64(),
-				req.TokenReserve.Uint64(),
-				req.Gas.Uint64(),
-				feeRatio,
-				req.Tip.Uint64(),
-				market,
-				positionClosed,
-				pk,
-				recentBlockHash,
-			)
-		}
-	} else { // pumpfun
-		bondingCurvePubKey := pumpfun.FindBondingCurve(tokenMint)
-		accounts, err = c.GetMultipleAccountsWithOpts(
-			s.ctx,
-			[]solana.PublicKey{ata, bondingCurvePubKey},
-			&rpc.GetMultipleAccountsOpts{Commitment: rpc.CommitmentConfirmed},
-		)
-		if err != nil {
-			return nil, err
-		}
-		var bondingCurve pumpfun.BondingCurve
-		err = borsh.Deserialize(&bondingCurve, accounts.Value[1].Data.GetBinary())
-		if err != nil {
-			return nil, err
-		}
-		createAta := accounts.Value[0] == nil
-
-		if !createAta {
-			var tokenAccount token.Account
-			err = borsh.Deserialize(&tokenAccount, accounts.Value[0].Data.GetBinary())
-			if err != nil {
-				return nil, err
-			}
-			tokenBalance = tokenAccount.Amount
-		}
-
-		if buy {
-			signature, err = pumpfun.SendBuy(
-				s.ctx,
-				s.cfg.RPC,
-				tokenMint,
-				feeRecipient,
-				req.InAmount.Uint64(),
-				uint64(req.SlipPage),
-				req.Gas.Uint64(),
-				feeRatio,
-				req.Tip.Uint64(),
-				bondingCurve,
-				createAta,
-				pk,
-				recentBlockHash,
-			)
-		} else {
-			positionClosed = tokenBalance == req.InAmount.Uint64()
-			signature, err = pumpfun.SendSell(
-				s.ctx,
-				s.cfg.RPC,
-				tokenMint,
-				feeRecipient,
-				req.InAmount.Uint64(),
-				uint64(req.SlipPage),
-				req.Gas.Uint64(),
-				feeRatio,
-				req.Tip.Uint64(),
-				bondingCurve,
-				positionClosed,
-				pk,
-				recentBlockHash,
-			)
-		}
+	dex, ok := dexByID[int(req.Dex)]
+	if !ok {
+		return nil, types.ErrInvalidPool
+	}
+	var pool PoolState
+	pool, err = dex.LoadPool(s.ctx, c, req, buy)
+	if errors.Is(err, types.ErrPoolCompleted) {
+		return s.transactMigrated(req, feeRecipient_, feeRatio, privateKey)
 	}
 	if err != nil {
-		errStr := err.Error()
-		if strings.Contains(errStr, "AccountNotInitialized") {
-			return nil, types.ErrAccountNotInitialized
-		} else if strings.Contains(errStr, "TooMuchSolRequired") || strings.Contains(errStr, "exceeds desired slippage limit") {
-			return nil, types.ErrSlippage
-		}
+		return nil, err
+	}
+	var mint *common.Mint
+	var createAta bool
+	mint, tokenBalance, createAta, err = s.tokenPosition(c, pk.PublicKey(), tokenMint)
+	if err != nil {
+		return nil, err
+	}
+	positionClosed = !buy && tokenBalance == req.InAmount.Uint64()
+	priorityFee := s.priorityFee(req.PriorityLevel, req.Gas, transactAccounts(req, mint.Program))
 
+	amount, fee, err := botFee(pool, mint, req.InAmount.Uint64(), feeRatio, buy)
+	if err != nil {
 		return nil, err
 	}
+	var instructions []solana.Instruction
+	instructions, err = pool.Instructions(&SwapRequest{
+		Payer:     pk.PublicKey(),
+		TokenMint: tokenMint,
+		Token:     mint,
+		Amount:    amount,
+		Slippage:  uint64(req.SlipPage),
+		CreateAta: createAta,
+		CloseAta:  positionClosed,
+	})
+	var signedTx string
+	if err == nil {
+		instructions = append(instructions, common.FeeAndTipInstructions(pk.PublicKey(), feeRecipient, fee, req.Tip.Uint64())...)
+		signature, signedTx, err = s.submitSwap(req, instructions, priorityFee, pk, recentBlockHash, nonce)
+	}
+	if err != nil {
+		return nil, swapError(err)
+	}
 	return &types.TransactResponse{
 		TxHash:              signature.String(),
+		SignedTx:            signedTx,
 		InitialTokenBalance: new(big.Int).SetUint64(tokenBalance),
 		PositionClosed:      positionClosed,
 	}, nil
 }
 
+// botFee takes the bot fee of feeRatio on a swap of amount on pool. A buy
+// pays it out of the SOL it swaps with, so the amount left to swap is
+// returned with it; a sell pays it on the SOL it is quoted to receive.
+func botFee(pool PoolState, token *common.Mint, amount, feeRatio uint64, buy bool) (uint64, uint64, error) {
+	if buy {
+		fee := amount * feeRatio / 10000
+		return amount - fee, fee, nil
+	}
+	amountOut, err := pool.Quote(token, amount)
+	if err != nil {
+		return 0, 0, err
+	}
+	return amount, amountOut * feeRatio / 10000, nil
+}
+
+// slippageErrors are how the venues report a swap that would have paid
+// more or received less than the limit.
+var slippageErrors = []string{
+	"TooMuchSolRequired",
+	"exceeds desired slippage limit",
+	"ExceededSlippage",
+	"ExceededAmountSlippageTolerance",
+	"AmountOutBelowMinimum",
+}
+
+func isSlippageError(message string) bool {
+	return lo.SomeBy(slippageErrors, func(e string) bool { return strings.Contains(message, e) })
+}
+
+// swapError maps the failures of sending a swap the caller acts on to their
+// types errors.
+func swapError(err error) error {
+	errStr := err.Error()
+	if strings.Contains(errStr, "AccountNotInitialized") {
+		return types.ErrAccountNotInitialized
+	} else if isSlippageError(errStr) {
+		return types.ErrSlippage
+	}
+	return err
+}
+
+// tokenPosition loads mint and the owner's balance of it, and whether its
+// ATA still has to be created.
+func (s *Solana) tokenPosition(c *rpc.Client, owner, tokenMint solana.PublicKey) (*common.Mint, uint64, bool, error) {
+	accounts, err := c.GetMultipleAccountsWithOpts(
+		s.ctx,
+		append([]solana.PublicKey{tokenMint}, common.TokenAccounts(owner, tokenMint)...),
+		&rpc.GetMultipleAccountsOpts{Commitment: rpc.CommitmentConfirmed},
+	)
+	if err != nil {
+		return nil, 0, false, err
+	}
+	mint, err := common.DecodeMint(accounts.Value[0])
+	if err != nil {
+		return nil, 0, false, err
+	}
+	err = mint.SetEpoch(s.ctx, c)
+	if err != nil {
+		return nil, 0, false, err
+	}
+
+	ata := common.TokenAccountOf(accounts.Value[0], accounts.Value[1:])
+	balance, err := common.TokenBalance(ata)
+	if err != nil {
+		return nil, 0, false, err
+	}
+	return mint, balance.Uint64(), ata == nil, nil
+}
+
 func (s *Solana) WsReconnect() {
 	conn, err := ws.Connect(context.Background(), s.cfg.WSRPC)
 	for err != nil {
@@ -598,15 +649,41 @@ func (s *Solana) GetBaseGas() *big.Int {
 	return new(big.Int).SetUint64(5000)
 }
 
+// EstimateTransactFee is the signature fee plus the priority fee and tip the
+// request pays.
+func (s *Solana) EstimateTransactFee(req *types.Transact) (*big.Int, error) {
+	tokenMint := solana.MPK(lo.If(solana.MPK(req.TokenIn).Equals(solana.SolMint), req.TokenOut).Else(req.TokenIn))
+	mint, err := common.GetMint(s.ctx, rpc.New(s.cfg.RPC), tokenMint)
+	if err != nil {
+		return nil, err
+	}
+	fee := s.GetBaseGas()
+	fee.Add(fee, new(big.Int).SetUint64(s.priorityFee(req.PriorityLevel, req.Gas, transactAccounts(req, mint.Program)).Fee(common.SwapComputeUnits)))
+	if req.Tip != nil {
+		fee.Add(fee, req.Tip)
+	}
+	return fee, nil
+}
+
 func (s *Solana) SendNative(bill *types.TransferBill, privateKey string) (string, error) {
+	recipient := solana.MustPublicKeyFromBase58(bill.Recipient)
+	pk := solana.MustPrivateKeyFromBase58(privateKey)
+	nonce, err := s.durableNonce(bill.NonceAccount, pk.PublicKey())
+	if err != nil {
+		return "", err
+	}
+
 	recentBlockHash, _ := s.watcher.GetRecentBlockHash()
 	signature, err := SendTransfer(
 		s.ctx,
 		s.cfg.RPC,
-		solana.MustPublicKeyFromBase58(bill.Recipient),
+		s.outbox,
+		recipient,
 		bill.Amount.Uint64(),
-		solana.MustPrivateKeyFromBase58(privateKey),
+		s.priorityFee(s.cfg.PriorityLevel, nil, []solana.PublicKey{pk.PublicKey(), recipient}),
+		pk,
 		recentBlockHash,
+		nonce,
 	)
 	if err != nil {
 		return "", err
@@ -615,12 +692,49 @@ func (s *Solana) SendNative(bill *types.TransferBill, privateKey string) (string
 }
 
 func (s *Solana) SendNativeBatch(bills []*types.TransferBill, privateKey string) (string, error) {
+	pk := solana.MustPrivateKeyFromBase58(privateKey)
+	accounts := []solana.PublicKey{pk.PublicKey()}
+	for _, bill := range bills {
+		accounts = append(accounts, solana.MustPublicKeyFromBase58(bill.Recipient))
+	}
+
 	recentBlockHash, _ := s.watcher.GetRecentBlockHash()
 	signature, err := SendTransferBatch(
 		s.ctx,
 		s.cfg.RPC,
-		solana.MustPrivateKeyFromBase58(privateKey),
+		s.outbox,
+		pk,
 		bills,
+		s.priorityFee(s.cfg.PriorityLevel, nil, accounts),
+		recentBlockHash,
+	)
+	if err != nil {
+		return "", err
+	}
+	return signature.String(), nil
+}
+
+func (s *Solana) SendToken(bill *types.TransferBill, token string, privateKey string) (string, error) {
+	mint := solana.MustPublicKeyFromBase58(token)
+	recipient := solana.MustPublicKeyFromBase58(bill.Recipient)
+	pk := solana.MustPrivateKeyFromBase58(privateKey)
+	mintAccount, err := common.GetMint(s.ctx, rpc.New(s.cfg.RPC), mint)
+	if err != nil {
+		return "", err
+	}
+	sourceAta := common.FindAssociatedTokenAddress(pk.PublicKey(), mint, mintAccount.Program)
+	destAta := common.FindAssociatedTokenAddress(recipient, mint, mintAccount.Program)
+
+	recentBlockHash, _ := s.watcher.GetRecentBlockHash()
+	signature, err := SendTokenTransfer(
+		s.ctx,
+		s.cfg.RPC,
+		s.outbox,
+		mint,
+		recipient,
+		bill.Amount.Uint64(),
+		s.priorityFee(s.cfg.PriorityLevel, nil, []solana.PublicKey{pk.PublicKey(), sourceAta, destAta}),
+		pk,
 		recentBlockHash,
 	)
 	if err != nil {
@@ -628,3 +742,39 @@ func (s *Solana) SendNativeBatch(bills []*types.TransferBill, privateKey string)
 	}
 	return signature.String(), nil
 }
+
+// SendTokenBatch sends token to bills in transactions of at most
+// MaxTokenRecipientCount recipients. Every bill gets a result, failed
+// transactions carry the error instead of a hash.
+func (s *Solana) SendTokenBatch(bills []*types.TransferBill, token string, privateKey string) ([]*types.TransferResult, error) {
+	mint := solana.MustPublicKeyFromBase58(token)
+	pk := solana.MustPrivateKeyFromBase58(privateKey)
+	mintAccount, err := common.GetMint(s.ctx, rpc.New(s.cfg.RPC), mint)
+	if err != nil {
+		return nil, err
+	}
+	sourceAta := common.FindAssociatedTokenAddress(pk.PublicKey(), mint, mintAccount.Program)
+
+	results := make([]*types.TransferResult, 0, len(bills))
+	for _, chunk := range lo.Chunk(bills, MaxTokenRecipientCount) {
+		recentBlockHash, _ := s.watcher.GetRecentBlockHash()
+		signature, err := SendTokenTransferBatch(
+			s.ctx,
+			s.cfg.RPC,
+			s.outbox,
+			mint,
+			pk,
+			chunk,
+			s.priorityFee(s.cfg.PriorityLevel, nil, []solana.PublicKey{pk.PublicKey(), sourceAta}),
+			recentBlockHash,
+		)
+		for _, bill := range chunk {
+			result := &types.TransferResult{Recipient: bill.Recipient, Amount: bill.Amount, Error: err}
+			if err == nil {
+				result.TxHash = signature.String()
+			}
+			results = append(results, result)
+		}
+	}
+	return results, nil
+}
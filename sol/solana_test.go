@@ -2,10 +2,19 @@ package sol
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/system"
 	"github.com/gagliardetto/solana-go/rpc"
 	"github.com/meme-bots/go-web3/sol/pumpfun"
+	"github.com/meme-bots/go-web3/sol/pumpswap"
+	"github.com/meme-bots/go-web3/types"
 	"github.com/near/borsh-go"
 )
 
@@ -27,3 +36,90 @@ func TestSolana_GetGlobal(t *testing.T) {
 	t.Logf("global: %+v", global)
 	t.Logf("token: %d", pumpfun.GetInitialBuyPrice(&global, 30000000000))
 }
+
+// newTransactionNode serves tx from getTransaction with the inner
+// instructions of meta.
+func newTransactionNode(tb testing.TB, tx *solana.Transaction, inner []rpc.InnerInstruction) *httptest.Server {
+	encoded, err := tx.ToBase64()
+	if err != nil {
+		tb.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var call struct{ Method string }
+		_ = json.NewDecoder(r.Body).Decode(&call)
+		if call.Method != "getTransaction" {
+			http.Error(w, fmt.Sprintf("unexpected %s", call.Method), http.StatusBadRequest)
+			return
+		}
+		result := map[string]interface{}{
+			"slot":        5,
+			"blockTime":   1700000000,
+			"transaction": []string{encoded, "base64"},
+			"meta": map[string]interface{}{
+				"err": nil, "fee": 5000, "preBalances": []int{}, "postBalances": []int{},
+				"innerInstructions": inner,
+			},
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": 1, "result": result})
+	}))
+	tb.Cleanup(server.Close)
+	return server
+}
+
+func TestSolana_GetTransaction_Routed(t *testing.T) {
+	payer := solana.NewWallet().PrivateKey
+	feeRecipient := solana.NewWallet().PublicKey()
+	mint := solana.NewWallet().PublicKey()
+
+	// a buy split over two PumpSwap pools, then the bot fee
+	var instructions []solana.Instruction
+	for i := 0; i < 2; i++ {
+		pool := solana.NewWallet().PublicKey()
+		instructions = append(instructions, solana.NewInstruction(pumpswap.ProgramID,
+			solana.AccountMetaSlice{solana.Meta(pool).WRITE(), solana.Meta(payer.PublicKey()).SIGNER().WRITE()},
+			pumpswap.Instruction_Buy[:]))
+	}
+	instructions = append(instructions, system.NewTransferInstruction(30, payer.PublicKey(), feeRecipient).Build())
+	tx, err := solana.NewTransaction(instructions, solana.Hash{1}, solana.TransactionPayer(payer.PublicKey()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = tx.Sign(func(solana.PublicKey) *solana.PrivateKey { return &payer }); err != nil {
+		t.Fatal(err)
+	}
+
+	programIndex, err := tx.GetAccountIndex(pumpswap.ProgramID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var inner []rpc.InnerInstruction
+	for i, event := range []pumpswap.BuyEvent{
+		{BaseAmountOut: 600, UserQuoteAmountIn: 1000},
+		{BaseAmountOut: 300, UserQuoteAmountIn: 700},
+	} {
+		data, err := bin.MarshalBorsh(&event)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data = append(append(pumpswap.EventInstructionTag[:], pumpswap.BuyEventDiscriminator[:]...), data...)
+		inner = append(inner, rpc.InnerInstruction{
+			Index:        uint16(i),
+			Instructions: []solana.CompiledInstruction{{ProgramIDIndex: programIndex, Data: data}},
+		})
+	}
+
+	server := newTransactionNode(t, tx, inner)
+	s := &Solana{ctx: context.Background(), cfg: &types.Config{RPC: server.URL}}
+	resp, err := s.GetTransaction(&types.GetTransactionRequest{
+		TxHash:       tx.Signatures[0].String(),
+		Owner:        payer.PublicKey().String(),
+		Token:        mint.String(),
+		FeeRecipient: feeRecipient.String(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.BalanceChanged.Int64() != -1700 || resp.TokenChanged.Int64() != 900 || resp.BotFee.Int64() != 30 {
+		t.Errorf("balance %s, token %s, bot fee %s", resp.BalanceChanged, resp.TokenChanged, resp.BotFee)
+	}
+}
@@ -6,24 +6,35 @@ import (
 
 	"github.com/gagliardetto/solana-go"
 	"github.com/gagliardetto/solana-go/programs/system"
+	"github.com/gagliardetto/solana-go/programs/token"
 	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/meme-bots/go-web3/sol/common"
 	"github.com/meme-bots/go-web3/types"
 )
 
-const MaxRecipientCount = 21
+const (
+	MaxRecipientCount = 21
+
+	// MaxTokenRecipientCount is how many token transfers fit in a
+	// transaction, each creating the recipient's ATA if needed.
+	MaxTokenRecipientCount = 8
+)
 
 func SendTransfer(
 	ctx context.Context,
 	url string,
+	outbox *common.Outbox,
 	recipient solana.PublicKey,
 	amount uint64,
+	priorityFee common.PriorityFee,
 	privKey solana.PrivateKey,
 	recentBlockHash solana.Hash,
+	nonce *common.DurableNonce,
 ) (solana.Signature, error) {
-	instruction := system.NewTransferInstruction(amount, privKey.PublicKey(), recipient).Build()
+	instructions := []solana.Instruction{system.NewTransferInstruction(amount, privKey.PublicKey(), recipient).Build()}
 
 	client := rpc.New(url)
-	if recentBlockHash.IsZero() {
+	if recentBlockHash.IsZero() && nonce == nil {
 		latestBlock, err := client.GetLatestBlockhash(ctx, rpc.CommitmentFinalized)
 		if err != nil {
 			return solana.Signature{}, err
@@ -31,8 +42,15 @@ func SendTransfer(
 		recentBlockHash = latestBlock.Value.Blockhash
 	}
 
+	instructions, err := withPriorityFee(ctx, client, instructions, privKey.PublicKey(), priorityFee)
+	if err != nil {
+		return solana.Signature{}, err
+	}
+
+	instructions, recentBlockHash = nonce.Apply(instructions, recentBlockHash)
+
 	tx, err := solana.NewTransaction(
-		[]solana.Instruction{instruction},
+		instructions,
 		recentBlockHash,
 		solana.TransactionPayer(privKey.PublicKey()),
 	)
@@ -47,14 +65,16 @@ func SendTransfer(
 		return solana.Signature{}, err
 	}
 
-	return client.SendTransaction(ctx, tx)
+	return common.SendTransaction(ctx, client, outbox, tx, rpc.TransactionOpts{})
 }
 
 func SendTransferBatch(
 	ctx context.Context,
 	url string,
+	outbox *common.Outbox,
 	privKey solana.PrivateKey,
 	bills []*types.TransferBill,
+	priorityFee common.PriorityFee,
 	recentBlockHash solana.Hash,
 ) (solana.Signature, error) {
 
@@ -76,6 +96,11 @@ func SendTransferBatch(
 		recentBlockHash = latestBlock.Value.Blockhash
 	}
 
+	instructions, err := withPriorityFee(ctx, client, instructions, privKey.PublicKey(), priorityFee)
+	if err != nil {
+		return solana.Signature{}, err
+	}
+
 	tx, err := solana.NewTransaction(
 		instructions,
 		recentBlockHash,
@@ -92,5 +117,169 @@ func SendTransferBatch(
 		return solana.Signature{}, err
 	}
 
-	return client.SendTransaction(ctx, tx)
+	return common.SendTransaction(ctx, client, outbox, tx, rpc.TransactionOpts{})
+}
+
+func SendTokenTransfer(
+	ctx context.Context,
+	url string,
+	outbox *common.Outbox,
+	mint, recipient solana.PublicKey,
+	amount uint64,
+	priorityFee common.PriorityFee,
+	privKey solana.PrivateKey,
+	recentBlockHash solana.Hash,
+) (solana.Signature, error) {
+	client := rpc.New(url)
+	owner := privKey.PublicKey()
+
+	accounts, err := client.GetMultipleAccountsWithOpts(
+		ctx,
+		append([]solana.PublicKey{mint}, common.TokenAccounts(recipient, mint)...),
+		&rpc.GetMultipleAccountsOpts{Commitment: rpc.CommitmentConfirmed},
+	)
+	if err != nil {
+		return solana.Signature{}, err
+	}
+
+	mintAccount, err := common.DecodeMint(accounts.Value[0])
+	if err != nil {
+		return solana.Signature{}, err
+	}
+	sourceAta := common.FindAssociatedTokenAddress(owner, mint, mintAccount.Program)
+	destAta := common.FindAssociatedTokenAddress(recipient, mint, mintAccount.Program)
+
+	var instructions []solana.Instruction
+	if common.TokenAccountOf(accounts.Value[0], accounts.Value[1:]) == nil {
+		instructions = append(instructions, common.NewCreateIdempotentAtaInstruction(owner, recipient, mint, mintAccount.Program))
+	}
+	instructions = append(instructions, common.WithProgram(token.NewTransferCheckedInstruction(
+		amount,
+		mintAccount.Decimals,
+		sourceAta,
+		mint,
+		destAta,
+		owner,
+		[]solana.PublicKey{},
+	).Build(), mintAccount.Program))
+
+	if recentBlockHash.IsZero() {
+		latestBlock, err := client.GetLatestBlockhash(ctx, rpc.CommitmentFinalized)
+		if err != nil {
+			return solana.Signature{}, err
+		}
+		recentBlockHash = latestBlock.Value.Blockhash
+	}
+
+	instructions, err = withPriorityFee(ctx, client, instructions, owner, priorityFee)
+	if err != nil {
+		return solana.Signature{}, err
+	}
+
+	tx, err := solana.NewTransaction(
+		instructions,
+		recentBlockHash,
+		solana.TransactionPayer(owner),
+	)
+	if err != nil {
+		return solana.Signature{}, err
+	}
+
+	_, err = tx.Sign(func(key solana.PublicKey) *solana.PrivateKey {
+		return &privKey
+	})
+	if err != nil {
+		return solana.Signature{}, err
+	}
+
+	return common.SendTransaction(ctx, client, outbox, tx, rpc.TransactionOpts{})
+}
+
+// SendTokenTransferBatch transfers mint to every bill in one transaction,
+// creating the recipients' ATAs where missing.
+func SendTokenTransferBatch(
+	ctx context.Context,
+	url string,
+	outbox *common.Outbox,
+	mint solana.PublicKey,
+	privKey solana.PrivateKey,
+	bills []*types.TransferBill,
+	priorityFee common.PriorityFee,
+	recentBlockHash solana.Hash,
+) (solana.Signature, error) {
+	if len(bills) > MaxTokenRecipientCount {
+		return solana.Signature{}, errors.New("exceeding the max recipients count")
+	}
+
+	client := rpc.New(url)
+	owner := privKey.PublicKey()
+	account, err := client.GetAccountInfoWithOpts(ctx, mint, &rpc.GetAccountInfoOpts{Commitment: rpc.CommitmentConfirmed})
+	if err != nil {
+		return solana.Signature{}, err
+	}
+	mintAccount, err := common.DecodeMint(account.Value)
+	if err != nil {
+		return solana.Signature{}, err
+	}
+
+	sourceAta := common.FindAssociatedTokenAddress(owner, mint, mintAccount.Program)
+	var instructions []solana.Instruction
+	for _, bill := range bills {
+		recipient := solana.MustPublicKeyFromBase58(bill.Recipient)
+		instructions = append(
+			instructions,
+			common.NewCreateIdempotentAtaInstruction(owner, recipient, mint, mintAccount.Program),
+			common.WithProgram(token.NewTransferCheckedInstruction(
+				bill.Amount.Uint64(),
+				mintAccount.Decimals,
+				sourceAta,
+				mint,
+				common.FindAssociatedTokenAddress(recipient, mint, mintAccount.Program),
+				owner,
+				[]solana.PublicKey{},
+			).Build(), mintAccount.Program),
+		)
+	}
+
+	if recentBlockHash.IsZero() {
+		latestBlock, err := client.GetLatestBlockhash(ctx, rpc.CommitmentFinalized)
+		if err != nil {
+			return solana.Signature{}, err
+		}
+		recentBlockHash = latestBlock.Value.Blockhash
+	}
+
+	instructions, err = withPriorityFee(ctx, client, instructions, owner, priorityFee)
+	if err != nil {
+		return solana.Signature{}, err
+	}
+
+	tx, err := solana.NewTransaction(instructions, recentBlockHash, solana.TransactionPayer(owner))
+	if err != nil {
+		return solana.Signature{}, err
+	}
+
+	_, err = tx.Sign(func(key solana.PublicKey) *solana.PrivateKey {
+		return &privKey
+	})
+	if err != nil {
+		return solana.Signature{}, err
+	}
+
+	return common.SendTransaction(ctx, client, outbox, tx, rpc.TransactionOpts{})
+}
+
+// withPriorityFee adds a compute budget paying priorityFee. Transfers are
+// cheap enough to go without one when no fee is asked for.
+func withPriorityFee(
+	ctx context.Context,
+	client *rpc.Client,
+	instructions []solana.Instruction,
+	payer solana.PublicKey,
+	priorityFee common.PriorityFee,
+) ([]solana.Instruction, error) {
+	if priorityFee.IsZero() {
+		return instructions, nil
+	}
+	return common.WithComputeBudget(ctx, client, instructions, payer, nil, priorityFee, common.TransferComputeUnits)
 }
@@ -10,6 +10,7 @@ import (
 	"github.com/gagliardetto/solana-go"
 	"github.com/gagliardetto/solana-go/programs/token"
 	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/meme-bots/go-web3/types"
 	"github.com/meme-bots/go-web3/utils"
 	"github.com/shopspring/decimal"
 )
@@ -19,7 +20,8 @@ type (
 
 	Watcher struct {
 		client        *rpc.Client
-		price         decimal.Decimal
+		price         *types.NativeTokenPrice
+		priceErr      error // of the latest refresh
 		priceLock     sync.RWMutex
 		hash          solana.Hash
 		hashUpdatedAt time.Time
@@ -42,16 +44,62 @@ const (
 	watcherStateClosed
 )
 
+type (
+	stablePool struct {
+		name           string
+		solVault       solana.PublicKey
+		stableVault    solana.PublicKey
+		stableDecimals int32
+	}
+
+	// pythPriceUpdate is the price message of a Pyth receiver PriceUpdateV2
+	// account, which follows the discriminator, the write authority and the
+	// verification level.
+	pythPriceUpdate struct {
+		FeedID          [32]byte
+		Price           int64
+		Conf            uint64
+		Exponent        int32
+		PublishTime     int64
+		PrevPublishTime int64
+		EmaPrice        int64
+		EmaConf         uint64
+	}
+)
+
+const (
+	priceMaxAge       = 5 * time.Minute
+	priceMaxDeviation = 0.02
+	// pyth confidence intervals wider than this share of the price are rejected
+	pythMaxConfRatio = 0.01
+)
+
 var (
-	solVaultAddress  = solana.MPK("876Z9waBygfzUrwwKFfnRcc7cfY4EQf6Kz1w7GRgbVYW")
-	usdtVaultAddress = solana.MPK("CB86HtaqpXbNWbq67L18y5x2RhqoJ6smb7xHUcyWdQAQ")
+	stablePools = []stablePool{
+		{
+			name:           "raydium SOL/USDT",
+			solVault:       solana.MPK("876Z9waBygfzUrwwKFfnRcc7cfY4EQf6Kz1w7GRgbVYW"),
+			stableVault:    solana.MPK("CB86HtaqpXbNWbq67L18y5x2RhqoJ6smb7xHUcyWdQAQ"),
+			stableDecimals: 6,
+		},
+		{
+			name:           "raydium SOL/USDC",
+			solVault:       solana.MPK("DQyrAcCrDXQ7NeoqGgDCZwBvWDcYmFCjSb9JtteuvPpz"),
+			stableVault:    solana.MPK("HLmqeL62xR1QoZ1HKKbXRrdN1p3phKpxRMb2VVopvBBz"),
+			stableDecimals: 6,
+		},
+	}
+
+	pythPriceAccounts = []solana.PublicKey{
+		solana.MPK("7UVimffxr9ow1uXYxsr4LHAcV58mLzhmwaeKvJ1pjLiE"), // SOL/USD sponsored feed
+	}
 )
 
 func NewWatcher(url string, withBlockHash bool) (*Watcher, error) {
 	ctx, cancel := context.WithCancel(context.Background())
 	return &Watcher{
 		client:        rpc.New(url),
-		price:         decimal.Zero,
+		price:         &types.NativeTokenPrice{Price: decimal.Zero, Confidence: decimal.Zero},
 		hash:          solana.Hash{},
 		withBlockHash: withBlockHash,
 		ctx:           ctx,
@@ -107,30 +155,89 @@ func (w *Watcher) Close() error {
 	return nil
 }
 
-func (w *Watcher) QuerySolPrice() (decimal.Decimal, error) {
+// QuerySolPrice reads every pyth account and stable pool in one request and
+// aggregates the fresh, agreeing prices.
+func (w *Watcher) QuerySolPrice() (*types.NativeTokenPrice, error) {
+	publicKeys := make([]solana.PublicKey, 0, len(stablePools)*2+len(pythPriceAccounts))
+	for _, pool := range stablePools {
+		publicKeys = append(publicKeys, pool.solVault, pool.stableVault)
+	}
+	publicKeys = append(publicKeys, pythPriceAccounts...)
+
 	accounts, err := w.client.GetMultipleAccountsWithOpts(
 		w.ctx,
-		[]solana.PublicKey{solVaultAddress, usdtVaultAddress},
+		publicKeys,
 		&rpc.GetMultipleAccountsOpts{Commitment: rpc.CommitmentConfirmed},
 	)
 	if err != nil {
-		return decimal.Zero, err
+		return nil, err
 	}
 
-	var wsolVault, usdtVault token.Account
-	err = wsolVault.UnmarshalWithDecoder(bin.NewBorshDecoder(accounts.Value[0].Data.GetBinary()))
-	if err != nil {
-		return decimal.Zero, err
+	now := time.Now()
+	samples := make([]utils.PriceSample, 0, len(publicKeys))
+	// the oracle goes first, to be preferred should two sources disagree
+	for i, account := range accounts.Value[len(stablePools)*2:] {
+		if account == nil {
+			continue
+		}
+		update, err := decodePythPriceUpdate(account.Data.GetBinary())
+		if err != nil {
+			continue
+		}
+		price := decimal.New(update.Price, update.Exponent)
+		conf := decimal.NewFromUint64(update.Conf).Shift(update.Exponent)
+		if !price.IsPositive() || conf.Div(price).GreaterThan(decimal.NewFromFloat(pythMaxConfRatio)) {
+			continue
+		}
+		samples = append(samples, utils.PriceSample{
+			Source:    "pyth " + pythPriceAccounts[i].String(),
+			Price:     price,
+			UpdatedAt: time.Unix(update.PublishTime, 0),
+		})
 	}
 
-	err = usdtVault.UnmarshalWithDecoder(bin.NewBorshDecoder(accounts.Value[1].Data.GetBinary()))
-	if err != nil {
-		return decimal.Zero, err
+	for i, pool := range stablePools {
+		solAccount, stableAccount := accounts.Value[i*2], accounts.Value[i*2+1]
+		if solAccount == nil || stableAccount == nil {
+			continue
+		}
+
+		var wsolVault, stableVault token.Account
+		err = wsolVault.UnmarshalWithDecoder(bin.NewBorshDecoder(solAccount.Data.GetBinary()))
+		if err != nil || wsolVault.Amount == 0 {
+			continue
+		}
+		err = stableVault.UnmarshalWithDecoder(bin.NewBorshDecoder(stableAccount.Data.GetBinary()))
+		if err != nil {
+			continue
+		}
+
+		wsolAmount := decimal.NewFromUint64(wsolVault.Amount).Div(decimal.NewFromInt(1e9))
+		stableAmount := decimal.NewFromUint64(stableVault.Amount).Shift(-pool.stableDecimals)
+		samples = append(samples, utils.PriceSample{Source: pool.name, Price: stableAmount.Div(wsolAmount), UpdatedAt: now})
+	}
+
+	return utils.AggregatePrices(samples, len(stablePools)+len(pythPriceAccounts), priceMaxAge, decimal.NewFromFloat(priceMaxDeviation))
+}
+
+func decodePythPriceUpdate(data []byte) (*pythPriceUpdate, error) {
+	// discriminator + write authority
+	offset := 8 + 32
+	if len(data) < offset+1 {
+		return nil, types.ErrInvalidPool
+	}
+	// VerificationLevel::Partial carries the number of signatures
+	if data[offset] == 0 {
+		offset++
 	}
+	offset++
 
-	wsolAmount := decimal.NewFromUint64(wsolVault.Amount).Div(decimal.NewFromInt(1e9))
-	usdtAmount := decimal.NewFromUint64(usdtVault.Amount).Div(decimal.NewFromInt(1e6))
-	return usdtAmount.Div(wsolAmount), nil
+	var update pythPriceUpdate
+	err := bin.NewBorshDecoder(data[offset:]).Decode(&update)
+	if err != nil {
+		return nil, err
+	}
+	return &update, nil
 }
 
 func (w *Watcher) QueryBlockHash() (solana.Hash, error) {
@@ -142,26 +249,20 @@ func (w *Watcher) QueryBlockHash() (solana.Hash, error) {
 }
 
 func (w *Watcher) WatchSolPrice(interval time.Duration) {
-	price, err := w.QuerySolPrice()
-	if err == nil {
+	for {
+		price, err := w.QuerySolPrice()
 		w.priceLock.Lock()
-		w.price = price
+		if err == nil {
+			w.price = price
+		}
+		w.priceErr = err
 		w.priceLock.Unlock()
-	}
 
-	for {
 		select {
 		case <-time.After(interval):
 		case <-w.ctx.Done():
 			return
 		}
-
-		price, err := w.QuerySolPrice()
-		if err == nil {
-			w.priceLock.Lock()
-			w.price = price
-			w.priceLock.Unlock()
-		}
 	}
 }
 
@@ -186,11 +287,17 @@ func (w *Watcher) WatchBlockHash(interval time.Duration) {
 func (w *Watcher) GetSolPrice() decimal.Decimal {
 	var price decimal.Decimal
 	w.priceLock.RLock()
-	price = w.price
+	price = utils.FreshPrice(w.price, priceMaxAge)
 	w.priceLock.RUnlock()
 	return price
 }
 
+func (w *Watcher) GetSolPriceInfo() *types.NativeTokenPrice {
+	w.priceLock.RLock()
+	defer w.priceLock.RUnlock()
+	return utils.CurrentPrice(w.price, w.priceErr, priceMaxAge)
+}
+
 func (w *Watcher) GetRecentBlockHash() (solana.Hash, bool) {
 	if !w.withBlockHash {
 		return solana.Hash{}, false
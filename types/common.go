@@ -2,6 +2,7 @@ package types
 
 import (
 	"math/big"
+	"time"
 
 	"github.com/shopspring/decimal"
 )
@@ -23,6 +24,12 @@ type (
 		Status          int
 		Dex             int
 		Marked          bool
+		HopPool         string // sol only, the SOL/USDC pool a USDC-quoted Orca pool is traded through
+
+		// sol only, the pool a completed pump.fun curve moved to, and the
+		// unix time it did
+		Migrated   bool
+		MigratedAt int64
 	}
 
 	PriceHistorical struct {
@@ -44,6 +51,7 @@ type (
 		PoolID           string
 		MarketId         string
 		MarketProgramId  string
+		HopPool          string // sol only, see Pool.HopPool
 		InAmount         *big.Int
 		Gas              *big.Int
 		Tip              *big.Int
@@ -57,5 +65,17 @@ type (
 		TokenReserve     *big.Int
 		QuoteReserve     *big.Int
 		Allowance        *big.Int
+		PriorityLevel    int    // sol only, see PriorityLevelManual
+		NonceAccount     string // sol only, durable nonce used instead of a recent blockhash
+		BestExecution    bool   // sol only, quote every venue of the token instead of trading on Dex and PoolID
+		SplitOrder       bool   // sol only, with BestExecution, the order may be split across two venues
+		SignOnly         bool   // sol only, build and sign without sending, see TransactResponse.SignedTx
 	}
 )
+
+type NativeTokenPrice struct {
+	Price      decimal.Decimal
+	Confidence decimal.Decimal // share of queried sources that agree with Price, 0..1, zero once Price is stale
+	Sources    []string
+	UpdatedAt  time.Time
+}
@@ -11,10 +11,24 @@ type (
 
 		QueryRPC       string // sol only
 		WatchBlockHash bool
+		LookupTables   []string // address lookup tables used to build v0 transactions
+		PriorityLevel  int      // priority level of transfers
+		MaxPriorityFee uint64   // lamports, caps PriorityLevelAuto when Gas is unset
+		JitoEngines    []string // block engine URLs tried in order, defaults to common.JitoBlockEngines
+		JitoAuth       string
 
 		Router                  string // evm only
+		Dex                     int    // venue swaps go through, types.DexUniswapV2 by default
 		WrapNativeToken         string
 		NativeTokenOracle       string
+		NativeTokenStablePool   string
 		UniswapPairInitCodeHash string
+		TxRoute                 int // public mempool, private relay or bundle
+		RelayRPC                string
+		RelayAuthKey            string
+		GasLimitMargin          int    // percent added to eth_estimateGas
+		OPStack                 bool   // fees include an L1 data fee
+		FeeRouter               string // collects the bot fee atomically with swaps
+		Disperse                string // token batch contract, evm.DISPERSE_ADDRESS by default
 	}
 )
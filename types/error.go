@@ -22,4 +22,8 @@ var (
 	ErrTxNotLand = errors.New("transaction did not land")
 
 	ErrSlippage = errors.New("slippage error")
+
+	ErrStalePrice = errors.New("stale price")
+
+	ErrSimulationFailed = errors.New("simulation failed")
 )
@@ -46,15 +46,16 @@ type (
 	}
 
 	LaunchRequest struct {
-		DexID        int
-		Name         string
-		Symbol       string
-		Description  string
-		Uri          string
-		Gas          *big.Int
-		Tip          *big.Int
-		BuyAmountSol *big.Int
-		SlipPage     uint64
+		DexID         int
+		Name          string
+		Symbol        string
+		Description   string
+		Uri           string
+		Gas           *big.Int
+		Tip           *big.Int
+		BuyAmountSol  *big.Int
+		SlipPage      uint64
+		PriorityLevel int
 	}
 
 	LaunchResponse struct {
@@ -66,6 +67,16 @@ type (
 		TxHash              string
 		InitialTokenBalance *big.Int
 		PositionClosed      bool
+		Venues              []Venue // sol only, where a BestExecution swap went
+		SignedTx            string  // sol only, base64 of the unsent transaction of a SignOnly request
+	}
+
+	// Venue is a pool a routed swap traded part of its amount on.
+	Venue struct {
+		DexID       int
+		PoolAddress string
+		InAmount    *big.Int
+		QuotedOut   *big.Int // before slippage, and before the bot fee on sells
 	}
 
 	WatchTransactionRequest struct {
@@ -84,7 +95,8 @@ type (
 	GetTransactionResponse struct {
 		BalanceChanged *big.Int
 		TokenChanged   *big.Int
-		Fee            *big.Int
+		Fee            *big.Int // total network fee, including L1Fee
+		L1Fee          *big.Int // L1 data fee of OP-stack chains
 		BotFee         *big.Int
 		Timestamp      time.Time
 	}
@@ -116,14 +128,37 @@ type (
 		PoolAddress           string
 		MarketId              string
 		MarketProgramId       string
+		HopPool               string // sol only, see Pool.HopPool
 		NativeBalance         *big.Int
 		TokenBalance          *big.Int
 		Allowance             *big.Int
+
+		PoolFeeBps uint16 // sol only, swap fee of the pool, where known
+
+		// sol only, Token-2022 extensions that tax or restrict holders
+		TokenProgram      string
+		TransferFeeBps    uint16
+		TransferHook      bool
+		PermanentDelegate bool
+		DefaultFrozen     bool
+		NonTransferable   bool
+
+		// sol only, the token completed its pump.fun curve and trades here now
+		Migrated   bool
+		MigratedAt time.Time
 	}
 
 	TransferBill struct {
+		Recipient    string
+		Amount       *big.Int
+		NonceAccount string // sol only, durable nonce used instead of a recent blockhash
+	}
+
+	TransferResult struct {
 		Recipient string
 		Amount    *big.Int
+		TxHash    string
+		Error     error
 	}
 
 	NetworkInterface interface {
@@ -134,6 +169,7 @@ type (
 		GetNativeTokenSymbol() string
 		GetNativeTokenDecimals() uint8
 		GetNativeTokenPrice() decimal.Decimal
+		GetNativeTokenPriceInfo() *NativeTokenPrice
 		GetMaxMultiSendCount() int
 		GetBalance(req *GetBalanceRequest) (*big.Int, error)
 		GetTokenBalance(req *GetTokenBalanceRequest) (*big.Int, error)
@@ -146,8 +182,11 @@ type (
 		Withdraw(to string, amount decimal.Decimal, privateKey string) (string, error)
 		Transact(req *Transact, feeRecipient_ string, feeRatio uint64, privateKey string) (*TransactResponse, error)
 		GetBaseGas() *big.Int
+		EstimateTransactFee(req *Transact) (*big.Int, error)
 		SendNative(bill *TransferBill, privateKey string) (string, error)
 		SendNativeBatch(bills []*TransferBill, privateKey string) (string, error)
+		SendToken(bill *TransferBill, token string, privateKey string) (string, error)
+		SendTokenBatch(bills []*TransferBill, token string, privateKey string) ([]*TransferResult, error)
 		Launch(req *LaunchRequest, feeRecipient_ string, feeRatio uint64, privateKey string) (*LaunchResponse, error)
 	}
 )
@@ -158,3 +197,31 @@ const (
 	NetworkTypeSUI
 	NetworkTypeTON
 )
+
+// Priority levels choose the Solana priority fee. Manual pays Gas as given,
+// a percentile from 1 to 100 pays what that share of recent blocks asked
+// to write the same accounts, and Auto pays the High level capped at Gas.
+const (
+	PriorityLevelAuto   int = -1
+	PriorityLevelManual int = 0
+	PriorityLevelLow    int = 25
+	PriorityLevelMedium int = 50
+	PriorityLevelHigh   int = 75
+)
+
+// Solana venues, as found in Pool.Dex, Transact.Dex and GetPoolResponse.DexID.
+const (
+	DexRaydium     = 0 // Raydium AMM v4, paired with an OpenBook market
+	DexPumpFun     = 1
+	DexRaydiumCpmm = 2
+	DexRaydiumClmm = 3
+	DexPumpSwap    = 4 // pump.fun's AMM, where completed curves migrate
+	DexMeteoraDlmm = 5
+	DexMeteoraDamm = 6 // Meteora dynamic AMM, reserves lent out through vaults
+	DexOrca        = 7 // Orca Whirlpools, USDC pairs route through a SOL/USDC pool
+)
+
+// EVM venues, as found in Config.Dex and GetPoolResponse.DexID.
+const (
+	DexUniswapV2 = 0 // Uniswap V2 or a fork of it, at Config.Router
+)
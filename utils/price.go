@@ -0,0 +1,115 @@
+package utils
+
+import (
+	"sort"
+	"time"
+
+	"github.com/meme-bots/go-web3/types"
+	"github.com/shopspring/decimal"
+)
+
+type PriceSample struct {
+	Source    string
+	Price     decimal.Decimal
+	UpdatedAt time.Time
+}
+
+// minOutlierSamples is how many fresh samples it takes to tell an outlier
+// from the rest.
+const minOutlierSamples = 3
+
+// AggregatePrices drops samples older than maxAge and returns the median of
+// the rest. Samples come in order of preference. From minOutlierSamples on,
+// samples deviating from the median by more than maxDeviation (a ratio, e.g.
+// 0.02 for 2%) are dropped first. Two samples that disagree cannot tell
+// which one is off, so the preferred one is taken alone, which is always the
+// case on EVM with its oracle and single pool. Confidence is the share of
+// total sources that survived.
+func AggregatePrices(samples []PriceSample, total int, maxAge time.Duration, maxDeviation decimal.Decimal) (*types.NativeTokenPrice, error) {
+	now := time.Now()
+	fresh := make([]PriceSample, 0, len(samples))
+	for _, sample := range samples {
+		if !sample.Price.IsPositive() || now.Sub(sample.UpdatedAt) > maxAge {
+			continue
+		}
+		fresh = append(fresh, sample)
+	}
+	if len(fresh) == 0 {
+		return nil, types.ErrStalePrice
+	}
+
+	accepted := fresh
+	if len(fresh) >= minOutlierSamples {
+		median := medianPrice(fresh)
+		accepted = make([]PriceSample, 0, len(fresh))
+		for _, sample := range fresh {
+			if deviation(sample.Price, median).LessThanOrEqual(maxDeviation) {
+				accepted = append(accepted, sample)
+			}
+		}
+	} else if len(fresh) == 2 && deviation(fresh[1].Price, fresh[0].Price).GreaterThan(maxDeviation) {
+		accepted = fresh[:1]
+	}
+	if len(accepted) == 0 {
+		return nil, types.ErrStalePrice
+	}
+
+	price := medianPrice(accepted)
+	sources := make([]string, len(accepted))
+	updatedAt := accepted[0].UpdatedAt
+	for i, sample := range accepted {
+		sources[i] = sample.Source
+		if sample.UpdatedAt.Before(updatedAt) {
+			updatedAt = sample.UpdatedAt
+		}
+	}
+
+	if total < len(samples) {
+		total = len(samples)
+	}
+
+	return &types.NativeTokenPrice{
+		Price:      price,
+		Confidence: decimal.NewFromInt(int64(len(accepted))).Div(decimal.NewFromInt(int64(total))),
+		Sources:    sources,
+		UpdatedAt:  updatedAt,
+	}, nil
+}
+
+// CurrentPrice is the copy of the last aggregated price handed to readers.
+// Its confidence is zero if the latest refresh failed or it is older than
+// maxAge; the price itself is kept for those who still want it.
+func CurrentPrice(price *types.NativeTokenPrice, refreshErr error, maxAge time.Duration) *types.NativeTokenPrice {
+	current := *price
+	if refreshErr != nil || time.Since(current.UpdatedAt) > maxAge {
+		current.Confidence = decimal.Zero
+	}
+	return &current
+}
+
+// FreshPrice is the last aggregated price, or zero once it is older than
+// maxAge.
+func FreshPrice(price *types.NativeTokenPrice, maxAge time.Duration) decimal.Decimal {
+	if time.Since(price.UpdatedAt) > maxAge {
+		return decimal.Zero
+	}
+	return price.Price
+}
+
+func deviation(price, reference decimal.Decimal) decimal.Decimal {
+	return price.Sub(reference).Abs().Div(reference)
+}
+
+func medianPrice(samples []PriceSample) decimal.Decimal {
+	prices := make([]decimal.Decimal, len(samples))
+	for i, sample := range samples {
+		prices[i] = sample.Price
+	}
+	sort.Slice(prices, func(i, j int) bool { return prices[i].LessThan(prices[j]) })
+
+	mid := len(prices) / 2
+	if len(prices)%2 == 1 {
+		return prices[mid]
+	}
+	return prices[mid-1].Add(prices[mid]).Div(decimal.NewFromInt(2))
+}
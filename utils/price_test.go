@@ -0,0 +1,91 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/meme-bots/go-web3/types"
+	"github.com/shopspring/decimal"
+)
+
+func TestAggregatePrices(t *testing.T) {
+	now := time.Now()
+	maxDeviation := decimal.NewFromFloat(0.02)
+	sample := func(source string, price float64, age time.Duration) PriceSample {
+		return PriceSample{Source: source, Price: decimal.NewFromFloat(price), UpdatedAt: now.Add(-age)}
+	}
+
+	// the outlier of three is dropped
+	price, err := AggregatePrices([]PriceSample{
+		sample("oracle", 100, 0),
+		sample("pool a", 101, 0),
+		sample("pool b", 150, 0),
+	}, 3, time.Minute, maxDeviation)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !price.Price.Equal(decimal.NewFromFloat(100.5)) || len(price.Sources) != 2 || !price.Confidence.Equal(decimal.NewFromFloat(2).Div(decimal.NewFromInt(3))) {
+		t.Errorf("three sources: %+v", price)
+	}
+
+	// two that agree are averaged
+	price, err = AggregatePrices([]PriceSample{sample("oracle", 100, 0), sample("pool", 101, 0)}, 2, time.Minute, maxDeviation)
+	if err != nil || !price.Price.Equal(decimal.NewFromFloat(100.5)) || !price.Confidence.Equal(decimal.NewFromInt(1)) {
+		t.Errorf("two agreeing: %+v, %v", price, err)
+	}
+
+	// two that disagree leave the preferred one
+	price, err = AggregatePrices([]PriceSample{sample("oracle", 100, 0), sample("pool", 150, 0)}, 2, time.Minute, maxDeviation)
+	if err != nil || !price.Price.Equal(decimal.NewFromInt(100)) || price.Sources[0] != "oracle" || !price.Confidence.Equal(decimal.NewFromFloat(0.5)) {
+		t.Errorf("two disagreeing: %+v, %v", price, err)
+	}
+
+	// stale and non-positive samples are not fresh
+	price, err = AggregatePrices([]PriceSample{
+		sample("oracle", 100, 2*time.Minute),
+		sample("pool a", 0, 0),
+		sample("pool b", 103, 0),
+	}, 3, time.Minute, maxDeviation)
+	if err != nil || !price.Price.Equal(decimal.NewFromInt(103)) || price.Sources[0] != "pool b" {
+		t.Errorf("one fresh: %+v, %v", price, err)
+	}
+	if !price.UpdatedAt.Equal(now) {
+		t.Errorf("updated at %s, want %s", price.UpdatedAt, now)
+	}
+
+	if _, err = AggregatePrices([]PriceSample{sample("oracle", 100, time.Hour)}, 2, time.Minute, maxDeviation); !errors.Is(err, types.ErrStalePrice) {
+		t.Errorf("none fresh: err = %v", err)
+	}
+}
+
+func TestCurrentPrice(t *testing.T) {
+	price := &types.NativeTokenPrice{Price: decimal.NewFromInt(100), Confidence: decimal.NewFromInt(1), UpdatedAt: time.Now()}
+
+	if current := CurrentPrice(price, nil, time.Minute); !current.Confidence.Equal(decimal.NewFromInt(1)) {
+		t.Errorf("fresh: confidence %s", current.Confidence)
+	}
+	current := CurrentPrice(price, types.ErrStalePrice, time.Minute)
+	if !current.Confidence.IsZero() || !current.Price.Equal(price.Price) {
+		t.Errorf("failed refresh: %+v", current)
+	}
+	if !price.Confidence.Equal(decimal.NewFromInt(1)) {
+		t.Error("the stored price was changed")
+	}
+
+	price.UpdatedAt = time.Now().Add(-time.Hour)
+	if current = CurrentPrice(price, nil, time.Minute); !current.Confidence.IsZero() {
+		t.Errorf("old: confidence %s", current.Confidence)
+	}
+}
+
+func TestFreshPrice(t *testing.T) {
+	price := &types.NativeTokenPrice{Price: decimal.NewFromInt(100), UpdatedAt: time.Now()}
+	if fresh := FreshPrice(price, time.Minute); !fresh.Equal(price.Price) {
+		t.Errorf("fresh: %s", fresh)
+	}
+	price.UpdatedAt = time.Now().Add(-time.Hour)
+	if stale := FreshPrice(price, time.Minute); !stale.IsZero() {
+		t.Errorf("stale: %s", stale)
+	}
+}